@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package httpserver provides the HTTP server scaffolding shared by our services: liveness and
+// readiness endpoints, Prometheus metrics, and the service's own handlers.
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// CheckFunc reports whether something the service depends on is working.
+type CheckFunc func(ctx context.Context) error
+
+// checkTimeout bounds how long /readyz waits for all the readiness checks.
+const checkTimeout = 5 * time.Second
+
+// Server serves /healthz, /readyz and /metrics, along with any handlers the service registers.
+type Server struct {
+	// DefaultPort is the port to listen on if $PORT is not set. New sets it to 8080.
+	DefaultPort string
+	// Healthz serves /healthz. New sets it to a handler that always responds "ok".
+	Healthz http.Handler
+	// DrainTimeout bounds how long ListenAndServe waits, once asked to shut down, for in-flight
+	// requests and the OnShutdown functions to finish. New sets it to 25 seconds, which fits in
+	// the default Kubernetes termination grace period.
+	DrainTimeout time.Duration
+	// MaxBodyBytes bounds the size of request bodies. Larger requests are rejected with 413, or,
+	// if they don't declare their length, fail once the handler has read MaxBodyBytes. New sets
+	// it to 1 MiB, which is far more than any Slack payload.
+	MaxBodyBytes int64
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are passed on to the
+	// http.Server, so that slow or idle clients can't hold connections open indefinitely. New
+	// sets them to 5 seconds, 10 seconds, 30 seconds and 2 minutes respectively.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	mux *http.ServeMux
+
+	lock       sync.Mutex
+	checks     map[string]CheckFunc
+	onShutdown []func(ctx context.Context) error
+}
+
+// New returns a Server with the standard endpoints registered:
+//
+//   - /healthz always responds with 200 while the process is serving, for liveness probes.
+//   - /readyz responds with 200 once every readiness check passes, and 503 otherwise.
+//   - /metrics serves Prometheus metrics.
+func New() *Server {
+	s := &Server{
+		DefaultPort:  "8080",
+		Healthz:      http.HandlerFunc(handleHealthz),
+		DrainTimeout: 25 * time.Second,
+		MaxBodyBytes: 1 << 20,
+
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+
+		mux:    http.NewServeMux(),
+		checks: map[string]CheckFunc{},
+	}
+	s.mux.HandleFunc("/healthz", func(rw http.ResponseWriter, r *http.Request) { s.Healthz.ServeHTTP(rw, r) })
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+	s.mux.Handle("/metrics", promhttp.Handler())
+	return s
+}
+
+// Handle registers handler for the given pattern, as http.ServeMux does.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers handler for the given pattern, as http.ServeMux does.
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.mux.HandleFunc(pattern, handler)
+}
+
+// AddReadinessCheck makes /readyz fail whenever check does. name identifies the check in the
+// /readyz response.
+func (s *Server) AddReadinessCheck(name string, check CheckFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.checks[name] = check
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if s.MaxBodyBytes > 0 {
+		if r.ContentLength > s.MaxBodyBytes {
+			http.Error(rw, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(rw, r.Body, s.MaxBodyBytes)
+	}
+	s.mux.ServeHTTP(rw, r)
+}
+
+// OnShutdown registers fn to be called during a graceful shutdown, once the server has stopped
+// accepting requests. Functions are called in the order they were registered, and share the
+// DrainTimeout.
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onShutdown = append(s.onShutdown, fn)
+}
+
+// ListenAndServe serves on the port in $PORT, or DefaultPort if it is not set, until the process
+// receives SIGTERM or SIGINT. It then shuts down gracefully: it stops accepting connections, waits
+// for in-flight requests, and calls the OnShutdown functions, giving up after DrainTimeout.
+// It returns nil after a clean shutdown.
+func (s *Server) ListenAndServe() error {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = s.DefaultPort
+		slog.Info("Defaulting to port", "port", port)
+	}
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%s", port),
+		Handler:           s,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		ReadTimeout:       s.ReadTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signals)
+	errs := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "port", port)
+		errs <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+	return s.shutdown(srv)
+}
+
+// shutdown stops srv and then runs the OnShutdown functions, returning the first error.
+func (s *Server) shutdown(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.DrainTimeout)
+	defer cancel()
+	var result error
+	if err := srv.Shutdown(ctx); err != nil {
+		result = fmt.Errorf("failed to drain HTTP requests: %v", err)
+	}
+	s.lock.Lock()
+	fns := s.onShutdown
+	s.lock.Unlock()
+	for _, fn := range fns {
+		if err := fn(ctx); err != nil && result == nil {
+			result = err
+		}
+	}
+	if result == nil {
+		slog.Info("Shut down cleanly")
+	}
+	return result
+}
+
+func handleHealthz(rw http.ResponseWriter, r *http.Request) {
+	_, _ = rw.Write([]byte("ok"))
+}
+
+func (s *Server) handleReadyz(rw http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	checks := make(map[string]CheckFunc, len(s.checks))
+	for name, check := range s.checks {
+		checks[name] = check
+	}
+	s.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+	defer cancel()
+	var failures []string
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		http.Error(rw, strings.Join(failures, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = rw.Write([]byte("ok"))
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		checks         map[string]CheckFunc
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "healthz",
+			path:           "/healthz",
+			checks:         map[string]CheckFunc{"broken": func(context.Context) error { return errors.New("nope") }},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		{
+			name:           "readyz with no checks",
+			path:           "/readyz",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		{
+			name: "readyz with passing checks",
+			path: "/readyz",
+			checks: map[string]CheckFunc{
+				"a": func(context.Context) error { return nil },
+				"b": func(context.Context) error { return nil },
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "ok",
+		},
+		{
+			name: "readyz with failing checks",
+			path: "/readyz",
+			checks: map[string]CheckFunc{
+				"a": func(context.Context) error { return nil },
+				"b": func(context.Context) error { return errors.New("nope") },
+				"c": func(context.Context) error { return errors.New("also nope") },
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   "b: nope\nc: also nope\n",
+		},
+		{
+			name:           "service handler",
+			path:           "/webhook",
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New()
+			for name, check := range tc.checks {
+				s.AddReadinessCheck(name, check)
+			}
+			s.HandleFunc("/webhook", func(rw http.ResponseWriter, r *http.Request) {
+				rw.WriteHeader(http.StatusTeapot)
+			})
+
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, httptest.NewRequest("GET", tc.path, nil))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, but got %d", tc.expectedStatus, rr.Code)
+			}
+			if tc.expectedBody != "" && rr.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, but got %q", tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSlackAuthCheck(t *testing.T) {
+	calls := 0
+	ok := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if ok {
+			_, _ = w.Write([]byte(`{"ok": true, "team_id": "T1", "user_id": "U1"}`))
+		} else {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	c := slack.New(slack.Config{AccessToken: "xoxb-token"})
+	c.HTTPClient = server.Client()
+	c.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+
+	check := SlackAuthCheck(func() []*slack.Client { return []*slack.Client{c} })
+	if err := check(context.Background()); err == nil {
+		t.Errorf("expected the check to fail while auth.test fails")
+	}
+	ok = true
+	if err := check(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := check(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a successful auth.test to be remembered, but auth.test was called %d times", calls)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := New()
+	s.DrainTimeout = time.Second
+	var called []string
+	s.OnShutdown(func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Errorf("expected shutdown functions to get a deadline")
+		}
+		called = append(called, "first")
+		return errors.New("first failed")
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		called = append(called, "second")
+		return errors.New("second failed")
+	})
+
+	err := s.shutdown(&http.Server{Handler: s})
+	if err == nil || err.Error() != "first failed" {
+		t.Errorf("expected the first error to be returned, but got %v", err)
+	}
+	if len(called) != 2 || called[0] != "first" || called[1] != "second" {
+		t.Errorf("expected every shutdown function to be called in order, but got %v", called)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		unknownLength  bool
+		expectedStatus int
+	}{
+		{
+			name:           "small body",
+			body:           "honk",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "large body",
+			body:           strings.Repeat("a", 11),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "large body of unknown length",
+			body:           strings.Repeat("a", 11),
+			unknownLength:  true,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := New()
+			s.MaxBodyBytes = 10
+			s.HandleFunc("/webhook", func(rw http.ResponseWriter, r *http.Request) {
+				if _, err := ioutil.ReadAll(r.Body); err != nil {
+					http.Error(rw, err.Error(), http.StatusInternalServerError)
+				}
+			})
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tc.body))
+			if tc.unknownLength {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+			s.ServeHTTP(rr, req)
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, but got %d", tc.expectedStatus, rr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// authCheckInterval is how long a successful auth.test is trusted before it is repeated, so that
+// frequent probes don't use up the rate limit.
+const authCheckInterval = time.Minute
+
+// SlackAuthCheck returns a readiness check that passes once auth.test succeeds with every Client
+// returned by clients, other than those that only have an incoming webhook. clients is called on
+// every check, so that Clients added by reloading the config are checked too.
+func SlackAuthCheck(clients func() []*slack.Client) CheckFunc {
+	var lock sync.Mutex
+	passed := map[*slack.Client]time.Time{}
+	return func(ctx context.Context) error {
+		lock.Lock()
+		defer lock.Unlock()
+		current := map[*slack.Client]time.Time{}
+		for _, c := range clients() {
+			// There's nothing to check for a webhook without a token.
+			if c.CurrentConfig().WebhookOnly() {
+				continue
+			}
+			if t, ok := passed[c]; ok && time.Since(t) < authCheckInterval {
+				current[c] = t
+				continue
+			}
+			if _, err := api.New(c).AuthTest(ctx); err != nil {
+				delete(passed, c)
+				return fmt.Errorf("auth.test failed: %v", err)
+			}
+			current[c] = time.Now()
+			passed[c] = current[c]
+		}
+		// Forget Clients that have been removed from the set.
+		passed = current
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package logging sets up structured logging for our services using log/slog, and carries
+// request-scoped loggers in contexts.
+package logging
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Options configures the default logger.
+type Options struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string
+	// Format is text, for logfmt-style lines, or json, for one JSON object per line.
+	Format string
+}
+
+// AddFlags adds --log-level and --log-format flags to fs, writing their values to o.
+func (o *Options) AddFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.Level, "log-level", "info", "Minimum level to log: debug, info, warn or error")
+	fs.StringVar(&o.Format, "log-format", "text", "Log format: text or json")
+}
+
+// Setup makes a logger configured by o the default, for both log/slog and the standard log
+// package, and returns it. Every record is tagged with the service name.
+func Setup(o Options, service string) (*slog.Logger, error) {
+	handler, err := newHandler(os.Stderr, o)
+	if err != nil {
+		return nil, err
+	}
+	logger := slog.New(handler).With("service", service)
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+func newHandler(w io.Writer, o Options) (slog.Handler, error) {
+	level := slog.LevelInfo
+	if o.Level != "" {
+		if err := level.UnmarshalText([]byte(o.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %v", o.Level, err)
+		}
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(o.Format) {
+	case "", "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be text or json", o.Format)
+	}
+}
+
+type loggerKey struct{}
+
+// NewContext returns a context carrying logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the default logger if it has none.
+func FromContext(ctx context.Context) *slog.Logger {
+	return FromContextOr(ctx, slog.Default())
+}
+
+// FromContextOr returns the logger carried by ctx, or fallback if it has none. A nil fallback
+// means the default logger.
+func FromContextOr(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	if fallback == nil {
+		return slog.Default()
+	}
+	return fallback
+}
+
+// With returns a context whose logger adds the given attributes, as slog.Logger.With does, to
+// everything logged with it.
+func With(ctx context.Context, args ...interface{}) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(args...))
+}
+
+// Fatal logs msg at error level using the default logger and exits.
+func Fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     Options
+		expectErr   bool
+		expectDebug bool
+		expectInfo  bool
+		expected    string
+	}{
+		{
+			name:       "defaults",
+			expectInfo: true,
+			expected:   `level=INFO msg=hello team_id=T1`,
+		},
+		{
+			name:        "debug level",
+			options:     Options{Level: "debug"},
+			expectDebug: true,
+			expectInfo:  true,
+			expected:    `level=INFO msg=hello team_id=T1`,
+		},
+		{
+			name:    "warn level",
+			options: Options{Level: "WARN"},
+		},
+		{
+			name:       "json format",
+			options:    Options{Format: "json"},
+			expectInfo: true,
+			expected:   `"level":"INFO","msg":"hello","team_id":"T1"}`,
+		},
+		{
+			name:      "invalid level",
+			options:   Options{Level: "loud"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid format",
+			options:   Options{Format: "xml"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			handler, err := newHandler(buf, tc.options)
+			if err != nil {
+				if !tc.expectErr {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if tc.expectErr {
+				t.Fatalf("Expected an error, but got none")
+			}
+			logger := slog.New(handler)
+			ctx := context.Background()
+			if enabled := logger.Enabled(ctx, slog.LevelDebug); enabled != tc.expectDebug {
+				t.Errorf("Expected debug enabled to be %v, but got %v", tc.expectDebug, enabled)
+			}
+			logger.Info("hello", "team_id", "T1")
+			if !tc.expectInfo {
+				if buf.Len() != 0 {
+					t.Errorf("Expected nothing to be logged, but got %q", buf.String())
+				}
+				return
+			}
+			if !strings.Contains(buf.String(), tc.expected) {
+				t.Errorf("Expected output to contain %q, but got %q", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestWith(t *testing.T) {
+	buf := &bytes.Buffer{}
+	fallback := slog.New(slog.NewTextHandler(buf, nil))
+
+	ctx := context.Background()
+	if logger := FromContextOr(ctx, fallback); logger != fallback {
+		t.Fatalf("Expected the fallback logger for a context without one")
+	}
+	ctx = NewContext(ctx, fallback)
+	ctx = With(ctx, "team_id", "T1")
+	ctx = With(ctx, "channel", "C1")
+	FromContextOr(ctx, nil).Info("hello")
+
+	expected := "msg=hello team_id=T1 channel=C1\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Expected output ending in %q, but got %q", expected, buf.String())
+	}
+}
@@ -17,13 +17,14 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *Handler) handleChannelUnarchive(body []byte) ([]byte, error) {
+func (h *Handler) handleChannelUnarchive(ctx context.Context, client *slack.Client, body []byte) error {
 	unarchiveEvent := struct {
 		Event struct {
 			Channel string `json:"channel"`
@@ -31,14 +32,14 @@ func (h *Handler) handleChannelUnarchive(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &unarchiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *unarchived* by <@%s>", unarchiveEvent.Event.Channel, unarchiveEvent.Event.User)
-	return nil, nil
+	h.sendMessage(ctx, client, "Channel <#%s> was *unarchived* by <@%s>", unarchiveEvent.Event.Channel, unarchiveEvent.Event.User)
+	return nil
 }
 
-func (h *Handler) handleChannelRename(body []byte) ([]byte, error) {
+func (h *Handler) handleChannelRename(ctx context.Context, client *slack.Client, body []byte) error {
 	renameEvent := struct {
 		Event struct {
 			Channel struct {
@@ -49,16 +50,16 @@ func (h *Handler) handleChannelRename(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &renameEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	channel := renameEvent.Event.Channel
 
-	h.sendMessage("Channel <#%s> was *renamed* to %q", channel.ID, slack.EscapeMessage(channel.Name))
-	return nil, nil
+	h.sendMessage(ctx, client, "Channel <#%s> was *renamed* to %q", channel.ID, slack.EscapeMessage(channel.Name))
+	return nil
 }
 
-func (h *Handler) handleChannelDeleted(body []byte) ([]byte, error) {
+func (h *Handler) handleChannelDeleted(ctx context.Context, client *slack.Client, body []byte) error {
 	unarchiveEvent := struct {
 		Event struct {
 			Channel string `json:"channel"`
@@ -66,14 +67,14 @@ func (h *Handler) handleChannelDeleted(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &unarchiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *deleted*", unarchiveEvent.Event.Channel)
-	return nil, nil
+	h.sendMessage(ctx, client, "Channel <#%s> was *deleted*", unarchiveEvent.Event.Channel)
+	return nil
 }
 
-func (h *Handler) handleChannelCreated(body []byte) ([]byte, error) {
+func (h *Handler) handleChannelCreated(ctx context.Context, client *slack.Client, body []byte) error {
 	createEvent := struct {
 		Event struct {
 			Channel struct {
@@ -85,16 +86,16 @@ func (h *Handler) handleChannelCreated(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &createEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	channel := createEvent.Event.Channel
 
-	h.sendMessage("Channel <#%s|%s> was *created* by <@%s>", channel.ID, channel.Name, channel.Creator)
-	return nil, nil
+	h.sendMessage(ctx, client, "Channel <#%s|%s> was *created* by <@%s>", channel.ID, channel.Name, channel.Creator)
+	return nil
 }
 
-func (h *Handler) handleChannelArchive(body []byte) ([]byte, error) {
+func (h *Handler) handleChannelArchive(ctx context.Context, client *slack.Client, body []byte) error {
 	archiveEvent := struct {
 		Event struct {
 			Channel string `json:"channel"`
@@ -102,9 +103,9 @@ func (h *Handler) handleChannelArchive(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &archiveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
-	h.sendMessage("Channel <#%s> was *archived* by <@%s>", archiveEvent.Event.Channel, archiveEvent.Event.User)
-	return nil, nil
+	h.sendMessage(ctx, client, "Channel <#%s> was *archived* by <@%s>", archiveEvent.Event.Channel, archiveEvent.Event.User)
+	return nil
 }
@@ -17,12 +17,15 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *Handler) handleEmojiChanged(body []byte) ([]byte, error) {
+func (h *Handler) handleEmojiChanged(ctx context.Context, client *slack.Client, body []byte) error {
 	emojiEvent := struct {
 		Event struct {
 			Subtype string   `json:"subtype"`
@@ -32,25 +35,25 @@ func (h *Handler) handleEmojiChanged(body []byte) ([]byte, error) {
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &emojiEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 	emoji := emojiEvent.Event
 	if emoji.Subtype == "add" {
 		if strings.HasPrefix(emoji.Value, "alias:") {
-			h.sendMessage("A *new emoji alias was added*: `:%s:`. It's an alias for `:%s:`. :%s:", emoji.Name, strings.TrimPrefix(emoji.Value, "alias:"), emoji.Name)
+			h.sendMessage(ctx, client, "A *new emoji alias was added*: `:%s:`. It's an alias for `:%s:`. :%s:", emoji.Name, strings.TrimPrefix(emoji.Value, "alias:"), emoji.Name)
 		} else {
-			h.sendMessage("A *new emoji was added*: `:%s:` :%s:", emoji.Name, emoji.Name)
+			h.sendMessage(ctx, client, "A *new emoji was added*: `:%s:` :%s:", emoji.Name, emoji.Name)
 		}
 	} else if emoji.Subtype == "remove" {
 		if len(emoji.Names) == 1 {
-			h.sendMessage("An *emoji was deleted*: `:%s:`", emoji.Names[0])
+			h.sendMessage(ctx, client, "An *emoji was deleted*: `:%s:`", emoji.Names[0])
 		} else {
 			var aliases []string
 			for _, a := range emoji.Names {
 				aliases = append(aliases, "`:"+a+":`")
 			}
-			h.sendMessage("An *emoji was deleted*. It had several names: %s", strings.Join(aliases, ", "))
+			h.sendMessage(ctx, client, "An *emoji was deleted*. It had several names: %s", strings.Join(aliases, ", "))
 		}
 	}
-	return nil, nil
+	return nil
 }
@@ -18,122 +18,41 @@ limitations under the License.
 package handlers
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
 
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
 )
 
-type handlerFunc func(body []byte) ([]byte, error)
-
 // Handler handles Slack events.
 type Handler struct {
-	client *slack.Client
-}
-
-// New returns a new Handler.
-func New(client *slack.Client) *Handler {
-	return &Handler{client: client}
+	*events.Dispatcher
 }
 
-// HandleWebhook can be passed to http.HandlerFunc and will perform all processing associated with
-// Slack webhooks.
-func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		log.Printf(fmt.Sprintf("failed to read body: %v", err))
-		http.Error(w, fmt.Sprintf("failed to read body: %v", err), 500)
-		return
-	}
-	log.Printf("%#v", r.Header)
-	log.Printf(string(body))
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		log.Printf(fmt.Sprintf("signature verification failed: %v", err))
-		http.Error(w, fmt.Sprintf("signature verification failed: %v", err), 403)
-		return
-	}
-
-	response, err := h.HandleMessage(body)
-
-	if err != nil {
-		log.Printf("Handling message failed: %v", err)
-	}
-
-	if response != nil {
-		w.Header().Set("Content-Type", "application/json")
-		_, _ = w.Write(response)
-	}
-}
-
-// HandleMessage handles a Slack webhook that has already been validated.
-func (h *Handler) HandleMessage(body []byte) ([]byte, error) {
-	t := struct {
-		Type string `json:"type"`
-	}{}
-	if err := json.Unmarshal(body, &t); err != nil {
-		return nil, err
-	}
-
-	messageMapping := map[string]handlerFunc{
-		"url_verification": h.handleURLVerification,
-		"event_callback":   h.handleEvent,
-	}
-
-	fn, ok := messageMapping[t.Type]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type %q", t.Type)
-	}
-	output, err := fn(body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", t.Type, err)
-	}
-	return output, nil
+// New returns a new Handler, which handles events from every workspace in clients.
+func New(clients *slack.ClientSet) *Handler {
+	h := &Handler{Dispatcher: events.NewDispatcher(clients)}
+	h.HandleFunc("emoji_changed", h.handleEmojiChanged)
+	h.HandleFunc("team_join", h.handleTeamJoin)
+	h.HandleFunc("user_change", h.handleUserChange)
+	h.HandleFunc("team_rename", h.handleTeamRename)
+	h.HandleFunc("team_domain_change", h.handleTeamDomainChange)
+	h.HandleFunc("subteam_updated", h.handleSubteamUpdated)
+	h.HandleFunc("subteam_created", h.handleSubteamCreated)
+	h.HandleFunc("channel_unarchive", h.handleChannelUnarchive)
+	h.HandleFunc("channel_rename", h.handleChannelRename)
+	h.HandleFunc("channel_deleted", h.handleChannelDeleted)
+	h.HandleFunc("channel_created", h.handleChannelCreated)
+	h.HandleFunc("channel_archive", h.handleChannelArchive)
+	return h
 }
 
-func (h *Handler) sendMessage(message string, args ...interface{}) {
+func (h *Handler) sendMessage(ctx context.Context, client *slack.Client, message string, args ...interface{}) {
 	s := fmt.Sprintf(message, args...)
-	log.Printf("Sending message: %q", s)
-	if err := h.client.SendMessage(s); err != nil {
-		log.Printf("Sending message failed: %v", err)
-	}
-}
-
-func (h *Handler) handleEvent(body []byte) ([]byte, error) {
-	event := struct {
-		Event struct {
-			Type string `json:"type"`
-		} `json:"event"`
-	}{}
-	if err := json.Unmarshal(body, &event); err != nil {
-		return nil, fmt.Errorf("error unmarshalling event type: %v", err)
-	}
-	t := event.Event.Type
-
-	eventMapping := map[string]handlerFunc{
-		"emoji_changed":      h.handleEmojiChanged,
-		"team_join":          h.handleTeamJoin,
-		"user_change":        h.handleUserChange,
-		"team_rename":        h.handleTeamRename,
-		"team_domain_change": h.handleTeamDomainChange,
-		"subteam_updated":    h.handleSubteamUpdated,
-		"subteam_created":    h.handleSubteamCreated,
-		"channel_unarchive":  h.handleChannelUnarchive,
-		"channel_rename":     h.handleChannelRename,
-		"channel_deleted":    h.handleChannelDeleted,
-		"channel_created":    h.handleChannelCreated,
-		"channel_archive":    h.handleChannelArchive,
-	}
-
-	fn, ok := eventMapping[t]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type %q", t)
-	}
-	response, err := fn(body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", t, err)
+	logging.FromContext(ctx).Info("Sending message", "text", s)
+	if err := client.SendMessageContext(ctx, s); err != nil {
+		logging.FromContext(ctx).Error("Sending message failed", "error", err)
 	}
-	return response, nil
 }
@@ -17,60 +17,61 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *Handler) handleSubteamUpdated(body []byte) ([]byte, error) {
+func (h *Handler) handleSubteamUpdated(ctx context.Context, client *slack.Client, body []byte) error {
 	moveEvent := struct {
 		Event struct {
 			Subteam slack.Subteam `json:"subteam"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &moveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	subteam := moveEvent.Event.Subteam
 
 	if !subteam.IsUsergroup {
-		return nil, nil
+		return nil
 	}
 
 	if subteam.DeleteTime != 0 {
-		h.sendMessage("Usergroup %s (%q) was *deleted* by <@%s>", subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.DeletedBy)
-		return nil, nil
+		h.sendMessage(ctx, client, "Usergroup %s (%q) was *deleted* by <@%s>", subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.DeletedBy)
+		return nil
 	}
 
 	// These groups (@test-infra-oncall, @google-build-admin) are "modified" hourly and are usually an uninteresting noop,
 	// just filter it all.
 	// TODO(Katharine): make this configurable (or maintain enough state to know this is a noop)
 	if subteam.ID == "SGLF0GUQH" || subteam.ID == "S017N31TLNN" {
-		return nil, nil
+		return nil
 	}
 
-	h.sendMessage("Usergroup <!subteam^%s|%s> (%q) was *updated* by <@%s>", subteam.ID, subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.UpdatedBy)
-	return nil, nil
+	h.sendMessage(ctx, client, "Usergroup <!subteam^%s|%s> (%q) was *updated* by <@%s>", subteam.ID, subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.UpdatedBy)
+	return nil
 }
 
-func (h *Handler) handleSubteamCreated(body []byte) ([]byte, error) {
+func (h *Handler) handleSubteamCreated(ctx context.Context, client *slack.Client, body []byte) error {
 	moveEvent := struct {
 		Event struct {
 			Subteam slack.Subteam `json:"subteam"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &moveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	subteam := moveEvent.Event.Subteam
 
 	if !subteam.IsUsergroup {
-		return nil, nil
+		return nil
 	}
 
-	h.sendMessage("Usergroup <!subteam^%s|%s> (%q) was *created* by <@%s>", subteam.ID, subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.CreatedBy)
-	return nil, nil
+	h.sendMessage(ctx, client, "Usergroup <!subteam^%s|%s> (%q) was *created* by <@%s>", subteam.ID, subteam.Handle, slack.EscapeMessage(subteam.Name), subteam.CreatedBy)
+	return nil
 }
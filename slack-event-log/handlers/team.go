@@ -17,32 +17,35 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *Handler) handleTeamRename(body []byte) ([]byte, error) {
+func (h *Handler) handleTeamRename(ctx context.Context, client *slack.Client, body []byte) error {
 	renameEvent := struct {
 		Event struct {
 			Name string `json:"name"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &renameEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
-	h.sendMessage("The *Slack team was renamed* to %q", renameEvent.Event.Name)
-	return nil, nil
+	h.sendMessage(ctx, client, "The *Slack team was renamed* to %q", renameEvent.Event.Name)
+	return nil
 }
 
-func (h *Handler) handleTeamDomainChange(body []byte) ([]byte, error) {
+func (h *Handler) handleTeamDomainChange(ctx context.Context, client *slack.Client, body []byte) error {
 	moveEvent := struct {
 		Event struct {
 			URL string `json:"url"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &moveEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
-	h.sendMessage("The *Slack team moved* to %s", moveEvent.Event.URL)
-	return nil, nil
+	h.sendMessage(ctx, client, "The *Slack team moved* to %s", moveEvent.Event.URL)
+	return nil
 }
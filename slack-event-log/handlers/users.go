@@ -17,20 +17,21 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"sigs.k8s.io/slack-infra/slack"
 )
 
-func (h *Handler) handleTeamJoin(body []byte) ([]byte, error) {
+func (h *Handler) handleTeamJoin(ctx context.Context, client *slack.Client, body []byte) error {
 	userEvent := struct {
 		Event struct {
 			User slack.User `json:"user"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &userEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	user := userEvent.Event.User
@@ -38,23 +39,25 @@ func (h *Handler) handleTeamJoin(body []byte) ([]byte, error) {
 	if displayName == "" {
 		displayName = "_none_"
 	}
-	h.sendMessage(fmt.Sprintf("A *new user joined*: <@%s> (display name: %s, real name: %s)", user.ID, displayName, slack.EscapeMessage(user.Profile.RealName)))
-	return nil, nil
+	h.sendMessage(ctx, client, fmt.Sprintf("A *new user joined*: <@%s> (display name: %s, real name: %s)", user.ID, displayName, slack.EscapeMessage(user.Profile.RealName)))
+	return nil
 }
 
-func (h *Handler) handleUserChange(body []byte) ([]byte, error) {
+func (h *Handler) handleUserChange(ctx context.Context, client *slack.Client, body []byte) error {
 	userEvent := struct {
 		Event struct {
 			User slack.User `json:"user"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &userEvent); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json: %v", err)
+		return fmt.Errorf("failed to unmarshal json: %v", err)
 	}
 
 	user := userEvent.Event.User
+	// The event carries the full user, so keep the cache current for free.
+	client.Users().Store(user)
 	if user.Deleted {
-		h.sendMessage("A *user was deactivated*: <@%s> (this is heuristic: they are definitely deactivated now, but may also have been before)", user.ID)
+		h.sendMessage(ctx, client, "A *user was deactivated*: <@%s> (this is heuristic: they are definitely deactivated now, but may also have been before)", user.ID)
 	}
-	return nil, nil
+	return nil
 }
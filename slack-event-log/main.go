@@ -17,53 +17,83 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"log"
-	"net/http"
 	"os"
 
+	"sigs.k8s.io/slack-infra/httpserver"
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-event-log/handlers"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+	"sigs.k8s.io/slack-infra/tracing"
 )
 
 type options struct {
 	configPath string
+	workers    int
+	queueSize  int
+	socketMode bool
+	logging    logging.Options
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Receive events using Socket Mode instead of the webhook (requires an appToken)")
+	flag.IntVar(&o.workers, "workers", 4, "Number of events to handle concurrently")
+	flag.IntVar(&o.queueSize, "queue-size", 100, "Number of events that can wait to be handled before Slack is asked to retry later")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
-func handleHealthz(w http.ResponseWriter, r *http.Request) {
-	_, _ = w.Write([]byte("ok"))
-}
-
-func runServer(client *slack.Client) {
-	h := handlers.New(client)
-
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
-	http.HandleFunc("/healthz", handleHealthz)
+func runServer(clients *slack.ClientSet, c slack.Config, o options) {
+	h := handlers.New(clients)
+	h.Async(o.workers, o.queueSize)
+	if o.socketMode {
+		go runSocketMode(c, h)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Printf("Defaulting to port %s", port)
+	srv := httpserver.New()
+	srv.AddReadinessCheck("slack", httpserver.SlackAuthCheck(clients.All))
+	// Finish handling the events we have already acknowledged before exiting.
+	srv.OnShutdown(h.Shutdown)
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/webhook", tracing.Handler("webhook", h))
+	if err := srv.ListenAndServe(); err != nil {
+		logging.Fatal("Failed to serve", "error", err)
 	}
+}
 
-	log.Printf("Listening on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
+func runSocketMode(c slack.Config, h *handlers.Handler) {
+	sm, err := socketmode.New(c)
+	if err != nil {
+		logging.Fatal("Failed to set up socket mode", "error", err)
+	}
+	logging.Fatal("Socket mode stopped", "error", sm.Run(context.Background(), h.HandleSocketModeEnvelope))
 }
 
 func main() {
 	o := parseFlags()
+	if _, err := logging.Setup(o.logging, "slack-event-log"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "slack-event-log")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	c, err := slack.LoadConfig(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to load config", "path", o.configPath, "error", err)
+	}
+	clients, err := slack.LoadClientSet(o.configPath)
+	if err != nil {
+		logging.Fatal("Failed to load workspaces", "path", o.configPath, "error", err)
 	}
-	s := slack.New(c)
-	runServer(s)
+	go slack.WatchConfig(context.Background(), o.configPath, func() error {
+		return clients.Reload(o.configPath)
+	})
+	runServer(clients, c, o)
 }
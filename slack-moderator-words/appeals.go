@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+)
+
+// IDs of the parts of the appeal flow.
+const (
+	// actionAppeal is the button on the direct message explaining a match, and
+	// blockAppealedMessage is the block in it that quotes the message.
+	actionAppeal         = "appeal"
+	blockAppealedMessage = "appealed_message"
+	// callbackAppeal is the modal that the button opens, and blockAppealReason and
+	// actionAppealReason are its input.
+	callbackAppeal     = "appeal"
+	blockAppealReason  = "appeal_reason"
+	actionAppealReason = "reason"
+)
+
+// maxAppealedMessage is how much of the appealed message is quoted, so that it fits in the
+// modal's private metadata.
+const maxAppealedMessage = 2000
+
+// appeal is what an appeal is about. It is the value of the appeal button, and the private metadata
+// of the modal it opens, with the message it quotes added.
+type appeal struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+	Deleted bool   `json:"deleted,omitempty"`
+	// EscalationChannel and EscalationTS are the match's escalation, which the appeal is posted in
+	// the thread of, if it was escalated.
+	EscalationChannel string `json:"escalation_channel,omitempty"`
+	EscalationTS      string `json:"escalation_ts,omitempty"`
+	Message           string `json:"message,omitempty"`
+}
+
+// offerAppeal sends the author of a message that a filter deleted or warned them about a direct
+// message explaining what happened, with a button to appeal to the moderators.
+func (h *handler) offerAppeal(ctx context.Context, client *slack.Client, m *match) error {
+	channel, _ := m.event.Channel.(string)
+	c := api.New(client)
+	dm, err := c.OpenConversation(ctx, m.event.User)
+	if err != nil {
+		return fmt.Errorf("failed to open DM: %v", err)
+	}
+	a := appeal{Channel: channel, TS: m.event.TS, Deleted: m.deleted, EscalationChannel: m.escalation.Channel, EscalationTS: m.escalation.TS}
+	value, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to marshal appeal: %v", err)
+	}
+	text := fmt.Sprintf("Your message in <#%s> matched one of the community's filters.", channel)
+	if m.deleted {
+		text = fmt.Sprintf("Your message in <#%s> was removed because it matched one of the community's filters.", channel)
+	}
+	b := []blocks.Block{blocks.Section(blocks.Markdown(text))}
+	if m.message != "" {
+		b = append(b, blocks.Section(blocks.Markdown("*Why*\n"+m.message)))
+	}
+	message := blocks.Section(blocks.Markdown(quote(truncate(m.event.Content(), maxAppealedMessage))))
+	message.BlockID = blockAppealedMessage
+	b = append(b, message,
+		blocks.Context(blocks.Markdown("If you think this was a mistake, you can appeal to the moderators.")),
+		blocks.Actions(blocks.Button(actionAppeal, "Appeal", string(value))),
+	)
+	if _, err := c.PostMessage(ctx, api.PostMessageRequest{Channel: dm, Text: text, Blocks: b}); err != nil {
+		return fmt.Errorf("failed to send appeal DM: %v", err)
+	}
+	return nil
+}
+
+// truncate returns s, cut short with an ellipsis if it is longer than n runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// handleAppeals registers the handlers for the appeal button and the modal it opens with ih.
+func (h *handler) handleAppeals(ih *interactive.Handler) {
+	ih.HandleFunc(interactive.TypeBlockActions, actionAppeal, handleAppealButton)
+	ih.HandleFunc(interactive.TypeViewSubmission, callbackAppeal, h.handleAppealSubmission)
+}
+
+// handleAppealButton opens the modal that asks why the filter was wrong.
+func handleAppealButton(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	a := appeal{}
+	if err := json.Unmarshal([]byte(p.Action(actionAppeal).Value.Value), &a); err != nil {
+		return nil, fmt.Errorf("malformed appeal: %v", err)
+	}
+	a.Message = appealedMessage(p.Message.Blocks)
+	reason := blocks.PlainTextInput(actionAppealReason)
+	reason.Multiline = true
+	reason.MaxLength = 2000
+	view := blocks.Modal(callbackAppeal, "Appeal",
+		blocks.Section(blocks.Markdown("Tell the moderators why you think the filter got this wrong. They'll get back to you.")),
+		blocks.Input(blockAppealReason, "Why should this be reconsidered?", reason),
+	)
+	view.Submit = blocks.PlainText("Send")
+	if err := view.SetMetadata(a); err != nil {
+		return nil, err
+	}
+	if _, err := api.New(p.Client()).OpenView(ctx, p.TriggerID, view); err != nil {
+		return nil, fmt.Errorf("failed to open appeal modal: %v", err)
+	}
+	return nil, nil
+}
+
+// appealedMessage returns the quote of the appealed message in the blocks of the direct message
+// that offered the appeal.
+func appealedMessage(raw []json.RawMessage) string {
+	for _, b := range raw {
+		block := struct {
+			BlockID string `json:"block_id"`
+			Text    struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		}{}
+		if err := json.Unmarshal(b, &block); err == nil && block.BlockID == blockAppealedMessage {
+			return block.Text.Text
+		}
+	}
+	return ""
+}
+
+// handleAppealSubmission posts an appeal to the moderators channel, in the thread of the match's
+// escalation if it was escalated, and thanks the appellant.
+func (h *handler) handleAppealSubmission(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	a := appeal{}
+	if err := p.View.Metadata(&a); err != nil {
+		return nil, err
+	}
+	reason, _ := p.View.Value(blockAppealReason, actionAppealReason)
+	if strings.TrimSpace(reason.Value) == "" {
+		return &interactive.Response{ResponseAction: interactive.ResponseErrors, Errors: map[string]string{blockAppealReason: "Please say why."}}, nil
+	}
+	client := p.Client()
+	channel, thread := a.EscalationChannel, a.EscalationTS
+	if channel == "" || thread == "" {
+		moderators, err := moderatorsChannel(ctx, client, h.rules())
+		if err != nil {
+			return nil, err
+		}
+		channel, thread = moderators, ""
+	}
+	action := "a filter's warning about"
+	if a.Deleted {
+		action = "a filter deleting"
+	}
+	text := fmt.Sprintf(":scales: <@%s> appealed against %s their message in <#%s>", p.User.ID, action, a.Channel)
+	b := []blocks.Block{blocks.Section(blocks.Markdown(text + ":\n" + quote(reason.Value)))}
+	if a.Message != "" {
+		b = append(b, blocks.Section(blocks.Markdown("*Their message*\n"+a.Message)))
+	}
+	logging.FromContext(ctx).Info("Appeal submitted", "message_channel", a.Channel, "ts", a.TS)
+	if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: channel, Text: text, Blocks: b, ThreadTS: thread}); err != nil {
+		return nil, fmt.Errorf("failed to post appeal: %v", err)
+	}
+	thanks := blocks.Modal(callbackAppeal, "Appeal", blocks.Section(blocks.Markdown("Thanks, your appeal has been sent to the moderators.")))
+	thanks.Close = blocks.PlainText("Close")
+	return &interactive.Response{ResponseAction: interactive.ResponseUpdate, View: thanks}, nil
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// appealServer returns a server that records the methods called on it and the bodies of the
+// messages posted and views opened with it.
+func appealServer(calls *[]string, bodies *[]map[string]interface{}) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/api/")
+		*calls = append(*calls, method)
+		w.Header().Set("Content-Type", "application/json")
+		switch method {
+		case "conversations.list":
+			_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0MOD", "name": "moderators"}]}`))
+			return
+		case "conversations.open":
+			_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "D1"}}`))
+			return
+		case "chat.postMessage", "views.open":
+			body := map[string]interface{}{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			*bodies = append(*bodies, body)
+			if method == "chat.postMessage" {
+				_, _ = w.Write([]byte(`{"ok": true, "channel": "C0MOD", "ts": "1612790190.000100"}`))
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+}
+
+func TestOfferAppeal(t *testing.T) {
+	tests := []struct {
+		name          string
+		steps         []string
+		expectedCalls []string
+		expectedValue *appeal
+	}{
+		{
+			name:          "deleted and escalated",
+			steps:         []string{model.ActionDelete, model.ActionEscalate},
+			expectedCalls: []string{"chat.getPermalink", "chat.delete", "conversations.list", "chat.postMessage", "conversations.open", "chat.postMessage"},
+			expectedValue: &appeal{Channel: "C1", TS: "1612790186.002000", Deleted: true, EscalationChannel: "C0MOD", EscalationTS: "1612790190.000100"},
+		},
+		{
+			name:          "warned",
+			steps:         []string{model.ActionPostEphemeral},
+			expectedCalls: []string{"chat.postEphemeral", "conversations.open", "chat.postMessage"},
+			expectedValue: &appeal{Channel: "C1", TS: "1612790186.002000"},
+		},
+		{
+			name:          "only logged",
+			steps:         []string{model.ActionLog},
+			expectedCalls: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var bodies []map[string]interface{}
+			server := appealServer(&calls, &bodies)
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			h := newHandler(nil, model.Config{ModeratorsChannel: "#moderators", Appeals: true})
+			h.runActions(context.Background(), client, &match{
+				rules:    h.rules(),
+				steps:    tc.steps,
+				event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+				triggers: []string{"free crypto"},
+			})
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			if tc.expectedValue == nil {
+				return
+			}
+			dm := bodies[len(bodies)-1]
+			blocks, _ := json.Marshal(dm["blocks"])
+			var parsed []struct {
+				Elements []struct {
+					ActionID string `json:"action_id"`
+					Value    string `json:"value"`
+				} `json:"elements"`
+			}
+			_ = json.Unmarshal(blocks, &parsed)
+			var value string
+			for _, b := range parsed {
+				for _, e := range b.Elements {
+					if e.ActionID == actionAppeal {
+						value = e.Value
+					}
+				}
+			}
+			a := appeal{}
+			if err := json.Unmarshal([]byte(value), &a); err != nil {
+				t.Fatalf("failed to parse appeal button value %q: %v", value, err)
+			}
+			if dm["channel"] != "D1" || !reflect.DeepEqual(a, *tc.expectedValue) {
+				t.Errorf("expected a DM offering appeal %+v, but sent %v", *tc.expectedValue, dm)
+			}
+		})
+	}
+}
+
+func TestAppealButton(t *testing.T) {
+	var calls []string
+	var bodies []map[string]interface{}
+	server := appealServer(&calls, &bodies)
+	defer server.Close()
+	client := testClient(server)
+
+	ih := interactive.NewHandler(slack.NewClientSet(client))
+	newHandler(nil, model.Config{}).handleAppeals(ih)
+	payload := `{
+		"type": "block_actions",
+		"team": {"id": "T1"},
+		"user": {"id": "U1"},
+		"trigger_id": "123.456",
+		"message": {"text": "Your message was removed", "blocks": [{"type": "section", "block_id": "appealed_message", "text": {"type": "mrkdwn", "text": "> free crypto"}}]},
+		"actions": [{"action_id": "appeal", "value": "{\"channel\": \"C1\", \"ts\": \"1612790186.002000\", \"deleted\": true}"}]
+	}`
+	if _, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(calls, []string{"views.open"}) {
+		t.Fatalf("expected a modal to be opened, but got calls %v", calls)
+	}
+	view, _ := bodies[0]["view"].(map[string]interface{})
+	a := appeal{}
+	if err := json.Unmarshal([]byte(view["private_metadata"].(string)), &a); err != nil {
+		t.Fatalf("failed to parse private metadata: %v", err)
+	}
+	expected := appeal{Channel: "C1", TS: "1612790186.002000", Deleted: true, Message: "> free crypto"}
+	if !reflect.DeepEqual(a, expected) {
+		t.Errorf("expected metadata %+v, but got %+v", expected, a)
+	}
+}
+
+func TestAppealSubmission(t *testing.T) {
+	tests := []struct {
+		name             string
+		metadata         string
+		reason           string
+		expectedCalls    []string
+		expectedThread   interface{}
+		expectedResponse string
+	}{
+		{
+			name:             "escalated",
+			metadata:         `{"channel": "C1", "ts": "1612790186.002000", "deleted": true, "escalation_channel": "C0MOD", "escalation_ts": "1612790190.000100"}`,
+			reason:           "It was a joke about crypto scams.",
+			expectedCalls:    []string{"chat.postMessage"},
+			expectedThread:   "1612790190.000100",
+			expectedResponse: interactive.ResponseUpdate,
+		},
+		{
+			name:             "not escalated",
+			metadata:         `{"channel": "C1", "ts": "1612790186.002000"}`,
+			reason:           "It was a joke about crypto scams.",
+			expectedCalls:    []string{"conversations.list", "chat.postMessage"},
+			expectedResponse: interactive.ResponseUpdate,
+		},
+		{
+			name:             "no reason",
+			metadata:         `{"channel": "C1", "ts": "1612790186.002000"}`,
+			reason:           " ",
+			expectedResponse: interactive.ResponseErrors,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var bodies []map[string]interface{}
+			server := appealServer(&calls, &bodies)
+			defer server.Close()
+			client := testClient(server)
+
+			ih := interactive.NewHandler(slack.NewClientSet(client))
+			newHandler(nil, model.Config{ModeratorsChannel: "#moderators"}).handleAppeals(ih)
+			metadata, _ := json.Marshal(tc.metadata)
+			reason, _ := json.Marshal(tc.reason)
+			payload := `{
+				"type": "view_submission",
+				"team": {"id": "T1"},
+				"user": {"id": "U1"},
+				"view": {"callback_id": "appeal", "private_metadata": ` + string(metadata) + `, "state": {"values": {"appeal_reason": {"reason": {"type": "plain_text_input", "value": ` + string(reason) + `}}}}}
+			}`
+			resp, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			response := struct {
+				ResponseAction string `json:"response_action"`
+			}{}
+			if err := json.Unmarshal(resp, &response); err != nil || response.ResponseAction != tc.expectedResponse {
+				t.Errorf("expected response action %q, but got %s", tc.expectedResponse, resp)
+			}
+			if len(bodies) == 0 {
+				return
+			}
+			if bodies[0]["channel"] != "C0MOD" || bodies[0]["thread_ts"] != tc.expectedThread {
+				t.Errorf("expected the appeal in C0MOD, in thread %v, but posted %v", tc.expectedThread, bodies[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+)
+
+const (
+	// actionToggleDryRun is the button on the App Home tab that switches shadow mode on, if its
+	// value is "on", or off.
+	actionToggleDryRun = "toggle_dry_run"
+	// homeFilters is how many filters the App Home tab lists, and homeTopUsers how many of the
+	// users with the most matches it shows.
+	homeFilters  = 25
+	homeTopUsers = 5
+)
+
+// matchCount is how many matches there have been for one user or filter.
+type matchCount struct {
+	Key   string
+	Count int
+}
+
+// CountMatches returns how many matches there have been since since.
+func (s *matchStore) CountMatches(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM matches WHERE matched_at >= $1`, since.UnixMilli()).Scan(&count)
+	return count, err
+}
+
+// TopUsers returns the limit users with the most matches since since, most first.
+func (s *matchStore) TopUsers(ctx context.Context, since time.Time, limit int) ([]matchCount, error) {
+	return s.countBy(ctx, "user_id", since, limit)
+}
+
+// FilterCounts returns how many matches each filter has had since since, by its label.
+func (s *matchStore) FilterCounts(ctx context.Context, since time.Time) (map[string]int, error) {
+	counts, err := s.countBy(ctx, "filter", since, 0)
+	if err != nil {
+		return nil, err
+	}
+	byFilter := map[string]int{}
+	for _, c := range counts {
+		byFilter[c.Key] = c.Count
+	}
+	return byFilter, nil
+}
+
+// countBy counts the matches since since for each value of column, which must be one of the
+// matches table's, most first and then by value. If limit is positive, only that many are returned.
+func (s *matchStore) countBy(ctx context.Context, column string, since time.Time, limit int) ([]matchCount, error) {
+	query := `SELECT ` + column + `, COUNT(*) AS n FROM matches WHERE matched_at >= $1 GROUP BY ` + column + ` ORDER BY n DESC, ` + column
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []matchCount
+	for rows.Next() {
+		var c matchCount
+		if err := rows.Scan(&c.Key, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// handleAppHomeOpened shows users the App Home tab when they open it.
+// Slack Event needed for this: app_home_opened
+func (h *handler) handleAppHomeOpened(ctx context.Context, client *slack.Client, event events.AppHomeOpened) error {
+	hash := ""
+	if event.View != nil {
+		hash = event.View.Hash
+	}
+	return h.publishHome(ctx, client, event.User, hash)
+}
+
+// handleHome handles the buttons on the App Home tab.
+func (h *handler) handleHome(ih *interactive.Handler) {
+	ih.HandleFunc(interactive.TypeBlockActions, actionToggleDryRun, h.handleToggleDryRun)
+}
+
+// publishHome publishes the App Home tab that user sees: the dashboard if they're a moderator, and
+// a note saying what the app does otherwise.
+func (h *handler) publishHome(ctx context.Context, client *slack.Client, user, hash string) error {
+	r := h.rules()
+	moderator, err := h.isModerator(ctx, client, r, user)
+	if err != nil {
+		return fmt.Errorf("failed to check whether %s is a moderator: %v", user, err)
+	}
+	var view *blocks.View
+	if moderator {
+		view = blocks.Home(h.dashboard(ctx, r)...)
+	} else {
+		view = blocks.Home(blocks.Section(blocks.Markdown(homeNote(r))))
+	}
+	if _, err := api.New(client).PublishView(ctx, user, view, hash); err != nil {
+		return fmt.Errorf("failed to publish App Home tab: %v", err)
+	}
+	return nil
+}
+
+// homeNote is what users who aren't moderators see on the App Home tab.
+func homeNote(r *rules) string {
+	note := "I moderate messages in this workspace, and take down the ones that break its rules."
+	if r.config.ModeratorUsergroup == "" {
+		return note
+	}
+	return note + fmt.Sprintf(" Members of <!subteam^%s> can see what I've been doing here.", r.config.ModeratorUsergroup)
+}
+
+// dashboard returns the blocks of the App Home tab that moderators see: whether filters are
+// enforced, with a button to switch, the active filters and, if there is a match store, how many
+// matches there have been lately and who they came from.
+func (h *handler) dashboard(ctx context.Context, r *rules) []blocks.Block {
+	var b []blocks.Block
+	if h.inShadowMode(ctx) {
+		b = append(b,
+			blocks.Section(blocks.Markdown(":eyes: *Dry run*: filters only log what they would do, without warning anyone or deleting anything.")),
+			blocks.Actions(&blocks.ButtonElement{Text: blocks.PlainText("Enforce filters"), ActionID: actionToggleDryRun, Value: "off", Style: blocks.StylePrimary}))
+	} else {
+		b = append(b,
+			blocks.Section(blocks.Markdown(":shield: Filters are *enforced*.")),
+			blocks.Actions(&blocks.ButtonElement{Text: blocks.PlainText("Switch to dry run"), ActionID: actionToggleDryRun, Value: "on", Style: blocks.StyleDanger}))
+	}
+	b = append(b, blocks.Divider())
+
+	var week map[string]int
+	if h.store == nil {
+		b = append(b, blocks.Section(blocks.Markdown("Match counts need a database to record matches in, with `--database`.")))
+	} else if stats, counts, err := h.matchStats(ctx); err != nil {
+		logging.FromContext(ctx).Error("Failed to count matches for App Home tab", "error", err)
+		b = append(b, blocks.Section(blocks.Markdown(":warning: Couldn't count matches.")))
+	} else {
+		week = counts
+		b = append(b, stats...)
+	}
+
+	b = append(b, blocks.Divider(), blocks.Section(blocks.Markdown(filterList(r, week))))
+	if r.config.Version != "" {
+		b = append(b, blocks.Context(blocks.Markdown(fmt.Sprintf("Filter config v%s", r.config.Version))))
+	}
+	return b
+}
+
+// matchStats returns the blocks showing how many matches there have been in the last day and week,
+// and the users with the most in the last week, and how many matches each filter has had in it.
+func (h *handler) matchStats(ctx context.Context) ([]blocks.Block, map[string]int, error) {
+	now := h.store.now()
+	day, err := h.store.CountMatches(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, nil, err
+	}
+	week, err := h.store.CountMatches(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, nil, err
+	}
+	top, err := h.store.TopUsers(ctx, now.Add(-7*24*time.Hour), homeTopUsers)
+	if err != nil {
+		return nil, nil, err
+	}
+	byFilter, err := h.store.FilterCounts(ctx, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := []string{"*Top users*, last 7 days"}
+	for i, c := range top {
+		lines = append(lines, fmt.Sprintf("%d. <@%s>: %s", i+1, c.Key, plural(c.Count, "match", "matches")))
+	}
+	if len(top) == 0 {
+		lines = append(lines, "Nobody has matched a filter.")
+	}
+	return []blocks.Block{
+		blocks.Section(blocks.Markdown("*Matches*"),
+			blocks.Markdown(fmt.Sprintf("*Last 24 hours*\n%d", day)),
+			blocks.Markdown(fmt.Sprintf("*Last 7 days*\n%d", week))),
+		blocks.Section(blocks.Markdown(strings.Join(lines, "\n"))),
+	}, byFilter, nil
+}
+
+// filterList returns the list of active filters on the App Home tab, with what they do and, if
+// week is set, how many matches they've had in the last week.
+func filterList(r *rules, week map[string]int) string {
+	lines := []string{fmt.Sprintf("*Active filters* (%d)", len(r.filters))}
+	for i, f := range r.filters {
+		if i == homeFilters {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(r.filters)-homeFilters))
+			break
+		}
+		line := fmt.Sprintf("• %s: %s", f.Label(i), strings.Join(f.Steps(), ", "))
+		if week != nil {
+			line += fmt.Sprintf(" (%s this week)", plural(week[f.Label(i)], "match", "matches"))
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// handleToggleDryRun switches shadow mode on or off when a moderator asks for it on the App Home
+// tab, and shows them the tab again. The switch is kept in the dry run store, so every replica
+// sharing it switches too.
+func (h *handler) handleToggleDryRun(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	r := h.rules()
+	moderator, err := h.isModerator(ctx, p.Client(), r, p.User.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check whether %s is a moderator: %v", p.User.ID, err)
+	}
+	if !moderator {
+		return nil, fmt.Errorf("%s isn't a moderator, so can't switch dry run", p.User.ID)
+	}
+	on := p.Action(actionToggleDryRun).Value.Value == "on"
+	was := h.inShadowMode(ctx)
+	if err := h.dryRun.SetDryRun(ctx, on); err != nil {
+		return nil, fmt.Errorf("failed to switch dry run: %v", err)
+	}
+	if was != on {
+		logging.FromContext(ctx).Info("Switched dry run", "dry_run", on, "user_id", p.User.ID)
+		text := fmt.Sprintf(":scroll: <@%s> started enforcing filters", p.User.ID)
+		if on {
+			text = fmt.Sprintf(":scroll: <@%s> switched filters to dry run", p.User.ID)
+		}
+		if r.audit != "" {
+			if err := h.audit(ctx, p.Client(), r, text); err != nil {
+				logging.FromContext(ctx).Error("Failed to record dry run switch in audit channel", "error", err)
+			}
+		}
+	}
+	return nil, h.publishHome(ctx, p.Client(), p.User.ID, "")
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+func TestMatchStoreCounts(t *testing.T) {
+	store, _ := testMatchStore(t)
+	now := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	for _, r := range []matchRecord{
+		{Time: now.Add(-8 * 24 * time.Hour), User: "U3", Filter: "filter 1"},
+		{Time: now.Add(-2 * 24 * time.Hour), User: "U1", Filter: "filter 1"},
+		{Time: now.Add(-time.Hour), User: "U2", Filter: "filter 2"},
+		{Time: now.Add(-time.Minute), User: "U2", Filter: "filter 1"},
+	} {
+		if err := store.Record(context.Background(), r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	since := now.Add(-7 * 24 * time.Hour)
+
+	if count, err := store.CountMatches(context.Background(), now.Add(-24*time.Hour)); err != nil || count != 2 {
+		t.Errorf("expected 2 matches in the last day, but got %d (error %v)", count, err)
+	}
+	top, err := store.TopUsers(context.Background(), since, 5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []matchCount{{"U2", 2}, {"U1", 1}}; !reflect.DeepEqual(top, expected) {
+		t.Errorf("expected top users %v, but got %v", expected, top)
+	}
+	byFilter, err := store.FilterCounts(context.Background(), since)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]int{"filter 1": 2, "filter 2": 1}; !reflect.DeepEqual(byFilter, expected) {
+		t.Errorf("expected filter counts %v, but got %v", expected, byFilter)
+	}
+}
+
+func TestAppHome(t *testing.T) {
+	var published []string
+	var posted []api.PostMessageRequest
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(r.URL.Path, "/api/") {
+		case "usergroups.users.list":
+			_, _ = w.Write([]byte(`{"ok": true, "users": ["U1"]}`))
+			return
+		case "views.publish":
+			req := struct {
+				UserID string          `json:"user_id"`
+				View   json.RawMessage `json:"view"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			published = append(published, req.UserID+": "+strings.NewReplacer(`\u003c`, "<", `\u003e`, ">").Replace(string(req.View)))
+		case "chat.postMessage":
+			req := api.PostMessageRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			posted = append(posted, req)
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token"})
+
+	store, _ := testMatchStore(t)
+	now := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	for _, user := range []string{"U2", "U2", "U3"} {
+		if err := store.Record(context.Background(), matchRecord{Time: now.Add(-time.Hour), User: user, Filter: "filter 1"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	config := model.Config{ModeratorUsergroup: "S1", AuditChannel: "G0AUDITLOG", Version: "1", Filters: model.FilterConfig{
+		{Triggers: []string{"guys"}, Action: model.ActionLog},
+		{Name: "crypto", Triggers: []string{"airdrop"}, Actions: []string{model.ActionLog, model.ActionDelete}},
+	}}
+	if err := config.Compile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(slack.NewClientSet(client), config)
+	h.store, h.dryRun = store, store
+	// Another replica, sharing the database.
+	other := newHandler(nil, config)
+	other.dryRun = store
+	ih := interactive.NewHandler(slack.NewClientSet(client))
+	h.handleHome(ih)
+
+	open := func(user string) string {
+		t.Helper()
+		published = nil
+		body := `{"type": "event_callback", "team_id": "T1", "event": {"type": "app_home_opened", "user": "` + user + `", "channel": "D1", "tab": "home"}}`
+		if err := h.Dispatch(context.Background(), []byte(body)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(published) != 1 {
+			t.Fatalf("expected one App Home tab to be published, but got %v", published)
+		}
+		return published[0]
+	}
+	toggle := func(user, value string) error {
+		published = nil
+		payload := `{"type": "block_actions", "team": {"id": "T1"}, "user": {"id": "` + user + `"}, "actions": [{"action_id": "` + actionToggleDryRun + `", "value": "` + value + `"}]}`
+		_, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload))
+		return err
+	}
+
+	home := open("U1")
+	for _, expected := range []string{
+		`U1: {"type":"home"`,
+		"Filters are *enforced*",
+		`"value":"on"`,
+		`*Last 24 hours*\n3`,
+		`1. <@U2>: 2 matches\n2. <@U3>: 1 match`,
+		`*Active filters* (2)\n• filter 1: log (3 matches this week)\n• crypto: log, delete (0 matches this week)`,
+		"Filter config v1",
+	} {
+		if !strings.Contains(home, expected) {
+			t.Errorf("expected the dashboard to contain %q, but got %s", expected, home)
+		}
+	}
+	if home := open("U2"); strings.Contains(home, "Active filters") || !strings.Contains(home, "subteam^S1") {
+		t.Errorf("expected users who aren't moderators to only see a note, but got %s", home)
+	}
+
+	if err := toggle("U2", "on"); err == nil || h.inShadowMode(context.Background()) {
+		t.Errorf("expected users who aren't moderators not to be able to switch to dry run")
+	}
+	if err := toggle("U1", "on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !h.inShadowMode(context.Background()) || !other.inShadowMode(context.Background()) {
+		t.Errorf("expected dry run to be switched on in every replica")
+	}
+	if len(published) != 1 || !strings.Contains(published[0], "*Dry run*") || !strings.Contains(published[0], `"value":"off"`) {
+		t.Errorf("expected the dashboard to be republished in dry run, but got %v", published)
+	}
+	if err := toggle("U1", "on"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := toggle("U1", "off"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.inShadowMode(context.Background()) || other.inShadowMode(context.Background()) {
+		t.Errorf("expected filters to be enforced again in every replica")
+	}
+
+	var audited []string
+	for _, p := range posted {
+		audited = append(audited, p.Channel+": "+p.Text)
+	}
+	expectedAudit := []string{
+		"G0AUDITLOG: :scroll: <@U1> switched filters to dry run",
+		"G0AUDITLOG: :scroll: <@U1> started enforcing filters",
+	}
+	if !reflect.DeepEqual(audited, expectedAudit) {
+		t.Errorf("expected audit records %v, but got %v", expectedAudit, audited)
+	}
+}
+
+func TestAppHomeWithoutStore(t *testing.T) {
+	h := newHandler(nil, model.Config{ModeratorUsergroup: "S1"})
+	text, _ := json.Marshal(h.dashboard(context.Background(), h.rules()))
+	if !strings.Contains(string(text), "--database") {
+		t.Errorf("expected the dashboard to say match counts need a database, but got %s", text)
+	}
+}
+
+func TestDryRunStores(t *testing.T) {
+	store, _ := testMatchStore(t)
+	for name, s := range map[string]dryRunStore{"memory": newMemoryDryRun(), "database": store} {
+		t.Run(name, func(t *testing.T) {
+			if on, switched, err := s.DryRun(context.Background()); err != nil || on || switched {
+				t.Errorf("expected the switch not to have been switched, but got on %v, switched %v (error %v)", on, switched, err)
+			}
+			for _, expected := range []bool{true, false} {
+				if err := s.SetDryRun(context.Background(), expected); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if on, switched, err := s.DryRun(context.Background()); err != nil || on != expected || !switched {
+					t.Errorf("expected the switch to be %v, but got on %v, switched %v (error %v)", expected, on, switched, err)
+				}
+			}
+		})
+	}
+}
+
+func TestInShadowMode(t *testing.T) {
+	h := newHandler(nil, model.Config{})
+	h.shadowMode = true
+	if !h.inShadowMode(context.Background()) {
+		t.Errorf("expected --shadow-mode to apply until the switch is switched")
+	}
+	_ = h.dryRun.SetDryRun(context.Background(), false)
+	if h.inShadowMode(context.Background()) {
+		t.Errorf("expected the switch to override --shadow-mode")
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// audits returns whether taking action is recorded in the audit channel. Logging a match and
+// adding it to the digest don't do anything to anyone, and are already recorded elsewhere.
+func audits(action string) bool {
+	return action != model.ActionLog && action != model.ActionDigest
+}
+
+// audit posts a record, such as auditText's, to the audit channel of r.
+func (h *handler) audit(ctx context.Context, client *slack.Client, r *rules, text string) error {
+	channel, err := client.Channels().ID(ctx, r.audit)
+	if err != nil {
+		return fmt.Errorf("failed to find audit channel %q: %v", r.audit, err)
+	}
+	if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: channel, Text: text}); err != nil {
+		return fmt.Errorf("failed to post to audit channel: %v", err)
+	}
+	return nil
+}
+
+// auditText returns the record of a filter taking action about a match: what was done, to what,
+// and which filter and version of the filter config did it.
+func auditText(action string, m *match) string {
+	done := "`" + action + "`"
+	if model.Confirmable(action) && m.rules.confirms(action, m.filter) {
+		done += " (waiting for a moderator to confirm it)"
+	}
+	channel, _ := m.event.Channel.(string)
+	var target string
+	switch {
+	case m.emoji != "":
+		target = "the custom emoji `:" + m.emoji + ":`"
+	case channel == "":
+		target = "<@" + m.event.User + ">'s profile"
+	default:
+		target = fmt.Sprintf("a message by <@%s> in <#%s>", m.event.User, channel)
+	}
+	triggers := make([]string, 0, len(m.triggers))
+	for _, t := range m.triggers {
+		triggers = append(triggers, "`"+t+"`")
+	}
+	return fmt.Sprintf(":scroll: %s on %s, by %s of config %s, matching %s", done, target, m.filter.Label(m.index), m.rules.config.Version, strings.Join(triggers, ", "))
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+func TestAuditText(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   model.Config
+		action   string
+		match    match
+		expected string
+	}{
+		{
+			name:     "message",
+			action:   model.ActionDelete,
+			match:    match{index: 1, event: model.Event{Channel: "C1", User: "U1"}, triggers: []string{"free crypto", "airdrop"}},
+			expected: ":scroll: `delete` on a message by <@U1> in <#C1>, by filter 2 of config v1, matching `free crypto`, `airdrop`",
+		},
+		{
+			name:     "named filter",
+			action:   model.ActionWarn,
+			match:    match{filter: model.Filter{Name: "crypto"}, event: model.Event{Channel: "C1", User: "U1"}, triggers: []string{"free crypto"}},
+			expected: ":scroll: `warn` on a message by <@U1> in <#C1>, by crypto of config v1, matching `free crypto`",
+		},
+		{
+			name:     "waiting for confirmation",
+			config:   model.Config{ModeratorsChannel: "#moderators", Confirm: []string{model.ActionKick}},
+			action:   model.ActionKick,
+			match:    match{event: model.Event{Channel: "C1", User: "U1"}, triggers: []string{"free crypto"}},
+			expected: ":scroll: `kick` (waiting for a moderator to confirm it) on a message by <@U1> in <#C1>, by filter 1 of config v1, matching `free crypto`",
+		},
+		{
+			name:     "profile",
+			action:   model.ActionRename,
+			match:    match{event: model.Event{User: "U1"}, triggers: []string{"admin"}, fields: []string{fieldDisplayName}},
+			expected: ":scroll: `rename` on <@U1>'s profile, by filter 1 of config v1, matching `admin`",
+		},
+		{
+			name:     "custom emoji",
+			action:   model.ActionDelete,
+			match:    match{event: model.Event{Text: ":free-nitro:"}, triggers: []string{"free nitro"}, emoji: "free-nitro"},
+			expected: ":scroll: `delete` on the custom emoji `:free-nitro:`, by filter 1 of config v1, matching `free nitro`",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Version = "v1"
+			m := tc.match
+			m.rules = newRules(tc.config)
+			if actual := auditText(tc.action, &m); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAudit(t *testing.T) {
+	var calls []string
+	var posted []api.PostMessageRequest
+	server := quarantineServer(&calls, &posted)
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+	h := newHandler(nil, model.Config{AuditChannel: "G0AUDITLOG", Version: "v1"})
+	h.runActions(context.Background(), client, &match{
+		rules:    h.rules(),
+		filter:   model.Filter{Message: "Please don't."},
+		steps:    []string{model.ActionLog, model.ActionDelete, model.ActionPostEphemeral},
+		event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+		triggers: []string{"free crypto"},
+	})
+
+	// Logging isn't recorded, but deleting and warning are, once they've been done.
+	expected := []api.PostMessageRequest{
+		{Channel: "G0AUDITLOG", Text: ":scroll: `delete` on a message by <@U1> in <#C1>, by filter 1 of config v1, matching `free crypto`"},
+		{Channel: "G0AUDITLOG", Text: ":scroll: `chat.postEphemeral` on a message by <@U1> in <#C1>, by filter 1 of config v1, matching `free crypto`"},
+	}
+	if !reflect.DeepEqual(posted, expected) {
+		t.Errorf("expected audit records %+v, but posted %+v", expected, posted)
+	}
+}
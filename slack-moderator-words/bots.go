@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// botPolicy decides which messages posted by bots and integrations are moderated. The zero value
+// moderates none of them.
+type botPolicy struct {
+	// moderate makes messages from bots subject to filters, unless the bot is trusted.
+	moderate bool
+	// trusted are the bot IDs whose messages are never moderated.
+	trusted map[string]bool
+
+	lock sync.Mutex
+	// self is our own bot ID in each workspace, which is always trusted so that we never respond
+	// to our own warnings.
+	self map[*slack.Client]string
+}
+
+// newBotPolicy returns a botPolicy that moderates bots if moderate is set, except for the bot IDs
+// in the comma-separated list trusted.
+func newBotPolicy(moderate bool, trusted string) *botPolicy {
+	p := &botPolicy{moderate: moderate, trusted: map[string]bool{}, self: map[*slack.Client]string{}}
+	for _, id := range strings.Split(trusted, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			p.trusted[id] = true
+		}
+	}
+	return p
+}
+
+// skip returns whether a message posted by the bot with the given ID should be left alone.
+func (p *botPolicy) skip(ctx context.Context, client *slack.Client, botID string) bool {
+	if !p.moderate || p.trusted[botID] {
+		return true
+	}
+	self, err := p.selfID(ctx, client)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to look up our own bot ID, not moderating bot message", "bot_id", botID, "error", err)
+		return true
+	}
+	return botID == self
+}
+
+// selfID returns the bot ID of client's token, which is empty for user tokens.
+func (p *botPolicy) selfID(ctx context.Context, client *slack.Client) (string, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if id, ok := p.self[client]; ok {
+		return id, nil
+	}
+	auth, err := api.New(client).AuthTest(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.self[client] = auth.BotID
+	return auth.BotID, nil
+}
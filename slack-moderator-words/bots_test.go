@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBotPolicy(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true, "user_id": "U0", "bot_id": "B0"}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	tests := []struct {
+		name     string
+		moderate bool
+		trusted  string
+		bot      string
+		expected bool
+	}{
+		{
+			name:     "bots are skipped by default",
+			bot:      "B1",
+			expected: true,
+		},
+		{
+			name:     "moderated",
+			moderate: true,
+			trusted:  "B2, B3",
+			bot:      "B1",
+		},
+		{
+			name:     "trusted",
+			moderate: true,
+			trusted:  "B2, B3",
+			bot:      "B3",
+			expected: true,
+		},
+		{
+			name:     "ourselves",
+			moderate: true,
+			bot:      "B0",
+			expected: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newBotPolicy(tc.moderate, tc.trusted)
+			if actual := p.skip(context.Background(), client, tc.bot); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	p := newBotPolicy(true, "")
+	p.skip(context.Background(), client, "B1")
+	p.skip(context.Background(), client, "B2")
+	if calls != 3 {
+		t.Errorf("expected our bot ID to be looked up once per policy, but auth.test was called %d times", calls)
+	}
+}
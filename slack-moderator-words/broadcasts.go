@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// broadcasts returns the mentions of @here, @channel and @everyone in a message, if there are any
+// and the channel is big enough for the filter's Broadcasts to care about them. Channels whose size
+// can't be looked up are logged, and treated as too small, rather than warning people for them.
+func (h *handler) broadcasts(ctx context.Context, client *slack.Client, config model.Broadcasts, channel string, message model.Event) []string {
+	mentions := model.BroadcastMentions(message.Text)
+	if len(mentions) == 0 || config.MinMembers == 0 {
+		return mentions
+	}
+	conversation, err := client.Channels().Conversation(ctx, channel)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to look up channel size", "channel", channel, "error", err)
+		return nil
+	}
+	if conversation.NumMembers < config.MinMembers {
+		logging.FromContext(ctx).Debug("Channel is too small for broadcast mentions to count", "channel", channel, "members", conversation.NumMembers, "mentions", mentions)
+		return nil
+	}
+	return mentions
+}
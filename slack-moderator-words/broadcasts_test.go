@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestBroadcasts(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel string
+		text    string
+		subtype string
+		warned  bool
+	}{
+		{
+			name:    "big channel",
+			channel: "C1",
+			text:    "<!channel> free tickets",
+			warned:  true,
+		},
+		{
+			name:    "small channel",
+			channel: "C2",
+			text:    "<!here> lunch is ready",
+		},
+		{
+			name:    "unknown channel",
+			channel: "C3",
+			text:    "<!everyone> hi",
+		},
+		{
+			name:    "no mentions",
+			channel: "C1",
+			text:    "hello everyone",
+		},
+		{
+			name:    "edited in",
+			channel: "C1",
+			text:    "<!channel> free tickets",
+			subtype: model.SubtypeMessageChanged,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/conversations.list":
+					_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C1", "name": "general", "num_members": 12000}, {"id": "C2", "name": "lunch", "num_members": 8}]}`))
+				case "/api/conversations.info":
+					_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+				case "/api/users.info":
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				default:
+					calls = append(calls, r.URL.Path[len("/api/"):])
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				}
+			}))
+			defer server.Close()
+			client := testClient(server)
+
+			filters := model.FilterConfig{{Broadcasts: &model.Broadcasts{MinMembers: 1000}, Action: model.ActionWarn, Message: "Please don't notify everyone here."}}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters})
+			message := `{"type": "message", "channel": "` + tc.channel + `", "user": "U1", "text": "` + tc.text + `", "ts": "1612790186.002000"}`
+			event := message
+			if tc.subtype != "" {
+				event = `{"type": "message", "subtype": "` + tc.subtype + `", "channel": "` + tc.channel + `", "message": ` + message + `, "previous_message": {"type": "message", "user": "U1", "text": "free tickets", "ts": "1612790186.002000"}}`
+			}
+			if err := h.handleMessage(context.Background(), client, []byte(`{"event": `+event+`}`)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var expected []string
+			if tc.warned {
+				expected = []string{"chat.postEphemeral"}
+			}
+			if !reflect.DeepEqual(calls, expected) {
+				t.Errorf("expected calls %v, but got %v", expected, calls)
+			}
+		})
+	}
+}
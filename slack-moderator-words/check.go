@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// checkOptions are the flags of the check subcommand.
+type checkOptions struct {
+	configPath string
+	text       string
+	file       string
+	channel    string
+	user       string
+	strikes    int
+}
+
+// runCheck implements `slack-moderator-words check`, which reports what a filter config would do
+// with sample messages without connecting to Slack, and returns the exit code.
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	o := checkOptions{}
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&o.configPath, "config", "filters.yaml", "Path to the filter config to check")
+	fs.StringVar(&o.text, "text", "", "Message to check (default one message per line of --file)")
+	fs.StringVar(&o.file, "file", "-", "File of messages to check, one per line; - reads them from stdin")
+	fs.StringVar(&o.channel, "channel", "", "Name or ID of the channel the messages are in, for filters limited to some channels (default any channel)")
+	fs.StringVar(&o.user, "user", "U00000000", "ID of the messages' author, for exempt_users")
+	fs.IntVar(&o.strikes, "strikes", 1, "Number of strikes the author has, including this message's, for filters that count them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	config, err := loadFilterConfig(o.configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", o.configPath, err)
+		return 1
+	}
+	// Check the trigger lists the filters would use in production, if we can get them.
+	lists, _ := newTriggerLists().fetch(context.Background(), config.TriggerLists())
+	if config, err = config.WithTriggerLists(lists); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", o.configPath, err)
+		return 1
+	}
+
+	if o.text != "" {
+		checkMessage(stdout, config.Filters, o, o.text)
+		return 0
+	}
+	in := stdin
+	if o.file != "-" {
+		f, err := os.Open(o.file)
+		if err != nil {
+			fmt.Fprintf(stderr, "couldn't open messages: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		checkMessage(stdout, config.Filters, o, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "couldn't read messages: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// checkMessage writes which filters match text, and what they would do, in the same way as
+// handleMessage. Membership of exempt usergroups can't be checked without Slack, so it is ignored.
+func checkMessage(w io.Writer, filters model.FilterConfig, o checkOptions, text string) {
+	fmt.Fprintf(w, "%q\n", text)
+	channel := strings.TrimPrefix(o.channel, "#")
+	matched := false
+	for i, filter := range filters {
+		if channel != "" && !filter.AppliesTo(channel, channel) {
+			continue
+		}
+		matches := filter.Matches(text)
+		if len(matches) == 0 {
+			continue
+		}
+		matched = true
+		if filter.ExemptsUser(o.user) {
+			fmt.Fprintf(w, "  %s matches %q, but %s is exempt\n", filter.Label(i), matches, o.user)
+			continue
+		}
+		steps := filter.Steps()
+		if filter.CountsStrikes() {
+			steps = filter.StrikeSteps(o.strikes)
+		}
+		fmt.Fprintf(w, "  %s matches %q: %s\n", filter.Label(i), matches, strings.Join(steps, ", "))
+		for _, s := range steps {
+			if model.IsWarning(s) {
+				message, err := filter.RenderMessage(model.NewMessageData(o.user, channel, matches, "<permalink>"))
+				if err != nil {
+					message = filter.Message
+				}
+				fmt.Fprintf(w, "    message: %s\n", message)
+				break
+			}
+		}
+		if (&match{steps: steps}).takes(model.ActionDelete) {
+			// The message is gone, so later filters never see it.
+			break
+		}
+	}
+	if !matched {
+		fmt.Fprintln(w, "  no filters match")
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		args     []string
+		stdin    string
+		code     int
+		expected string
+	}{
+		{
+			name: "messages from stdin",
+			config: `
+moderators_channel: "#moderators"
+filters:
+- triggers: [guys]
+  match: word
+  action: warn
+  message: "Hi {{.User}}, how about \"all\" instead of {{.MatchedWord}}?"
+- triggers: [free crypto]
+  severity: critical
+  message: Removed.
+- triggers: [guys]
+  action: log
+`,
+			stdin: "hey guys\n\nfree crypto, guys\nhello\n",
+			expected: `"hey guys"
+  filter 1 matches ["guys"]: warn
+    message: Hi <@U00000000>, how about "all" instead of guys?
+  filter 3 matches ["guys"]: log
+"free crypto, guys"
+  filter 1 matches ["guys"]: warn
+    message: Hi <@U00000000>, how about "all" instead of guys?
+  filter 2 matches ["free crypto"]: delete, warn, escalate
+    message: Removed.
+"hello"
+  no filters match
+`,
+		},
+		{
+			name: "channel, user and strikes",
+			config: `
+- triggers: [honk]
+  channels: [geese]
+  action: log
+- triggers: [honk]
+  exempt_users: [U1]
+  action: delete
+- triggers: [honk]
+  strikes: true
+`,
+			args: []string{"--text", "honk", "--channel", "#ducks", "--user", "U1", "--strikes", "5"},
+			expected: `"honk"
+  filter 2 matches ["honk"], but U1 is exempt
+  filter 3 matches ["honk"]: delete, kick
+`,
+		},
+		{
+			name: "invalid config",
+			config: `
+- triggers: [honk]
+  acton: delete
+`,
+			code: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "filters.yaml")
+			if err := ioutil.WriteFile(path, []byte(tc.config), 0644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			code := runCheck(append([]string{"--config", path}, tc.args...), strings.NewReader(tc.stdin), stdout, stderr)
+			if code != tc.code {
+				t.Errorf("expected exit code %d, but got %d (%s)", tc.code, code, stderr)
+			}
+			if stdout.String() != tc.expected {
+				t.Errorf("expected output:\n%s\nbut got:\n%s", tc.expected, stdout)
+			}
+		})
+	}
+}
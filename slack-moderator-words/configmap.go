@@ -0,0 +1,247 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+)
+
+// serviceAccountDir is where Kubernetes mounts the pod's service account credentials.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// configMapRetryInterval is how long to wait before watching a ConfigMap again after it failed.
+const configMapRetryInterval = 10 * time.Second
+
+// configMapSource reads the filter config from one key of a Kubernetes ConfigMap using the API
+// server, which sees changes straight away, unlike ConfigMap volumes, which the kubelet can take
+// a minute or more to update.
+type configMapSource struct {
+	server    string
+	namespace string
+	name      string
+	key       string
+	// tokenPath is read for every request, since service account tokens are rotated.
+	tokenPath string
+	client    *http.Client
+}
+
+// configMap is the part of a ConfigMap we care about.
+type configMap struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Data map[string]string `json:"data"`
+}
+
+// watchEvent is one change reported by a watch.
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// newInClusterConfigMapSource returns a source for key of the ConfigMap ref, written as name or
+// namespace/name, using the pod's service account. The namespace defaults to the pod's own.
+func newInClusterConfigMapSource(ref, key string) (*configMapSource, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster")
+	}
+	namespace, name := "", ref
+	if i := strings.Index(ref, "/"); i >= 0 {
+		namespace, name = ref[:i], ref[i+1:]
+	} else {
+		ns, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the pod's namespace: %v", err)
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the cluster's CA certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in the cluster's CA certificate")
+	}
+	return &configMapSource{
+		server:    "https://" + net.JoinHostPort(host, port),
+		namespace: namespace,
+		name:      name,
+		key:       key,
+		tokenPath: filepath.Join(serviceAccountDir, "token"),
+		// No timeout, because watches are long-lived; requests are bounded by their contexts.
+		client: &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}},
+	}, nil
+}
+
+// read returns the filter config currently in the ConfigMap.
+func (s *configMapSource) read(ctx context.Context) ([]byte, error) {
+	cm, err := s.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.content(cm)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+// watch calls reload with the filter config whenever it changes, until ctx is done. last is the
+// filter config already in use. Failed reloads are logged, and aren't retried until the ConfigMap
+// changes again.
+func (s *configMapSource) watch(ctx context.Context, last []byte, reload func(content []byte) error) {
+	current := string(last)
+	apply := func(cm *configMap) {
+		content, err := s.content(cm)
+		if err != nil {
+			logging.FromContext(ctx).Error("Ignoring filter ConfigMap", "error", err)
+			return
+		}
+		if content == current {
+			return
+		}
+		current = content
+		logging.FromContext(ctx).Info("Filter ConfigMap changed, reloading it", "configmap", s.namespace+"/"+s.name, "resource_version", cm.Metadata.ResourceVersion)
+		if err := reload([]byte(content)); err != nil {
+			logging.FromContext(ctx).Error("Failed to reload config", "configmap", s.namespace+"/"+s.name, "error", err)
+		}
+	}
+	for {
+		// Start from the current version, in case we missed changes while we weren't watching.
+		cm, err := s.get(ctx)
+		if err == nil {
+			apply(cm)
+			err = s.watchFrom(ctx, cm.Metadata.ResourceVersion, apply)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// The API server ends watches every so often, so this isn't a problem.
+			continue
+		}
+		logging.FromContext(ctx).Warn("Failed to watch filter ConfigMap, retrying", "configmap", s.namespace+"/"+s.name, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(configMapRetryInterval):
+		}
+	}
+}
+
+// watchFrom calls apply with each version of the ConfigMap after resourceVersion, until the API
+// server ends the watch.
+func (s *configMapSource) watchFrom(ctx context.Context, resourceVersion string, apply func(*configMap)) error {
+	query := url.Values{}
+	query.Set("watch", "true")
+	query.Set("fieldSelector", "metadata.name="+s.name)
+	query.Set("resourceVersion", resourceVersion)
+	resp, err := s.do(ctx, "/api/v1/namespaces/"+url.PathEscape(s.namespace)+"/configmaps?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		event := watchEvent{}
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil || err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to decode watch event: %v", err)
+		}
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			cm := &configMap{}
+			if err := json.Unmarshal(event.Object, cm); err != nil {
+				return fmt.Errorf("failed to decode ConfigMap: %v", err)
+			}
+			apply(cm)
+		case "DELETED":
+			logging.FromContext(ctx).Warn("Filter ConfigMap was deleted, keeping the current filters", "configmap", s.namespace+"/"+s.name)
+		case "ERROR":
+			// Usually because resourceVersion is too old, in which case we need to start over.
+			return fmt.Errorf("watch failed: %s", event.Object)
+		}
+	}
+}
+
+// get returns the ConfigMap.
+func (s *configMapSource) get(ctx context.Context) (*configMap, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	resp, err := s.do(ctx, "/api/v1/namespaces/"+url.PathEscape(s.namespace)+"/configmaps/"+url.PathEscape(s.name))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	cm := &configMap{}
+	if err := json.NewDecoder(resp.Body).Decode(cm); err != nil {
+		return nil, fmt.Errorf("failed to decode ConfigMap: %v", err)
+	}
+	return cm, nil
+}
+
+// content returns the filter config in cm.
+func (s *configMapSource) content(cm *configMap) (string, error) {
+	content, ok := cm.Data[s.key]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap %s/%s has no key %q", s.namespace, s.name, s.key)
+	}
+	return content, nil
+}
+
+// do makes a GET request to the API server, and checks that it succeeded.
+func (s *configMapSource) do(ctx context.Context, path string) (*http.Response, error) {
+	token, err := ioutil.ReadFile(s.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read service account token: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.server+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to API server failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API server returned %s: %s", resp.Status, body)
+	}
+	return resp, nil
+}
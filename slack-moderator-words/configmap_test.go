@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func testConfigMapSource(t *testing.T, server *httptest.Server) *configMapSource {
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenPath, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &configMapSource{server: server.URL, namespace: "slack", name: "filters", key: "filters.yaml", tokenPath: tokenPath, client: server.Client()}
+}
+
+func TestConfigMapRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "key present",
+			status:   http.StatusOK,
+			body:     `{"metadata": {"resourceVersion": "1"}, "data": {"filters.yaml": "filters: []"}}`,
+			expected: "filters: []",
+		},
+		{
+			name:   "key missing",
+			status: http.StatusOK,
+			body:   `{"metadata": {"resourceVersion": "1"}, "data": {"other.yaml": "filters: []"}}`,
+			err:    true,
+		},
+		{
+			name:   "forbidden",
+			status: http.StatusForbidden,
+			body:   `{"kind": "Status", "reason": "Forbidden"}`,
+			err:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v1/namespaces/slack/configmaps/filters" || r.Header.Get("Authorization") != "Bearer sa-token" {
+					t.Errorf("unexpected request to %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+				}
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			content, err := testConfigMapSource(t, server).read(context.Background())
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, but got %q", content)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(content) != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, content)
+			}
+		})
+	}
+}
+
+func TestConfigMapWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var lock sync.Mutex
+	content, watches := "a", 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Query().Get("watch") != "true" {
+			fmt.Fprintf(w, `{"metadata": {"resourceVersion": "1"}, "data": {"filters.yaml": %q}}`, content)
+			return
+		}
+		watches++
+		if watches > 1 {
+			// The watch was restarted, and nothing changed in the meantime.
+			cancel()
+			return
+		}
+		if r.URL.Query().Get("fieldSelector") != "metadata.name=filters" || r.URL.Query().Get("resourceVersion") != "1" {
+			t.Errorf("unexpected watch query %s", r.URL.RawQuery)
+		}
+		content = "b"
+		fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"resourceVersion": "2"}, "data": {"filters.yaml": "b"}}}`)
+		// Changes to other keys don't reload the filters.
+		fmt.Fprintln(w, `{"type": "MODIFIED", "object": {"metadata": {"resourceVersion": "3"}, "data": {"filters.yaml": "b", "other.yaml": "c"}}}`)
+		fmt.Fprintln(w, `{"type": "DELETED", "object": {"metadata": {"resourceVersion": "4"}}}`)
+	}))
+	defer server.Close()
+
+	var reloads []string
+	testConfigMapSource(t, server).watch(ctx, []byte("a"), func(content []byte) error {
+		reloads = append(reloads, string(content))
+		return nil
+	})
+	if expected := []string{"b"}; !reflect.DeepEqual(reloads, expected) {
+		t.Errorf("expected reloads %v, but got %v", expected, reloads)
+	}
+}
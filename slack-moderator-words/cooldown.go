@@ -0,0 +1,117 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// cooldownPruneInterval is how often expired cooldowns are forgotten.
+const cooldownPruneInterval = time.Minute
+
+// cooldownStore remembers which users have recently been warned by which filters, so that someone
+// who pastes a log full of trigger words isn't warned for every line of it.
+type cooldownStore interface {
+	// Allow returns whether the user can be warned by the filter, given by its label, now, and if
+	// so starts a cooldown of the given length during which they won't be warned by it again.
+	// Labels, unlike positions, stay the same when the filter config is reloaded or runtime
+	// filters are added, and are the same in replicas running other versions of it.
+	Allow(ctx context.Context, client *slack.Client, filter, user string, cooldown time.Duration) (bool, error)
+}
+
+// memoryCooldowns is a cooldownStore that keeps cooldowns in memory. They are lost when the process
+// exits, and aren't shared between replicas.
+type memoryCooldowns struct {
+	lock     sync.Mutex
+	until    map[cooldownKey]time.Time
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+type cooldownKey struct {
+	client *slack.Client
+	// filter is the label of the filter.
+	filter string
+	user   string
+}
+
+func newMemoryCooldowns() *memoryCooldowns {
+	return &memoryCooldowns{until: map[cooldownKey]time.Time{}, now: time.Now}
+}
+
+// Allow implements cooldownStore.
+func (c *memoryCooldowns) Allow(ctx context.Context, client *slack.Client, filter, user string, cooldown time.Duration) (bool, error) {
+	if cooldown <= 0 {
+		return true, nil
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	if now.Sub(c.prunedAt) > cooldownPruneInterval {
+		for k, until := range c.until {
+			if !now.Before(until) {
+				delete(c.until, k)
+			}
+		}
+		c.prunedAt = now
+	}
+	key := cooldownKey{client, filter, user}
+	if until, ok := c.until[key]; ok && now.Before(until) {
+		return false, nil
+	}
+	c.until[key] = now.Add(cooldown)
+	return true, nil
+}
+
+// redisCooldowns is a cooldownStore that keeps each cooldown in a Redis key that expires with it,
+// so that replicas don't each warn the same user. Users' IDs are unique across workspaces, so the
+// keys don't need to say which workspace they are in.
+type redisCooldowns struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func newRedisCooldowns(client redis.UniversalClient, prefix string) *redisCooldowns {
+	return &redisCooldowns{client: client, prefix: prefix}
+}
+
+// Allow implements cooldownStore. Only the replica that creates the key starts the cooldown. The
+// user comes first in the key, since users' IDs never contain colons but labels can.
+func (r *redisCooldowns) Allow(ctx context.Context, client *slack.Client, filter, user string, cooldown time.Duration) (bool, error) {
+	if cooldown <= 0 {
+		return true, nil
+	}
+	return r.client.SetNX(ctx, r.prefix+user+":"+filter, 1, cooldown).Result()
+}
+
+// allowWarning returns whether the author of a match can be warned by its filter now. If the
+// cooldown store can't be checked, they are warned, since a warning too many is better than none.
+func (h *handler) allowWarning(ctx context.Context, client *slack.Client, m *match) bool {
+	allowed, err := h.cooldowns.Allow(ctx, client, m.filter.Label(m.index), m.event.User, m.filter.Cooldown)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to check filter's cooldown", "user_id", m.event.User, "error", err)
+		return true
+	}
+	return allowed
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestCooldowns(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := newMemoryCooldowns()
+	c.now = func() time.Time { return now }
+
+	steps := []struct {
+		name     string
+		after    time.Duration
+		filter   string
+		user     string
+		cooldown time.Duration
+		expected bool
+	}{
+		{name: "first warning", filter: "filter 1", user: "U1", cooldown: 10 * time.Minute, expected: true},
+		{name: "same user and filter during the cooldown", after: time.Minute, filter: "filter 1", user: "U1", cooldown: 10 * time.Minute, expected: false},
+		{name: "another user", filter: "filter 1", user: "U2", cooldown: 10 * time.Minute, expected: true},
+		{name: "another filter", filter: "filter 2", user: "U1", cooldown: 10 * time.Minute, expected: true},
+		{name: "no cooldown", filter: "filter 3", user: "U1", expected: true},
+		{name: "no cooldown again", filter: "filter 3", user: "U1", expected: true},
+		{name: "after the cooldown", after: 10 * time.Minute, filter: "filter 1", user: "U1", cooldown: 10 * time.Minute, expected: true},
+	}
+	for _, s := range steps {
+		now = now.Add(s.after)
+		if allowed, err := c.Allow(context.Background(), nil, s.filter, s.user, s.cooldown); err != nil || allowed != s.expected {
+			t.Errorf("%s: expected Allow to return %v, but got %v (error %v)", s.name, s.expected, allowed, err)
+		}
+	}
+}
+
+func TestRunActionsCooldown(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	filters := model.FilterConfig{{Triggers: []string{"honk"}, Actions: []string{model.ActionLog, model.ActionWarn}, Message: "Please don't.", Cooldown: 10 * time.Minute}}
+	if err := filters.Compile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(nil, model.Config{Filters: filters})
+	for i := 0; i < 3; i++ {
+		h.runActions(context.Background(), client, &match{rules: h.rules(), filter: filters[0], steps: filters[0].Steps(), event: model.Event{Channel: "C1", User: "U1", TS: "1612790186.002000"}})
+	}
+	if expected := []string{"chat.postEphemeral"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, but got %v", expected, calls)
+	}
+}
+
+func TestRunActionsCooldownSurvivesReload(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	honk := model.Filter{Name: "honk", Triggers: []string{"honk"}, Actions: []string{model.ActionWarn}, Message: "Please don't.", Cooldown: 10 * time.Minute}
+	before := model.FilterConfig{honk}
+	after := model.FilterConfig{{Triggers: []string{"quack"}, Actions: []string{model.ActionWarn}, Message: "Please don't.", Cooldown: 10 * time.Minute}, honk}
+	for _, filters := range []model.FilterConfig{before, after} {
+		if err := filters.Compile(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	h := newHandler(nil, model.Config{Filters: before})
+	event := model.Event{Channel: "C1", User: "U1", TS: "1612790186.002000"}
+	h.runActions(context.Background(), client, &match{rules: h.rules(), index: 0, filter: before[0], steps: before[0].Steps(), event: event})
+	// A filter added in front of it moves it along, but it's still the same filter.
+	h.setRules(newRules(model.Config{Filters: after}))
+	h.runActions(context.Background(), client, &match{rules: h.rules(), index: 1, filter: after[1], steps: after[1].Steps(), event: event})
+	if expected := []string{"chat.postEphemeral"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected the cooldown to outlast the reload, with calls %v, but got %v", expected, calls)
+	}
+}
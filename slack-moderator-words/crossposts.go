@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// maxTrackedPosts is how many recent messages of each user we remember, so that a flood of
+// messages can't use up all our memory.
+const maxTrackedPosts = 50
+
+// crossPosts remembers users' recent messages, so that filters can tell when the same message has
+// been posted in several channels.
+type crossPosts struct {
+	lock     sync.Mutex
+	posts    map[crossPostKey][]post
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+type crossPostKey struct {
+	client *slack.Client
+	user   string
+}
+
+// post is a message that a user posted recently.
+type post struct {
+	channel     string
+	ts          string
+	fingerprint model.Fingerprint
+	at          time.Time
+	// deleted is set once the message has been deleted as a copy of another.
+	deleted bool
+}
+
+func newCrossPosts() *crossPosts {
+	return &crossPosts{posts: map[crossPostKey][]post{}, now: time.Now}
+}
+
+// add remembers a message for keep, replacing the previous version of it if it has been edited,
+// and returns it.
+func (c *crossPosts) add(client *slack.Client, user, channel, ts string, fingerprint model.Fingerprint, keep time.Duration) post {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := c.now()
+	if now.Sub(c.prunedAt) > keep {
+		for k, posts := range c.posts {
+			if len(posts) == 0 || now.Sub(posts[len(posts)-1].at) > keep {
+				delete(c.posts, k)
+			}
+		}
+		c.prunedAt = now
+	}
+	key := crossPostKey{client, user}
+	p := post{channel: channel, ts: ts, fingerprint: fingerprint, at: now}
+	posts := c.posts[key][:0]
+	for _, old := range c.posts[key] {
+		if now.Sub(old.at) <= keep && !(old.channel == channel && old.ts == ts) {
+			posts = append(posts, old)
+		}
+	}
+	posts = append(posts, p)
+	if len(posts) > maxTrackedPosts {
+		posts = posts[len(posts)-maxTrackedPosts:]
+	}
+	c.posts[key] = posts
+	return p
+}
+
+// copies returns the most recent copy of p that its author posted in each other channel, as the
+// filter's CrossPosts counts copies.
+func (c *crossPosts) copies(client *slack.Client, user string, p post, config model.CrossPosts) []post {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var copies []post
+	seen := map[string]bool{p.channel: true}
+	posts := c.posts[crossPostKey{client, user}]
+	for i := len(posts) - 1; i >= 0; i-- {
+		old := posts[i]
+		if seen[old.channel] || p.at.Sub(old.at) > config.Window() || !config.Copies(p.fingerprint, old.fingerprint) {
+			continue
+		}
+		seen[old.channel] = true
+		copies = append(copies, old)
+	}
+	return copies
+}
+
+// markDeleted records that the user's posts have been deleted, so that later copies of them don't
+// try to delete them again.
+func (c *crossPosts) markDeleted(client *slack.Client, user string, deleted []post) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	posts := c.posts[crossPostKey{client, user}]
+	for i, p := range posts {
+		for _, d := range deleted {
+			if p.channel == d.channel && p.ts == d.ts {
+				posts[i].deleted = true
+			}
+		}
+	}
+}
+
+// deleteCopies deletes the copies of a cross-posted message that haven't been deleted already. A
+// copy that can't be deleted is logged, and doesn't stop the rest being deleted.
+func (h *handler) deleteCopies(ctx context.Context, client *slack.Client, m *match) {
+	deleted := []post{m.post}
+	for _, p := range m.copies {
+		if p.deleted {
+			continue
+		}
+		err := api.New(client).DeleteMessage(slack.WithToken(ctx, slack.TokenUser), api.DeleteMessageRequest{
+			Channel: p.channel,
+			TS:      p.ts,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to delete copy of cross-posted message", "channel", p.channel, "ts", p.ts, "error", err)
+			continue
+		}
+		deleted = append(deleted, p)
+	}
+	h.crossPosts.markDeleted(client, m.event.User, deleted)
+}
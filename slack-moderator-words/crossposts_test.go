@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestCrossPosts(t *testing.T) {
+	spam := "Giving away free KubeCon tickets to the first ten people who DM me"
+	tests := []struct {
+		name            string
+		messages        []string
+		gap             time.Duration
+		expectedDeleted [][]string
+	}{
+		{
+			name:            "posted in three channels",
+			messages:        []string{"C1 " + spam, "C2 " + spam, "C3 " + spam + "!!", "C4 " + spam},
+			expectedDeleted: [][]string{nil, nil, {"C3/3", "C2/2", "C1/1"}, {"C4/4"}},
+		},
+		{
+			name:            "posted twice in one channel",
+			messages:        []string{"C1 " + spam, "C1 " + spam, "C2 " + spam},
+			expectedDeleted: [][]string{nil, nil, nil},
+		},
+		{
+			name:            "too far apart",
+			messages:        []string{"C1 " + spam, "C2 " + spam, "C3 " + spam},
+			gap:             6 * time.Minute,
+			expectedDeleted: [][]string{nil, nil, nil},
+		},
+		{
+			name:            "different messages",
+			messages:        []string{"C1 " + spam, "C2 Has anyone seen the new release notes for 1.22 yet?", "C3 " + spam},
+			expectedDeleted: [][]string{nil, nil, nil},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/chat.delete" {
+					body := struct {
+						Channel string `json:"channel"`
+						TS      string `json:"ts"`
+					}{}
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					deleted = append(deleted, body.Channel+"/"+body.TS)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			filters := model.FilterConfig{{CrossPosts: &model.CrossPosts{Within: 10 * time.Minute}, Action: model.ActionDelete}}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters})
+			now := time.Now()
+			h.crossPosts.now = func() time.Time { return now }
+			for i, m := range tc.messages {
+				deleted = nil
+				event := map[string]interface{}{"type": "message", "channel": m[:2], "user": "U1", "text": m[3:], "ts": string(rune('1' + i))}
+				body, _ := json.Marshal(map[string]interface{}{"event": event})
+				if err := h.handleMessage(context.Background(), client, body); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(deleted, tc.expectedDeleted[i]) {
+					t.Errorf("expected message %d to delete %q, but it deleted %q", i+1, tc.expectedDeleted[i], deleted)
+				}
+				now = now.Add(tc.gap)
+			}
+		})
+	}
+}
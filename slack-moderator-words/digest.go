@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// digests collects the matches of filters with ActionDigest in each workspace until they are
+// posted to the moderators channel together.
+type digests struct {
+	lock    sync.Mutex
+	matches map[*slack.Client][]digestMatch
+}
+
+// digestMatch is what a digest says about a match.
+type digestMatch struct {
+	user string
+	// filter is the index of the filter that matched.
+	filter   int
+	triggers []string
+	// where is the channel the message was posted in, or the parts of the user's profile that
+	// matched.
+	where string
+}
+
+func newDigests() *digests {
+	return &digests{matches: map[*slack.Client][]digestMatch{}}
+}
+
+// add adds a match to the next digest of client's workspace.
+func (d *digests) add(client *slack.Client, m *match) {
+	where := ""
+	if channel, _ := m.event.Channel.(string); channel != "" {
+		where = "<#" + channel + ">"
+	} else if len(m.fields) > 0 {
+		where = "their " + m.fieldNames()
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.matches[client] = append(d.matches[client], digestMatch{user: m.event.User, filter: m.index, triggers: m.triggers, where: where})
+}
+
+// take returns the matches collected in each workspace, and starts collecting them again.
+func (d *digests) take() map[*slack.Client][]digestMatch {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	matches := d.matches
+	d.matches = map[*slack.Client][]digestMatch{}
+	return matches
+}
+
+// postDigestsEvery posts the digest of each workspace to its moderators channel, at the interval
+// set by the current filter config, until ctx is done. Workspaces with no matches get no digest.
+func (h *handler) postDigestsEvery(ctx context.Context) {
+	for {
+		timer := time.NewTimer(h.rules().digestInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			h.postDigests(ctx)
+		}
+	}
+}
+
+// postDigests posts the matches collected since the last digest in each workspace.
+func (h *handler) postDigests(ctx context.Context) {
+	r := h.rules()
+	for client, matches := range h.digests.take() {
+		moderators, err := moderatorsChannel(ctx, client, r)
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to post digest", "matches", len(matches), "error", err)
+			continue
+		}
+		if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: moderators, Text: digestText(matches)}); err != nil {
+			logging.FromContext(ctx).Error("Failed to post digest", "matches", len(matches), "error", err)
+		}
+	}
+}
+
+// digestText returns a digest of matches, grouped by user and then by filter, in the order they
+// first matched.
+func digestText(matches []digestMatch) string {
+	type group struct {
+		filter   int
+		count    int
+		triggers []string
+		places   []string
+	}
+	var users []string
+	groups := map[string][]*group{}
+	for _, m := range matches {
+		if _, ok := groups[m.user]; !ok {
+			users = append(users, m.user)
+		}
+		var g *group
+		for _, existing := range groups[m.user] {
+			if existing.filter == m.filter {
+				g = existing
+			}
+		}
+		if g == nil {
+			g = &group{filter: m.filter}
+			groups[m.user] = append(groups[m.user], g)
+		}
+		g.count++
+		g.triggers = appendNew(g.triggers, m.triggers...)
+		if m.where != "" {
+			g.places = appendNew(g.places, m.where)
+		}
+	}
+
+	lines := []string{fmt.Sprintf(":bookmark_tabs: %s since the last digest:", plural(len(matches), "match", "matches"))}
+	for _, user := range users {
+		author := "<@" + user + ">"
+		if user == "" {
+			author = "Unknown author"
+		}
+		total := 0
+		for _, g := range groups[user] {
+			total += g.count
+		}
+		lines = append(lines, fmt.Sprintf("*%s*: %s", author, plural(total, "match", "matches")))
+		for _, g := range groups[user] {
+			line := fmt.Sprintf("• filter %d, %s", g.filter+1, plural(g.count, "time", "times"))
+			if len(g.places) > 0 {
+				line += " in " + strings.Join(g.places, ", ")
+			}
+			triggers := make([]string, 0, len(g.triggers))
+			for _, t := range g.triggers {
+				triggers = append(triggers, "`"+t+"`")
+			}
+			lines = append(lines, line+": "+strings.Join(triggers, ", "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// appendNew appends the values that aren't already in list to it.
+func appendNew(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// plural returns n followed by one or many, depending on n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestDigestText(t *testing.T) {
+	matches := []digestMatch{
+		{user: "U1", filter: 1, triggers: []string{"free crypto"}, where: "<#C1>"},
+		{user: "U2", filter: 0, triggers: []string{"guys"}, where: "<#C1>"},
+		{user: "U1", filter: 1, triggers: []string{"airdrop"}, where: "<#C2>"},
+		{user: "U1", filter: 1, triggers: []string{"free crypto"}, where: "<#C1>"},
+		{user: "U1", filter: 3, triggers: []string{"nazi"}, where: "their display name"},
+	}
+	expected := ":bookmark_tabs: 5 matches since the last digest:\n" +
+		"*<@U1>*: 4 matches\n" +
+		"• filter 2, 3 times in <#C1>, <#C2>: `free crypto`, `airdrop`\n" +
+		"• filter 4, 1 time in their display name: `nazi`\n" +
+		"*<@U2>*: 1 match\n" +
+		"• filter 1, 1 time in <#C1>: `guys`"
+	if actual := digestText(matches); actual != expected {
+		t.Errorf("expected digest:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestPostDigests(t *testing.T) {
+	var calls, posted []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/api/")
+		calls = append(calls, method)
+		w.Header().Set("Content-Type", "application/json")
+		switch method {
+		case "conversations.list":
+			_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0MOD", "name": "moderators"}]}`))
+			return
+		case "chat.postMessage":
+			body := struct {
+				Channel string `json:"channel"`
+				Text    string `json:"text"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			posted = append(posted, body.Channel+": "+body.Text)
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	h := newHandler(nil, model.Config{ModeratorsChannel: "#moderators"})
+	for i := 0; i < 2; i++ {
+		h.runActions(context.Background(), client, &match{
+			rules:    h.rules(),
+			steps:    []string{model.ActionDigest},
+			event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+			triggers: []string{"free crypto"},
+		})
+	}
+	if len(calls) != 0 {
+		t.Fatalf("expected matches to wait for the digest, but got calls %v", calls)
+	}
+	h.postDigests(context.Background())
+	expected := []string{"C0MOD: :bookmark_tabs: 2 matches since the last digest:\n*<@U1>*: 2 matches\n• filter 1, 2 times in <#C1>: `free crypto`"}
+	if !reflect.DeepEqual(posted, expected) {
+		t.Errorf("expected digest %q, but posted %q", expected, posted)
+	}
+	// Each match is only in one digest.
+	calls = nil
+	h.postDigests(context.Background())
+	if len(calls) != 0 {
+		t.Errorf("expected no digest without new matches, but got calls %v", calls)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// Subtypes of emoji_changed events that give an emoji a new name.
+const (
+	emojiAdded   = "add"
+	emojiRenamed = "rename"
+)
+
+// handleEmojiChanged checks the names of custom emoji, and aliases, that are added to the workspace
+// or renamed with the filters that check custom emoji, to catch offensive ones.
+// Slack Event needed for this: emoji_changed
+func (h *handler) handleEmojiChanged(ctx context.Context, client *slack.Client, body []byte) error {
+	event := struct {
+		Event struct {
+			Type    string `json:"type"`
+			Subtype string `json:"subtype"`
+			Name    string `json:"name"`
+			NewName string `json:"new_name"`
+			// Value is the URL of the emoji's image, or "alias:" and the name of the emoji it is an
+			// alias of.
+			Value string `json:"value"`
+		} `json:"event"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
+	}
+	var name string
+	switch event.Event.Subtype {
+	case emojiAdded:
+		name = event.Event.Name
+	case emojiRenamed:
+		name = event.Event.NewName
+	}
+	if name == "" {
+		return nil
+	}
+	r := h.rules()
+	if !r.checksEmoji {
+		return nil
+	}
+	alias := strings.TrimPrefix(event.Event.Value, "alias:")
+	if alias == event.Event.Value {
+		alias = ""
+	}
+
+	for i, filter := range r.filters {
+		if !filter.ChecksEmoji() {
+			continue
+		}
+		matches := filter.MatchesEmoji(name)
+		if len(matches) == 0 {
+			continue
+		}
+		e := model.Event{Type: event.Event.Type, Text: ":" + name + ":"}
+		m := &match{rules: r, index: i, filter: filter, event: e, triggers: matches, emoji: name}
+		m.steps, m.strikes = h.steps(ctx, m)
+		logging.FromContext(ctx).Info("Custom emoji matched triggers", "emoji", name, "alias_of", alias, "triggers", matches, "severity", filter.Severity, "actions", m.steps)
+		h.runActions(ctx, client, m)
+		// There's nothing left for other filters to respond to.
+		if m.deleted {
+			break
+		}
+	}
+	return nil
+}
+
+// removeEmoji removes a custom emoji that matched a filter. Only Enterprise Grid org admins can
+// remove emoji through the API, so this needs the org token.
+func removeEmoji(ctx context.Context, client *slack.Client, m *match) error {
+	if err := api.New(client).AdminRemoveEmoji(ctx, m.emoji); err != nil {
+		return fmt.Errorf("failed to remove emoji: %v", err)
+	}
+	m.deleted = true
+	return nil
+}
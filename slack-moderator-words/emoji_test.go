@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestEmojiChanged(t *testing.T) {
+	tests := []struct {
+		name            string
+		event           string
+		orgToken        string
+		expectedCalls   []string
+		expectedButtons []string
+	}{
+		{
+			name:            "offensive emoji",
+			event:           `{"type": "emoji_changed", "subtype": "add", "name": "nazi_flag", "value": "https://emoji.slack-edge.com/T1/nazi_flag/abc.png"}`,
+			orgToken:        "xoxp-org",
+			expectedCalls:   []string{"admin.emoji.remove xoxp-org", "conversations.list ", "chat.postMessage xoxb-token"},
+			expectedButtons: []string{actionDismissEscalation},
+		},
+		{
+			name:            "offensive alias",
+			event:           `{"type": "emoji_changed", "subtype": "add", "name": "nazi", "value": "alias:flag"}`,
+			orgToken:        "xoxp-org",
+			expectedCalls:   []string{"admin.emoji.remove xoxp-org", "conversations.list ", "chat.postMessage xoxb-token"},
+			expectedButtons: []string{actionDismissEscalation},
+		},
+		{
+			name:            "renamed to something offensive",
+			event:           `{"type": "emoji_changed", "subtype": "rename", "old_name": "flag", "new_name": "nazi-flag", "value": "https://emoji.slack-edge.com/T1/nazi-flag/abc.png"}`,
+			orgToken:        "xoxp-org",
+			expectedCalls:   []string{"admin.emoji.remove xoxp-org", "conversations.list ", "chat.postMessage xoxb-token"},
+			expectedButtons: []string{actionDismissEscalation},
+		},
+		{
+			name:            "no org token to remove it with",
+			event:           `{"type": "emoji_changed", "subtype": "add", "name": "nazi_flag", "value": "https://emoji.slack-edge.com/T1/nazi_flag/abc.png"}`,
+			expectedCalls:   []string{"conversations.list ", "chat.postMessage xoxb-token"},
+			expectedButtons: []string{actionDismissEscalation},
+		},
+		{
+			name:  "removed",
+			event: `{"type": "emoji_changed", "subtype": "remove", "names": ["nazi_flag"]}`,
+		},
+		{
+			name:  "ordinary emoji",
+			event: `{"type": "emoji_changed", "subtype": "add", "name": "party_parrot", "value": "https://emoji.slack-edge.com/T1/party_parrot/abc.png"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var escalation struct {
+				Blocks json.RawMessage `json:"blocks"`
+			}
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method := strings.TrimPrefix(r.URL.Path, "/api/")
+				calls = append(calls, method+" "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+				w.Header().Set("Content-Type", "application/json")
+				switch method {
+				case "conversations.list":
+					_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0MOD", "name": "moderators"}]}`))
+				case "chat.postMessage":
+					body, _ := ioutil.ReadAll(r.Body)
+					if err := json.Unmarshal(body, &escalation); err != nil {
+						t.Errorf("failed to parse escalation: %v", err)
+					}
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				default:
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				}
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", OrgToken: tc.orgToken})
+
+			config, err := model.ParseConfig([]byte(`
+moderators_channel: "#moderators"
+filters:
+- custom_emoji: true
+  triggers: [nazi]
+  match: word
+  actions: [delete, escalate]
+- triggers: [nazi]
+  action: delete
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, config)
+			if err := h.handleEmojiChanged(context.Background(), client, []byte(`{"event": `+tc.event+`}`)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			if tc.expectedButtons == nil {
+				return
+			}
+			if buttons := escalationButtons(t, escalation.Blocks); !reflect.DeepEqual(buttons, tc.expectedButtons) {
+				t.Errorf("expected buttons %v, but got %v", tc.expectedButtons, buttons)
+			}
+		})
+	}
+}
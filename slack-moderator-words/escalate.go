@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/slashcmd"
+)
+
+// Action IDs of the buttons on escalations.
+const (
+	actionViewMessage       = "view_message"
+	actionDeleteMessage     = "delete_message"
+	actionDismissEscalation = "dismiss_escalation"
+	actionShadowBanUser     = "shadow_ban_user"
+)
+
+// escalate tells the moderators about a match by posting it to the moderators channel, with
+// buttons to view the message, delete it if the filter hasn't already, shadow-ban its author, or
+// dismiss the escalation.
+func (h *handler) escalate(ctx context.Context, client *slack.Client, m *match) error {
+	moderators, err := moderatorsChannel(ctx, client, m.rules)
+	if err != nil {
+		return err
+	}
+	text, b := escalationBlocks(m)
+	resp, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: moderators, Text: text, Blocks: b})
+	if err != nil {
+		return fmt.Errorf("failed to escalate message: %v", err)
+	}
+	m.escalation = resp
+	return nil
+}
+
+// moderatorsChannel returns the ID of the moderators channel of r in client's workspace.
+func moderatorsChannel(ctx context.Context, client *slack.Client, r *rules) (string, error) {
+	if r.moderators == "" {
+		return "", fmt.Errorf("there is no moderators channel")
+	}
+	moderators, err := client.Channels().ID(ctx, r.moderators)
+	if err != nil {
+		return "", fmt.Errorf("failed to find moderators channel %q: %v", r.moderators, err)
+	}
+	return moderators, nil
+}
+
+// escalationBlocks returns the notification text and blocks of the escalation of m.
+func escalationBlocks(m *match) (string, []blocks.Block) {
+	channel, _ := m.event.Channel.(string)
+	severity := m.filter.Severity
+	if severity == "" {
+		severity = "moderated"
+	}
+	text := fmt.Sprintf("<@%s> posted a message in <#%s> that matched a %s filter", m.event.User, channel, severity)
+	if len(m.fields) > 0 {
+		text = fmt.Sprintf("<@%s>'s %s matched a %s filter", m.event.User, m.fieldNames(), severity)
+	}
+	if m.emoji != "" {
+		text = fmt.Sprintf("The custom emoji `:%s:` matched a %s filter", m.emoji, severity)
+	}
+	if m.deleted {
+		text += ", and it was deleted"
+	}
+
+	triggers := make([]string, 0, len(m.triggers))
+	for _, t := range m.triggers {
+		triggers = append(triggers, "`"+t+"`")
+	}
+	fields := []*blocks.Text{
+		blocks.Markdown("*Matched*\n" + strings.Join(triggers, ", ")),
+		blocks.Markdown("*Actions*\n" + strings.Join(m.steps, ", ")),
+	}
+	if m.strikes > 0 {
+		fields = append(fields, blocks.Markdown(fmt.Sprintf("*Strikes*\n%d", m.strikes)))
+	}
+	b := []blocks.Block{blocks.Section(blocks.Markdown(":rotating_light: "+text+":\n"+quote(m.event.Text)), fields...)}
+
+	var buttons []blocks.Element
+	if m.permalink != "" {
+		buttons = append(buttons, blocks.LinkButton(actionViewMessage, "View message", m.permalink))
+	}
+	value := channel + "/" + m.event.TS
+	// There's no message to delete if the filter matched the user's profile or an emoji, and Slack
+	// doesn't say who added an emoji.
+	if !m.deleted && len(m.fields) == 0 && m.emoji == "" {
+		del := blocks.Button(actionDeleteMessage, "Delete message", value)
+		del.Style = blocks.StyleDanger
+		buttons = append(buttons, del)
+	}
+	if m.event.User != "" {
+		buttons = append(buttons, blocks.Button(actionShadowBanUser, "Shadow-ban author", m.event.User))
+	}
+	buttons = append(buttons, blocks.Button(actionDismissEscalation, "Dismiss", value))
+	return text, append(b, blocks.Actions(buttons...))
+}
+
+// quote formats text as a Slack block quote.
+func quote(text string) string {
+	return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+}
+
+// handleEscalations registers the handlers for the buttons on escalations with ih.
+func (h *handler) handleEscalations(ih *interactive.Handler) {
+	// The link button opens the message in Slack, but Slack still sends us a payload for it.
+	ih.HandleFunc(interactive.TypeBlockActions, actionViewMessage, func(context.Context, *interactive.Payload) (*interactive.Response, error) {
+		return nil, nil
+	})
+	ih.HandleFunc(interactive.TypeBlockActions, actionDeleteMessage, handleDeleteEscalated)
+	ih.HandleFunc(interactive.TypeBlockActions, actionShadowBanUser, h.handleShadowBanEscalated)
+	ih.HandleFunc(interactive.TypeBlockActions, actionDismissEscalation, handleDismissEscalation)
+}
+
+// handleDeleteEscalated deletes an escalated message when a moderator asks for it.
+func handleDeleteEscalated(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	channel, ts, ok := strings.Cut(p.Action(actionDeleteMessage).Value.Value, "/")
+	if !ok {
+		return nil, fmt.Errorf("malformed message reference %q", p.Action(actionDeleteMessage).Value.Value)
+	}
+	logging.FromContext(ctx).Info("Deleting escalated message", "message_channel", channel, "ts", ts)
+	// Only admins can delete other people's messages, so this needs their user token.
+	deleteCtx := slack.WithPriority(slack.WithToken(ctx, slack.TokenUser), slack.PriorityHigh)
+	if err := api.New(p.Client()).DeleteMessage(deleteCtx, api.DeleteMessageRequest{Channel: channel, TS: ts}); err != nil {
+		return nil, fmt.Errorf("failed to delete escalated message: %v", err)
+	}
+	return nil, resolveEscalation(ctx, p, fmt.Sprintf(":wastebasket: Deleted by <@%s>", p.User.ID))
+}
+
+// handleShadowBanEscalated shadow-bans the author of an escalated message when a moderator asks for
+// it, so that everything they post from then on is deleted.
+func (h *handler) handleShadowBanEscalated(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	user := p.Action(actionShadowBanUser).Value.Value
+	logging.FromContext(ctx).Info("Shadow-banning user", "banned_user_id", user)
+	if err := h.shadowBans.Ban(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to shadow-ban %s: %v", user, err)
+	}
+	return nil, resolveEscalation(ctx, p, fmt.Sprintf(":no_entry: <@%s> was shadow-banned by <@%s>", user, p.User.ID))
+}
+
+// handleDismissEscalation marks an escalation as dealt with, leaving the message alone.
+func handleDismissEscalation(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	return nil, resolveEscalation(ctx, p, fmt.Sprintf(":white_check_mark: Dismissed by <@%s>", p.User.ID))
+}
+
+// resolveEscalation replaces the escalation's buttons with a note saying how it was resolved, so
+// that other moderators don't act on it again.
+func resolveEscalation(ctx context.Context, p *interactive.Payload, note string) error {
+	var resolved []blocks.Block
+	for _, b := range p.Message.Blocks {
+		block := struct {
+			Type string `json:"type"`
+		}{}
+		if err := json.Unmarshal(b, &block); err != nil {
+			return fmt.Errorf("failed to parse escalation block: %v", err)
+		}
+		if block.Type != "actions" {
+			resolved = append(resolved, blocks.RawBlock(b))
+		}
+	}
+	resolved = append(resolved, blocks.Context(blocks.Markdown(note)))
+	return p.Respond(ctx, slashcmd.Response{Text: p.Message.Text, Blocks: resolved, ReplaceOriginal: true})
+}
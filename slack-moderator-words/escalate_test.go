@@ -0,0 +1,220 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// escalationButtons returns the action IDs of the buttons in blocks.
+func escalationButtons(t *testing.T, blocks json.RawMessage) []string {
+	var parsed []struct {
+		Type     string `json:"type"`
+		Elements []struct {
+			ActionID string `json:"action_id"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal(blocks, &parsed); err != nil {
+		t.Fatalf("failed to parse blocks: %v", err)
+	}
+	var buttons []string
+	for _, b := range parsed {
+		if b.Type != "actions" {
+			continue
+		}
+		for _, e := range b.Elements {
+			buttons = append(buttons, e.ActionID)
+		}
+	}
+	return buttons
+}
+
+func TestEscalate(t *testing.T) {
+	tests := []struct {
+		name            string
+		deleteResponse  string
+		expectedCalls   []string
+		expectedButtons []string
+	}{
+		{
+			name:            "deleted message",
+			deleteResponse:  `{"ok": true}`,
+			expectedCalls:   []string{"chat.getPermalink", "chat.delete", "chat.postEphemeral", "conversations.list", "chat.postMessage"},
+			expectedButtons: []string{actionViewMessage, actionShadowBanUser, actionDismissEscalation},
+		},
+		{
+			name:            "message that couldn't be deleted",
+			deleteResponse:  `{"ok": false, "error": "cant_delete_message"}`,
+			expectedCalls:   []string{"chat.getPermalink", "chat.delete", "chat.postEphemeral", "conversations.list", "chat.postMessage"},
+			expectedButtons: []string{actionViewMessage, actionDeleteMessage, actionShadowBanUser, actionDismissEscalation},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var escalation struct {
+				Channel string          `json:"channel"`
+				Blocks  json.RawMessage `json:"blocks"`
+			}
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method := strings.TrimPrefix(r.URL.Path, "/api/")
+				calls = append(calls, method)
+				w.Header().Set("Content-Type", "application/json")
+				switch method {
+				case "chat.getPermalink":
+					_, _ = w.Write([]byte(`{"ok": true, "permalink": "https://example.slack.com/archives/C1/p1612790186002000"}`))
+				case "chat.delete":
+					_, _ = w.Write([]byte(tc.deleteResponse))
+				case "conversations.list":
+					_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0MOD", "name": "moderators"}]}`))
+				case "chat.postMessage":
+					body, _ := ioutil.ReadAll(r.Body)
+					if err := json.Unmarshal(body, &escalation); err != nil {
+						t.Errorf("failed to parse escalation: %v", err)
+					}
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				default:
+					_, _ = w.Write([]byte(`{"ok": true}`))
+				}
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			config, err := model.ParseConfig([]byte(`
+moderators_channel: "#moderators"
+filters:
+- triggers: [free crypto]
+  severity: critical
+  message: "Please don't."
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, config)
+			h.runActions(context.Background(), client, &match{
+				rules:    h.rules(),
+				filter:   config.Filters[0],
+				steps:    config.Filters[0].Steps(),
+				event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+				triggers: []string{"free crypto"},
+			})
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			if escalation.Channel != "C0MOD" {
+				t.Errorf("expected escalation to C0MOD, but it went to %q", escalation.Channel)
+			}
+			if buttons := escalationButtons(t, escalation.Blocks); !reflect.DeepEqual(buttons, tc.expectedButtons) {
+				t.Errorf("expected buttons %v, but got %v", tc.expectedButtons, buttons)
+			}
+		})
+	}
+}
+
+func TestResolveEscalation(t *testing.T) {
+	tests := []struct {
+		name          string
+		actionID      string
+		expectedCalls []string
+		expectedNote  string
+	}{
+		{
+			name:          "delete",
+			actionID:      actionDeleteMessage,
+			expectedCalls: []string{"/api/chat.delete xoxp-admin", "/actions/T1/1/abc xoxb-token"},
+			expectedNote:  ":wastebasket: Deleted by <@U0MOD>",
+		},
+		{
+			name:          "dismiss",
+			actionID:      actionDismissEscalation,
+			expectedCalls: []string{"/actions/T1/1/abc xoxb-token"},
+			expectedNote:  ":white_check_mark: Dismissed by <@U0MOD>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var response struct {
+				Blocks []struct {
+					Type     string `json:"type"`
+					Elements []struct {
+						Text string `json:"text"`
+					} `json:"elements"`
+				} `json:"blocks"`
+				ReplaceOriginal bool `json:"replace_original"`
+			}
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.URL.Path+" "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+				if strings.HasPrefix(r.URL.Path, "/actions/") {
+					body, _ := ioutil.ReadAll(r.Body)
+					if err := json.Unmarshal(body, &response); err != nil {
+						t.Errorf("failed to parse response: %v", err)
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+			ih := interactive.NewHandler(slack.NewClientSet(client))
+			newHandler(nil, model.Config{}).handleEscalations(ih)
+			payload := `{
+				"type": "block_actions",
+				"team": {"id": "T1"},
+				"user": {"id": "U0MOD"},
+				"response_url": "https://hooks.slack.com/actions/T1/1/abc",
+				"message": {
+					"text": "<@U1> posted a message",
+					"blocks": [
+						{"type": "section", "text": {"type": "mrkdwn", "text": "<@U1> posted a message"}},
+						{"type": "actions", "elements": []}
+					]
+				},
+				"actions": [{"action_id": "` + tc.actionID + `", "value": "C1/1612790186.002000"}]
+			}`
+			if _, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			if !response.ReplaceOriginal {
+				t.Errorf("expected the escalation to be replaced")
+			}
+			if len(response.Blocks) != 2 || response.Blocks[0].Type != "section" || response.Blocks[1].Type != "context" {
+				t.Fatalf("expected the buttons to be replaced with a note, but got %+v", response.Blocks)
+			}
+			if note := response.Blocks[1].Elements[0].Text; note != tc.expectedNote {
+				t.Errorf("expected note %q, but got %q", tc.expectedNote, note)
+			}
+		})
+	}
+}
@@ -17,127 +17,482 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"strings"
+	"sync"
+	"time"
 
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/events"
 )
 
 type handler struct {
-	client  *slack.Client
-	filters model.FilterConfig
+	*events.Dispatcher
+	rulesLock sync.RWMutex
+	current   *rules
+	// configureLock stops the filter config, its trigger lists and the runtime filters being
+	// updated at the same time.
+	configureLock sync.Mutex
+	// runtimeFilters are the filters moderators have added with /modwords, which apply after the
+	// filter config's own.
+	runtimeFilters []runtimeFilter
+	triggerLists   *triggerLists
+	// join decides which new channels are joined.
+	join joinPolicy
+	// bots decides which messages from bots are moderated.
+	bots       *botPolicy
+	usergroups *usergroupCache
+	cooldowns  cooldownStore
+	// strikes counts strikes against users, and shadowStrikes counts them in shadow mode, so that
+	// they don't count once filters are enforced.
+	strikes       strikeStore
+	shadowStrikes strikeStore
+	// shadowBans are the users shadow-banned since the filter config was written.
+	shadowBans shadowBanStore
+	// shadowMode stops the handler acting on matches; they're only logged, and posted to
+	// reviewChannel, by name or ID, if it is set. dryRun overrides it once moderators switch it on
+	// the App Home tab; see inShadowMode.
+	shadowMode    bool
+	dryRun        dryRunStore
+	reviewChannel string
+	// scanFiles makes filters check the content of snippets and text files shared in messages.
+	scanFiles bool
+	// resolveShortLinks makes filters that check links see where short links lead, using links.
+	resolveShortLinks bool
+	links             *linkResolver
+	// crossPosts remembers recent messages for filters that look for cross-posted messages, and
+	// rates counts them for filters that limit how fast users can post.
+	crossPosts *crossPosts
+	rates      *rates
+	// scorer scores messages for filters that use an external classifier, with scoreTimeout to
+	// answer. Those filters don't match anything if it isn't set.
+	scorer       scorer
+	scoreTimeout time.Duration
+	// userProfiles are what users' profiles said when filters that check users last checked them.
+	userProfiles *userProfiles
+	// digests collects matches for the next digest.
+	digests *digests
+	// topics are the topics and purposes channels were last seen changing to, for ActionRevert.
+	topics *topics
+	// store records every match, if there is a database to record them in.
+	store *matchStore
+	// redact cuts down the text of messages, and what filters matched in them, before it's logged.
+	redact redactor
 }
 
-// ServeHTTP handles Slack webhook requests.
-func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		logError(rw, "Failed to read incoming request body: %v", err)
-		return
+// match is a message that matched a filter, and what has been done about it so far.
+type match struct {
+	// rules are the rules the message was moderated with, and index is the index of filter in them.
+	rules    *rules
+	index    int
+	filter   model.Filter
+	event    model.Event
+	triggers []string
+	// copies are the copies of the message in other channels, if the filter matched it for having
+	// been cross-posted, and post is the message as it was remembered then.
+	copies []post
+	post   post
+	// fields are the parts of the user's profile, such as fieldDisplayName, that matched, if the
+	// filter checks users rather than messages. The event's text is then what they say, and it has
+	// no channel.
+	fields []string
+	// flooding is set if the filter matched the message for being posted too fast, but not for
+	// being the first message that was, so moderators have already been told about the flood.
+	flooding bool
+	// steps are the actions to take, which depend on the author's strikes if the filter counts them.
+	steps   []string
+	strikes int
+	// permalink links to the message, if the filter escalates it or its message links to it.
+	permalink string
+	// message is the filter's message, filled in for this match.
+	message string
+	// deleted is set once the message has been deleted, and warned once its author has been warned.
+	deleted bool
+	warned  bool
+	// escalation is the match's escalation in the moderators channel, once it has been posted.
+	escalation api.PostMessageResponse
+	// emoji is the name of the custom emoji that matched, if the filter checks custom emoji. The
+	// event's text is then the emoji, and it has no user or channel.
+	emoji string
+	// previousTopic is the topic or purpose the channel had before the message changed it, if
+	// previousTopicKnown.
+	previousTopic      string
+	previousTopicKnown bool
+}
+
+// newHandler returns a handler that moderates messages from every workspace in clients using the
+// rules in config.
+func newHandler(clients *slack.ClientSet, config model.Config) *handler {
+	h := &handler{Dispatcher: events.NewDispatcher(clients), current: newRules(config), triggerLists: newTriggerLists(), bots: newBotPolicy(false, ""), usergroups: newUsergroupCache(), cooldowns: newMemoryCooldowns(), strikes: newMemoryStrikes(), shadowStrikes: newMemoryStrikes(), dryRun: newMemoryDryRun(), shadowBans: newMemoryShadowBans(), links: newLinkResolver(), crossPosts: newCrossPosts(), rates: newRates(), scoreTimeout: defaultScoreTimeout, userProfiles: newUserProfiles(), topics: newTopics(), digests: newDigests()}
+	h.HandleFunc("channel_created", h.handleChannelCreated)
+	h.HandleFunc("emoji_changed", h.handleEmojiChanged)
+	h.HandleFunc("message", h.handleMessage)
+	h.HandleFunc("reaction_added", h.handleReactionAdded)
+	h.HandleFunc("team_join", h.handleUserChange)
+	h.HandleFunc("user_change", h.handleUserChange)
+	h.HandleAppHomeOpened(h.handleAppHomeOpened)
+	return h
+}
+
+// handleChannelCreated joins new channels allowed by the join policy, so that we can moderate them.
+// Slack Event needed for this: channel_created
+func (h *handler) handleChannelCreated(ctx context.Context, client *slack.Client, body []byte) error {
+	event := struct {
+		Event struct {
+			Channel model.Channel `json:"channel"`
+		} `json:"event"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to decode event channel: %v", err)
 	}
-	defer r.Body.Close()
+	channelCreated := event.Event.Channel
 
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		logError(rw, "Failed validation: %v", err)
-		return
+	logging.FromContext(ctx).Info("New public channel", "channel_name", channelCreated.Name)
+	if !h.join.allows(channelCreated.Name) {
+		logging.FromContext(ctx).Info("New public channel is not allowed by the join policy, not joining", "channel_name", channelCreated.Name)
+		return nil
+	}
+	if _, err := api.New(client).JoinConversation(ctx, channelCreated.ID); err != nil {
+		return fmt.Errorf("failed to join channel %s: %v", channelCreated.Name, err)
 	}
+	return nil
+}
 
+// handleMessage moderates messages from the channels the bot is listening to.
+// Slack Event needed for this: message.channels
+func (h *handler) handleMessage(ctx context.Context, client *slack.Client, body []byte) error {
 	event := &model.SlackEvent{}
-	err = json.NewDecoder(bytes.NewReader(body)).Decode(event)
-	if err != nil {
-		logError(rw, "Failed to unmarshal payload: %v", err)
-		panic(err)
+	if err := json.Unmarshal(body, event); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
 	}
 
-	// This is used for the first time when configuring the slack events
-	if event.Type == "url_verification" {
-		resp := &model.Challenge{}
-		resp.Challenge = event.Challenge
-		challengeJson, err := json.Marshal(resp)
-		if err != nil {
-			logError(rw, "Failed to marshal challenge payload: %v", err)
+	message, previous := event.Event, ""
+	if message.Subtype == model.SubtypeMessageChanged {
+		// Spammers edit links into innocent messages, so edits get moderated like new messages.
+		edited, ok := message.Edited()
+		if !ok {
+			return nil
 		}
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusOK)
-		_, _ = rw.Write(challengeJson)
-		return
+		message = edited
+		if event.Event.PreviousMessage != nil {
+			previous = event.Event.PreviousMessage.Content()
+		}
+		logging.FromContext(ctx).Debug("Message was edited", "ts", message.TS, "user_id", message.User)
 	}
 
-	// Triggered when is a new channel created
-	// and the bot will join to the channel
-	// Slack Event needed for this: channel_created
-	if event.Event.Type == "channel_created" {
-		b, err := json.Marshal(event.Event.Channel)
-		if err != nil {
-			panic(err)
+	channel, _ := message.Channel.(string)
+	// Remember every topic and purpose, including the ones set by bots and the ones we revert to,
+	// so that the next change can be reverted to it.
+	previousTopic, previousTopicKnown := "", false
+	if isTopicChange(message) && channel != "" {
+		previousTopic, previousTopicKnown = h.topics.swap(client, channel, message.Subtype, topicValue(message))
+	}
+
+	// Only moderate bots if we've been asked to, and never ourselves.
+	if message.BotID != "" && h.bots.skip(ctx, client, message.BotID) {
+		return nil
+	}
+
+	logging.FromContext(ctx).Debug("Got message", "ts", message.TS, "user_id", message.User, "channel", channel, "text", h.redact.text(message.Text))
+
+	// Use the same rules for the whole message, even if they are reloaded in the meantime.
+	r := h.rules()
+	if message.User != "" && h.shadowBanned(ctx, r, message.User) {
+		return h.deleteShadowBanned(ctx, client, event.Event)
+	}
+
+	content := message.Content()
+	if h.scanFiles && (len(message.Files) > 0 || message.File != nil) {
+		if files := h.fileContent(ctx, client, message); files != "" {
+			content += "\n" + files
 		}
-		channelCreated := &model.Channel{}
-		err = json.Unmarshal(b, channelCreated)
-		if err != nil {
-			log.Fatalf("Failed to decode event channel: %v", err)
+	}
+	if h.resolveShortLinks && r.checksLinks {
+		if links := h.resolveLinks(ctx, content); links != "" {
+			content += "\n" + links
 		}
-
-		log.Printf("New public channels: %s/%s\n", channelCreated.ID, channelCreated.Name)
-		req := map[string]interface{}{
-			"channel": channelCreated.ID,
+	}
+	var current *post
+	if r.crossPostWindow > 0 && message.User != "" && channel != "" {
+		if fingerprint, ok := model.FingerprintOf(content); ok {
+			p := h.crossPosts.add(client, message.User, channel, message.TS, fingerprint, r.crossPostWindow)
+			current = &p
 		}
-		err = h.client.CallMethod("conversations.join", req, nil)
-		if err != nil {
-			log.Fatalf("Failed to join channel %s: %v", channelCreated.Name, err)
+	}
+	// Edits aren't new messages, so they don't count towards rates.
+	var postedAt time.Time
+	if r.rateWindow > 0 && message.User != "" && event.Event.Subtype != model.SubtypeMessageChanged {
+		postedAt = h.rates.add(client, message.User, r.rateWindow)
+	}
+	channelName, resolved := "", false
+	// Messages are only scored once a filter needs it, since it is slow.
+	var scores map[string]float64
+	scored := false
+	for i, filter := range r.filters {
+		if filter.ChecksUsers() || filter.ChecksEmoji() {
+			continue
+		}
+		if filter.Scoped() {
+			if !resolved {
+				channelName, resolved = h.channelName(ctx, client, channel), true
+			}
+			if !filter.AppliesTo(channel, channelName) {
+				continue
+			}
+		}
+		matches := filter.Matches(content)
+		var copies []post
+		if filter.CrossPosts != nil && current != nil {
+			copies = h.crossPosts.copies(client, message.User, *current, *filter.CrossPosts)
+			if channels := len(copies) + 1; channels >= filter.CrossPosts.MinChannels() {
+				matches = append(matches, fmt.Sprintf("posted in %d channels", channels))
+			} else {
+				copies = nil
+			}
+		}
+		// Editing a broadcast mention into a message doesn't notify anyone.
+		if filter.Broadcasts != nil && event.Event.Subtype != model.SubtypeMessageChanged {
+			matches = append(matches, h.broadcasts(ctx, client, *filter.Broadcasts, channel, message)...)
+		}
+		flooding := false
+		if filter.Rate != nil && !postedAt.IsZero() {
+			if n := h.rates.count(client, message.User, postedAt, filter.Rate.Window()); n > filter.Rate.Limit() {
+				matches = append(matches, fmt.Sprintf("%d messages in %s", n, filter.Rate.Window()))
+				flooding = n > filter.Rate.Limit()+1
+			}
+		}
+		if filter.Score != nil && h.scorer != nil {
+			if !scored {
+				scores, scored = h.scores(ctx, r, content), true
+			}
+			if score, ok := scores[filter.Score.Attribute]; ok && score >= filter.Score.Threshold {
+				matches = append(matches, fmt.Sprintf("%s %.2f", filter.Score.Attribute, score))
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if previous != "" && len(filter.Matches(previous)) > 0 {
+			// The filter already responded to the message before it was edited, such as when Slack
+			// unfurls a link in it.
+			logging.FromContext(ctx).Debug("Edited message already matched filter", "ts", message.TS, "user_id", message.User, "triggers", h.redact.texts(matches))
+			continue
+		}
+		if h.exempt(ctx, client, filter, message.User) {
+			logging.FromContext(ctx).Debug("User is exempt from matching filter", "ts", message.TS, "user_id", message.User, "triggers", h.redact.texts(matches))
+			continue
+		}
+		m := &match{rules: r, index: i, filter: filter, event: message, triggers: matches, copies: copies, flooding: flooding, previousTopic: previousTopic, previousTopicKnown: previousTopicKnown}
+		if current != nil {
+			m.post = *current
+		}
+		m.steps, m.strikes = h.steps(ctx, m)
+		logging.FromContext(ctx).Info("Message matched triggers", "ts", message.TS, "user", client.Users().DisplayName(ctx, message.User), "user_id", message.User, "triggers", h.redact.texts(matches), "severity", filter.Severity, "actions", m.steps)
+		h.runActions(ctx, client, m)
+		// There's nothing left for other filters to respond to.
+		if m.takes(model.ActionDelete) {
+			break
 		}
-
-		rw.Header().Set("Content-Type", "application/json")
-		rw.WriteHeader(http.StatusOK)
-		_, _ = rw.Write([]byte(""))
-		return
 	}
+	return nil
+}
 
-	// When is a message from the channels the bot is listening
-	// Slack Event needed for this: message.channels
-	// reply ok rigth away
-	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusOK)
-	_, _ = rw.Write([]byte(""))
-
-	// If come from Bot just ignore and not moderate
-	if event.Event.BotID != "" {
-		return
+// steps returns the actions the filter takes for a match. If the filter counts strikes, it records
+// one against the match's author, and also returns how many they now have.
+func (h *handler) steps(ctx context.Context, m *match) ([]string, int) {
+	filter, user := m.filter, m.event.User
+	if !filter.CountsStrikes() {
+		return filter.Steps(), 0
 	}
+	store := h.strikes
+	if h.inShadowMode(ctx) {
+		store = h.shadowStrikes
+	}
+	strikes, err := store.Add(ctx, user, m.rules.strikeTTL)
+	if err != nil {
+		// Don't punish users more than we can be sure they deserve.
+		logging.FromContext(ctx).Error("Failed to record strike, treating it as the first", "user_id", user, "error", err)
+		strikes = 1
+	}
+	logging.FromContext(ctx).Info("Recorded strike", "user_id", user, "strikes", strikes)
+	return filter.StrikeSteps(strikes), strikes
+}
 
-	log.Printf("[EVENT] %+v", event)
+// takes returns whether action is one of the match's steps.
+func (m *match) takes(action string) bool {
+	for _, a := range m.steps {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
 
-	if h.filters != nil {
-		for _, filter := range h.filters {
-			for _, word := range filter.Triggers {
-				if strings.Contains(event.Event.Text, word) {
-					req := map[string]interface{}{
-						"channel": event.Event.Channel,
-						"user":    event.Event.User,
-						"text":    filter.Message,
-					}
+// warns returns whether any of the match's steps show the filter's message.
+func (m *match) warns() bool {
+	for _, a := range m.steps {
+		if model.IsWarning(a) {
+			return true
+		}
+	}
+	return false
+}
 
-					if event.Event.ThreadTS != "" {
-						req["thread_ts"] = event.Event.ThreadTS
-					}
+// channelName returns the name of the channel with the given ID, or an empty string if it can't be
+// looked up, so that filters scoped to channels by ID still work.
+func (h *handler) channelName(ctx context.Context, client *slack.Client, id string) string {
+	name, err := client.Channels().Name(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to look up channel name", "error", err)
+		return ""
+	}
+	return name
+}
 
-					err = h.client.CallMethod(filter.Action, req, nil)
-					if err != nil {
-						logError(rw, "Failed send message to slack: %v", err)
-					}
-				}
+// runActions takes each of the matching filter's actions in turn. An action that fails is logged,
+// and doesn't stop the rest, so that a message that can't be deleted still gets a warning.
+func (h *handler) runActions(ctx context.Context, client *slack.Client, m *match) {
+	channel, _ := m.event.Channel.(string)
+	filter := m.filter.Label(m.index)
+	matches.WithLabelValues(filter, channel).Inc()
+	// Check the dry run switch once, so that a moderator switching it doesn't leave a match half
+	// handled.
+	shadow := h.inShadowMode(ctx)
+	if m.event.TS != "" && h.needsPermalink(m, shadow) {
+		// The message might be about to be deleted, so link to it while we still can.
+		permalink, err := api.New(client).GetPermalink(ctx, channel, m.event.TS)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to get permalink", "error", err)
+		}
+		m.permalink = permalink
+	}
+	message, err := m.filter.RenderMessage(model.NewMessageData(m.event.User, channel, m.triggers, m.permalink))
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to render filter message, using it as it is", "error", err)
+		message = m.filter.Message
+	}
+	m.message = message
+	if shadow {
+		if err := h.review(ctx, client, m); err != nil {
+			logging.FromContext(ctx).Error("Failed to report match for review", "error", err)
+		}
+		h.record(ctx, m, nil)
+		return
+	}
+	warn := !m.warns() || h.allowWarning(ctx, client, m)
+	var taken []string
+	for _, action := range m.steps {
+		if model.IsWarning(action) && !warn {
+			logging.FromContext(ctx).Info("Not warning user again during the filter's cooldown", "ts", m.event.TS, "user_id", m.event.User, "action", action)
+			actionsTaken.WithLabelValues(filter, action, channel, statusSkipped).Inc()
+			continue
+		}
+		if action == model.ActionEscalate && m.flooding {
+			logging.FromContext(ctx).Info("Not escalating every message of a flood", "ts", m.event.TS, "user_id", m.event.User)
+			actionsTaken.WithLabelValues(filter, action, channel, statusSkipped).Inc()
+			continue
+		}
+		if err := h.sendFilterMessage(ctx, client, action, m); err != nil {
+			logging.FromContext(ctx).Error("Failed to send message to Slack", "action", action, "error", err)
+			actionsTaken.WithLabelValues(filter, action, channel, statusError).Inc()
+			continue
+		}
+		actionsTaken.WithLabelValues(filter, action, channel, statusOK).Inc()
+		taken = append(taken, action)
+		if m.rules.audit != "" && audits(action) {
+			if err := h.audit(ctx, client, m.rules, auditText(action, m)); err != nil {
+				logging.FromContext(ctx).Error("Failed to record action in audit channel", "action", action, "error", err)
 			}
 		}
+		m.warned = m.warned || model.IsWarning(action)
+	}
+	h.record(ctx, m, taken)
+	if m.rules.appeals && (m.deleted || m.warned) && m.event.User != "" && m.event.TS != "" {
+		if err := h.offerAppeal(ctx, client, m); err != nil {
+			logging.FromContext(ctx).Error("Failed to offer appeal", "ts", m.event.TS, "user_id", m.event.User, "error", err)
+		}
 	}
 }
 
-func logError(rw http.ResponseWriter, format string, args ...interface{}) {
-	s := fmt.Sprintf(format, args...)
-	log.Println(s)
-	http.Error(rw, s, 500)
+// needsPermalink returns whether anything done about a match links to its message, given whether
+// the handler is in shadow mode.
+func (h *handler) needsPermalink(m *match, shadow bool) bool {
+	if m.takes(model.ActionEscalate) || m.filter.NeedsPermalink() || (shadow && h.reviewChannel != "") {
+		return true
+	}
+	return m.takes(model.ActionDelete) && (m.rules.quarantine != "" || m.rules.confirms(model.ActionDelete, m.filter))
+}
+
+// sendFilterMessage responds to a message that matched a filter by taking one of its actions.
+func (h *handler) sendFilterMessage(ctx context.Context, client *slack.Client, action string, m *match) error {
+	// Moderation shouldn't queue behind other calls if we're close to Slack's rate limits.
+	ctx = slack.WithPriority(ctx, slack.PriorityHigh)
+	event, message := m.event, m.message
+	channel, _ := event.Channel.(string)
+	c := api.New(client)
+	switch action {
+	case model.ActionLog:
+		// handleMessage has already logged the match.
+		return nil
+	case model.ActionDelete:
+		if m.emoji != "" {
+			return removeEmoji(ctx, client, m)
+		}
+		if m.rules.confirms(model.ActionDelete, m.filter) {
+			return h.requestConfirmation(ctx, client, action, m)
+		}
+		if m.rules.quarantine != "" {
+			// Deleting spam matters more than keeping a copy of it, so carry on if this fails.
+			if err := h.quarantine(ctx, client, m); err != nil {
+				logging.FromContext(ctx).Error("Failed to quarantine message before deleting it", "ts", event.TS, "error", err)
+			}
+		}
+		// Only admins can delete other people's messages, so this needs their user token.
+		err := c.DeleteMessage(slack.WithToken(ctx, slack.TokenUser), api.DeleteMessageRequest{
+			Channel: channel,
+			TS:      event.TS,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete message: %v", err)
+		}
+		m.deleted = true
+		if len(m.copies) > 0 {
+			h.deleteCopies(ctx, client, m)
+		}
+		return nil
+	case model.ActionWarn:
+		return h.warn(ctx, client, m)
+	case model.ActionPostEphemeral:
+		_, err := c.PostEphemeral(ctx, api.PostEphemeralRequest{
+			Channel:  channel,
+			User:     event.User,
+			Text:     message,
+			ThreadTS: event.ThreadTS,
+		})
+		return err
+	case model.ActionPostMessage:
+		_, err := c.PostMessage(ctx, api.PostMessageRequest{
+			Channel:  channel,
+			Text:     message,
+			ThreadTS: event.ThreadTS,
+		})
+		return err
+	case model.ActionEscalate:
+		return h.escalate(ctx, client, m)
+	case model.ActionKick, model.ActionDeactivate:
+		return h.remove(ctx, client, action, m)
+	case model.ActionRename:
+		return rename(ctx, client, m)
+	case model.ActionRevert:
+		return h.revert(ctx, client, m)
+	case model.ActionDigest:
+		h.digests.add(client, m)
+		return nil
+	default:
+		return fmt.Errorf("unsupported filter action %q", action)
+	}
 }
@@ -2,11 +2,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
 )
 
 func TestEventsHandler(t *testing.T) {
@@ -35,7 +41,7 @@ func TestEventsHandler(t *testing.T) {
 
 	rr := httptest.NewRecorder()
 
-	h := &handler{client: nil, filters: nil}
+	h := newHandler(nil, model.Config{})
 	handler := http.Handler(h)
 
 	// TODO: this is a failing test when the slack headers does not match
@@ -49,3 +55,167 @@ func TestEventsHandler(t *testing.T) {
 			status, http.StatusOK)
 	}
 }
+
+func TestRunActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   model.Filter
+		expected []string
+	}{
+		{
+			name:     "delete only",
+			filter:   model.Filter{Action: model.ActionDelete},
+			expected: []string{"chat.delete xoxp-admin"},
+		},
+		{
+			name:     "delete and warn",
+			filter:   model.Filter{Action: model.ActionDelete, Message: "Please don't."},
+			expected: []string{"chat.delete xoxp-admin", "chat.postEphemeral xoxb-token"},
+		},
+		{
+			name:     "several actions in order",
+			filter:   model.Filter{Actions: []string{model.ActionPostEphemeral, model.ActionDelete, model.ActionPostMessage}, Message: "Please don't."},
+			expected: []string{"chat.postEphemeral xoxb-token", "chat.delete xoxp-admin", "chat.postMessage xoxb-token"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/")+" "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+				w.Header().Set("Content-Type", "application/json")
+				// A failed action doesn't stop the ones after it.
+				if r.URL.Path == "/api/chat.delete" {
+					_, _ = w.Write([]byte(`{"ok": false, "error": "cant_delete_message"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			tc.filter.Triggers = []string{"honk"}
+			filters := model.FilterConfig{tc.filter}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters})
+			h.runActions(context.Background(), client, &match{rules: h.rules(), filter: filters[0], steps: filters[0].Steps(), event: model.Event{Channel: "C1", User: "U1", TS: "1612790186.002000"}})
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/users.info" {
+			calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	parse := func(filters string) model.Config {
+		config, err := model.ParseConfig([]byte(filters))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return config
+	}
+	h := newHandler(nil, parse(`filters: [{triggers: [honk], action: warn, message: "Please don't."}]`))
+	h.setRules(newRules(parse(`filters: [{triggers: [quack], action: warn, message: "Please don't."}]`)))
+	for _, text := range []string{"honk", "quack"} {
+		body := []byte(`{"event": {"type": "message", "channel": "C1", "user": "U1", "text": "` + text + `", "ts": "1612790186.002000"}}`)
+		if err := h.handleMessage(context.Background(), client, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if expected := []string{"chat.postEphemeral"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, but got %v", expected, calls)
+	}
+}
+
+func TestHandleMessageSubtypes(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		expected []string
+	}{
+		{
+			name:     "scam edited in",
+			event:    `{"type": "message", "subtype": "message_changed", "channel": "C1", "ts": "1612790200.000100", "message": {"type": "message", "user": "U1", "text": "free crypto at example.com", "ts": "1612790186.002000"}, "previous_message": {"type": "message", "user": "U1", "text": "hello", "ts": "1612790186.002000"}}`,
+			expected: []string{"chat.delete", "chat.postEphemeral"},
+		},
+		{
+			name:  "link unfurled",
+			event: `{"type": "message", "subtype": "message_changed", "channel": "C1", "ts": "1612790200.000100", "message": {"type": "message", "user": "U1", "text": "free crypto", "ts": "1612790186.002000"}, "previous_message": {"type": "message", "user": "U1", "text": "free crypto", "ts": "1612790186.002000"}}`,
+		},
+		{
+			name:     "scam in unfurled link",
+			event:    `{"type": "message", "subtype": "message_changed", "channel": "C1", "ts": "1612790200.000100", "message": {"type": "message", "user": "U1", "text": "look at example.com", "ts": "1612790186.002000", "attachments": [{"title": "Example", "text": "Get free crypto now", "fallback": "Example"}]}, "previous_message": {"type": "message", "user": "U1", "text": "look at example.com", "ts": "1612790186.002000"}}`,
+			expected: []string{"chat.delete", "chat.postEphemeral"},
+		},
+		{
+			name:  "scam unfurled after it was moderated",
+			event: `{"type": "message", "subtype": "message_changed", "channel": "C1", "ts": "1612790200.000100", "message": {"type": "message", "user": "U1", "text": "free crypto at example.com", "ts": "1612790186.002000", "attachments": [{"title": "Example", "text": "Free crypto"}]}, "previous_message": {"type": "message", "user": "U1", "text": "free crypto at example.com", "ts": "1612790186.002000"}}`,
+		},
+		{
+			name:     "scam in bot blocks",
+			event:    `{"type": "message", "channel": "C1", "user": "U1", "text": "notification", "ts": "1612790186.002000", "blocks": [{"type": "section", "text": {"type": "mrkdwn", "text": "Claim your free crypto"}}]}`,
+			expected: []string{"chat.delete", "chat.postEphemeral"},
+		},
+		{
+			name:     "scam file shared",
+			event:    `{"type": "message", "subtype": "file_share", "channel": "C1", "user": "U1", "text": "", "ts": "1612790186.002000", "files": [{"id": "F1", "name": "claim.txt", "title": "Free crypto instructions"}]}`,
+			expected: []string{"chat.delete", "chat.postEphemeral"},
+		},
+		{
+			name:  "edited by a bot",
+			event: `{"type": "message", "subtype": "message_changed", "channel": "C1", "ts": "1612790200.000100", "message": {"type": "message", "bot_id": "B1", "text": "free crypto", "ts": "1612790186.002000"}, "previous_message": {"type": "message", "bot_id": "B1", "text": "hello", "ts": "1612790186.002000"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var deleted string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/users.info" {
+					calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/"))
+				}
+				if r.URL.Path == "/api/chat.delete" {
+					body := struct {
+						TS string `json:"ts"`
+					}{}
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					deleted = body.TS
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			filters := model.FilterConfig{{Triggers: []string{"free crypto"}, Action: model.ActionDelete, Message: "Please don't."}}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters})
+			if err := h.handleMessage(context.Background(), client, []byte(`{"event": `+tc.event+`}`)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+			if deleted != "" && deleted != "1612790186.002000" {
+				t.Errorf("expected the edited message to be deleted, but deleted %s", deleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// usergroupTTL is how long the members of a usergroup are remembered.
+const usergroupTTL = 10 * time.Minute
+
+// usergroupCache remembers the members of usergroups, so that exempting a usergroup from a filter
+// doesn't cost a call to usergroups.users.list for every message.
+type usergroupCache struct {
+	lock    sync.Mutex
+	entries map[usergroupKey]usergroupEntry
+}
+
+type usergroupKey struct {
+	client    *slack.Client
+	usergroup string
+}
+
+type usergroupEntry struct {
+	members map[string]bool
+	fetched time.Time
+}
+
+func newUsergroupCache() *usergroupCache {
+	return &usergroupCache{entries: map[usergroupKey]usergroupEntry{}}
+}
+
+// isMember returns whether user is a member of the usergroup with the given ID in client's
+// workspace.
+func (uc *usergroupCache) isMember(ctx context.Context, client *slack.Client, usergroup, user string) (bool, error) {
+	key := usergroupKey{client, usergroup}
+	uc.lock.Lock()
+	entry, ok := uc.entries[key]
+	uc.lock.Unlock()
+	if !ok || time.Since(entry.fetched) > usergroupTTL {
+		members, err := api.New(client).UsergroupMembers(ctx, usergroup)
+		if err != nil {
+			return false, err
+		}
+		entry = usergroupEntry{members: map[string]bool{}, fetched: time.Now()}
+		for _, m := range members {
+			entry.members[m] = true
+		}
+		uc.lock.Lock()
+		uc.entries[key] = entry
+		uc.lock.Unlock()
+	}
+	return entry.members[user], nil
+}
+
+// exempt returns whether user is exempt from filter, either directly or through a usergroup. If a
+// usergroup can't be looked up, the user is moderated as usual.
+func (h *handler) exempt(ctx context.Context, client *slack.Client, filter model.Filter, user string) bool {
+	if filter.ExemptsUser(user) {
+		return true
+	}
+	for _, g := range filter.ExemptUsergroups {
+		member, err := h.usergroups.isMember(ctx, client, g, user)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to look up exempt usergroup", "usergroup", g, "error", err)
+			continue
+		}
+		if member {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestExempt(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/usergroups.users.list" {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		switch r.Form.Get("usergroup") {
+		case "S1":
+			_, _ = w.Write([]byte(`{"ok": true, "users": ["U2", "U3"]}`))
+		default:
+			_, _ = w.Write([]byte(`{"ok": false, "error": "no_such_subteam"}`))
+		}
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	h := newHandler(nil, model.Config{})
+	filter := model.Filter{ExemptUsers: []string{"U1"}, ExemptUsergroups: []string{"S404", "S1"}}
+	tests := []struct {
+		user     string
+		expected bool
+	}{
+		{user: "U1", expected: true},
+		{user: "U2", expected: true},
+		{user: "U3", expected: true},
+		{user: "U4"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.user, func(t *testing.T) {
+			if actual := h.exempt(context.Background(), client, filter, tc.user); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+	// S1 is looked up once and then cached; S404 fails every time.
+	if calls != 4 {
+		t.Errorf("expected 4 calls to usergroups.users.list, but got %d", calls)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// maxScannedFileSize is the largest text file whose content is downloaded to be checked. Only
+// Slack's preview of bigger ones is checked.
+const maxScannedFileSize = 1 << 20
+
+// fileContent returns the text of the snippets and text files shared in message, for filters to
+// check alongside the message itself. Files that can't be looked up are logged and skipped.
+func (h *handler) fileContent(ctx context.Context, client *slack.Client, message model.Event) string {
+	files := message.Files
+	if message.File != nil {
+		files = append(files, *message.File)
+	}
+	var content []string
+	for _, f := range files {
+		// Events don't always include the whole file, such as for files shared from other workspaces.
+		info, err := api.New(client).GetFileInfo(ctx, f.ID)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to look up shared file", "file_id", f.ID, "error", err)
+			continue
+		}
+		if !isTextFile(info) {
+			continue
+		}
+		text := info.Preview
+		if info.Size <= maxScannedFileSize && info.URLPrivateDownload != "" {
+			downloaded, err := downloadFile(ctx, client, info.URLPrivateDownload)
+			if err != nil {
+				logging.FromContext(ctx).Warn("Failed to download shared file, checking its preview instead", "file_id", f.ID, "error", err)
+			} else {
+				text = downloaded
+			}
+		}
+		if text != "" {
+			content = append(content, text)
+		}
+	}
+	return strings.Join(content, "\n")
+}
+
+// isTextFile returns whether f is a snippet or another kind of text file.
+func isTextFile(f api.File) bool {
+	return f.Mode == "snippet" || strings.HasPrefix(f.Mimetype, "text/")
+}
+
+// downloadFile returns the content of the text file at url, which must be one of Slack's private
+// file URLs.
+func downloadFile(ctx context.Context, client *slack.Client, url string) (string, error) {
+	token, err := client.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download file: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download file: got %s", resp.Status)
+	}
+	// Without the files:read scope, Slack sends its login page instead.
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return "", fmt.Errorf("got a web page instead of the file; does the token have the files:read scope?")
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxScannedFileSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+	return string(body), nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestFileContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		file         string
+		download     string
+		downloadType string
+		expected     string
+	}{
+		{
+			name:     "snippet",
+			file:     `{"id": "F1", "mode": "snippet", "mimetype": "text/plain", "size": 11, "preview": "free", "url_private_download": "https://files.slack.com/files-pri/T1-F1/download/snippet.txt"}`,
+			download: "free crypto",
+			expected: "free crypto",
+		},
+		{
+			name:     "too big to download",
+			file:     fmt.Sprintf(`{"id": "F1", "mimetype": "text/plain", "size": %d, "preview": "free crypto...", "url_private_download": "https://files.slack.com/files-pri/T1-F1/download/big.txt"}`, maxScannedFileSize+1),
+			expected: "free crypto...",
+		},
+		{
+			name:         "missing files:read",
+			file:         `{"id": "F1", "mimetype": "text/plain", "size": 11, "preview": "free crypto", "url_private_download": "https://files.slack.com/files-pri/T1-F1/download/snippet.txt"}`,
+			download:     "<html>Sign in</html>",
+			downloadType: "text/html; charset=utf-8",
+			expected:     "free crypto",
+		},
+		{
+			name: "not text",
+			file: `{"id": "F1", "mimetype": "image/png", "size": 11, "url_private_download": "https://files.slack.com/files-pri/T1-F1/download/cat.png"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/files.info" {
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write([]byte(`{"ok": true, "file": ` + tc.file + `}`))
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer xoxb-token" {
+					t.Errorf("expected the file to be downloaded with the bot token, but got %q", r.Header.Get("Authorization"))
+				}
+				if tc.downloadType != "" {
+					w.Header().Set("Content-Type", tc.downloadType)
+				}
+				_, _ = w.Write([]byte(tc.download))
+			}))
+			defer server.Close()
+
+			h := newHandler(nil, model.Config{})
+			content := h.fileContent(context.Background(), testClient(server), model.Event{Files: []model.File{{ID: "F1"}}})
+			if content != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, content)
+			}
+		})
+	}
+}
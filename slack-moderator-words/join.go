@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// joinPolicy decides which public channels the bot joins by itself, by name. The zero value
+// allows every channel.
+type joinPolicy struct {
+	// include are glob patterns, as understood by path.Match, of the channels to join. If it is
+	// empty, every channel is joined unless it is excluded.
+	include []string
+	// exclude are glob patterns of channels never to join, even if they are included.
+	exclude []string
+}
+
+// newJoinPolicy returns a joinPolicy from comma-separated lists of patterns, which may start with
+// a #.
+func newJoinPolicy(include, exclude string) joinPolicy {
+	return joinPolicy{include: splitPatterns(include), exclude: splitPatterns(exclude)}
+}
+
+func splitPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimPrefix(strings.TrimSpace(p), "#"); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// validate returns an error if any of the patterns is malformed.
+func (p joinPolicy) validate() error {
+	for _, pattern := range append(append([]string{}, p.include...), p.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid channel pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// allows returns whether the bot should join the channel called name.
+func (p joinPolicy) allows(name string) bool {
+	if len(p.include) > 0 && !matchesAny(p.include, name) {
+		return false
+	}
+	return !matchesAny(p.exclude, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// backfillChannels joins every public channel allowed by policy that the bot isn't in yet, so
+// that channels created before it was deployed, or while it was down, are moderated too. It
+// returns how many channels it joined. The Client's rate limiter paces the joins, and channels that
+// can't be joined are logged and skipped.
+func backfillChannels(ctx context.Context, client *slack.Client, policy joinPolicy) (int, error) {
+	channels, err := client.GetConversationsContext(ctx, []slack.ConversationType{slack.ConversationTypePublicChannel})
+	if err != nil {
+		return 0, err
+	}
+	log := logging.FromContext(ctx)
+	joined := 0
+	for _, channel := range channels {
+		if channel.IsArchived || channel.IsMember {
+			continue
+		}
+		if !policy.allows(channel.Name) {
+			log.Debug("Public channel is not allowed by the join policy, skipping", "channel", channel.ID, "channel_name", channel.Name)
+			continue
+		}
+		if _, err := api.New(client).JoinConversation(ctx, channel.ID); err != nil {
+			if ctx.Err() != nil {
+				return joined, ctx.Err()
+			}
+			log.Error("Failed to join channel", "channel", channel.ID, "channel_name", channel.Name, "error", err)
+			continue
+		}
+		log.Info("Joined public channel", "channel", channel.ID, "channel_name", channel.Name)
+		joined++
+	}
+	return joined, nil
+}
+
+// backfillChannelsEvery runs backfillChannels for every workspace in clients straight away, and
+// then every interval until ctx is done. If interval is zero, it only runs once.
+func backfillChannelsEvery(ctx context.Context, clients *slack.ClientSet, policy joinPolicy, interval time.Duration) {
+	for {
+		for _, client := range clients.All() {
+			joined, err := backfillChannels(ctx, client, policy)
+			if err != nil {
+				logging.FromContext(ctx).Error("Failed to join public channels", "joined", joined, "error", err)
+				continue
+			}
+			logging.FromContext(ctx).Info("Finished joining public channels", "joined", joined)
+		}
+		if interval <= 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestJoinPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  string
+		exclude  string
+		channel  string
+		expected bool
+	}{
+		{
+			name:     "default allows everything",
+			channel:  "random",
+			expected: true,
+		},
+		{
+			name:     "included by pattern",
+			include:  "sig-*, wg-*",
+			channel:  "wg-batch",
+			expected: true,
+		},
+		{
+			name:    "not included",
+			include: "sig-*,wg-*",
+			channel: "test-bot",
+		},
+		{
+			name:    "excluded by name",
+			exclude: "#test-bot",
+			channel: "test-bot",
+		},
+		{
+			name:    "excluded despite being included",
+			include: "sig-*",
+			exclude: "sig-*-private",
+			channel: "sig-security-private",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := newJoinPolicy(tc.include, tc.exclude)
+			if err := p.validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := p.allows(tc.channel); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	if err := newJoinPolicy("sig-[", "").validate(); err == nil {
+		t.Errorf("expected an error for a malformed pattern")
+	}
+}
+
+func TestBackfillChannels(t *testing.T) {
+	var joined []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/conversations.list":
+			_, _ = w.Write([]byte(`{"ok": true, "channels": [
+				{"id": "C1", "name": "sig-node", "is_member": true},
+				{"id": "C2", "name": "sig-apps"},
+				{"id": "C3", "name": "sig-old", "is_archived": true},
+				{"id": "C4", "name": "random"},
+				{"id": "C5", "name": "sig-gone"},
+				{"id": "C6", "name": "wg-batch"}
+			]}`))
+		case "/api/conversations.join":
+			body, _ := ioutil.ReadAll(r.Body)
+			req := struct {
+				Channel string `json:"channel"`
+			}{}
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Errorf("failed to unmarshal request: %v", err)
+			}
+			if req.Channel == "C5" {
+				_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+				return
+			}
+			joined = append(joined, req.Channel)
+			_, _ = w.Write([]byte(`{"ok": true}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	count, err := backfillChannels(context.Background(), testClient(server), newJoinPolicy("sig-*,wg-*", ""))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"C2", "C6"}
+	if count != len(expected) || !reflect.DeepEqual(joined, expected) {
+		t.Errorf("expected to join %v, but joined %d channels: %v", expected, count, joined)
+	}
+}
+
+// testClient returns a slack.Client that sends all its calls to server.
+func testClient(server *httptest.Server) *slack.Client {
+	return testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token"})
+}
+
+// testClientWithConfig is like testClient, but uses the given config.
+func testClientWithConfig(server *httptest.Server, config slack.Config) *slack.Client {
+	serverURL, _ := url.Parse(server.URL)
+	client := slack.New(config)
+	client.HTTPClient = server.Client()
+	client.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+	return client
+}
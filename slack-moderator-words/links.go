@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// maxShortLinkRedirects is how many short links we follow from one, since short links can lead to
+// other short links.
+const maxShortLinkRedirects = 5
+
+// linkResolver finds where short links lead, so that filters can check the domains they hide.
+type linkResolver struct {
+	client *http.Client
+}
+
+func newLinkResolver() *linkResolver {
+	return &linkResolver{client: &http.Client{
+		Timeout: 5 * time.Second,
+		// Only ask the link shortener where the link leads, rather than following it there.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// resolveLinks returns where the short links in content lead, each on its own line. Links that
+// can't be resolved are logged and left out.
+func (h *handler) resolveLinks(ctx context.Context, content string) string {
+	var resolved []string
+	for _, u := range model.ExtractURLs(content) {
+		if !model.IsShortLink(u) {
+			continue
+		}
+		target, err := h.links.resolve(ctx, u)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Failed to resolve short link", "url", u.String(), "error", err)
+			continue
+		}
+		if target.String() != u.String() {
+			resolved = append(resolved, target.String())
+		}
+	}
+	return strings.Join(resolved, "\n")
+}
+
+// resolve follows u while it is a short link, and returns the link it leads to.
+func (l *linkResolver) resolve(ctx context.Context, u *url.URL) (*url.URL, error) {
+	for i := 0; i < maxShortLinkRedirects && model.IsShortLink(u); i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		resp, err := l.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to request %s: %v", u, err)
+		}
+		resp.Body.Close()
+		location := resp.Header.Get("Location")
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+			// The link shortener doesn't know the link, or shows a page instead of redirecting.
+			return u, nil
+		}
+		next, err := u.Parse(location)
+		if err != nil {
+			return nil, fmt.Errorf("%s redirected to an invalid URL %q: %v", u, location, err)
+		}
+		normalized, ok := model.NormalizeURL(next.String())
+		if !ok {
+			return nil, fmt.Errorf("%s redirected to an unsupported URL %q", u, location)
+		}
+		u = normalized
+	}
+	return u, nil
+}
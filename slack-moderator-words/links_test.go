@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// shortenerTransport sends every request to server, whatever its host, so that tests can pretend
+// to be link shorteners.
+type shortenerTransport struct {
+	server *httptest.Server
+}
+
+func (s shortenerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(s.server.URL)
+	r = r.Clone(r.Context())
+	r.Header.Set("X-Original-Host", r.URL.Host)
+	r.URL.Scheme, r.URL.Host = u.Scheme, u.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestResolveLinks(t *testing.T) {
+	redirects := map[string]string{
+		"bit.ly/scam":      "https://tinyurl.com/next",
+		"tinyurl.com/next": "https://evil.example/claim?utm_source=bitly",
+		"t.co/loop":        "https://t.co/loop",
+		"bit.ly/relative":  "/elsewhere",
+	}
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		link := r.Header.Get("X-Original-Host") + r.URL.Path
+		requested = append(requested, link)
+		if target, ok := redirects[link]; ok {
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name              string
+		content           string
+		expected          string
+		expectedRequested []string
+	}{
+		{
+			name:              "chain of short links",
+			content:           "claim at <https://bit.ly/scam>",
+			expected:          "https://evil.example/claim",
+			expectedRequested: []string{"bit.ly/scam", "tinyurl.com/next"},
+		},
+		{
+			name:    "not a short link",
+			content: "see https://kubernetes.io/docs",
+		},
+		{
+			name:              "unknown short link",
+			content:           "https://bit.ly/gone",
+			expectedRequested: []string{"bit.ly/gone"},
+		},
+		{
+			name:              "redirect loop",
+			content:           "https://t.co/loop",
+			expectedRequested: []string{"t.co/loop", "t.co/loop", "t.co/loop", "t.co/loop", "t.co/loop"},
+		},
+		{
+			name:              "relative redirect",
+			content:           "https://bit.ly/relative",
+			expected:          "https://bit.ly/elsewhere",
+			expectedRequested: []string{"bit.ly/relative", "bit.ly/elsewhere"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requested = nil
+			h := newHandler(nil, model.Config{})
+			h.links.client.Transport = shortenerTransport{server: server}
+			if actual := h.resolveLinks(context.Background(), tc.content); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+			if !reflect.DeepEqual(requested, tc.expectedRequested) {
+				t.Errorf("expected requests for %q, but got %q", tc.expectedRequested, requested)
+			}
+		})
+	}
+}
@@ -17,115 +17,269 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"github.com/go-redis/redis/v8"
 
+	"sigs.k8s.io/slack-infra/httpserver"
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/slashcmd"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+	"sigs.k8s.io/slack-infra/tracing"
 )
 
 type options struct {
-	configPath       string
-	filterConfigPath string
+	configPath        string
+	filterConfigPath  string
+	filterConfigMap   string
+	filterConfigKey   string
+	listRefresh       time.Duration
+	shadowMode        bool
+	reviewChannel     string
+	scanFiles         bool
+	resolveShortLinks bool
+	scorer            string
+	scorerURL         string
+	scorerTimeout     time.Duration
+	scorerCacheTTL    time.Duration
+	workers           int
+	queueSize         int
+	socketMode        bool
+	redisAddr         string
+	database          string
+	logText           string
+	joinChannels      string
+	skipChannels      string
+	backfillInterval  time.Duration
+	moderateBots      bool
+	trustedBots       string
+	logging           logging.Options
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
 	flag.StringVar(&o.filterConfigPath, "filter-config-path", "filters.yaml", "Path to a file containing the filter config")
+	flag.StringVar(&o.filterConfigMap, "filter-configmap", "", "Kubernetes ConfigMap, as name or namespace/name, to watch for the filter config instead of --filter-config-path")
+	flag.StringVar(&o.filterConfigKey, "filter-configmap-key", "filters.yaml", "Key of the filter config in --filter-configmap")
+	flag.BoolVar(&o.shadowMode, "shadow-mode", false, "Only log what filters would do, and post it to --review-channel if set, without warning anyone or deleting anything")
+	flag.StringVar(&o.reviewChannel, "review-channel", "", "Channel, by name or ID (required for private channels), to post matches to in --shadow-mode")
+	flag.BoolVar(&o.scanFiles, "scan-files", false, "Check the content of snippets and text files shared in messages too (requires the files:read scope)")
+	flag.BoolVar(&o.resolveShortLinks, "resolve-short-links", true, "Follow links from link shorteners such as bit.ly, for filters that check links' domains")
+	flag.StringVar(&o.scorer, "scorer", "", "External classifier to score messages with for filters that use one: perspective or http (API key from $SCORER_API_KEY)")
+	flag.StringVar(&o.scorerURL, "scorer-url", "", "URL to post messages to with --scorer=http, or to use instead of the Perspective API")
+	flag.DurationVar(&o.scorerTimeout, "scorer-timeout", defaultScoreTimeout, "How long to wait for a message to be scored before moderating it without its scores")
+	flag.DurationVar(&o.scorerCacheTTL, "scorer-cache-ttl", 10*time.Minute, "How long to remember the scores of a message, so copies of it aren't scored again (0 doesn't cache them)")
+	flag.DurationVar(&o.listRefresh, "trigger-list-refresh", time.Hour, "How often to check filters' trigger lists for changes (0 only fetches them when the filter config is loaded)")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Receive events using Socket Mode instead of the webhook (requires an appToken)")
+	flag.StringVar(&o.redisAddr, "redis-addr", "", "Address of a Redis server used to share deduplicated events, cooldowns, strikes and shadow bans between replicas (password from $REDIS_PASSWORD)")
+	flag.StringVar(&o.database, "database", "", "Database to record every match and keep strikes in, as sqlite:///path/to/file.db or a postgres:// URL (password from $PGPASSWORD)")
+	flag.StringVar(&o.logText, "log-text", logTextFull, "How much of the text of messages, and what filters matched in them, to log: full, truncate (the first 20 characters) or hash")
+	flag.StringVar(&o.joinChannels, "join-channels", "", "Comma-separated glob patterns, such as sig-*, of the public channels to join automatically (default all)")
+	flag.StringVar(&o.skipChannels, "skip-channels", "", "Comma-separated names or glob patterns of public channels never to join automatically")
+	flag.DurationVar(&o.backfillInterval, "backfill-interval", 0, "How often to look for public channels that haven't been joined yet, such as ones created while the bot was down (0 only looks at startup)")
+	flag.BoolVar(&o.moderateBots, "moderate-bots", false, "Moderate messages posted by bots and integrations too, other than --trusted-bots")
+	flag.StringVar(&o.trustedBots, "trusted-bots", "", "Comma-separated bot IDs whose messages are never moderated, with --moderate-bots")
+	flag.IntVar(&o.workers, "workers", 4, "Number of events to handle concurrently")
+	flag.IntVar(&o.queueSize, "queue-size", 100, "Number of events that can wait to be handled before Slack is asked to retry later")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
-func runServer(h *handler) error {
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
-	http.HandleFunc("/healthz", handleHealthz)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8077"
-		log.Printf("Defaulting to port %s", port)
-	}
-
-	log.Printf("Listening on port %s", port)
-	return http.ListenAndServe(fmt.Sprintf(":%s", port), nil)
+func runServer(clients *slack.ClientSet, h *handler, ih *interactive.Handler, sh *slashcmd.Handler) error {
+	srv := httpserver.New()
+	srv.DefaultPort = "8077"
+	srv.Healthz = http.HandlerFunc(handleHealthz)
+	srv.AddReadinessCheck("slack", httpserver.SlackAuthCheck(clients.All))
+	// Finish handling the events we have already acknowledged before exiting.
+	srv.OnShutdown(h.Shutdown)
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/webhook", tracing.Handler("webhook", h))
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/interactive", tracing.Handler("interactive", ih))
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/slash", tracing.Handler("slash", sh))
+	return srv.ListenAndServe()
 }
 
-func loadFilterConfig(path string) (model.FilterConfig, error) {
+func loadFilterConfig(path string) (model.Config, error) {
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't open file: %v", err)
+		return model.Config{}, fmt.Errorf("couldn't open file: %v", err)
 	}
-	filterConfig := &model.FilterConfig{}
-	if err := yaml.Unmarshal(content, filterConfig); err != nil {
-		return nil, fmt.Errorf("couldn't parse filter config: %v", err)
-	}
-
-	return *filterConfig, nil
+	return model.ParseConfig(content)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "matches" {
+		os.Exit(runMatches(os.Args[2:], os.Stdout, os.Stderr))
+	}
 	o := parseFlags()
-	c, err := slack.LoadConfig(o.configPath)
+	if _, err := logging.Setup(o.logging, "slack-moderator-words"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "slack-moderator-words")
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to set up tracing", "error", err)
 	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
 
-	filters, err := loadFilterConfig(o.filterConfigPath)
+	c, err := slack.LoadConfig(o.configPath)
+	if err != nil {
+		logging.Fatal("Failed to load config", "path", o.configPath, "error", err)
+	}
+	clients, err := slack.LoadClientSet(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load filter config from %s: %v", o.filterConfigPath, err)
+		logging.Fatal("Failed to load workspaces", "path", o.configPath, "error", err)
 	}
+	go slack.WatchConfig(context.Background(), o.configPath, func() error {
+		return clients.Reload(o.configPath)
+	})
 
-	s := slack.New(c)
+	var filterConfig model.Config
+	var configMap *configMapSource
+	var configMapContent []byte
+	if o.filterConfigMap != "" {
+		configMap, err = newInClusterConfigMapSource(o.filterConfigMap, o.filterConfigKey)
+		if err != nil {
+			logging.Fatal("Failed to set up filter ConfigMap", "configmap", o.filterConfigMap, "error", err)
+		}
+		configMapContent, err = configMap.read(context.Background())
+		if err == nil {
+			filterConfig, err = model.ParseConfig(configMapContent)
+		}
+		if err != nil {
+			logging.Fatal("Failed to load filter config", "configmap", o.filterConfigMap, "error", err)
+		}
+	} else {
+		filterConfig, err = loadFilterConfig(o.filterConfigPath)
+		if err != nil {
+			logging.Fatal("Failed to load filter config", "path", o.filterConfigPath, "error", err)
+		}
+	}
 
-	// List all public channels and try to join.
-	// This is needed otherwise the bot cannot receive the events for the channels
-	// and cannot moderate it
-	channels, err := s.GetPublicChannels()
-	if err != nil {
-		log.Fatalf("Failed to list all public channels: %v", err)
+	join := newJoinPolicy(o.joinChannels, o.skipChannels)
+	if err := join.validate(); err != nil {
+		logging.Fatal("Invalid channel join policy", "error", err)
 	}
 
-	for _, channel := range channels {
-		log.Printf("Public Channel: %s/%s\n", channel.ID, channel.Name)
+	go backfillChannelsEvery(context.Background(), clients, join, o.backfillInterval)
 
-		if channel.IsArchived {
-			log.Printf("Public Channel: %s/%s is archived, skipping...\n", channel.ID, channel.Name)
-			continue
+	h := newHandler(clients, filterConfig)
+	if err := h.configure(context.Background(), filterConfig); err != nil {
+		logging.Fatal("Failed to load filter config", "error", err)
+	}
+	go h.postDigestsEvery(context.Background())
+	if o.listRefresh > 0 {
+		go h.refreshTriggerListsEvery(context.Background(), o.listRefresh)
+	}
+	h.join = join
+	h.bots = newBotPolicy(o.moderateBots, o.trustedBots)
+	h.shadowMode, h.reviewChannel = o.shadowMode, o.reviewChannel
+	h.scanFiles = o.scanFiles
+	if h.redact, err = newRedactor(o.logText); err != nil {
+		logging.Fatal("Invalid --log-text", "error", err)
+	}
+	h.resolveShortLinks = o.resolveShortLinks
+	if o.scorer != "" {
+		s, err := newScorer(o.scorer, o.scorerURL, os.Getenv("SCORER_API_KEY"))
+		if err != nil {
+			logging.Fatal("Failed to set up scorer", "error", err)
 		}
-
-		if channel.IsMember {
-			log.Printf("Bot is already member of public Channel: %s/%s, skipping...\n", channel.ID, channel.Name)
-			continue
+		if o.scorerCacheTTL > 0 {
+			s = newCachedScorer(s, o.scorerCacheTTL)
 		}
-
-		for {
-			req := map[string]string{
-				"channel": channel.ID,
-			}
-			err := s.CallMethod("conversations.join", req, nil)
-			if err == nil {
-				break
+		h.scorer, h.scoreTimeout = s, o.scorerTimeout
+	} else if len(h.rules().scoreAttributes) > 0 {
+		slog.Warn("Filters score messages, but there is no --scorer to score them with")
+	}
+	if o.redisAddr != "" {
+		rdb := redis.NewClient(&redis.Options{Addr: o.redisAddr, Password: os.Getenv("REDIS_PASSWORD")})
+		h.Deduplicator = events.NewRedisDeduplicator(rdb, "slack-moderator-words:event:", events.DefaultDedupTTL)
+		h.strikes = newRedisStrikes(rdb, "slack-moderator-words:strikes:")
+		h.shadowBans = newRedisShadowBans(rdb, "slack-moderator-words:shadow-banned")
+		h.cooldowns = newRedisCooldowns(rdb, "slack-moderator-words:cooldown:")
+		h.dryRun = newRedisDryRun(rdb, "slack-moderator-words:dry-run")
+	}
+	if o.database != "" {
+		store, err := openMatchStore(context.Background(), o.database)
+		if err != nil {
+			logging.Fatal("Failed to open database", "error", err)
+		}
+		h.store = store
+		if err := h.loadRuntimeFilters(context.Background()); err != nil {
+			logging.Fatal("Failed to load runtime filters", "error", err)
+		}
+		go h.loadRuntimeFiltersEvery(context.Background(), runtimeFilterRefresh)
+		// With --redis-addr too, strikes and the dry run switch are kept in the database, which is
+		// the more durable of the two.
+		h.strikes, h.dryRun = store, store
+	}
+	h.Async(o.workers, o.queueSize)
+	// A filter config that doesn't parse is rejected, and the handler keeps using the old one.
+	if configMap != nil {
+		go configMap.watch(context.Background(), configMapContent, func(content []byte) error {
+			filterConfig, err := model.ParseConfig(content)
+			if err != nil {
+				return err
 			}
-			if timeout, ok := err.(slack.ErrRateLimit); ok {
-				log.Printf("Slack is rate limiting us, trying again in %s...\n", timeout.Wait)
-				time.Sleep(timeout.Wait)
-				continue
+			if err := h.configure(context.Background(), filterConfig); err != nil {
+				return err
 			}
+			slog.Info("Reloaded filter config", "configmap", o.filterConfigMap, "filters", len(filterConfig.Filters))
+			return nil
+		})
+	} else {
+		go slack.WatchConfig(context.Background(), o.filterConfigPath, func() error {
+			filterConfig, err := loadFilterConfig(o.filterConfigPath)
 			if err != nil {
-				log.Fatalf("Failed to join channel %s: %v", channel.Name, err)
+				return err
 			}
+			if err := h.configure(context.Background(), filterConfig); err != nil {
+				return err
+			}
+			slog.Info("Reloaded filter config", "path", o.filterConfigPath, "filters", len(filterConfig.Filters))
+			return nil
+		})
+	}
+	ih := interactive.NewHandler(clients)
+	h.handleEscalations(ih)
+	handleRemovals(ih)
+	handleQuarantine(ih)
+	h.handleAppeals(ih)
+	h.handleHome(ih)
+	sh := slashcmd.NewHandler(clients)
+	sh.HandleFunc(modwordsCommand, h.handleModwords)
+	if o.socketMode {
+		sm, err := socketmode.New(c)
+		if err != nil {
+			logging.Fatal("Failed to set up socket mode", "error", err)
 		}
-		time.Sleep(500 * time.Millisecond)
+		go func() {
+			logging.Fatal("Socket mode stopped", "error", sm.Run(context.Background(), func(ctx context.Context, envelopeType string, payload []byte) ([]byte, error) {
+				switch envelopeType {
+				case socketmode.TypeInteractive:
+					return ih.HandleSocketModeEnvelope(ctx, envelopeType, payload)
+				case socketmode.TypeSlashCommands:
+					return sh.HandleSocketModeEnvelope(ctx, envelopeType, payload)
+				}
+				return h.HandleSocketModeEnvelope(ctx, envelopeType, payload)
+			}))
+		}()
+	}
+	if err := runServer(clients, h, ih, sh); err != nil {
+		logging.Fatal("Failed to serve", "error", err)
 	}
-
-	h := &handler{client: s, filters: filters}
-	log.Fatal(runServer(h))
 }
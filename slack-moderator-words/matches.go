@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// runMatches implements `slack-moderator-words matches`, which lists the matches recorded in a
+// --database, newest first, and returns the exit code.
+func runMatches(args []string, stdout, stderr io.Writer) int {
+	var database string
+	var since time.Duration
+	q := matchQuery{}
+	fs := flag.NewFlagSet("matches", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&database, "database", "", "Database the matches were recorded in, as given to --database")
+	fs.DurationVar(&since, "since", 24*time.Hour, "How far back to list matches (0 lists all of them)")
+	fs.StringVar(&q.User, "user", "", "Only list matches of messages by the user with this ID")
+	fs.StringVar(&q.Channel, "channel", "", "Only list matches in the channel with this ID")
+	fs.StringVar(&q.Filter, "filter", "", "Only list matches of the filter with this name, such as \"filter 2\" for the second filter if it has none")
+	fs.IntVar(&q.Limit, "limit", 100, "Most matches to list (0 lists all of them)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if database == "" {
+		fmt.Fprintln(stderr, "--database is required")
+		return 2
+	}
+
+	store, err := openMatchStore(context.Background(), database)
+	if err != nil {
+		fmt.Fprintf(stderr, "couldn't open database: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+	if since > 0 {
+		q.Since = store.now().Add(-since)
+	}
+	records, err := store.Query(context.Background(), q)
+	if err != nil {
+		fmt.Fprintf(stderr, "couldn't list matches: %v\n", err)
+		return 1
+	}
+	for _, r := range records {
+		actions := strings.Join(r.Actions, ",")
+		if actions == "" {
+			actions = "-"
+		}
+		channel := r.Channel
+		if channel == "" {
+			channel = "-"
+		}
+		hash := r.TextHash
+		if len(hash) > 12 {
+			hash = hash[:12]
+		}
+		fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Time.Format(time.RFC3339), r.User, channel, r.Filter, actions, hash)
+	}
+	return 0
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+
+	// The drivers for the databases that --database can point at.
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// matchRecord is a match as the match store keeps it. It doesn't keep the text of the message, only
+// a hash of it, so that copies of the same message can be told apart from different ones.
+type matchRecord struct {
+	Time     time.Time
+	User     string
+	Channel  string
+	Filter   string
+	TextHash string
+	// Actions are the actions that were taken, in order, leaving out any that failed or were
+	// skipped. It is empty for matches in shadow mode.
+	Actions []string
+}
+
+// matchQuery picks out matches from the match store. Empty fields match everything.
+type matchQuery struct {
+	Since   time.Time
+	Until   time.Time
+	User    string
+	Channel string
+	Filter  string
+	// Limit is the most matches to return, newest first; 0 returns all of them.
+	Limit int
+}
+
+// matchStore is a SQL database of every match, of the strikes against users, of the runtime
+// filters and of the dry run switch, so that they all survive restarts.
+type matchStore struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// storeSchema creates the match store's tables, in SQL that both SQLite and Postgres understand.
+// Times are kept as Unix milliseconds so that they compare the same way in both.
+var storeSchema = []string{
+	`CREATE TABLE IF NOT EXISTS matches (
+		matched_at BIGINT NOT NULL,
+		user_id TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		filter TEXT NOT NULL,
+		text_hash TEXT NOT NULL,
+		actions TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS matches_matched_at ON matches (matched_at)`,
+	`CREATE TABLE IF NOT EXISTS strikes (
+		user_id TEXT NOT NULL,
+		struck_at BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS strikes_user_id ON strikes (user_id, struck_at)`,
+	`CREATE TABLE IF NOT EXISTS runtime_filters (
+		trigger_text TEXT PRIMARY KEY,
+		severity TEXT NOT NULL,
+		added_by TEXT NOT NULL,
+		added_at BIGINT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS settings (
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	)`,
+}
+
+// openMatchStore opens the database at url, which is either sqlite:// followed by the path of a
+// SQLite database, which is created if it doesn't exist, or a postgres:// URL, and creates the
+// match store's tables in it if they don't exist yet.
+func openMatchStore(ctx context.Context, url string) (*matchStore, error) {
+	var driver, dsn string
+	switch {
+	case strings.HasPrefix(url, "sqlite://"):
+		driver, dsn = "sqlite", strings.TrimPrefix(url, "sqlite://")
+	case strings.HasPrefix(url, "postgres://"), strings.HasPrefix(url, "postgresql://"):
+		driver, dsn = "postgres", url
+	default:
+		return nil, fmt.Errorf("unsupported database %q: expected a sqlite:// or postgres:// URL", url)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite" {
+		// SQLite only allows one writer at a time, so share one connection rather than have
+		// writes fail with SQLITE_BUSY.
+		db.SetMaxOpenConns(1)
+	}
+	for _, statement := range storeSchema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("couldn't create tables: %w", err)
+		}
+	}
+	return &matchStore{db: db, now: time.Now}, nil
+}
+
+// Close closes the database.
+func (s *matchStore) Close() error {
+	return s.db.Close()
+}
+
+// hashText returns the hash of a message's text that the match store keeps instead of the text.
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Record adds a match to the store.
+func (s *matchStore) Record(ctx context.Context, r matchRecord) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO matches (matched_at, user_id, channel, filter, text_hash, actions) VALUES ($1, $2, $3, $4, $5, $6)`,
+		r.Time.UnixMilli(), r.User, r.Channel, r.Filter, r.TextHash, strings.Join(r.Actions, ","))
+	return err
+}
+
+// Query returns the matches that q picks out, newest first.
+func (s *matchStore) Query(ctx context.Context, q matchQuery) ([]matchRecord, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, condition+" $"+strconv.Itoa(len(args)))
+	}
+	if !q.Since.IsZero() {
+		where("matched_at >=", q.Since.UnixMilli())
+	}
+	if !q.Until.IsZero() {
+		where("matched_at <", q.Until.UnixMilli())
+	}
+	if q.User != "" {
+		where("user_id =", q.User)
+	}
+	if q.Channel != "" {
+		where("channel =", q.Channel)
+	}
+	if q.Filter != "" {
+		where("filter =", q.Filter)
+	}
+	query := `SELECT matched_at, user_id, channel, filter, text_hash, actions FROM matches`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY matched_at DESC"
+	if q.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []matchRecord
+	for rows.Next() {
+		var r matchRecord
+		var at int64
+		var actions string
+		if err := rows.Scan(&at, &r.User, &r.Channel, &r.Filter, &r.TextHash, &actions); err != nil {
+			return nil, err
+		}
+		r.Time = time.UnixMilli(at).UTC()
+		if actions != "" {
+			r.Actions = strings.Split(actions, ",")
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// Add implements strikeStore.
+func (s *matchStore) Add(ctx context.Context, user string, ttl time.Duration) (int, error) {
+	now := s.now()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM strikes WHERE user_id = $1 AND struck_at <= $2`, user, now.Add(-ttl).UnixMilli()); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO strikes (user_id, struck_at) VALUES ($1, $2)`, user, now.UnixMilli()); err != nil {
+		return 0, err
+	}
+	var count int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM strikes WHERE user_id = $1`, user).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, tx.Commit()
+}
+
+// record adds a match, and the actions that were taken about it, to the handler's match store, if
+// it has one.
+func (h *handler) record(ctx context.Context, m *match, actions []string) {
+	if h.store == nil {
+		return
+	}
+	channel, _ := m.event.Channel.(string)
+	r := matchRecord{
+		Time:     h.store.now(),
+		User:     m.event.User,
+		Channel:  channel,
+		Filter:   m.filter.Label(m.index),
+		TextHash: hashText(m.event.Text),
+		Actions:  actions,
+	}
+	if err := h.store.Record(ctx, r); err != nil {
+		logging.FromContext(ctx).Error("Failed to record match", "ts", m.event.TS, "user_id", m.event.User, "error", err)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func testMatchStore(t *testing.T) (*matchStore, string) {
+	t.Helper()
+	database := "sqlite://" + filepath.Join(t.TempDir(), "matches.db")
+	store, err := openMatchStore(context.Background(), database)
+	if err != nil {
+		t.Fatalf("couldn't open match store: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, database
+}
+
+func TestOpenMatchStoreRejectsOtherDatabases(t *testing.T) {
+	if _, err := openMatchStore(context.Background(), "mysql://localhost/matches"); err == nil {
+		t.Error("expected an error for a mysql:// URL")
+	}
+}
+
+func TestMatchStoreQuery(t *testing.T) {
+	store, _ := testMatchStore(t)
+	start := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	records := []matchRecord{
+		{Time: start, User: "U1", Channel: "C1", Filter: "filter 1", TextHash: hashText("guys"), Actions: []string{model.ActionWarn}},
+		{Time: start.Add(time.Hour), User: "U2", Channel: "C1", Filter: "crypto", TextHash: hashText("free crypto"), Actions: []string{model.ActionDelete, model.ActionWarn}},
+		{Time: start.Add(2 * time.Hour), User: "U1", Channel: "C2", Filter: "crypto", TextHash: hashText("free crypto")},
+	}
+	for _, r := range records {
+		if err := store.Record(context.Background(), r); err != nil {
+			t.Fatalf("couldn't record match: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		query    matchQuery
+		expected []matchRecord
+	}{
+		{
+			name:     "everything, newest first",
+			expected: []matchRecord{records[2], records[1], records[0]},
+		},
+		{
+			name:     "by user",
+			query:    matchQuery{User: "U1"},
+			expected: []matchRecord{records[2], records[0]},
+		},
+		{
+			name:     "by channel and filter",
+			query:    matchQuery{Channel: "C1", Filter: "crypto"},
+			expected: []matchRecord{records[1]},
+		},
+		{
+			name:     "by time",
+			query:    matchQuery{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)},
+			expected: []matchRecord{records[1]},
+		},
+		{
+			name:     "limited",
+			query:    matchQuery{Limit: 1},
+			expected: []matchRecord{records[2]},
+		},
+		{
+			name:  "nothing",
+			query: matchQuery{User: "U3"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := store.Query(context.Background(), tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %+v, but got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMatchStoreStrikes(t *testing.T) {
+	store, database := testMatchStore(t)
+	now := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	for i, expected := range []int{1, 2} {
+		strikes, err := store.Add(context.Background(), "U1", time.Hour)
+		if err != nil {
+			t.Fatalf("couldn't add strike %d: %v", i+1, err)
+		}
+		if strikes != expected {
+			t.Errorf("expected %d strikes, but got %d", expected, strikes)
+		}
+		now = now.Add(30 * time.Minute)
+	}
+
+	// Strikes survive the store being opened again, until they expire.
+	_ = store.Close()
+	store, err := openMatchStore(context.Background(), database)
+	if err != nil {
+		t.Fatalf("couldn't reopen match store: %v", err)
+	}
+	defer store.Close()
+	store.now = func() time.Time { return now }
+	if strikes, err := store.Add(context.Background(), "U1", time.Hour); err != nil || strikes != 2 {
+		t.Errorf("expected 2 strikes after the first expired, but got %d (error %v)", strikes, err)
+	}
+	if strikes, err := store.Add(context.Background(), "U2", time.Hour); err != nil || strikes != 1 {
+		t.Errorf("expected strikes to be counted per user, but got %d (error %v)", strikes, err)
+	}
+}
+
+func TestRecordMatch(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/chat.delete") {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "cant_delete_message"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	store, database := testMatchStore(t)
+	now := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	h := newHandler(nil, model.Config{})
+	h.store = store
+	h.runActions(context.Background(), client, &match{
+		rules:    h.rules(),
+		index:    1,
+		filter:   model.Filter{Message: "Please don't."},
+		steps:    []string{model.ActionDelete, model.ActionPostEphemeral},
+		event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+		triggers: []string{"free crypto"},
+	})
+
+	// Only the actions that were taken are recorded.
+	expected := []matchRecord{{Time: now, User: "U1", Channel: "C1", Filter: "filter 2", TextHash: hashText("free crypto"), Actions: []string{model.ActionPostEphemeral}}}
+	actual, err := store.Query(context.Background(), matchQuery{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, but got %+v", expected, actual)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runMatches([]string{"--database", database, "--since", "0"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit code 0, but got %d: %s", code, stderr.String())
+	}
+	expectedOutput := "2021-02-08T12:00:00Z\tU1\tC1\tfilter 2\tchat.postEphemeral\t" + hashText("free crypto")[:12] + "\n"
+	if stdout.String() != expectedOutput {
+		t.Errorf("expected output %q, but got %q", expectedOutput, stdout.String())
+	}
+}
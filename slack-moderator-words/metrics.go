@@ -0,0 +1,45 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	matches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack_moderator_words",
+		Name:      "matches_total",
+		Help:      "Number of messages, users and emoji that matched a filter, by filter and channel.",
+	}, []string{"filter", "channel"})
+	actionsTaken = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack_moderator_words",
+		Name:      "actions_total",
+		Help:      "Number of actions taken by filters, by filter, action, channel and outcome.",
+	}, []string{"filter", "action", "channel", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(matches, actionsTaken)
+}
+
+// Values of the status label on slack_moderator_words_actions_total.
+const (
+	statusOK      = "ok"
+	statusError   = "error"
+	statusSkipped = "skipped"
+)
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestActionMetrics(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/chat.delete") {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "cant_delete_message"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	h := newHandler(nil, model.Config{})
+	filter := model.Filter{Name: "metrics test", Message: "Please don't.", Cooldown: time.Hour}
+	for i := 0; i < 2; i++ {
+		h.runActions(context.Background(), client, &match{
+			rules:    h.rules(),
+			filter:   filter,
+			steps:    []string{model.ActionDelete, model.ActionPostEphemeral},
+			event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000"},
+			triggers: []string{"free crypto"},
+		})
+	}
+
+	if actual := testutil.ToFloat64(matches.WithLabelValues("metrics test", "C1")); actual != 2 {
+		t.Errorf("expected 2 matches, but got %v", actual)
+	}
+	for _, c := range []struct {
+		action, status string
+		expected       float64
+	}{
+		{action: model.ActionDelete, status: statusError, expected: 2},
+		{action: model.ActionPostEphemeral, status: statusOK, expected: 1},
+		{action: model.ActionPostEphemeral, status: statusSkipped, expected: 1},
+	} {
+		if actual := testutil.ToFloat64(actionsTaken.WithLabelValues("metrics test", c.action, "C1", c.status)); actual != c.expected {
+			t.Errorf("expected %v %s actions with status %s, but got %v", c.expected, c.action, c.status, actual)
+		}
+	}
+}
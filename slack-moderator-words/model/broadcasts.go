@@ -0,0 +1,48 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "regexp"
+
+// broadcastPattern matches the special mentions that notify a whole channel, which Slack sends as
+// <!here>, <!channel> and <!everyone>, perhaps with a label after a |.
+var broadcastPattern = regexp.MustCompile(`<!(here|channel|everyone)(\|[^>]*)?>`)
+
+// Broadcasts makes a filter match messages that notify everyone in the channel with @here,
+// @channel or @everyone, in channels with at least MinMembers members.
+type Broadcasts struct {
+	MinMembers int `yaml:"min_members"`
+}
+
+func (b Broadcasts) validate() *configError {
+	if b.MinMembers < 0 {
+		return fieldError("broadcasts", -1, "has a negative number of members")
+	}
+	return nil
+}
+
+// BroadcastMentions returns the mentions of @here, @channel and @everyone in the text of a message,
+// such as "@here", without duplicates.
+func BroadcastMentions(text string) []string {
+	var mentions []string
+	for _, m := range broadcastPattern.FindAllStringSubmatch(text, -1) {
+		if mention := "@" + m[1]; !contains(mentions, mention) {
+			mentions = append(mentions, mention)
+		}
+	}
+	return mentions
+}
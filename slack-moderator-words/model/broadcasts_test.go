@@ -0,0 +1,40 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBroadcastMentions(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{text: "<!here> free tickets!", expected: []string{"@here"}},
+		{text: "<!channel|@channel> and <!everyone> and <!here> and <!channel>", expected: []string{"@channel", "@everyone", "@here"}},
+		{text: "ask <@U123> or <!subteam^S123|@admins>"},
+		{text: "here, channel and everyone, without mentioning anyone"},
+	}
+
+	for _, tc := range tests {
+		if actual := BroadcastMentions(tc.text); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("expected %q in %q, but got %q", tc.expected, tc.text, actual)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the filter config file. The file can be a list of filters, or an object that sets
+// other options alongside the filters.
+type Config struct {
+	// Severities overrides the actions taken for some severities by filters that don't list
+	// their own. Severities that aren't listed keep their DefaultSeverities.
+	Severities map[string][]string `yaml:"severities"`
+	// ModeratorsChannel is the channel, by name or ID, that filters escalate matches to.
+	ModeratorsChannel string `yaml:"moderators_channel"`
+	// QuarantineChannel is the channel, by name or ID, that messages are copied to before filters
+	// delete them, so that moderators can restore ones deleted by mistake. A private channel has
+	// to be given by ID.
+	QuarantineChannel string `yaml:"quarantine_channel"`
+	// AuditChannel is the channel, by name or ID, that a record of every action filters take is
+	// posted to, with the filter and the Version of the config that took it. A private channel has
+	// to be given by ID.
+	AuditChannel string `yaml:"audit_channel"`
+	// Version identifies the filter config in the AuditChannel, such as by the commit it came
+	// from. ParseConfig defaults it to the start of a hash of the file, which changes with it.
+	Version string `yaml:"version"`
+	// DigestInterval is how often the digest of matches of filters with ActionDigest is posted. It
+	// defaults to DefaultDigestInterval.
+	DigestInterval time.Duration `yaml:"digest_interval"`
+	// Cooldown is the Cooldown of filters that don't set their own.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Strikes configures filters that count strikes.
+	Strikes StrikesConfig `yaml:"strikes"`
+	// Confirm lists the actions, of ActionDelete, ActionKick and ActionDeactivate, that wait for a
+	// moderator to confirm them in the ModeratorsChannel instead of being taken straight away.
+	// ConfirmSeverities lists severities, such as SeverityMedium, whose filters wait for all of
+	// those actions to be confirmed, so that stricter filters can be tried out safely.
+	Confirm           []string `yaml:"confirm"`
+	ConfirmSeverities []string `yaml:"confirm_severities"`
+	// ModeratorUsergroup is the ID of the usergroup whose members can manage runtime filters with
+	// the /modwords command and see the App Home dashboard. Nobody can if it isn't set.
+	ModeratorUsergroup string `yaml:"moderator_usergroup"`
+	// Appeals sends the authors of messages that filters delete or warn them about a direct message
+	// explaining why, with a button to appeal to the moderators in the ModeratorsChannel.
+	Appeals bool `yaml:"appeals"`
+	// ShadowBanned are the IDs of users whose every message is deleted, whatever it says.
+	ShadowBanned []string     `yaml:"shadow_banned"`
+	Filters      FilterConfig `yaml:"filters"`
+}
+
+// DefaultDigestInterval is how often digests are posted, unless the filter config says otherwise.
+const DefaultDigestInterval = 15 * time.Minute
+
+// DigestEvery returns how often the digest of matches is posted.
+func (c Config) DigestEvery() time.Duration {
+	if c.DigestInterval == 0 {
+		return DefaultDigestInterval
+	}
+	return c.DigestInterval
+}
+
+// Confirmable returns whether action can wait for a moderator to confirm it, because it is hard to
+// undo.
+func Confirmable(action string) bool {
+	return action == ActionDelete || action == ActionKick || action == ActionDeactivate
+}
+
+// ParseConfig parses and compiles a filter config file. Fields that Config and Filter don't have
+// are errors, since they are usually typos, and errors say which line of the file they are about.
+func ParseConfig(data []byte) (Config, error) {
+	node := yaml.Node{}
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return Config{}, fmt.Errorf("couldn't parse filter config: %v", err)
+	}
+	c := Config{}
+	var target interface{} = &c
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+		target = &c.Filters
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(target); err != nil && err != io.EOF {
+		return Config{}, fmt.Errorf("couldn't parse filter config: %v", err)
+	}
+	if err := c.Compile(); err != nil {
+		var ce *configError
+		if errors.As(err, &ce) {
+			if line := ce.line(&node); line > 0 {
+				return Config{}, fmt.Errorf("invalid filter config: line %d: %v", line, err)
+			}
+		}
+		return Config{}, fmt.Errorf("invalid filter config: %v", err)
+	}
+	if c.Version == "" {
+		sum := sha256.Sum256(data)
+		c.Version = hex.EncodeToString(sum[:])[:12]
+	}
+	return c, nil
+}
+
+// Compile validates the config and compiles its filters.
+func (c Config) Compile() error {
+	severities := map[string][]string{}
+	for s, actions := range DefaultSeverities {
+		severities[s] = actions
+	}
+	for s, actions := range c.Severities {
+		if _, ok := DefaultSeverities[s]; !ok {
+			return fieldError("severities", -1, "unknown severity %q (expected one of %q)", s, knownSeverities)
+		}
+		for _, a := range actions {
+			if !contains(knownActions, a) {
+				return fieldError("severities", -1, "severity %s has an unknown action %q (expected one of %q)", s, a, knownActions)
+			}
+		}
+		severities[s] = actions
+	}
+	for i, a := range c.Confirm {
+		if !Confirmable(a) {
+			return fieldError("confirm", i, "only %s, %s and %s can be confirmed, not %q", ActionDelete, ActionKick, ActionDeactivate, a)
+		}
+		if c.ModeratorsChannel == "" {
+			return fieldError("confirm", i, "%s needs to be confirmed, but there is no moderators_channel to confirm it in", a)
+		}
+	}
+	for i, s := range c.ConfirmSeverities {
+		if !contains(knownSeverities, s) {
+			return fieldError("confirm_severities", i, "unknown severity %q (expected one of %q)", s, knownSeverities)
+		}
+		if c.ModeratorsChannel == "" {
+			return fieldError("confirm_severities", i, "%s matches need to be confirmed, but there is no moderators_channel to confirm them in", s)
+		}
+	}
+	if c.Appeals && c.ModeratorsChannel == "" {
+		return fieldError("appeals", -1, "appeals need a moderators_channel to go to")
+	}
+	if c.DigestInterval < 0 {
+		return fieldError("digest_interval", -1, "digest_interval can't be negative")
+	}
+	if c.Cooldown < 0 {
+		return fieldError("cooldown", -1, "cooldown can't be negative")
+	}
+	for i := range c.Filters {
+		if c.Filters[i].Cooldown == 0 {
+			c.Filters[i].Cooldown = c.Cooldown
+		}
+	}
+	strikeLevels, err := c.Strikes.levels()
+	if err != nil {
+		return err
+	}
+	return c.Filters.compile(severities, strikeLevels, c.ModeratorsChannel != "")
+}
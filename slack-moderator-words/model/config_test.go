@@ -0,0 +1,388 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectedSteps [][]string
+		expectedError string
+	}{
+		{
+			name: "list of filters",
+			config: `
+- triggers: [guys]
+  action: chat.postEphemeral
+  message: hi
+`,
+			expectedSteps: [][]string{{ActionPostEphemeral}},
+		},
+		{
+			name: "default severities",
+			config: `
+filters:
+- triggers: [a]
+  severity: low
+- triggers: [b]
+  severity: medium
+  message: hi
+- triggers: [c]
+  severity: high
+  message: hi
+- triggers: [d]
+  severity: critical
+`,
+			expectedSteps: [][]string{{ActionLog}, {ActionWarn}, {ActionDelete, ActionWarn}, {ActionDelete}},
+		},
+		{
+			name: "critical escalates with a moderators channel",
+			config: `
+moderators_channel: "#moderators"
+filters:
+- triggers: [a]
+  severity: critical
+  message: hi
+`,
+			expectedSteps: [][]string{{ActionDelete, ActionWarn, ActionEscalate}},
+		},
+		{
+			name: "escalate without a moderators channel",
+			config: `
+- triggers: [a]
+  actions: [delete, escalate]
+`,
+			expectedError: "no moderators_channel",
+		},
+		{
+			name: "confirmed removals",
+			config: `
+moderators_channel: "#moderators"
+confirm: [kick, deactivate]
+filters:
+- triggers: [a]
+  actions: [delete, deactivate]
+`,
+			expectedSteps: [][]string{{ActionDelete, ActionDeactivate}},
+		},
+		{
+			name: "confirmed deletion",
+			config: `
+moderators_channel: "#moderators"
+confirm: [delete]
+filters:
+- triggers: [a]
+  action: delete
+`,
+			expectedSteps: [][]string{{ActionDelete}},
+		},
+		{
+			name: "confirmed severity",
+			config: `
+moderators_channel: "#moderators"
+confirm_severities: [high]
+filters:
+- triggers: [a]
+  severity: high
+`,
+			expectedSteps: [][]string{{ActionDelete}},
+		},
+		{
+			name: "confirming an unknown severity",
+			config: `
+moderators_channel: "#moderators"
+confirm_severities: [mild]
+filters: []
+`,
+			expectedError: `line 3: unknown severity "mild"`,
+		},
+		{
+			name: "confirming a severity without a moderators channel",
+			config: `
+confirm_severities: [medium]
+filters: []
+`,
+			expectedError: "no moderators_channel to confirm them in",
+		},
+		{
+			name: "confirming without a moderators channel",
+			config: `
+confirm: [kick]
+filters:
+- triggers: [a]
+  action: kick
+`,
+			expectedError: "no moderators_channel to confirm it in",
+		},
+		{
+			name: "negative cooldown",
+			config: `
+cooldown: -10m
+filters:
+- triggers: [a]
+  action: log
+`,
+			expectedError: "cooldown can't be negative",
+		},
+		{
+			name: "overridden severity",
+			config: `
+severities:
+  medium: [chat.postMessage]
+filters:
+- triggers: [a]
+  severity: medium
+  message: hi
+- triggers: [b]
+  severity: high
+  message: hi
+`,
+			expectedSteps: [][]string{{ActionPostMessage}, {ActionDelete, ActionWarn}},
+		},
+		{
+			name: "explicit actions win over severity",
+			config: `
+- triggers: [a]
+  severity: critical
+  action: log
+`,
+			expectedSteps: [][]string{{ActionLog}},
+		},
+		{
+			name: "unknown severity on a filter",
+			config: `
+- triggers: [a]
+  severity: urgent
+`,
+			expectedError: `filter 1 has an unknown severity "urgent"`,
+		},
+		{
+			name: "unknown severity override",
+			config: `
+severities:
+  urgent: [delete]
+filters: []
+`,
+			expectedError: `unknown severity "urgent"`,
+		},
+		{
+			name: "unknown action in a severity override",
+			config: `
+severities:
+  high: [ban]
+filters: []
+`,
+			expectedError: `severity high has an unknown action "ban"`,
+		},
+		{
+			name:          "not YAML",
+			config:        "- [",
+			expectedError: "couldn't parse filter config",
+		},
+		{
+			name: "unknown field on a filter",
+			config: `
+- triggers: [a]
+  acton: delete
+`,
+			expectedError: "line 3: field acton not found",
+		},
+		{
+			name: "unknown top-level field",
+			config: `
+moderator_channel: "#moderators"
+filters: []
+`,
+			expectedError: "line 2: field moderator_channel not found",
+		},
+		{
+			name: "empty trigger",
+			config: `
+filters:
+- triggers: [a]
+  action: log
+- triggers:
+  - b
+  - " "
+  action: log
+`,
+			expectedError: "line 7: filter 2 has an empty trigger",
+		},
+		{
+			name: "invalid regex",
+			config: `
+- regexes:
+  - 'ok'
+  - '(unclosed'
+  action: log
+`,
+			expectedError: `line 4: filter 1 has an invalid regex "(unclosed"`,
+		},
+		{
+			name: "unknown action in a list",
+			config: `
+- triggers: [a]
+  actions:
+  - delete
+  - ban
+`,
+			expectedError: `line 5: filter 1 has an unknown action "ban"`,
+		},
+		{
+			name: "digest without a moderators channel",
+			config: `
+- triggers: [a]
+  actions: [log, digest]
+`,
+			expectedError: "no moderators_channel to post it in",
+		},
+		{
+			name: "negative digest interval",
+			config: `
+moderators_channel: "#moderators"
+digest_interval: -1h
+filters: []
+`,
+			expectedError: "digest_interval can't be negative",
+		},
+		{
+			name: "appeals without a moderators channel",
+			config: `
+appeals: true
+filters: []
+`,
+			expectedError: "appeals need a moderators_channel",
+		},
+		{
+			name: "filter without an action",
+			config: `
+- triggers: [a]
+  action: log
+- triggers: [b]
+  message: hi
+`,
+			expectedError: "line 4: filter 2 has no action or severity",
+		},
+		{
+			name: "unconfirmable action",
+			config: `
+moderators_channel: "#moderators"
+confirm: [kick, warn]
+filters: []
+`,
+			expectedError: `line 3: only delete, kick and deactivate can be confirmed, not "warn"`,
+		},
+		{
+			name: "duplicate names",
+			config: `
+- name: spam
+  triggers: [a]
+  action: log
+- name: spam
+  triggers: [b]
+  action: log
+`,
+			expectedError: `line 5: filter 2 is called "spam", like filter 1`,
+		},
+		{
+			name: "name that is another filter's position",
+			config: `
+- triggers: [a]
+  action: log
+- triggers: [b]
+  action: log
+- name: filter 2
+  triggers: [c]
+  action: log
+`,
+			expectedError: `line 6: filter 3 is called "filter 2", like filter 2`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseConfig([]byte(tc.config))
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected an error containing %q, but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var steps [][]string
+			for _, f := range c.Filters {
+				steps = append(steps, f.Steps())
+			}
+			if !reflect.DeepEqual(steps, tc.expectedSteps) {
+				t.Errorf("expected steps %v, but got %v", tc.expectedSteps, steps)
+			}
+		})
+	}
+}
+
+func TestParseConfigCooldown(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+cooldown: 10m
+filters:
+- triggers: [a]
+  action: warn
+  message: hi
+- triggers: [b]
+  action: warn
+  message: hi
+  cooldown: 1h
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []time.Duration{10 * time.Minute, time.Hour}
+	for i, f := range config.Filters {
+		if f.Cooldown != expected[i] {
+			t.Errorf("expected filter %d to have a cooldown of %v, but got %v", i+1, expected[i], f.Cooldown)
+		}
+	}
+}
+
+func TestParseConfigVersion(t *testing.T) {
+	first, err := ParseConfig([]byte("- triggers: [a]\n  action: log\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := ParseConfig([]byte("- triggers: [b]\n  action: log\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Version) != 12 || first.Version == second.Version {
+		t.Errorf("expected different files to get different versions, but got %q and %q", first.Version, second.Version)
+	}
+	explicit, err := ParseConfig([]byte("version: abc123\nfilters: []\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if explicit.Version != "abc123" {
+		t.Errorf("expected the config's own version, but got %q", explicit.Version)
+	}
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Defaults for CrossPosts.
+const (
+	DefaultCrossPostChannels = 3
+	DefaultCrossPostWithin   = 10 * time.Minute
+)
+
+// minFingerprintLength is how long, in letters and digits, a message has to be to be fingerprinted.
+// Short messages, like "thanks!", are posted in lots of channels by everyone.
+const minFingerprintLength = 20
+
+// shingleLength is how many letters long the features of a text that its simhash is made of are.
+const shingleLength = 4
+
+// maxSimhashDistance is how many bits of their simhashes nearly identical messages can differ by.
+// Changing a word of a short message changes about 6, whereas different messages differ in about
+// half of them.
+const maxSimhashDistance = 10
+
+// CrossPosts makes a filter match messages that their author has recently posted in other channels
+// too, which is how most spam is spread: the same, or nearly the same, text in at least Channels
+// channels, counting the one it was just posted in, within Within.
+type CrossPosts struct {
+	Channels int           `yaml:"channels"`
+	Within   time.Duration `yaml:"within"`
+	// Exact only counts copies whose text is the same, once it is normalized, rather than also
+	// ones that are nearly the same.
+	Exact bool `yaml:"exact"`
+}
+
+// MinChannels returns how many channels a message has to be posted in to match, which defaults to
+// DefaultCrossPostChannels.
+func (c CrossPosts) MinChannels() int {
+	if c.Channels == 0 {
+		return DefaultCrossPostChannels
+	}
+	return c.Channels
+}
+
+// Window returns how close together copies of a message have to be posted, which defaults to
+// DefaultCrossPostWithin.
+func (c CrossPosts) Window() time.Duration {
+	if c.Within == 0 {
+		return DefaultCrossPostWithin
+	}
+	return c.Within
+}
+
+// Copies returns whether messages with fingerprints a and b count as copies of each other.
+func (c CrossPosts) Copies(a, b Fingerprint) bool {
+	if c.Exact {
+		return a.exact == b.exact
+	}
+	return a.exact == b.exact || bits.OnesCount64(a.simhash^b.simhash) <= maxSimhashDistance
+}
+
+func (c CrossPosts) validate() *configError {
+	if c.Channels < 0 || c.Channels == 1 {
+		return fieldError("cross_posts", -1, "needs at least 2 channels to count a message as cross-posted, not %d", c.Channels)
+	}
+	if c.Within < 0 {
+		return fieldError("cross_posts", -1, "has a negative time to look for copies within")
+	}
+	return nil
+}
+
+// Fingerprint identifies a message's text, and which other messages' texts are nearly the same.
+type Fingerprint struct {
+	// exact is a hash of the words of the text, and simhash is a hash of them that only differs in
+	// a few bits for texts that only differ in a few words.
+	exact   uint64
+	simhash uint64
+}
+
+// FingerprintOf returns the fingerprint of a message's text, after normalizing and deobfuscating
+// it. Messages too short to tell apart from other people's aren't fingerprinted.
+func FingerprintOf(text string) (Fingerprint, bool) {
+	words := strings.FieldsFunc(Deobfuscate(fold(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	length := 0
+	for _, w := range words {
+		length += utf8.RuneCountInString(w)
+	}
+	if length < minFingerprintLength {
+		return Fingerprint{}, false
+	}
+	exact := fnv.New64a()
+	for _, w := range words {
+		exact.Write([]byte(w))
+		exact.Write([]byte{0})
+	}
+	// Each run of a few letters is a feature of the text, so that changing a word only changes a
+	// few of the many features, and so a few bits of the simhash.
+	var weights [64]int
+	joined := []rune(strings.Join(words, " "))
+	for i := 0; i+shingleLength <= len(joined); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(string(joined[i : i+shingleLength])))
+		sum := h.Sum64()
+		for j := range weights {
+			if sum&(1<<j) != 0 {
+				weights[j]++
+			} else {
+				weights[j]--
+			}
+		}
+	}
+	f := Fingerprint{exact: exact.Sum64()}
+	for i, w := range weights {
+		if w > 0 {
+			f.simhash |= 1 << i
+		}
+	}
+	return f, true
+}
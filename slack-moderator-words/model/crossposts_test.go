@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestCrossPostsCopies(t *testing.T) {
+	spam := "Hey everyone, I'm giving away free tickets to KubeCon, just send me a DM to claim yours"
+	tests := []struct {
+		name     string
+		a, b     string
+		exact    bool
+		expected bool
+	}{
+		{
+			name:     "same text",
+			a:        spam,
+			b:        spam,
+			expected: true,
+		},
+		{
+			name:     "different case, punctuation and lookalikes",
+			a:        spam,
+			b:        "HEY EVERYONE!!! I'm giving away free tickets to KubeCon... just send me a DM to cl4im yours",
+			expected: true,
+		},
+		{
+			name:     "nearly the same",
+			a:        spam,
+			b:        "Hey everyone, I'm giving away free tickets to KubeCon, just send me a DM to claim yours now",
+			expected: true,
+		},
+		{
+			name:  "nearly the same, exactly",
+			a:     spam,
+			b:     "Hey everyone, I'm giving away free tickets to KubeCon, just send me a DM to claim yours now",
+			exact: true,
+		},
+		{
+			name: "different text",
+			a:    spam,
+			b:    "Does anyone know why my pods are stuck in ContainerCreating after upgrading to 1.22?",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, ok := FingerprintOf(tc.a)
+			if !ok {
+				t.Fatalf("expected %q to be fingerprinted", tc.a)
+			}
+			b, ok := FingerprintOf(tc.b)
+			if !ok {
+				t.Fatalf("expected %q to be fingerprinted", tc.b)
+			}
+			if actual := (CrossPosts{Exact: tc.exact}).Copies(a, b); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFingerprintOfShortMessage(t *testing.T) {
+	if _, ok := FingerprintOf("thanks, that worked!"); ok {
+		t.Errorf("expected a short message not to be fingerprinted")
+	}
+}
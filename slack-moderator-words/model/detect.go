@@ -0,0 +1,80 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Built-in detectors, which filters can list under Detect to match messages without needing
+// triggers for them.
+const (
+	// DetectInviteLinks matches invite links to other Slack workspaces, Discord servers, Telegram
+	// groups and channels, and WhatsApp groups and chats.
+	DetectInviteLinks = "invite_links"
+)
+
+// knownDetectors are the detectors a filter can use.
+var knownDetectors = []string{DetectInviteLinks}
+
+// inviteLink is a kind of invite link: one to host, or one of its subdomains if subdomains is set,
+// whose path starts with one of paths, if there are any.
+type inviteLink struct {
+	host       string
+	subdomains bool
+	paths      []string
+}
+
+// inviteLinks are the invite links that DetectInviteLinks matches.
+var inviteLinks = []inviteLink{
+	{host: "join.slack.com", paths: []string{"/t/", "/share/"}},
+	{host: "slack.com", subdomains: true, paths: []string{"/join/"}},
+	{host: "discord.gg"},
+	{host: "discord.com", paths: []string{"/invite/"}},
+	{host: "discordapp.com", paths: []string{"/invite/"}},
+	{host: "t.me"},
+	{host: "telegram.me"},
+	{host: "telegram.dog"},
+	{host: "chat.whatsapp.com"},
+	{host: "wa.me"},
+}
+
+// IsInviteLink returns whether u is an invite link that DetectInviteLinks matches.
+func IsInviteLink(u *url.URL) bool {
+	host, path := u.Hostname(), strings.ToLower(u.EscapedPath())
+	for _, l := range inviteLinks {
+		if host != l.host && !(l.subdomains && inDomain(host, l.host)) {
+			continue
+		}
+		if len(l.paths) == 0 {
+			// The link itself is the invite, so a link to the home page isn't one.
+			return strings.Trim(path, "/") != ""
+		}
+		for _, p := range l.paths {
+			if strings.HasPrefix(path, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// detects returns whether the filter uses the named detector.
+func (f Filter) detects(detector string) bool {
+	return contains(f.Detect, detector)
+}
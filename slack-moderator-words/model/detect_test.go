@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestIsInviteLink(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{url: "https://join.slack.com/t/spam-workspace/shared_invite/zt-abc123", expected: true},
+		{url: "https://spam-workspace.slack.com/join/shared_invite/zt-abc123", expected: true},
+		{url: "https://join.slack.com/share/enQtABC", expected: true},
+		{url: "https://kubernetes.slack.com/archives/C09NXKJKA"},
+		{url: "https://slack.com/join/shared_invite/zt-abc123", expected: true},
+		{url: "https://discord.gg/AbCdEf", expected: true},
+		{url: "https://discord.com/invite/AbCdEf", expected: true},
+		{url: "https://discordapp.com/invite/AbCdEf", expected: true},
+		{url: "https://discord.com/channels/123/456"},
+		{url: "https://discord.gg/"},
+		{url: "https://t.me/joinchat/AAAAAE", expected: true},
+		{url: "https://t.me/freecrypto", expected: true},
+		{url: "https://telegram.me/freecrypto", expected: true},
+		{url: "https://chat.whatsapp.com/AbCdEf", expected: true},
+		{url: "https://wa.me/15551234567", expected: true},
+		{url: "https://www.whatsapp.com/download"},
+		{url: "https://notdiscord.gg/AbCdEf"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := IsInviteLink(u); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "strings"
+
+// emojiActions are the actions that filters that check custom emoji can take, since nobody posted
+// a message and Slack doesn't say who added the emoji. ActionDelete removes the emoji.
+var emojiActions = []string{ActionLog, ActionEscalate, ActionDelete}
+
+// emojiSeparators are the characters that separate the words of an emoji's name, such as
+// "free_crypto".
+var emojiSeparators = strings.NewReplacer("_", " ", "-", " ")
+
+// ChecksEmoji returns whether the filter checks the names of custom emoji, rather than messages.
+func (f Filter) ChecksEmoji() bool {
+	return f.CustomEmoji
+}
+
+// MatchesEmoji returns the triggers in the name of a custom emoji, and the regexes that match it,
+// with the underscores and hyphens between its words treated as spaces.
+func (f Filter) MatchesEmoji(name string) []string {
+	return f.Matches(emojiSeparators.Replace(name))
+}
+
+// validateEmojiActions checks that a filter that checks custom emoji only takes actions that make
+// sense for an emoji.
+func (f *Filter) validateEmojiActions(field string, steps []string) *configError {
+	if !f.ChecksEmoji() {
+		return nil
+	}
+	for j, a := range steps {
+		item := -1
+		if field == "actions" {
+			item = j
+		}
+		if !contains(emojiActions, a) {
+			return fieldError(field, item, "checks custom emoji rather than messages, so it can't %s (expected one of %q)", a, emojiActions)
+		}
+	}
+	return nil
+}
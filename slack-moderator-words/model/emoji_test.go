@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterMatchesEmoji(t *testing.T) {
+	filters := FilterConfig{{CustomEmoji: true, Triggers: []string{"nazi", "free crypto"}, Match: MatchWord, Severity: SeverityCritical}}
+	if err := filters.compile(DefaultSeverities, DefaultStrikeLevels, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter := filters[0]
+	if steps := filter.Steps(); !reflect.DeepEqual(steps, []string{ActionDelete, ActionEscalate}) {
+		t.Errorf("expected the severity's steps that can be taken for an emoji, but got %q", steps)
+	}
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "nazi", expected: []string{"nazi"}},
+		{name: "nazi_flag", expected: []string{"nazi"}},
+		{name: "free-crypto", expected: []string{"free crypto"}},
+		{name: "n4zi", expected: []string{"nazi"}},
+		{name: "nazareth"},
+		{name: "party_parrot"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := filter.MatchesEmoji(tc.name); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,630 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Match modes for triggers.
+const (
+	// MatchSubstring matches triggers anywhere in a message, even inside other words.
+	MatchSubstring = "substring"
+	// MatchWord only matches triggers that appear as whole words, so that "ass" doesn't match
+	// "class".
+	MatchWord = "word"
+)
+
+// Filter actions.
+const (
+	// ActionPostEphemeral warns the message's author with Message, which only they can see.
+	ActionPostEphemeral = "chat.postEphemeral"
+	// ActionPostMessage replies to the message with Message, for everyone to see.
+	ActionPostMessage = "chat.postMessage"
+	// ActionWarn shows Message to the message's author, as the filter's Delivery says.
+	ActionWarn = "warn"
+	// ActionDelete deletes the message. On its own, as a filter's Action, it is followed by
+	// ActionWarn if the filter has a Message.
+	ActionDelete = "delete"
+	// ActionLog does nothing but log the match, which happens for every match anyway.
+	ActionLog = "log"
+	// ActionEscalate tells the moderators about the message in the config's ModeratorsChannel.
+	ActionEscalate = "escalate"
+	// ActionKick removes the message's author from the channel.
+	ActionKick = "kick"
+	// ActionDeactivate deactivates the message's author's account using SCIM.
+	ActionDeactivate = "deactivate"
+	// ActionRename resets the name that matched a filter that checks names.
+	ActionRename = "rename"
+	// ActionRevert changes a channel's topic or purpose back to what it was, if the message is
+	// about it being changed.
+	ActionRevert = "revert"
+	// ActionDigest adds the match to the next digest of matches posted to the moderators channel,
+	// instead of telling the moderators about each one.
+	ActionDigest = "digest"
+)
+
+// knownActions are the actions a filter can take.
+var knownActions = []string{ActionPostEphemeral, ActionPostMessage, ActionWarn, ActionDelete, ActionLog, ActionEscalate, ActionKick, ActionDeactivate, ActionRename, ActionRevert, ActionDigest}
+
+// Ways of delivering warnings.
+const (
+	// DeliveryEphemeral shows the warning in the channel, only to the author. It is the default.
+	DeliveryEphemeral = "ephemeral"
+	// DeliveryDM sends the warning as a direct message to the author.
+	DeliveryDM = "dm"
+	// DeliveryThread replies to the message in a thread, for everyone to see.
+	DeliveryThread = "thread"
+	// DeliveryChannel posts the warning in the channel, or the message's thread, for everyone to see.
+	DeliveryChannel = "channel"
+)
+
+// knownDeliveries are the ways a filter can deliver warnings.
+var knownDeliveries = []string{DeliveryEphemeral, DeliveryDM, DeliveryThread, DeliveryChannel}
+
+// FilterConfig is the list of filters that messages are checked against.
+type FilterConfig []Filter
+
+// Filter responds to messages that contain any of its triggers, match any of its regexes, link to
+// any of its domains or are picked up by any of its detectors. Triggers and regexes are compared
+// with the message after it has been normalized by Normalize, so that lookalike characters and
+// accents don't get around them; triggers also ignore case and, unless Deobfuscate is false,
+// leetspeak.
+type Filter struct {
+	// Name identifies the filter in metrics. Filters without one are known by their position, as
+	// "filter N", and no two filters can have the same name.
+	Name     string   `yaml:"name"`
+	Triggers []string `yaml:"triggers"`
+	// TriggerLists are the URLs of lists of more triggers, such as shared community blocklists.
+	// See ParseTriggerList for their format, and Config.WithTriggerLists for how they are used.
+	TriggerLists []string `yaml:"trigger_lists"`
+	// Regexes use Go's regexp syntax, and are case-sensitive unless they start with (?i).
+	Regexes []string `yaml:"regexes"`
+	// Domains makes the filter match links to the listed domains or their subdomains, and links
+	// to AllowedDomains never match. With AllowedDomains but no Domains, the filter matches links to
+	// any other domain. Links are found with ExtractURLs.
+	Domains        []string `yaml:"domains"`
+	AllowedDomains []string `yaml:"allowed_domains"`
+	// ProtectedDomains makes the filter match links to domains that look like the listed ones, but
+	// aren't them or their subdomains, such as kubernetes-io.xyz for kubernetes.io. See
+	// Filter.imitates for what counts as looking alike.
+	ProtectedDomains []string `yaml:"protected_domains"`
+	// Detect lists built-in detectors, such as DetectInviteLinks, that the filter matches messages
+	// with.
+	Detect []string `yaml:"detect"`
+	// RulePack is the name of a built-in set of rules, such as RulePackCryptoScams, that the filter
+	// matches messages with, as well as its own triggers and regexes. DisableRules are the names of
+	// the pack's rules that the filter doesn't use. Unless the filter sets them, its severity and
+	// message are the pack's.
+	RulePack     string   `yaml:"rule_pack"`
+	DisableRules []string `yaml:"disable_rules"`
+	// CrossPosts makes the filter match messages that their author has also posted in other
+	// channels, whatever they say.
+	CrossPosts *CrossPosts `yaml:"cross_posts"`
+	// Broadcasts makes the filter match messages that mention @here, @channel or @everyone.
+	Broadcasts *Broadcasts `yaml:"broadcasts"`
+	// Rate makes the filter match messages from users who are posting too many messages too fast.
+	Rate *Rate `yaml:"rate"`
+	// Score makes the filter match messages that an external classifier scores highly.
+	Score *Score `yaml:"score"`
+	// Reactions makes the filter match messages that people react to with certain emoji.
+	Reactions *Reactions `yaml:"reactions"`
+	// Names makes the filter check users' display names and real names with its triggers and
+	// regexes, when they join or change their profile, instead of messages. ProtectedNames are names,
+	// such as "Kubernetes Admin", that the filter matches names that look like, which also makes it
+	// check names.
+	Names          bool     `yaml:"names"`
+	ProtectedNames []string `yaml:"protected_names"`
+	// Profiles makes the filter check the rest of users' profiles, their title, status and custom
+	// fields, instead of messages, with its triggers, regexes, domains and rules.
+	Profiles bool `yaml:"profiles"`
+	// CustomEmoji makes the filter check the names of custom emoji, and their aliases, when they are
+	// added to the workspace or renamed, instead of messages, with its triggers and regexes.
+	CustomEmoji bool `yaml:"custom_emoji"`
+	// Match is how triggers are matched: MatchSubstring (the default) or MatchWord.
+	Match string `yaml:"match"`
+	// Deobfuscate makes triggers match text that uses leetspeak or lookalike letters from other
+	// alphabets, as described in Deobfuscate. It defaults to true; set it to false for triggers
+	// that contain digits or symbols on purpose.
+	Deobfuscate *bool `yaml:"deobfuscate"`
+	// Language is the BCP 47 tag, such as "de" or "tr", of the language the filter's triggers are
+	// written in, which makes them also ignore the differences in spelling that are usual in it, such
+	// as "ß" and "ss" in German. Transliterate makes triggers written in Cyrillic or Greek letters
+	// match the same words written in Latin ones, and the other way around, as described in
+	// Transliterate.
+	Language      string `yaml:"language"`
+	Transliterate bool   `yaml:"transliterate"`
+	// Channels limits the filter to the listed channels, and ExcludeChannels stops it applying to
+	// the listed channels. Either can be given as names, with or without a #, or IDs.
+	Channels        []string `yaml:"channels"`
+	ExcludeChannels []string `yaml:"exclude_channels"`
+	// ExemptUsers and ExemptUsergroups are the IDs of users, and of usergroups whose members,
+	// never trigger the filter, such as moderators quoting spam.
+	ExemptUsers      []string `yaml:"exempt_users"`
+	ExemptUsergroups []string `yaml:"exempt_usergroups"`
+	// Action is what to do with a matching message: ActionWarn, ActionPostEphemeral,
+	// ActionPostMessage, ActionDelete, ActionLog, ActionEscalate, ActionKick, ActionDeactivate,
+	// ActionRename, ActionRevert or ActionDigest. To do several things, list them in Actions
+	// instead; they are done in order. If neither is set, the actions come from the filter's
+	// Severity.
+	Action   string   `yaml:"action"`
+	Actions  []string `yaml:"actions"`
+	Severity string   `yaml:"severity"`
+	// Message is a Go template, filled in with MessageData.
+	Message string `yaml:"message"`
+	// Delivery is how ActionWarn delivers Message: DeliveryEphemeral (the default), DeliveryDM,
+	// DeliveryThread or DeliveryChannel.
+	Delivery string `yaml:"delivery"`
+	// Cooldown is how long after warning a user the filter waits before warning them again, such
+	// as "10m". Matches during the cooldown are still logged, and other actions are still taken.
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Strikes makes the filter count each match as a strike against the message's author, and take
+	// the actions of the StrikeLevel they have reached instead of an Action, Actions or Severity.
+	Strikes bool `yaml:"strikes"`
+
+	steps        []string
+	strikeLevels []StrikeLevel
+	// listTriggers are the triggers from TriggerLists, and triggerNames are all of the triggers as
+	// they were written, which triggers are the folded forms of.
+	listTriggers []string
+	triggerNames []string
+	triggers     []string
+	compiled     []*regexp.Regexp
+	// domains and allowedDomains are Domains and AllowedDomains normalized by normalizeHost.
+	domains        []string
+	allowedDomains []string
+	protected      []lookalike
+	rules          []compiledRule
+	message        *template.Template
+	// language is the ISO 639 code of Language.
+	language string
+	// protectedNames are ProtectedNames as nameSkeleton sees them.
+	protectedNames []string
+}
+
+// Normalize applies NFKC normalization to s, which turns compatibility characters such as
+// fullwidth letters into their plain equivalents, and strips diacritics, so "ｆｒéé" becomes "free".
+func Normalize(s string) string {
+	t := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFKC)
+	normalized, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return normalized
+}
+
+// fold returns the form of s that triggers are compared in.
+func fold(s string) string {
+	return strings.ToLower(Normalize(s))
+}
+
+// deobfuscator maps common substitutions for lowercase Latin letters back to them: digits and
+// symbols used in leetspeak, and Cyrillic and Greek letters that look the same.
+var deobfuscator = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "@", "a", "$", "s",
+	"а", "a", "в", "b", "е", "e", "һ", "h", "і", "i", "ј", "j", "к", "k", "м", "m", "н", "h",
+	"о", "o", "р", "p", "с", "c", "т", "t", "ѕ", "s", "у", "y", "х", "x", "ԁ", "d", "ӏ", "l",
+	"α", "a", "β", "b", "ε", "e", "ι", "i", "κ", "k", "ν", "v", "ο", "o", "ρ", "p", "τ", "t", "υ", "u", "χ", "x",
+)
+
+// Deobfuscate undoes common tricks for getting lowercase text past filters, so "fr33 а1rdr0p" (with
+// a Cyrillic "а") becomes "free airdrop". Punctuation that usually ends a word, like "!", is left
+// alone, so that whole-word matching still works.
+func Deobfuscate(s string) string {
+	return deobfuscator.Replace(s)
+}
+
+func (f Filter) deobfuscates() bool {
+	return f.Deobfuscate == nil || *f.Deobfuscate
+}
+
+// Compile prepares the triggers, regexes and actions of every filter, using DefaultSeverities for
+// filters that rely on their severity. It must be called before Matches or Steps.
+func (fc FilterConfig) Compile() error {
+	return fc.compile(DefaultSeverities, DefaultStrikeLevels, false)
+}
+
+// compile compiles the filters, taking the actions for each severity from severities and for each
+// number of strikes from strikeLevels. If canEscalate isn't set, there is nowhere to escalate to, so
+// filters that explicitly escalate are an error and severities and strike levels don't escalate.
+func (fc FilterConfig) compile(severities map[string][]string, strikeLevels []StrikeLevel, canEscalate bool) error {
+	// Cooldowns, stored matches and metrics are all keyed on labels, so they have to be unique.
+	labels := map[string]int{}
+	for i := range fc {
+		f := &fc[i]
+		if j, ok := labels[f.Label(i)]; ok {
+			return fieldError("name", -1, "is called %q, like filter %d", f.Label(i), j+1).inFilter(i)
+		}
+		labels[f.Label(i)] = i
+		f.language = baseLanguage(f.Language)
+		if err := f.applyRulePack(); err != nil {
+			return err.inFilter(i)
+		}
+		if err := f.validate(); err != nil {
+			return err.inFilter(i)
+		}
+		if err := f.compileActions(severities, strikeLevels, canEscalate); err != nil {
+			return err.inFilter(i)
+		}
+		if err := f.compileMessage(); err != nil {
+			return err.inFilter(i)
+		}
+		f.triggerNames = append(f.Triggers[:len(f.Triggers):len(f.Triggers)], f.listTriggers...)
+		f.triggers = make([]string, 0, len(f.triggerNames))
+		for _, t := range f.triggerNames {
+			f.triggers = append(f.triggers, f.fold(t))
+		}
+		f.compiled = make([]*regexp.Regexp, 0, len(f.Regexes))
+		for j, r := range f.Regexes {
+			re, err := regexp.Compile(r)
+			if err != nil {
+				return fieldError("regexes", j, "has an invalid regex %q: %v", r, err).inFilter(i)
+			}
+			f.compiled = append(f.compiled, re)
+		}
+		f.domains = normalizeHosts(f.Domains)
+		f.allowedDomains = normalizeHosts(f.AllowedDomains)
+		f.protected = make([]lookalike, 0, len(f.ProtectedDomains))
+		for _, d := range normalizeHosts(f.ProtectedDomains) {
+			f.protected = append(f.protected, newLookalike(d))
+		}
+		f.protectedNames = make([]string, 0, len(f.ProtectedNames))
+		for _, n := range f.ProtectedNames {
+			f.protectedNames = append(f.protectedNames, nameSkeleton(n))
+		}
+	}
+	return nil
+}
+
+// validate checks the parts of the filter that don't need compiling.
+func (f *Filter) validate() *configError {
+	if len(f.Triggers) == 0 && len(f.TriggerLists) == 0 && len(f.Regexes) == 0 && !f.ChecksLinks() && len(f.Detect) == 0 && len(f.rules) == 0 && f.CrossPosts == nil && f.Broadcasts == nil && f.Rate == nil && f.Score == nil && f.Reactions == nil && len(f.ProtectedNames) == 0 {
+		return fieldError("", -1, "has nothing to match messages with, such as triggers, regexes or domains")
+	}
+	if f.CrossPosts != nil {
+		if err := f.CrossPosts.validate(); err != nil {
+			return err
+		}
+	}
+	if f.Broadcasts != nil {
+		if err := f.Broadcasts.validate(); err != nil {
+			return err
+		}
+	}
+	if f.Rate != nil {
+		if err := f.Rate.validate(); err != nil {
+			return err
+		}
+	}
+	if f.Score != nil {
+		if err := f.Score.validate(); err != nil {
+			return err
+		}
+	}
+	if f.Reactions != nil {
+		if err := f.Reactions.validate(); err != nil {
+			return err
+		}
+	}
+	if f.ChecksEmoji() && f.ChecksUsers() {
+		return fieldError("custom_emoji", -1, "checks custom emoji, so it can't also check users")
+	}
+	for j, n := range f.ProtectedNames {
+		if nameSkeleton(n) == "" {
+			return fieldError("protected_names", j, "has a protected name with no letters or digits")
+		}
+	}
+	if f.Language != "" && !validLanguage(f.Language) {
+		return fieldError("language", -1, "has an invalid language %q", f.Language)
+	}
+	// An empty trigger or regex would match every message.
+	for j, t := range f.Triggers {
+		if strings.TrimSpace(t) == "" {
+			return fieldError("triggers", j, "has an empty trigger")
+		}
+	}
+	for j, r := range f.Regexes {
+		if r == "" {
+			return fieldError("regexes", j, "has an empty regex")
+		}
+	}
+	switch f.Match {
+	case "", MatchSubstring, MatchWord:
+	default:
+		return fieldError("match", -1, "has an unknown match mode %q (expected %q or %q)", f.Match, MatchSubstring, MatchWord)
+	}
+	if f.Cooldown < 0 {
+		return fieldError("cooldown", -1, "has a negative cooldown")
+	}
+	if f.Delivery != "" && !contains(knownDeliveries, f.Delivery) {
+		return fieldError("delivery", -1, "has an unknown delivery %q (expected one of %q)", f.Delivery, knownDeliveries)
+	}
+	for _, list := range []struct {
+		field   string
+		domains []string
+	}{{"domains", f.Domains}, {"allowed_domains", f.AllowedDomains}, {"protected_domains", f.ProtectedDomains}} {
+		for j, d := range list.domains {
+			if !validDomain(d) {
+				return fieldError(list.field, j, "has an invalid domain %q (expected a host name, such as example.com)", d)
+			}
+		}
+	}
+	for j, d := range f.Detect {
+		if !contains(knownDetectors, d) {
+			return fieldError("detect", j, "has an unknown detector %q (expected one of %q)", d, knownDetectors)
+		}
+	}
+	for j, u := range f.TriggerLists {
+		if !validTriggerListURL(u) {
+			return fieldError("trigger_lists", j, "has a trigger list with an unsupported URL %q (expected one of %q)", u, triggerListSchemes)
+		}
+	}
+	return nil
+}
+
+// compileActions works out the steps the filter takes from Action, Actions or Severity, or for
+// each strike level.
+func (f *Filter) compileActions(severities map[string][]string, strikeLevels []StrikeLevel, canEscalate bool) *configError {
+	if _, ok := severities[f.Severity]; f.Severity != "" && !ok {
+		return fieldError("severity", -1, "has an unknown severity %q (expected one of %q)", f.Severity, knownSeverities)
+	}
+	if f.Strikes {
+		if f.ChecksUsers() {
+			return fieldError("strikes", -1, "checks users rather than messages, so it can't count strikes")
+		}
+		if f.ChecksEmoji() {
+			return fieldError("strikes", -1, "checks custom emoji rather than messages, so it can't count strikes")
+		}
+		if f.Action != "" || len(f.Actions) > 0 || f.Severity != "" {
+			return fieldError("strikes", -1, "counts strikes, so it can't also have an action, actions or severity")
+		}
+		f.strikeLevels = make([]StrikeLevel, 0, len(strikeLevels))
+		for _, l := range strikeLevels {
+			f.strikeLevels = append(f.strikeLevels, StrikeLevel{Strikes: l.Strikes, Actions: severitySteps(l.Actions, f.Message, canEscalate)})
+		}
+		return nil
+	}
+	// field is where the steps came from, for errors about them.
+	field := ""
+	switch {
+	case f.Action != "" && len(f.Actions) > 0:
+		return fieldError("actions", -1, "has both an action and actions")
+	case f.Action == ActionDelete && f.Message != "":
+		f.steps, field = []string{ActionDelete, ActionWarn}, "action"
+	case f.Action != "":
+		f.steps, field = []string{f.Action}, "action"
+	case len(f.Actions) > 0:
+		f.steps, field = f.Actions, "actions"
+	case f.Severity != "":
+		f.steps, field = severitySteps(severities[f.Severity], f.Message, canEscalate), "severity"
+	default:
+		return fieldError("", -1, "has no action or severity")
+	}
+	if field == "severity" && (f.ChecksUsers() || f.ChecksEmoji()) {
+		// Severities are written for messages, so leave out what can't be done about a user or an
+		// emoji.
+		allowed := userActions
+		if f.ChecksEmoji() {
+			allowed = emojiActions
+		}
+		var steps []string
+		for _, a := range f.steps {
+			if contains(allowed, a) {
+				steps = append(steps, a)
+			}
+		}
+		f.steps = steps
+	}
+	for j, a := range f.steps {
+		item := -1
+		if field == "actions" {
+			item = j
+		}
+		if !contains(knownActions, a) {
+			return fieldError(field, item, "has an unknown action %q (expected one of %q)", a, knownActions)
+		}
+		if a == ActionEscalate && !canEscalate {
+			return fieldError(field, item, "escalates, but there is no moderators_channel to escalate to")
+		}
+		if a == ActionDigest && !canEscalate {
+			return fieldError(field, item, "adds matches to a digest, but there is no moderators_channel to post it in")
+		}
+	}
+	if err := f.validateUserActions(field, f.steps); err != nil {
+		return err
+	}
+	return f.validateEmojiActions(field, f.steps)
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Steps returns the actions to take, in order, when the filter matches a message. Filters that count
+// strikes have none; see StrikeSteps.
+func (f Filter) Steps() []string {
+	return f.steps
+}
+
+// IsWarning returns whether action shows the filter's Message to people, so it is subject to the
+// filter's Cooldown.
+func IsWarning(action string) bool {
+	return action == ActionWarn || action == ActionPostEphemeral || action == ActionPostMessage
+}
+
+// Label returns the filter's Name, or "filter N" if it has none, where index is its index in its
+// FilterConfig.
+func (f Filter) Label(index int) string {
+	if f.Name != "" {
+		return f.Name
+	}
+	return fmt.Sprintf("filter %d", index+1)
+}
+
+// DeliversBy returns how the filter's warnings are delivered.
+func (f Filter) DeliversBy() string {
+	if f.Delivery == "" {
+		return DeliveryEphemeral
+	}
+	return f.Delivery
+}
+
+// Scoped returns whether the filter only applies to some channels, so AppliesTo needs their names.
+func (f Filter) Scoped() bool {
+	return len(f.Channels) > 0 || len(f.ExcludeChannels) > 0
+}
+
+// AppliesTo returns whether the filter applies to messages in the channel with the given ID and
+// name. name may be empty if it isn't known, in which case only channels listed by ID match.
+func (f Filter) AppliesTo(id, name string) bool {
+	if len(f.Channels) > 0 && !listsChannel(f.Channels, id, name) {
+		return false
+	}
+	return !listsChannel(f.ExcludeChannels, id, name)
+}
+
+func listsChannel(channels []string, id, name string) bool {
+	for _, c := range channels {
+		c = strings.TrimPrefix(c, "#")
+		if c == id || (name != "" && c == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExemptsUser returns whether the user with the given ID is listed in ExemptUsers. Membership of
+// ExemptUsergroups has to be checked separately.
+func (f Filter) ExemptsUser(user string) bool {
+	for _, u := range f.ExemptUsers {
+		if u == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns the triggers in text and the regexes that match it.
+func (f Filter) Matches(text string) []string {
+	var matches []string
+	normalized := Normalize(text)
+	folded := f.fold(text)
+	contains := strings.Contains
+	if f.Match == MatchWord {
+		contains = containsWord
+	}
+	for i, word := range f.triggers {
+		if contains(folded, word) {
+			matches = append(matches, f.triggerNames[i])
+		}
+	}
+	for _, re := range f.compiled {
+		if re.MatchString(normalized) {
+			matches = append(matches, re.String())
+		}
+	}
+	for _, r := range f.rules {
+		if r.matches(folded, normalized, contains) {
+			matches = append(matches, r.name)
+		}
+	}
+	if f.ChecksLinks() {
+		// Links are checked as they were written, since normalizing them could change their domain.
+		for _, u := range ExtractURLs(text) {
+			if f.matchesLink(u) {
+				matches = append(matches, u.String())
+			}
+		}
+	}
+	return matches
+}
+
+// ChecksLinks returns whether the filter matches links, by their domain or with a detector.
+func (f Filter) ChecksLinks() bool {
+	return len(f.Domains) > 0 || len(f.AllowedDomains) > 0 || len(f.ProtectedDomains) > 0 || f.detects(DetectInviteLinks)
+}
+
+// matchesLink returns whether a link in a message matches the filter.
+func (f Filter) matchesLink(u *url.URL) bool {
+	host := u.Hostname()
+	switch {
+	case inAnyDomain(host, f.allowedDomains):
+		return false
+	case inAnyDomain(host, f.domains), f.imitates(host), f.detects(DetectInviteLinks) && IsInviteLink(u):
+		return true
+	default:
+		// A filter with only allowed domains matches links to every other domain.
+		return len(f.domains) == 0 && len(f.protected) == 0 && !f.detects(DetectInviteLinks)
+	}
+}
+
+// validDomain returns whether d is a host name, rather than a URL or a pattern.
+func validDomain(d string) bool {
+	return normalizeHost(d) != "" && !strings.ContainsAny(d, "/:@*? \t") && strings.Contains(strings.Trim(d, "."), ".")
+}
+
+// normalizeHosts normalizes each of hosts with normalizeHost.
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		normalized = append(normalized, normalizeHost(h))
+	}
+	return normalized
+}
+
+// containsWord returns whether word appears in s without a letter or digit directly before or
+// after it.
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	for start := 0; start <= len(s)-len(word); {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		before, _ := utf8.DecodeLastRuneInString(s[:i])
+		after, _ := utf8.DecodeRuneInString(s[i+len(word):])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		start = i + size
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,484 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		text     string
+		expected []string
+	}{
+		{
+			name:     "trigger",
+			filter:   Filter{Triggers: []string{"guys"}},
+			text:     "hi guys",
+			expected: []string{"guys"},
+		},
+		{
+			name:   "no match",
+			filter: Filter{Triggers: []string{"guys"}, Regexes: []string{`\bbc1[a-z0-9]{25,59}\b`}},
+			text:   "hi all",
+		},
+		{
+			name:     "regex",
+			filter:   Filter{Regexes: []string{`\bbc1[a-z0-9]{25,59}\b`}},
+			text:     "send to bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq now",
+			expected: []string{`\bbc1[a-z0-9]{25,59}\b`},
+		},
+		{
+			name:     "case-insensitive regex",
+			filter:   Filter{Regexes: []string{`(?i)free\s*nitro`}},
+			text:     "FREE   Nitro here",
+			expected: []string{`(?i)free\s*nitro`},
+		},
+		{
+			name:     "trigger ignores case",
+			filter:   Filter{Triggers: []string{"Free Crypto"}},
+			text:     "FREE CRYPTO for everyone",
+			expected: []string{"Free Crypto"},
+		},
+		{
+			name:     "trigger ignores fullwidth characters and accents",
+			filter:   Filter{Triggers: []string{"free crypto"}},
+			text:     "ｆｒｅｅ crŷptö",
+			expected: []string{"free crypto"},
+		},
+		{
+			name:     "regex sees normalized text",
+			filter:   Filter{Regexes: []string{`free\s+nitro`}},
+			text:     "ｆｒｅｅ  ñitro",
+			expected: []string{`free\s+nitro`},
+		},
+		{
+			name:   "regex is case-sensitive",
+			filter: Filter{Regexes: []string{`free\s+nitro`}},
+			text:   "FREE NITRO",
+		},
+		{
+			name:     "substring inside a word",
+			filter:   Filter{Triggers: []string{"ass"}},
+			text:     "see you in class",
+			expected: []string{"ass"},
+		},
+		{
+			name:   "word mode ignores triggers inside words",
+			filter: Filter{Triggers: []string{"ass", "guys"}, Match: MatchWord},
+			text:   "classes for the guyses of Scunthorpe",
+		},
+		{
+			name:     "word mode matches whole words",
+			filter:   Filter{Triggers: []string{"ass", "free crypto"}, Match: MatchWord},
+			text:     "don't be an ass. (FREE CRYPTO!)",
+			expected: []string{"ass", "free crypto"},
+		},
+		{
+			name:     "word mode finds a later whole word",
+			filter:   Filter{Triggers: []string{"guys"}, Match: MatchWord},
+			text:     "guyself, hi guys",
+			expected: []string{"guys"},
+		},
+		{
+			name:     "trigger ignores leetspeak and lookalike letters",
+			filter:   Filter{Triggers: []string{"free airdrop"}},
+			text:     "fr33 аіrdr0p!",
+			expected: []string{"free airdrop"},
+		},
+		{
+			name:     "deobfuscation applies to triggers too",
+			filter:   Filter{Triggers: []string{"b0t"}, Match: MatchWord},
+			text:     "what a bot!",
+			expected: []string{"b0t"},
+		},
+		{
+			name:   "deobfuscation can be turned off",
+			filter: Filter{Triggers: []string{"free airdrop"}, Deobfuscate: new(bool)},
+			text:   "fr33 a1rdr0p",
+		},
+		{
+			name:     "Turkish dotless i",
+			filter:   Filter{Triggers: []string{"SIKINTI"}, Language: "tr", Match: MatchWord},
+			text:     "ne sıkıntı ama",
+			expected: []string{"SIKINTI"},
+		},
+		{
+			name:   "dotless i without a language",
+			filter: Filter{Triggers: []string{"sikinti"}, Match: MatchWord},
+			text:   "ne sıkıntı ama",
+		},
+		{
+			name:     "German sharp s",
+			filter:   Filter{Triggers: []string{"Scheiße"}, Language: "de-CH"},
+			text:     "so eine SCHEISSE",
+			expected: []string{"Scheiße"},
+		},
+		{
+			name:     "Greek final sigma",
+			filter:   Filter{Triggers: []string{"ΜΑΛΑΚΑΣ"}, Language: "el", Match: MatchWord},
+			text:     "είσαι μαλάκας",
+			expected: []string{"ΜΑΛΑΚΑΣ"},
+		},
+		{
+			name:     "transliterated message",
+			filter:   Filter{Triggers: []string{"дурак"}, Transliterate: true, Match: MatchWord},
+			text:     "ty durak!",
+			expected: []string{"дурак"},
+		},
+		{
+			name:     "transliterated trigger",
+			filter:   Filter{Triggers: []string{"durak"}, Transliterate: true, Match: MatchWord},
+			text:     "ты дурак!",
+			expected: []string{"durak"},
+		},
+		{
+			name:   "Cyrillic isn't transliterated unless asked",
+			filter: Filter{Triggers: []string{"durak"}, Match: MatchWord},
+			text:   "ты дурак!",
+		},
+		{
+			name:     "trigger and regex",
+			filter:   Filter{Triggers: []string{"guys"}, Regexes: []string{`g+u+y+s`}},
+			text:     "hey guys",
+			expected: []string{"guys", "g+u+y+s"},
+		},
+		{
+			name:     "blocked domain",
+			filter:   Filter{Domains: []string{"Steamcommunlty.com"}},
+			text:     "free skins at <https://login.steamcommunlty.com/trade?utm_source=slack|steamcommunity.com>",
+			expected: []string{"https://login.steamcommunlty.com/trade"},
+		},
+		{
+			name:   "domain that only ends like a blocked one",
+			filter: Filter{Domains: []string{"evil.example"}},
+			text:   "see https://notevil.example/",
+		},
+		{
+			name:     "allowed domains",
+			filter:   Filter{AllowedDomains: []string{"kubernetes.io", "github.com"}},
+			text:     "docs at https://kubernetes.io/docs and https://k8s.github.com, prizes at http://prizes.example.",
+			expected: []string{"http://prizes.example"},
+		},
+		{
+			name:   "allowed subdomain of a blocked domain",
+			filter: Filter{Domains: []string{"example.com"}, AllowedDomains: []string{"docs.example.com"}},
+			text:   "https://docs.example.com/guide",
+		},
+		{
+			name:     "internationalized domain",
+			filter:   Filter{Domains: []string{"bücher.example"}},
+			text:     "https://BÜCHER.example/sale",
+			expected: []string{"https://xn--bcher-kva.example/sale"},
+		},
+		{
+			name:     "invite links",
+			filter:   Filter{Detect: []string{DetectInviteLinks}},
+			text:     "join us at <https://discord.gg/AbCdEf|discord.gg/AbCdEf> or https://t.me/+xyz, docs at https://discord.com/developers",
+			expected: []string{"https://discord.gg/AbCdEf", "https://t.me/+xyz"},
+		},
+		{
+			name:     "lookalike domain",
+			filter:   Filter{ProtectedDomains: []string{"kubernetes.io"}, AllowedDomains: []string{"kubernetes.dev"}},
+			text:     "docs at https://kubernetes.io and https://kubernetes.dev, free swag at https://kubernetes-io.xyz/swag",
+			expected: []string{"https://kubernetes-io.xyz/swag"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := FilterConfig{tc.filter}
+			fc[0].Action = ActionPostEphemeral
+			if err := fc.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := fc[0].Matches(tc.text); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "plain text", expected: "plain text"},
+		{text: "ｆｒéé", expected: "free"},
+		{text: "Ｃａｆé Ünïcödé", expected: "Cafe Unicode"},
+		{text: "ﬁnance", expected: "finance"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			if actual := Normalize(tc.text); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDeobfuscate(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "plain text", expected: "plain text"},
+		{text: "fr33 a1rdr0p", expected: "free airdrop"},
+		{text: "$c@m", expected: "scam"},
+		{text: "раураӏ", expected: "paypal"},
+		{text: "hi guys!", expected: "hi guys!"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.text, func(t *testing.T) {
+			if actual := Deobfuscate(tc.text); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilterAppliesTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		id       string
+		channel  string
+		expected bool
+	}{
+		{
+			name:     "unscoped",
+			filter:   Filter{},
+			id:       "C1",
+			channel:  "memes",
+			expected: true,
+		},
+		{
+			name:     "listed by name",
+			filter:   Filter{Channels: []string{"#kubernetes-dev"}},
+			id:       "C1",
+			channel:  "kubernetes-dev",
+			expected: true,
+		},
+		{
+			name:    "not listed",
+			filter:  Filter{Channels: []string{"kubernetes-dev"}},
+			id:      "C1",
+			channel: "memes",
+		},
+		{
+			name:     "listed by ID without a name",
+			filter:   Filter{Channels: []string{"C1"}},
+			id:       "C1",
+			expected: true,
+		},
+		{
+			name:    "excluded by name",
+			filter:  Filter{ExcludeChannels: []string{"#memes"}},
+			id:      "C1",
+			channel: "memes",
+		},
+		{
+			name:     "not excluded",
+			filter:   Filter{ExcludeChannels: []string{"memes", "C2"}},
+			id:       "C1",
+			channel:  "kubernetes-dev",
+			expected: true,
+		},
+		{
+			name:    "listed and excluded",
+			filter:  Filter{Channels: []string{"kubernetes-dev"}, ExcludeChannels: []string{"C1"}},
+			id:      "C1",
+			channel: "kubernetes-dev",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.filter.AppliesTo(tc.id, tc.channel); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFilterConfigCompile(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        FilterConfig
+		expectedError string
+	}{
+		{
+			name:   "valid",
+			config: FilterConfig{{Triggers: []string{"guys"}, Action: ActionPostEphemeral}, {Regexes: []string{`(?i)free\s*nitro`}, Action: ActionDelete}},
+		},
+		{
+			name:          "invalid regex",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: ActionPostEphemeral}, {Regexes: []string{"free(nitro"}, Action: ActionPostMessage}},
+			expectedError: `filter 2 has an invalid regex "free(nitro"`,
+		},
+		{
+			name:          "unknown match mode",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Match: "words", Action: ActionPostEphemeral}},
+			expectedError: `filter 1 has an unknown match mode "words"`,
+		},
+		{
+			name:          "unknown delivery",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: ActionWarn, Delivery: "email"}},
+			expectedError: `filter 1 has an unknown delivery "email"`,
+		},
+		{
+			name:          "invalid message template",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: ActionWarn, Message: "Hi {{.User"}},
+			expectedError: "filter 1 has an invalid message template",
+		},
+		{
+			name:          "unknown message template field",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: ActionWarn, Message: "Hi {{.Name}}"}},
+			expectedError: "filter 1 has an invalid message template",
+		},
+		{
+			name:          "unknown action",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: "chat.update"}},
+			expectedError: `filter 1 has an unknown action "chat.update"`,
+		},
+		{
+			name:          "unknown action in a list",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Actions: []string{ActionDelete, "ban"}}},
+			expectedError: `filter 1 has an unknown action "ban"`,
+		},
+		{
+			name:          "both action and actions",
+			config:        FilterConfig{{Triggers: []string{"guys"}, Action: ActionDelete, Actions: []string{ActionDelete}}},
+			expectedError: "filter 1 has both an action and actions",
+		},
+		{
+			name:          "no action",
+			config:        FilterConfig{{Triggers: []string{"guys"}}},
+			expectedError: "filter 1 has no action",
+		},
+		{
+			name:          "nothing to match",
+			config:        FilterConfig{{Message: "hi", Action: ActionPostEphemeral}},
+			expectedError: "filter 1 has nothing to match messages with",
+		},
+		{
+			name:   "domains are enough to match",
+			config: FilterConfig{{Domains: []string{"evil.example"}, Action: ActionDelete}},
+		},
+		{
+			name:          "unknown detector",
+			config:        FilterConfig{{Detect: []string{"spam"}, Action: ActionDelete}},
+			expectedError: `filter 1 has an unknown detector "spam"`,
+		},
+		{
+			name:          "reactions without emoji",
+			config:        FilterConfig{{Reactions: &Reactions{Count: 3}, Action: ActionEscalate}},
+			expectedError: "filter 1 has no emoji to match reactions with",
+		},
+		{
+			name:          "name filter deletes",
+			config:        FilterConfig{{ProtectedNames: []string{"Kubernetes Admin"}, Action: ActionDelete}},
+			expectedError: `filter 1 checks users rather than messages, so it can't delete`,
+		},
+		{
+			name:          "message filter renames",
+			config:        FilterConfig{{Triggers: []string{"nazi"}, Actions: []string{ActionLog, ActionRename}}},
+			expectedError: "filter 1 renames users, but only filters that check names can",
+		},
+		{
+			name:          "name filter counts strikes",
+			config:        FilterConfig{{Names: true, Triggers: []string{"nazi"}, Strikes: true}},
+			expectedError: "filter 1 checks users rather than messages, so it can't count strikes",
+		},
+		{
+			name:          "profile filter renames",
+			config:        FilterConfig{{Profiles: true, Domains: []string{"evil.example"}, Action: ActionRename}},
+			expectedError: "filter 1 renames users, but only filters that check names can",
+		},
+		{
+			name:          "emoji filter warns",
+			config:        FilterConfig{{CustomEmoji: true, Triggers: []string{"nazi"}, Action: ActionWarn}},
+			expectedError: `filter 1 checks custom emoji rather than messages, so it can't warn`,
+		},
+		{
+			name:          "emoji filter checks names",
+			config:        FilterConfig{{CustomEmoji: true, Names: true, Triggers: []string{"nazi"}, Action: ActionLog}},
+			expectedError: "filter 1 checks custom emoji, so it can't also check users",
+		},
+		{
+			name:          "emoji filter counts strikes",
+			config:        FilterConfig{{CustomEmoji: true, Triggers: []string{"nazi"}, Strikes: true}},
+			expectedError: "filter 1 checks custom emoji rather than messages, so it can't count strikes",
+		},
+		{
+			name:          "invalid language",
+			config:        FilterConfig{{Triggers: []string{"scheiße"}, Language: "german!", Action: ActionDelete}},
+			expectedError: `filter 1 has an invalid language "german!"`,
+		},
+		{
+			name:          "score without a threshold",
+			config:        FilterConfig{{Score: &Score{Attribute: "TOXICITY"}, Action: ActionDelete}},
+			expectedError: "filter 1 has a threshold of 0",
+		},
+		{
+			name:          "domain given as a URL",
+			config:        FilterConfig{{Domains: []string{"https://evil.example/"}, Action: ActionDelete}},
+			expectedError: `filter 1 has an invalid domain "https://evil.example/"`,
+		},
+		{
+			name:          "allowed domain with a wildcard",
+			config:        FilterConfig{{AllowedDomains: []string{"*.example.com"}, Action: ActionDelete}},
+			expectedError: `filter 1 has an invalid domain "*.example.com"`,
+		},
+		{
+			name:          "trigger list without a supported URL",
+			config:        FilterConfig{{TriggerLists: []string{"ftp://example.com/scams.txt"}, Action: ActionDelete}},
+			expectedError: "filter 1 has a trigger list with an unsupported URL",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Compile()
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected an error containing %q, but got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestFilterLabel(t *testing.T) {
+	if actual := (Filter{}).Label(1); actual != "filter 2" {
+		t.Errorf("expected an unnamed filter to be labeled by its position, but got %q", actual)
+	}
+	if actual := (Filter{Name: "crypto"}).Label(1); actual != "crypto" {
+		t.Errorf("expected a named filter to be labeled by its name, but got %q", actual)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// languageFolders fold the letters of some languages that people write in more than one way, on top
+// of what fold does for every language. They are keyed by ISO 639 language code.
+var languageFolders = map[string]*strings.Replacer{
+	// Turkish has a dotless ı as well as i, but people often type Turkish on keyboards without it.
+	"tr": strings.NewReplacer("ı", "i"),
+	"az": strings.NewReplacer("ı", "i"),
+	// ß is written "ss" in Switzerland and in capitals.
+	"de": strings.NewReplacer("ß", "ss"),
+	// σ is written ς at the end of a word.
+	"el": strings.NewReplacer("ς", "σ"),
+}
+
+// cyrillic transliterates Russian Cyrillic, after fold has removed diacritics, to Latin letters.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y",
+	'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'є': "ye", 'ґ': "g", 'ђ': "dj", 'ј': "j",
+	'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+}
+
+// greek transliterates Greek, after fold has removed diacritics, to Latin letters.
+var greek = map[rune]string{
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i",
+	'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s",
+	'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// transliterationOverrides are the letters that some languages transliterate differently from
+// cyrillic and greek.
+var transliterationOverrides = map[string]map[rune]string{
+	"uk": {'г': "h", 'и': "y"},
+	"bg": {'щ': "sht", 'ъ': "a"},
+}
+
+// Transliterate writes the Cyrillic and Greek letters in s, which must already be folded, in Latin
+// letters, the way they are usually written in lang, so "дурак" becomes "durak".
+func Transliterate(s, lang string) string {
+	overrides := transliterationOverrides[lang]
+	var b strings.Builder
+	for _, r := range s {
+		if latin, ok := overrides[r]; ok {
+			b.WriteString(latin)
+		} else if latin, ok := cyrillic[r]; ok {
+			b.WriteString(latin)
+		} else if latin, ok := greek[r]; ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// validLanguage returns whether tag is a valid BCP 47 language tag, such as "de" or "pt-BR".
+func validLanguage(tag string) bool {
+	_, err := language.Parse(tag)
+	return err == nil
+}
+
+// baseLanguage returns the ISO 639 code of the language of tag, so "pt-BR" becomes "pt", or "" if
+// tag isn't valid.
+func baseLanguage(tag string) string {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	base, _ := t.Base()
+	return base.String()
+}
+
+// fold returns the form of s that the filter's triggers are compared in: folded for the filter's
+// language, transliterated if it asks for that, and deobfuscated unless it doesn't.
+func (f Filter) fold(s string) string {
+	s = fold(s)
+	if r, ok := languageFolders[f.language]; ok {
+		s = r.Replace(s)
+	}
+	if f.Transliterate {
+		s = Transliterate(s, f.language)
+	}
+	if f.deobfuscates() {
+		s = Deobfuscate(s)
+	}
+	return s
+}
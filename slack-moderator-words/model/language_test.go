@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		lang     string
+		expected string
+	}{
+		{name: "Russian", text: "щука и жук", expected: "shchuka i zhuk"},
+		{name: "Ukrainian", text: "гривня", lang: "uk", expected: "hryvnya"},
+		{name: "Bulgarian", text: "щъркел", lang: "bg", expected: "shtarkel"},
+		{name: "Serbian", text: "љубав", lang: "sr", expected: "ljubav"},
+		{name: "Greek", text: "ψυχή", expected: "psychi"},
+		{name: "Latin and punctuation are left alone", text: "free дурак!", expected: "free durak!"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Transliterate(fold(tc.text), tc.lang); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// confusables maps letters, and pairs of letters, that look alike in most fonts to one of them,
+// on top of what Deobfuscate does.
+var confusables = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d", "l", "i")
+
+// lookalike is a protected domain, as it is compared with the domains of links.
+type lookalike struct {
+	domain string
+	// skeleton is the domain as it looks, and name is how the part of it before the top-level
+	// domain looks, such as "kubernetes" for kubernetes.io.
+	skeleton string
+	name     string
+}
+
+// secondLevelDomains are the labels, such as the "co" in example.co.uk, that come before some
+// top-level domains, so that the name of the domain is the label before them.
+var secondLevelDomains = map[string]bool{"ac": true, "co": true, "com": true, "edu": true, "gov": true, "net": true, "org": true}
+
+func newLookalike(domain string) lookalike {
+	l := lookalike{domain: domain, skeleton: skeleton(domain)}
+	if names := names(l.skeleton); len(names) > 0 {
+		l.name = names[0]
+	}
+	return l
+}
+
+// skeleton returns how a host looks, so that domains that look alike have the same skeleton: it is
+// converted from punycode, normalized and deobfuscated like messages are, and has lookalike letters
+// replaced with confusables.
+func skeleton(host string) string {
+	if unicode, err := idna.ToUnicode(host); err == nil {
+		host = unicode
+	}
+	return confusables.Replace(Deobfuscate(fold(host)))
+}
+
+// names returns the name of the domain a host is registered under, such as "example" for
+// www.example.co.uk, followed by its hyphenated parts, if it has any.
+func names(host string) []string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return nil
+	}
+	name := labels[len(labels)-2]
+	if len(labels) > 2 && secondLevelDomains[name] {
+		name = labels[len(labels)-3]
+	}
+	if !strings.Contains(name, "-") {
+		return []string{name}
+	}
+	return append([]string{strings.ReplaceAll(name, "-", "")}, strings.Split(name, "-")...)
+}
+
+// imitates returns whether host looks like, but isn't, one of the protected domains or their
+// subdomains. That is the case if it contains a protected domain, perhaps with hyphens or nothing
+// for its dots, such as kubernetes.io.example.com or kubernetes-io.example; or if the name it is
+// registered under, or a hyphenated part of that, is only a typo or two away from a protected
+// domain's, such as kubernets.dev or kubernetes-login.xyz. Lookalike letters, such as a Cyrillic
+// "е", a "1" for an "l" or "rn" for "m", don't count as differences.
+func (f Filter) imitates(host string) bool {
+	for _, p := range f.protected {
+		if inDomain(host, p.domain) {
+			return false
+		}
+	}
+	s := skeleton(host)
+	dotted := strings.ReplaceAll(s, "-", ".")
+	for _, p := range f.protected {
+		if containsLabels(dotted, p.skeleton) || containsLabels(dotted, strings.ReplaceAll(p.skeleton, ".", "")) {
+			return true
+		}
+		for _, name := range names(s) {
+			if p.name != "" && distance(name, p.name) <= maxDistance(p.name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maxDistance is how many typos a name can have before a domain with it stops looking like one
+// with the protected name. Any typo in a short name makes a different word.
+func maxDistance(name string) int {
+	switch n := len([]rune(name)); {
+	case n < 6:
+		return 0
+	case n < 9:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// containsLabels returns whether sub is a whole number of the labels of host, which are separated
+// by dots.
+func containsLabels(host, sub string) bool {
+	if sub == "" {
+		return false
+	}
+	for start := 0; start <= len(host)-len(sub); {
+		i := strings.Index(host[start:], sub)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(sub)
+		if (i == 0 || host[i-1] == '.') && (end == len(host) || host[end] == '.') {
+			return true
+		}
+		start = i + 1
+	}
+	return false
+}
+
+// distance returns the number of insertions, deletions, substitutions and swaps of adjacent letters
+// that it takes to turn a into b.
+func distance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] is the distance between the first i runes of s and the first j runes of t.
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestFilterImitates(t *testing.T) {
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{host: "kubernetes.io"},
+		{host: "docs.kubernetes.io"},
+		{host: "k8s.dev"},
+		{host: "app.slack.com"},
+		{host: "kubernetes-sigs.github.io"},
+		{host: "github.com"},
+		{host: "black.com"},
+		{host: "slackcommunity.org"},
+		{host: "kubernetes-io.xyz", expected: true},
+		{host: "kubernetesio.example", expected: true},
+		{host: "kubernetes.io.account-verify.example", expected: true},
+		{host: "kubernets.io", expected: true},
+		{host: "kuberentes.dev", expected: true},
+		{host: "kubernetes-security.com", expected: true},
+		{host: "kubernetes.co.uk", expected: true},
+		{host: "kubemetes.io", expected: true},
+		{host: "xn--kbernetes-p6a.io", expected: true}, // kubernetes with a Cyrillic "е"
+		{host: "k8s-dev.net", expected: true},
+		{host: "k8s.example", expected: true},
+		{host: "k9s.example"},
+		{host: "s1ack.com", expected: true},
+		{host: "slack.com-login.example", expected: true},
+	}
+
+	fc := FilterConfig{{ProtectedDomains: []string{"kubernetes.io", "k8s.dev", "slack.com"}, Action: ActionEscalate}}
+	if err := fc.compile(DefaultSeverities, DefaultStrikeLevels, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tc := range tests {
+		t.Run(tc.host, func(t *testing.T) {
+			if actual := fc[0].imitates(tc.host); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "kubernetes", b: "kubernetes", expected: 0},
+		{a: "kubernets", b: "kubernetes", expected: 1},
+		{a: "kuberentes", b: "kubernetes", expected: 1},
+		{a: "kubernetess", b: "kubernetes", expected: 1},
+		{a: "cubernetis", b: "kubernetes", expected: 2},
+		{a: "", b: "k8s", expected: 3},
+	}
+
+	for _, tc := range tests {
+		if actual := distance(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("expected the distance between %q and %q to be %d, but got %d", tc.a, tc.b, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// MessageData is what a filter's Message can refer to as a Go template, such as "{{.User}}, please
+// don't say {{.MatchedWord}}".
+type MessageData struct {
+	// User and Channel mention the author of the message and the channel it was posted in.
+	User    string
+	Channel string
+	// MatchedWord is the first trigger or regex that the message matched.
+	MatchedWord string
+	// Permalink links to the message.
+	Permalink string
+}
+
+// NewMessageData returns the MessageData for a message posted by user in channel that matched
+// triggers.
+func NewMessageData(user, channel string, triggers []string, permalink string) MessageData {
+	d := MessageData{User: "<@" + user + ">", Channel: "<#" + channel + ">", Permalink: permalink}
+	if len(triggers) > 0 {
+		d.MatchedWord = triggers[0]
+	}
+	return d
+}
+
+func (f *Filter) compileMessage() *configError {
+	t, err := template.New("message").Parse(f.Message)
+	if err != nil {
+		return fieldError("message", -1, "has an invalid message template: %v", err)
+	}
+	// Catch references to fields that MessageData doesn't have now, rather than on every match.
+	if err := t.Execute(io.Discard, MessageData{}); err != nil {
+		return fieldError("message", -1, "has an invalid message template: %v", err)
+	}
+	f.message = t
+	return nil
+}
+
+// RenderMessage fills in the filter's Message with data.
+func (f Filter) RenderMessage(data MessageData) (string, error) {
+	if f.message == nil {
+		return f.Message, nil
+	}
+	var b bytes.Buffer
+	if err := f.message.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render message: %v", err)
+	}
+	return b.String(), nil
+}
+
+// NeedsPermalink returns whether the filter's Message links to the message, so that the link has
+// to be looked up before rendering it.
+func (f Filter) NeedsPermalink() bool {
+	return strings.Contains(f.Message, ".Permalink")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"testing"
+)
+
+func TestRenderMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			message:  "Please don't.",
+			expected: "Please don't.",
+		},
+		{
+			name:     "placeholders",
+			message:  "{{.User}}, please don't say {{.MatchedWord}} in {{.Channel}}: {{.Permalink}}",
+			expected: "<@U1>, please don't say guys in <#C1>: https://example.slack.com/archives/C1/p1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := FilterConfig{{Triggers: []string{"guys"}, Action: ActionWarn, Message: tc.message}}
+			if err := fc.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			message, err := fc[0].RenderMessage(NewMessageData("U1", "C1", []string{"guys", "dudes"}, "https://example.slack.com/archives/C1/p1"))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if message != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, message)
+			}
+		})
+	}
+}
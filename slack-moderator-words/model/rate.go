@@ -0,0 +1,59 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "time"
+
+// Defaults for Rate.
+const (
+	DefaultRateMessages = 15
+	DefaultRatePer      = 30 * time.Second
+)
+
+// Rate makes a filter match messages from users who are posting faster than Messages messages, in
+// any channels, Per period of time, which is how bots flood a workspace.
+type Rate struct {
+	Messages int           `yaml:"messages"`
+	Per      time.Duration `yaml:"per"`
+}
+
+// Limit returns how many messages users can post in Window, which defaults to
+// DefaultRateMessages.
+func (r Rate) Limit() int {
+	if r.Messages == 0 {
+		return DefaultRateMessages
+	}
+	return r.Messages
+}
+
+// Window returns the period that messages are counted over, which defaults to DefaultRatePer.
+func (r Rate) Window() time.Duration {
+	if r.Per == 0 {
+		return DefaultRatePer
+	}
+	return r.Per
+}
+
+func (r Rate) validate() *configError {
+	if r.Messages < 0 {
+		return fieldError("rate", -1, "has a negative number of messages")
+	}
+	if r.Per < 0 {
+		return fieldError("rate", -1, "has a negative period to count messages over")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "strings"
+
+// Reactions makes a filter match messages that at least Count people, 1 by default, have reacted to
+// with any of Emoji, such as "report", so that members can report messages to moderators.
+type Reactions struct {
+	Emoji []string `yaml:"emoji"`
+	Count int      `yaml:"count"`
+}
+
+// MinCount returns how many people have to react to a message for the filter to match it.
+func (r Reactions) MinCount() int {
+	if r.Count == 0 {
+		return 1
+	}
+	return r.Count
+}
+
+// Includes returns whether the reaction with the given name, as Slack sends it, is one of Emoji.
+func (r Reactions) Includes(name string) bool {
+	name = ReactionName(name)
+	for _, e := range r.Emoji {
+		if ReactionName(e) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Reactions) validate() *configError {
+	if len(r.Emoji) == 0 {
+		return fieldError("reactions", -1, "has no emoji to match reactions with")
+	}
+	for j, e := range r.Emoji {
+		if ReactionName(e) == "" {
+			return fieldError("reactions", j, "has an empty emoji")
+		}
+	}
+	if r.Count < 0 {
+		return fieldError("reactions", -1, "has a negative count")
+	}
+	return nil
+}
+
+// ReactionName returns the name of an emoji without the colons around it or the skin tone Slack
+// adds after it, so ":+1::skin-tone-2:" becomes "+1".
+func ReactionName(emoji string) string {
+	emoji = strings.Trim(strings.TrimSpace(emoji), ":")
+	name, _, _ := strings.Cut(emoji, "::")
+	return name
+}
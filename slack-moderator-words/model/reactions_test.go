@@ -0,0 +1,40 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestReactionsIncludes(t *testing.T) {
+	reactions := Reactions{Emoji: []string{":report:", "warning"}}
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{name: "report", expected: true},
+		{name: "warning", expected: true},
+		{name: "report::skin-tone-2", expected: true},
+		{name: "reported"},
+		{name: "+1"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := reactions.Includes(tc.name); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
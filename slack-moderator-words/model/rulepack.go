@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"regexp"
+	"sort"
+)
+
+// Built-in rule packs, which filters can use with RulePack.
+const (
+	// RulePackCryptoScams matches cryptocurrency and giveaway scams: wallet addresses, requests
+	// for seed phrases, promises of profits for DMing the author, and fake giveaways and airdrops.
+	RulePackCryptoScams = "crypto_scams"
+)
+
+// rulePack is a set of rules that a filter can use instead of writing its own triggers and
+// regexes, and the severity and message the filter has unless it sets its own.
+type rulePack struct {
+	severity string
+	message  string
+	rules    []rule
+}
+
+// rule is one of a rule pack's rules, which matches messages that contain any of its triggers or
+// match any of its regexes, as long as verify, if it is set, accepts what the regex matched.
+// Matches are reported by the rule's name.
+type rule struct {
+	name     string
+	triggers []string
+	regexes  []string
+	verify   func(string) bool
+}
+
+// rulePacks are the rule packs that filters can use.
+var rulePacks = map[string]rulePack{
+	RulePackCryptoScams: {
+		severity: SeverityHigh,
+		message:  "Your message was removed because it looked like a cryptocurrency scam. If it wasn't, please ask the moderators to restore it.",
+		rules: []rule{
+			{
+				name:    "bitcoin_address",
+				regexes: []string{`\b(bc1[a-z0-9]{25,59}|[13][a-km-zA-HJ-NP-Z1-9]{25,34})\b`},
+				verify:  isBitcoinAddress,
+			},
+			{
+				name:    "ethereum_address",
+				regexes: []string{`\b0x[a-fA-F0-9]{40}\b`},
+			},
+			{
+				name:     "seed_phrase",
+				triggers: []string{"seed phrase", "recovery phrase", "secret phrase", "mnemonic phrase", "wallet phrase"},
+				regexes:  []string{`(?i)\b(12|24)[ -]words?\b.{0,20}?\b(phrase|seed|wallet|recovery)\b`},
+			},
+			{
+				name: "dm_for_profit",
+				regexes: []string{
+					`(?i)\b(dm|inbox|message|text|contact|write to)\s+me\b.{0,60}?\b(profits?|earn(ings?)?|invest(ing|ments?)?|trading|returns?|income|bitcoin|btc|crypto|usdt|forex)\b`,
+					`(?i)\b(profits?|earn(ings?)?|invest(ing|ments?)?|trading|returns?|income|bitcoin|btc|crypto|usdt|forex)\b.{0,60}?\b(dm|inbox|message|text|contact|write to)\s+me\b`,
+					`(?i)\bearn(ed|ing)?\s+(\$|usd\s*)?\d[\d,.]*k?\s*(\$|usd|usdt|dollars)?\s*(daily|weekly|per\s+(day|week)|a\s+(day|week)|every\s+(day|week)|in\s+\d+\s+(hours|days))\b`,
+				},
+			},
+			{
+				name: "giveaway",
+				triggers: []string{
+					"crypto giveaway", "bitcoin giveaway", "btc giveaway", "eth giveaway", "nft giveaway",
+					"free airdrop", "claim your airdrop", "claim your reward", "claim your nft",
+					"double your bitcoin", "double your btc", "double your crypto", "guaranteed profit",
+					"guaranteed returns", "free nitro",
+				},
+			},
+		},
+	},
+}
+
+// knownRulePacks are the names of the rule packs, in order.
+var knownRulePacks = func() []string {
+	var names []string
+	for name := range rulePacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// compiledRule is a rule of the filter's rule pack, compiled for Matches.
+type compiledRule struct {
+	name     string
+	triggers []string
+	regexes  []*regexp.Regexp
+	verify   func(string) bool
+}
+
+// applyRulePack gives the filter its rule pack's severity and message, unless it has its own, and
+// compiles the rules that DisableRules doesn't turn off.
+func (f *Filter) applyRulePack() *configError {
+	f.rules = nil
+	if f.RulePack == "" {
+		if len(f.DisableRules) > 0 {
+			return fieldError("disable_rules", -1, "disables rules, but has no rule_pack")
+		}
+		return nil
+	}
+	pack, ok := rulePacks[f.RulePack]
+	if !ok {
+		return fieldError("rule_pack", -1, "has an unknown rule pack %q (expected one of %q)", f.RulePack, knownRulePacks)
+	}
+	names := make([]string, 0, len(pack.rules))
+	for _, r := range pack.rules {
+		names = append(names, r.name)
+	}
+	for j, name := range f.DisableRules {
+		if !contains(names, name) {
+			return fieldError("disable_rules", j, "disables an unknown rule %q (expected one of %q)", name, names)
+		}
+	}
+	if f.Action == "" && len(f.Actions) == 0 && f.Severity == "" && !f.Strikes {
+		f.Severity = pack.severity
+	}
+	if f.Message == "" {
+		f.Message = pack.message
+	}
+	for _, r := range pack.rules {
+		if contains(f.DisableRules, r.name) {
+			continue
+		}
+		c := compiledRule{name: r.name, verify: r.verify}
+		for _, t := range r.triggers {
+			c.triggers = append(c.triggers, f.fold(t))
+		}
+		for _, re := range r.regexes {
+			c.regexes = append(c.regexes, regexp.MustCompile(re))
+		}
+		f.rules = append(f.rules, c)
+	}
+	return nil
+}
+
+// matches returns whether the rule matches a message, given its folded and normalized forms and
+// how the filter matches triggers.
+func (r compiledRule) matches(folded, normalized string, contains func(s, substr string) bool) bool {
+	for _, t := range r.triggers {
+		if contains(folded, t) {
+			return true
+		}
+	}
+	for _, re := range r.regexes {
+		if r.verify == nil {
+			if re.MatchString(normalized) {
+				return true
+			}
+			continue
+		}
+		for _, m := range re.FindAllString(normalized, -1) {
+			if r.verify(m) {
+				return true
+			}
+		}
+	}
+	return false
+}
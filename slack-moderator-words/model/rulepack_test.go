@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRulePackMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		disable  []string
+		expected []string
+	}{
+		{
+			name:     "bitcoin address",
+			text:     "send 0.1 BTC to 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2 and get 1 back",
+			expected: []string{"bitcoin_address"},
+		},
+		{
+			name:     "segwit address",
+			text:     "wallet: bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+			expected: []string{"bitcoin_address"},
+		},
+		{
+			name: "hash that looks like an address",
+			text: "the image digest is 3f2a9b1c4d5e6f7a8b9c1d2e3f4a5b6c",
+		},
+		{
+			name:     "ethereum address",
+			text:     "0x52908400098527886E0F7030069857D2E4169EE7",
+			expected: []string{"ethereum_address"},
+		},
+		{
+			name:     "seed phrase request",
+			text:     "Support here, please verify your wallet by sending your 12-word recovery phrase",
+			expected: []string{"seed_phrase"},
+		},
+		{
+			name:     "dm for profit",
+			text:     "I made huge profits trading crypto, DM me to learn how",
+			expected: []string{"dm_for_profit"},
+		},
+		{
+			name:     "earnings per week",
+			text:     "Earn $5,000 weekly from home",
+			expected: []string{"dm_for_profit"},
+		},
+		{
+			name:     "obfuscated giveaway",
+			text:     "Cl4im your 41rdr0p: FREE AIRDROP now",
+			expected: []string{"giveaway"},
+		},
+		{
+			name: "everyday conversation",
+			text: "DM me if the TLS private key rotation breaks again, the 12 word limit on titles is fine",
+		},
+		{
+			name:     "disabled rule",
+			text:     "free airdrop to 1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			disable:  []string{"giveaway"},
+			expected: []string{"bitcoin_address"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := FilterConfig{{RulePack: RulePackCryptoScams, DisableRules: tc.disable}}
+			if err := fc.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := fc[0].Matches(tc.text); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRulePackOverrides(t *testing.T) {
+	tests := []struct {
+		name            string
+		filter          Filter
+		expectedSteps   []string
+		expectedMessage string
+	}{
+		{
+			name:            "pack defaults",
+			filter:          Filter{RulePack: RulePackCryptoScams},
+			expectedSteps:   []string{ActionDelete, ActionWarn},
+			expectedMessage: rulePacks[RulePackCryptoScams].message,
+		},
+		{
+			name:            "own severity and message",
+			filter:          Filter{RulePack: RulePackCryptoScams, Severity: SeverityLow, Message: "Logged."},
+			expectedSteps:   []string{ActionLog},
+			expectedMessage: "Logged.",
+		},
+		{
+			name:            "own action",
+			filter:          Filter{RulePack: RulePackCryptoScams, Action: ActionEscalate},
+			expectedSteps:   []string{ActionEscalate},
+			expectedMessage: rulePacks[RulePackCryptoScams].message,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fc := FilterConfig{tc.filter}
+			if err := fc.compile(DefaultSeverities, DefaultStrikeLevels, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if steps := fc[0].Steps(); !reflect.DeepEqual(steps, tc.expectedSteps) {
+				t.Errorf("expected steps %q, but got %q", tc.expectedSteps, steps)
+			}
+			if fc[0].Message != tc.expectedMessage {
+				t.Errorf("expected message %q, but got %q", tc.expectedMessage, fc[0].Message)
+			}
+		})
+	}
+}
+
+func TestRulePackErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        Filter
+		expectedError string
+	}{
+		{
+			name:          "unknown rule pack",
+			filter:        Filter{RulePack: "nft_scams"},
+			expectedError: `filter 1 has an unknown rule pack "nft_scams"`,
+		},
+		{
+			name:          "unknown rule",
+			filter:        Filter{RulePack: RulePackCryptoScams, DisableRules: []string{"wallets"}},
+			expectedError: `filter 1 disables an unknown rule "wallets"`,
+		},
+		{
+			name:          "disabled rules without a pack",
+			filter:        Filter{Triggers: []string{"scam"}, Action: ActionDelete, DisableRules: []string{"giveaway"}},
+			expectedError: "filter 1 disables rules, but has no rule_pack",
+		},
+		{
+			name:          "every rule disabled",
+			filter:        Filter{RulePack: RulePackCryptoScams, DisableRules: []string{"bitcoin_address", "ethereum_address", "seed_phrase", "dm_for_profit", "giveaway"}},
+			expectedError: "filter 1 has nothing to match messages with",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := FilterConfig{tc.filter}.Compile()
+			if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected an error containing %q, but got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
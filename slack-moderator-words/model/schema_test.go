@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// schemaDefinition is the part of a JSON schema definition that TestSchema checks.
+type schemaDefinition struct {
+	Properties map[string]json.RawMessage `json:"properties"`
+	Items      *schemaDefinition          `json:"items"`
+	Enum       []string                   `json:"enum"`
+}
+
+// TestSchema checks that filters.schema.json, which editors use to check filter files, knows about
+// the same fields and values as ParseConfig.
+func TestSchema(t *testing.T) {
+	data, err := ioutil.ReadFile("../filters.schema.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	schema := struct {
+		Definitions map[string]*schemaDefinition `json:"definitions"`
+	}{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d := schema.Definitions
+
+	properties := func(def *schemaDefinition) []string {
+		var names []string
+		for name := range def.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	tests := []struct {
+		name     string
+		fields   []string
+		expected []string
+	}{
+		{name: "config", fields: properties(d["config"]), expected: yamlFields(Config{})},
+		{name: "filter", fields: properties(d["filter"]), expected: yamlFields(Filter{})},
+		{name: "cross posts", fields: properties(d["crossPosts"]), expected: yamlFields(CrossPosts{})},
+		{name: "broadcasts", fields: properties(d["filter"].properties("broadcasts")), expected: yamlFields(Broadcasts{})},
+		{name: "rate", fields: properties(d["filter"].properties("rate")), expected: yamlFields(Rate{})},
+		{name: "score", fields: properties(d["filter"].properties("score")), expected: yamlFields(Score{})},
+		{name: "reactions", fields: properties(d["filter"].properties("reactions")), expected: yamlFields(Reactions{})},
+		{name: "strikes", fields: properties(d["strikes"]), expected: yamlFields(StrikesConfig{})},
+		{name: "strike level", fields: properties(d["strikes"].properties("levels").Items), expected: yamlFields(StrikeLevel{})},
+		{name: "actions", fields: d["action"].Enum, expected: knownActions},
+		{name: "severities", fields: d["severity"].Enum, expected: knownSeverities},
+		{name: "deliveries", fields: d["filter"].properties("delivery").Enum, expected: knownDeliveries},
+		{name: "detectors", fields: d["filter"].properties("detect").Items.Enum, expected: knownDetectors},
+		{name: "rule packs", fields: d["filter"].properties("rule_pack").Enum, expected: knownRulePacks},
+		{name: "match modes", fields: d["filter"].properties("match").Enum, expected: []string{MatchSubstring, MatchWord}},
+	}
+	for _, tc := range tests {
+		if !reflect.DeepEqual(tc.fields, tc.expected) {
+			t.Errorf("expected the schema's %s to be %q, but got %q", tc.name, tc.expected, tc.fields)
+		}
+	}
+}
+
+// properties returns the definition of the named property of def.
+func (def *schemaDefinition) properties(name string) *schemaDefinition {
+	property := &schemaDefinition{}
+	_ = json.Unmarshal(def.Properties[name], property)
+	return property
+}
+
+// yamlFields returns the sorted YAML names of v's fields.
+func yamlFields(v interface{}) []string {
+	var names []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		if tag := typ.Field(i).Tag.Get("yaml"); tag != "" {
+			names = append(names, strings.Split(tag, ",")[0])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestExampleFilters(t *testing.T) {
+	data, err := ioutil.ReadFile("../filters.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ParseConfig(data); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
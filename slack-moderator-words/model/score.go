@@ -0,0 +1,36 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "strings"
+
+// Score makes a filter match messages that an external classifier, such as the Perspective API,
+// gives a score of at least Threshold, between 0 and 1, for Attribute, such as "TOXICITY".
+type Score struct {
+	Attribute string  `yaml:"attribute"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+func (s Score) validate() *configError {
+	if strings.TrimSpace(s.Attribute) == "" {
+		return fieldError("score", -1, "has no attribute to score messages for")
+	}
+	if s.Threshold <= 0 || s.Threshold > 1 {
+		return fieldError("score", -1, "has a threshold of %v, which isn't more than 0 and at most 1", s.Threshold)
+	}
+	return nil
+}
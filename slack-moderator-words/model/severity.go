@@ -0,0 +1,55 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+// Severities of filters. A filter that doesn't list its own actions takes the actions of its
+// severity.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var knownSeverities = []string{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// DefaultSeverities are the actions taken for each severity, unless the filter config overrides
+// them: low severity matches are only logged, medium ones warn the author, high ones delete the
+// message too, and critical ones also tell the moderators.
+var DefaultSeverities = map[string][]string{
+	SeverityLow:      {ActionLog},
+	SeverityMedium:   {ActionWarn},
+	SeverityHigh:     {ActionDelete, ActionWarn},
+	SeverityCritical: {ActionDelete, ActionWarn, ActionEscalate},
+}
+
+// severitySteps returns the steps of a filter that takes the given severity actions. Steps that
+// would post the filter's message are left out if it doesn't have one, and escalation and digests
+// are left out unless canEscalate is set.
+func severitySteps(actions []string, message string, canEscalate bool) []string {
+	steps := make([]string, 0, len(actions))
+	for _, a := range actions {
+		if message == "" && IsWarning(a) {
+			continue
+		}
+		if (a == ActionEscalate || a == ActionDigest) && !canEscalate {
+			continue
+		}
+		steps = append(steps, a)
+	}
+	return steps
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+)
+
+// DefaultStrikeTTL is how long strikes count against a user, unless the filter config says
+// otherwise.
+const DefaultStrikeTTL = 30 * 24 * time.Hour
+
+// StrikesConfig configures what filters that count strikes do, depending on how many strikes the
+// author of a message has.
+type StrikesConfig struct {
+	// TTL is how long each strike counts against a user. It defaults to DefaultStrikeTTL.
+	TTL time.Duration `yaml:"ttl"`
+	// Levels are the actions taken once a user has at least the given number of strikes. They
+	// default to DefaultStrikeLevels.
+	Levels []StrikeLevel `yaml:"levels"`
+}
+
+// StrikeLevel is what happens to a message from a user with at least Strikes strikes, including
+// the one for this message.
+type StrikeLevel struct {
+	Strikes int      `yaml:"strikes"`
+	Actions []string `yaml:"actions"`
+}
+
+// DefaultStrikeLevels warn users on their first strike, delete their messages and tell the
+// moderators from the third, and kick them from the channel from the fifth.
+var DefaultStrikeLevels = []StrikeLevel{
+	{Strikes: 1, Actions: []string{ActionWarn}},
+	{Strikes: 3, Actions: []string{ActionDelete, ActionWarn, ActionEscalate}},
+	{Strikes: 5, Actions: []string{ActionDelete, ActionKick, ActionEscalate}},
+}
+
+// StrikeTTL returns how long each strike counts against a user.
+func (sc StrikesConfig) StrikeTTL() time.Duration {
+	if sc.TTL == 0 {
+		return DefaultStrikeTTL
+	}
+	return sc.TTL
+}
+
+// levels returns the configured strike levels, after checking that they make sense.
+func (sc StrikesConfig) levels() ([]StrikeLevel, error) {
+	if sc.TTL < 0 {
+		return nil, fieldError("strikes", -1, "strikes have a negative ttl")
+	}
+	if len(sc.Levels) == 0 {
+		return DefaultStrikeLevels, nil
+	}
+	previous := 0
+	for _, l := range sc.Levels {
+		if l.Strikes <= previous {
+			return nil, fieldError("strikes", -1, "strike levels must be for increasing numbers of strikes, starting from 1 or more")
+		}
+		for _, a := range l.Actions {
+			if !contains(knownActions, a) {
+				return nil, fieldError("strikes", -1, "strike level %d has an unknown action %q (expected one of %q)", l.Strikes, a, knownActions)
+			}
+		}
+		previous = l.Strikes
+	}
+	return sc.Levels, nil
+}
+
+// CountsStrikes returns whether the filter counts its matches as strikes against their authors,
+// and takes the actions for their strike level rather than its own.
+func (f Filter) CountsStrikes() bool {
+	return f.Strikes
+}
+
+// StrikeSteps returns the actions to take, in order, when the filter matches a message from a user
+// who now has the given number of strikes. Users with fewer strikes than the lowest level only
+// have the match logged.
+func (f Filter) StrikeSteps(strikes int) []string {
+	steps := []string{ActionLog}
+	for _, l := range f.strikeLevels {
+		if strikes >= l.Strikes {
+			steps = l.Actions
+		}
+	}
+	return steps
+}
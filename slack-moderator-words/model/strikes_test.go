@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrikeSteps(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectedSteps map[int][]string
+		expectedError string
+	}{
+		{
+			name: "default levels",
+			config: `
+moderators_channel: "#moderators"
+filters:
+- triggers: [a]
+  strikes: true
+  message: hi
+`,
+			expectedSteps: map[int][]string{
+				1: {ActionWarn},
+				2: {ActionWarn},
+				3: {ActionDelete, ActionWarn, ActionEscalate},
+				6: {ActionDelete, ActionKick, ActionEscalate},
+			},
+		},
+		{
+			name: "configured levels without a message or moderators channel",
+			config: `
+strikes:
+  ttl: 24h
+  levels:
+  - strikes: 2
+    actions: [warn]
+  - strikes: 4
+    actions: [delete, escalate]
+filters:
+- triggers: [a]
+  strikes: true
+`,
+			expectedSteps: map[int][]string{
+				1: {ActionLog},
+				2: {},
+				4: {ActionDelete},
+			},
+		},
+		{
+			name: "strikes and actions",
+			config: `
+- triggers: [a]
+  strikes: true
+  action: delete
+`,
+			expectedError: "counts strikes, so it can't also have an action",
+		},
+		{
+			name: "levels out of order",
+			config: `
+strikes:
+  levels:
+  - strikes: 3
+    actions: [delete]
+  - strikes: 1
+    actions: [warn]
+filters:
+- triggers: [a]
+  strikes: true
+`,
+			expectedError: "strike levels must be for increasing numbers of strikes",
+		},
+		{
+			name: "unknown action in a level",
+			config: `
+strikes:
+  levels:
+  - strikes: 1
+    actions: [ban]
+filters:
+- triggers: [a]
+  strikes: true
+`,
+			expectedError: `strike level 1 has an unknown action "ban"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := ParseConfig([]byte(tc.config))
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected an error containing %q, but got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for strikes, expected := range tc.expectedSteps {
+				if steps := config.Filters[0].StrikeSteps(strikes); !reflect.DeepEqual(steps, expected) {
+					t.Errorf("expected steps %v for %d strikes, but got %v", expected, strikes, steps)
+				}
+			}
+		})
+	}
+}
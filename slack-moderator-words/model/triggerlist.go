@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// triggerListSchemes are the kinds of URL trigger lists can be fetched from.
+var triggerListSchemes = []string{"https://", "http://", "s3://", "gs://"}
+
+// ParseTriggerList parses a trigger list, which has one trigger per line. Blank lines, and lines
+// starting with #, are ignored, as is space around each trigger.
+func ParseTriggerList(data []byte) []string {
+	var triggers []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		triggers = append(triggers, line)
+	}
+	return triggers
+}
+
+// TriggerLists returns the URLs of the trigger lists used by the config's filters, without
+// duplicates.
+func (c Config) TriggerLists() []string {
+	var urls []string
+	for _, f := range c.Filters {
+		for _, u := range f.TriggerLists {
+			if !contains(urls, u) {
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// WithTriggerLists returns a copy of the config, which must already have been compiled, whose
+// filters also match the triggers in their TriggerLists. lists maps the URL of each list to its
+// triggers; lists that are missing from it add no triggers.
+func (c Config) WithTriggerLists(lists map[string][]string) (Config, error) {
+	filters := make(FilterConfig, len(c.Filters))
+	copy(filters, c.Filters)
+	for i := range filters {
+		filters[i].listTriggers = nil
+		for _, u := range filters[i].TriggerLists {
+			filters[i].listTriggers = append(filters[i].listTriggers, lists[u]...)
+		}
+	}
+	c.Filters = filters
+	if err := c.Compile(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// validTriggerListURL returns whether u is a kind of URL that trigger lists can be fetched from.
+func validTriggerListURL(u string) bool {
+	for _, scheme := range triggerListSchemes {
+		if strings.HasPrefix(u, scheme) {
+			return true
+		}
+	}
+	return false
+}
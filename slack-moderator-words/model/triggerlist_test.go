@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTriggerList(t *testing.T) {
+	list := `# Shared scam blocklist
+
+free crypto
+  airdrop  
+# Retired: nitro
+`
+	expected := []string{"free crypto", "airdrop"}
+	if triggers := ParseTriggerList([]byte(list)); !reflect.DeepEqual(triggers, expected) {
+		t.Errorf("expected %q, but got %q", expected, triggers)
+	}
+}
+
+func TestWithTriggerLists(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+- trigger_lists: [https://example.com/scams.txt]
+  action: delete
+- triggers: [guys]
+  trigger_lists: [https://example.com/scams.txt, https://example.com/missing.txt]
+  action: warn
+  message: hi
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"https://example.com/scams.txt", "https://example.com/missing.txt"}; !reflect.DeepEqual(config.TriggerLists(), expected) {
+		t.Errorf("expected trigger lists %q, but got %q", expected, config.TriggerLists())
+	}
+	lists := map[string][]string{"https://example.com/scams.txt": {"Free Crypto"}}
+	withLists, err := config.WithTriggerLists(lists)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Applying the lists again doesn't add their triggers twice.
+	if withLists, err = withLists.WithTriggerLists(lists); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		filter   Filter
+		text     string
+		expected []string
+	}{
+		{filter: config.Filters[0], text: "free crypto here"},
+		{filter: withLists.Filters[0], text: "free crypto here", expected: []string{"Free Crypto"}},
+		{filter: withLists.Filters[1], text: "free crypto, guys", expected: []string{"guys", "Free Crypto"}},
+	}
+	for _, tc := range tests {
+		if matches := tc.filter.Matches(tc.text); !reflect.DeepEqual(matches, tc.expected) {
+			t.Errorf("expected %q to match %q, but got %q", tc.text, tc.expected, matches)
+		}
+	}
+}
@@ -16,6 +16,12 @@ limitations under the License.
 
 package model
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
 type Challenge struct {
 	Challenge string `json:"challenge"`
 }
@@ -42,6 +48,171 @@ type Event struct {
 	ThreadTS    string      `json:"thread_ts"`
 	ChannelType string      `json:"channel_type"`
 	BotID       string      `json:"bot_id"`
+	Subtype     string      `json:"subtype"`
+	// Files are the files shared in the message. Older file_share events have a single File, and
+	// the text the file was shared with in InitialComment.
+	Files          []File          `json:"files"`
+	File           *File           `json:"file"`
+	InitialComment *InitialComment `json:"initial_comment"`
+	// Attachments are legacy attachments, including the ones Slack adds when it unfurls a link, and
+	// Blocks are the message's Block Kit blocks.
+	Attachments []Attachment      `json:"attachments"`
+	Blocks      []json.RawMessage `json:"blocks"`
+	// Message and PreviousMessage are the new and old versions of an edited message, in
+	// SubtypeMessageChanged events.
+	Message         *Event `json:"message"`
+	PreviousMessage *Event `json:"previous_message"`
+	// Topic and Purpose are the new topic or purpose of the channel, in SubtypeChannelTopic and
+	// SubtypeChannelPurpose messages.
+	Topic   string `json:"topic"`
+	Purpose string `json:"purpose"`
+	// Reaction is the name of the emoji in reaction_added events, and Item is what was reacted to.
+	Reaction string        `json:"reaction"`
+	Item     *ReactionItem `json:"item"`
+}
+
+// ReactionItem is what a reaction was added to. Only reactions to messages have a Channel and TS.
+type ReactionItem struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// File is a file shared in a message.
+type File struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+}
+
+// InitialComment is the comment a file was shared with.
+type InitialComment struct {
+	Comment string `json:"comment"`
+}
+
+// Attachment is a legacy message attachment, such as a link unfurl.
+type Attachment struct {
+	Fallback   string            `json:"fallback"`
+	Pretext    string            `json:"pretext"`
+	AuthorName string            `json:"author_name"`
+	Title      string            `json:"title"`
+	Text       string            `json:"text"`
+	Fields     []AttachmentField `json:"fields"`
+	Footer     string            `json:"footer"`
+	Blocks     []json.RawMessage `json:"blocks"`
+}
+
+// AttachmentField is one of the fields shown in a table in an attachment.
+type AttachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// SubtypeMessageChanged is the subtype of message events about a message being edited.
+const SubtypeMessageChanged = "message_changed"
+
+// Subtypes of the messages Slack posts when someone changes a channel's topic or purpose.
+const (
+	SubtypeChannelTopic   = "channel_topic"
+	SubtypeChannelPurpose = "channel_purpose"
+)
+
+// Content returns everything in the message that filters check: its text, including the comment
+// of a file_share, me_message or thread_broadcast, the text in its blocks and attachments, such as
+// link unfurls, and the titles and names of the files shared in it, each on its own line. Text that
+// is repeated, such as the blocks Slack generates from the text of a message, is only included once.
+func (e Event) Content() string {
+	parts := []string{e.Text, e.Topic, e.Purpose}
+	if e.InitialComment != nil {
+		parts = append(parts, e.InitialComment.Comment)
+	}
+	parts = append(parts, blocksText(e.Blocks)...)
+	for _, a := range e.Attachments {
+		parts = append(parts, a.Pretext, a.AuthorName, a.Title, a.Text)
+		for _, f := range a.Fields {
+			parts = append(parts, f.Title, f.Value)
+		}
+		parts = append(parts, a.Footer, a.Fallback)
+		parts = append(parts, blocksText(a.Blocks)...)
+	}
+	files := e.Files
+	if e.File != nil {
+		files = append(files, *e.File)
+	}
+	for _, f := range files {
+		parts = append(parts, f.Title, f.Name)
+	}
+	content := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" && !repeats(content, p) {
+			content = append(content, p)
+		}
+	}
+	return strings.Join(content, "\n")
+}
+
+// repeats returns whether p is one of parts, or part of one of them.
+func repeats(parts []string, p string) bool {
+	for _, part := range parts {
+		if strings.Contains(part, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// blocksText returns the text in Block Kit blocks: every "text" string in them, at any depth, which
+// covers the text of sections, headers, context elements, rich text and buttons. Blocks that can't
+// be decoded are skipped.
+func blocksText(blocks []json.RawMessage) []string {
+	var text []string
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			// Go in a fixed order, so that the same blocks always have the same content.
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				if s, ok := v[key].(string); ok && key == "text" {
+					text = append(text, s)
+					continue
+				}
+				walk(v[key])
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	for _, b := range blocks {
+		var block interface{}
+		if err := json.Unmarshal(b, &block); err != nil {
+			continue
+		}
+		walk(block)
+	}
+	return text
+}
+
+// Edited returns the new version of the message, in the channel it is in, if e is about a message
+// being edited. Edits that don't change the content of the message don't count, so that it isn't
+// moderated twice.
+func (e Event) Edited() (Event, bool) {
+	if e.Subtype != SubtypeMessageChanged || e.Message == nil {
+		return Event{}, false
+	}
+	if e.PreviousMessage != nil && e.PreviousMessage.Content() == e.Message.Content() {
+		return Event{}, false
+	}
+	edited := *e.Message
+	edited.Channel = e.Channel
+	edited.ChannelType = e.ChannelType
+	return edited, true
 }
 
 type Channel struct {
@@ -50,9 +221,3 @@ type Channel struct {
 	Creator string `json:"creator"`
 	Created int    `json:"created"`
 }
-
-type FilterConfig []struct {
-	Triggers []string `yaml:"triggers"`
-	Action   string   `yaml:"action"`
-	Message  string   `yaml:"message"`
-}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    Event
+		expected string
+	}{
+		{
+			name:     "plain message",
+			event:    Event{Text: "hello"},
+			expected: "hello",
+		},
+		{
+			name:     "me_message and thread_broadcast",
+			event:    Event{Subtype: "thread_broadcast", Text: "waves"},
+			expected: "waves",
+		},
+		{
+			name:     "file_share",
+			event:    Event{Subtype: "file_share", Text: "see attached", Files: []File{{Name: "free-crypto.txt", Title: "Free crypto"}, {Name: "notes.txt", Title: "notes.txt"}}},
+			expected: "see attached\nFree crypto\nfree-crypto.txt\nnotes.txt",
+		},
+		{
+			name:     "channel topic",
+			event:    Event{Subtype: SubtypeChannelTopic, Text: "set the channel topic: Claim at <https://evil.example>", Topic: "Claim at <https://evil.example>"},
+			expected: "set the channel topic: Claim at <https://evil.example>",
+		},
+		{
+			name:     "older file_share",
+			event:    Event{Subtype: "file_share", File: &File{Title: "Airdrop"}, InitialComment: &InitialComment{Comment: "claim now"}},
+			expected: "claim now\nAirdrop",
+		},
+		{
+			name: "link unfurl",
+			event: Event{Text: "look at <https://example.com>", Attachments: []Attachment{{
+				Title:    "Example",
+				Text:     "Free crypto",
+				Fields:   []AttachmentField{{Title: "Price", Value: "0"}},
+				Footer:   "example.com",
+				Fallback: "Example",
+			}}},
+			expected: "look at <https://example.com>\nExample\nFree crypto\nPrice\n0",
+		},
+		{
+			name: "blocks",
+			event: Event{
+				Text:   "hello there",
+				Blocks: []json.RawMessage{[]byte(`{"type": "rich_text", "elements": [{"type": "rich_text_section", "elements": [{"type": "text", "text": "hello there"}]}]}`)},
+				Attachments: []Attachment{{Blocks: []json.RawMessage{
+					[]byte(`{"type": "section", "text": {"type": "mrkdwn", "text": "Claim now"}, "accessory": {"type": "button", "text": {"type": "plain_text", "text": "Open"}}}`),
+					[]byte(`not json`),
+				}}},
+			},
+			expected: "hello there\nOpen\nClaim now",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if content := tc.event.Content(); content != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, content)
+			}
+		})
+	}
+}
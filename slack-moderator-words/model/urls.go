@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// urlPattern finds links in text. Slack sends links as <url> or <url|label>, which the pattern
+// stops at, but links in attachments and files are usually bare.
+var urlPattern = regexp.MustCompile(`(?i)\bhttps?://[^\s<>|"'` + "`" + `]+`)
+
+// trackingParameters are query parameters that only track where a link was shared, and are
+// stripped from links so that they don't hide which links are the same. Parameters starting with
+// utm_ are stripped too.
+var trackingParameters = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"mkt_tok": true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+	"ref_src": true,
+}
+
+// shortLinkHosts are link shorteners, whose links hide the domain they lead to.
+var shortLinkHosts = []string{
+	"bit.ly",
+	"bitly.com",
+	"buff.ly",
+	"cutt.ly",
+	"goo.gl",
+	"is.gd",
+	"lnkd.in",
+	"ow.ly",
+	"rb.gy",
+	"rebrand.ly",
+	"s.id",
+	"shorturl.at",
+	"t.co",
+	"t.ly",
+	"tiny.cc",
+	"tinyurl.com",
+	"v.gd",
+}
+
+// ExtractURLs returns the http and https links in text, normalized by NormalizeURL, without
+// duplicates.
+func ExtractURLs(text string) []*url.URL {
+	var urls []*url.URL
+	seen := map[string]bool{}
+	for _, raw := range urlPattern.FindAllString(text, -1) {
+		// Punctuation straight after a link is almost always the end of a sentence.
+		raw = strings.TrimRight(raw, ".,;:!?)]}*_~")
+		u, ok := NormalizeURL(raw)
+		if !ok || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// NormalizeURL parses an http or https link and puts it in a canonical form: the host is lowercased
+// and converted to punycode, and the default port, any user info, the fragment and tracking query
+// parameters are removed.
+func NormalizeURL(raw string) (*url.URL, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return nil, false
+	}
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host = net.JoinHostPort(host, port)
+	}
+	u.User = nil
+	u.Fragment, u.RawFragment = "", ""
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if trackingParameters[strings.ToLower(key)] || strings.HasPrefix(strings.ToLower(key), "utm_") {
+				query.Del(key)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u, true
+}
+
+// normalizeHost returns host lowercased and in punycode, or an empty string if it isn't a valid
+// host name.
+func normalizeHost(host string) string {
+	host, err := idna.ToASCII(strings.TrimSuffix(strings.ToLower(host), "."))
+	if err != nil {
+		return ""
+	}
+	return host
+}
+
+// IsShortLink returns whether u is a link shortener's link.
+func IsShortLink(u *url.URL) bool {
+	for _, d := range shortLinkHosts {
+		if inDomain(u.Hostname(), d) {
+			return true
+		}
+	}
+	return false
+}
+
+// inDomain returns whether host is domain or one of its subdomains.
+func inDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// inAnyDomain returns whether host is in any of domains.
+func inAnyDomain(host string, domains []string) bool {
+	for _, d := range domains {
+		if inDomain(host, d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "Slack links",
+			text:     "see <https://example.com/a|example.com/a> and <http://example.org>",
+			expected: []string{"https://example.com/a", "http://example.org"},
+		},
+		{
+			name:     "bare links",
+			text:     "go to https://example.com/claim. Or (https://example.org/x)!",
+			expected: []string{"https://example.com/claim", "https://example.org/x"},
+		},
+		{
+			name:     "duplicates",
+			text:     "https://example.com/?utm_source=a https://EXAMPLE.com/#top",
+			expected: []string{"https://example.com/"},
+		},
+		{
+			name: "no links",
+			text: "mailto:someone@example.com ftp://example.com example.com",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var actual []string
+			for _, u := range ExtractURLs(tc.text) {
+				actual = append(actual, u.String())
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "already normal",
+			url:      "https://example.com/path?q=1",
+			expected: "https://example.com/path?q=1",
+		},
+		{
+			name:     "case, default port and fragment",
+			url:      "HTTPS://Example.COM.:443/Path#section",
+			expected: "https://example.com/Path",
+		},
+		{
+			name:     "other port",
+			url:      "http://example.com:8080/",
+			expected: "http://example.com:8080/",
+		},
+		{
+			name:     "user info hiding the host",
+			url:      "https://kubernetes.io@evil.example/login",
+			expected: "https://evil.example/login",
+		},
+		{
+			name:     "tracking parameters",
+			url:      "https://example.com/?utm_source=slack&UTM_Campaign=x&fbclid=abc&id=7",
+			expected: "https://example.com/?id=7",
+		},
+		{
+			name:     "internationalized host",
+			url:      "https://bücher.example/",
+			expected: "https://xn--bcher-kva.example/",
+		},
+		{
+			name: "not a web link",
+			url:  "javascript:alert(1)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			u, ok := NormalizeURL(tc.url)
+			if tc.expected == "" {
+				if ok {
+					t.Errorf("expected no URL, but got %s", u)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("expected %s, but got no URL", tc.expected)
+			}
+			if u.String() != tc.expected {
+				t.Errorf("expected %s, but got %s", tc.expected, u)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// userActions are the actions that filters that check users can take, since the others act on a
+// message.
+var userActions = []string{ActionWarn, ActionLog, ActionEscalate, ActionDeactivate, ActionRename, ActionDigest}
+
+// ChecksNames returns whether the filter checks users' display names and real names.
+func (f Filter) ChecksNames() bool {
+	return f.Names || len(f.ProtectedNames) > 0
+}
+
+// ChecksUsers returns whether the filter checks users' names or profiles, rather than messages.
+func (f Filter) ChecksUsers() bool {
+	return f.ChecksNames() || f.Profiles
+}
+
+// MatchesName returns the triggers in a user's name, the regexes that match it and the protected
+// names it looks like.
+func (f Filter) MatchesName(name string) []string {
+	matches := f.Matches(name)
+	s := nameSkeleton(name)
+	for i, p := range f.protectedNames {
+		if strings.Contains(s, p) || distance(s, p) <= maxDistance(p) {
+			matches = append(matches, f.ProtectedNames[i])
+		}
+	}
+	return matches
+}
+
+// nameSkeleton returns how a name looks, like skeleton does for hosts, with everything but letters
+// and digits left out, so "Kubernetes-Adrnin" becomes "kubernetesadmin".
+func nameSkeleton(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, confusables.Replace(Deobfuscate(fold(name))))
+}
+
+// validateUserActions checks that a filter that checks users only takes actions that don't need a
+// message, and that only filters that check names rename users.
+func (f *Filter) validateUserActions(field string, steps []string) *configError {
+	for j, a := range steps {
+		item := -1
+		if field == "actions" {
+			item = j
+		}
+		if f.ChecksUsers() && !contains(userActions, a) {
+			return fieldError(field, item, "checks users rather than messages, so it can't %s (expected one of %q)", a, userActions)
+		}
+		if !f.ChecksNames() && a == ActionRename {
+			return fieldError(field, item, "renames users, but only filters that check names can")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterMatchesName(t *testing.T) {
+	filters := FilterConfig{{ProtectedNames: []string{"Kubernetes Admin"}, Triggers: []string{"nazi"}, Match: MatchWord, Action: ActionEscalate}}
+	if err := filters.compile(DefaultSeverities, DefaultStrikeLevels, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter := filters[0]
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "Kubernetes Admin", expected: []string{"Kubernetes Admin"}},
+		{name: "kubernetes_admin", expected: []string{"Kubernetes Admin"}},
+		{name: "Kubernetes Adrnin 🛡", expected: []string{"Kubernetes Admin"}},
+		{name: "Кubеrnеtеs Аdmin", expected: []string{"Kubernetes Admin"}},
+		{name: "Kubernets Admin", expected: []string{"Kubernetes Admin"}},
+		{name: "Official Kubernetes Admin Team", expected: []string{"Kubernetes Admin"}},
+		{name: "N4ZI", expected: []string{"nazi"}},
+		{name: "Kubernetes fan"},
+		{name: "Jane Doe"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := filter.MatchesName(tc.name); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configError is a problem with part of a filter config. It records which part, so that
+// ParseConfig can say which line of the file the problem is on.
+type configError struct {
+	// filter is the index of the filter with the problem, or -1 if it is elsewhere in the config.
+	filter int
+	// field is the YAML name of the field with the problem, if there is one, and item is the index
+	// of the item with the problem if the field is a list, or -1.
+	field string
+	item  int
+	err   error
+}
+
+// fieldError returns a configError about field, or about its item'th item unless item is -1.
+func fieldError(field string, item int, format string, args ...interface{}) *configError {
+	return &configError{filter: -1, field: field, item: item, err: fmt.Errorf(format, args...)}
+}
+
+// inFilter returns e, as a problem with the filter with the given index.
+func (e *configError) inFilter(index int) *configError {
+	e.filter = index
+	return e
+}
+
+func (e *configError) Error() string {
+	if e.filter < 0 {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("filter %d %v", e.filter+1, e.err)
+}
+
+// line returns the line that e is about in the config file parsed into root, or 0 if it can't
+// be found.
+func (e *configError) line(root *yaml.Node) int {
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return 0
+		}
+		root = root.Content[0]
+	}
+	node := root
+	if e.filter >= 0 {
+		filters := root
+		if root.Kind == yaml.MappingNode {
+			_, filters = mappingValue(root, "filters")
+		}
+		if filters == nil || filters.Kind != yaml.SequenceNode || e.filter >= len(filters.Content) {
+			return 0
+		}
+		node = filters.Content[e.filter]
+	}
+	if e.field == "" {
+		return node.Line
+	}
+	key, value := mappingValue(node, e.field)
+	switch {
+	case key == nil:
+		return node.Line
+	case e.item >= 0 && value.Kind == yaml.SequenceNode && e.item < len(value.Content):
+		return value.Content[e.item].Line
+	default:
+		return key.Line
+	}
+}
+
+// mappingValue returns the key and value nodes for key in the mapping node, or nils if it isn't
+// there.
+func mappingValue(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// base58Alphabet is the alphabet of legacy Bitcoin addresses, which leaves out 0, O, I and l.
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// bech32Alphabet is the alphabet of SegWit Bitcoin addresses.
+const bech32Alphabet = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// isBitcoinAddress returns whether s is a Bitcoin address with a valid checksum, so that random
+// strings that happen to look like one, such as hashes, aren't mistaken for one.
+func isBitcoinAddress(s string) bool {
+	if strings.HasPrefix(strings.ToLower(s), "bc1") {
+		return isBech32("bc", strings.ToLower(s[3:]))
+	}
+	return isBase58Check(s)
+}
+
+// isBase58Check returns whether s is a legacy address: 25 bytes in base58, the last 4 of which are
+// the start of the double SHA-256 of the rest.
+func isBase58Check(s string) bool {
+	n := new(big.Int)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return false
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	// Each leading 1 is a leading zero byte, which the number loses.
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	decoded := append(make([]byte, zeros), n.Bytes()...)
+	if len(decoded) != 25 {
+		return false
+	}
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	return bytes.Equal(second[:4], decoded[21:])
+}
+
+// isBech32 returns whether data, the part of an address after hrp and its separator, has a valid
+// bech32 or bech32m checksum.
+func isBech32(hrp, data string) bool {
+	values := make([]byte, 0, len(hrp)*2+1+len(data))
+	for _, c := range hrp {
+		values = append(values, byte(c>>5))
+	}
+	values = append(values, 0)
+	for _, c := range hrp {
+		values = append(values, byte(c&31))
+	}
+	for _, c := range data {
+		i := strings.IndexRune(bech32Alphabet, c)
+		if i < 0 {
+			return false
+		}
+		values = append(values, byte(i))
+	}
+	checksum := bech32Polymod(values)
+	return checksum == 1 || checksum == 0x2bc830a3
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, v := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if (top>>i)&1 == 1 {
+				checksum ^= g
+			}
+		}
+	}
+	return checksum
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestIsBitcoinAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected bool
+	}{
+		{address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", expected: true},
+		{address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", expected: true},
+		{address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", expected: true},
+		{address: "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", expected: true},
+		{address: "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", expected: true},
+		{address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"},
+		{address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdp"},
+		{address: "3f2a9b1c4d5e6f7a8b9c1d2e3f4a5b6c"},
+	}
+
+	for _, tc := range tests {
+		if actual := isBitcoinAddress(tc.address); actual != tc.expected {
+			t.Errorf("expected %v for %s, but got %v", tc.expected, tc.address, actual)
+		}
+	}
+}
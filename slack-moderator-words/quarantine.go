@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+)
+
+// actionRestoreMessage is the action ID of the button that restores a quarantined message.
+const actionRestoreMessage = "restore_message"
+
+// quarantine copies a message that is about to be deleted into the quarantine channel, with who
+// posted it, where, and what it matched, and buttons to restore it or dismiss it. The copy's text
+// is the message's content, which is what restoring it posts.
+func (h *handler) quarantine(ctx context.Context, client *slack.Client, m *match) error {
+	quarantine, err := client.Channels().ID(ctx, m.rules.quarantine)
+	if err != nil {
+		return fmt.Errorf("failed to find quarantine channel %q: %v", m.rules.quarantine, err)
+	}
+	content := m.event.Content()
+	b := quarantineBlocks(m, content)
+	if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: quarantine, Text: content, Blocks: b}); err != nil {
+		return fmt.Errorf("failed to quarantine message: %v", err)
+	}
+	return nil
+}
+
+// quarantineBlocks returns the blocks of the copy of m's message, with the given content, in the
+// quarantine channel.
+func quarantineBlocks(m *match, content string) []blocks.Block {
+	channel, _ := m.event.Channel.(string)
+	text := fmt.Sprintf("<@%s> posted a message in <#%s> that a filter deleted", m.event.User, channel)
+	triggers := make([]string, 0, len(m.triggers))
+	for _, t := range m.triggers {
+		triggers = append(triggers, "`"+t+"`")
+	}
+	fields := []*blocks.Text{blocks.Markdown("*Matched*\n" + strings.Join(triggers, ", "))}
+	if m.permalink != "" {
+		fields = append(fields, blocks.Markdown("*Permalink*\n"+m.permalink))
+	}
+	// The thread, if any, is kept so that the message can be restored to it.
+	value := channel + "/" + m.event.User + "/" + m.event.ThreadTS
+	restore := blocks.Button(actionRestoreMessage, "Restore", value)
+	restore.Style = blocks.StylePrimary
+	return []blocks.Block{
+		blocks.Section(blocks.Markdown(":file_folder: "+text+":\n"+quote(content)), fields...),
+		blocks.Actions(restore, blocks.Button(actionDismissEscalation, "Dismiss", value)),
+	}
+}
+
+// handleQuarantine registers the handlers for the buttons on quarantined messages with ih. They
+// are dismissed like escalations.
+func handleQuarantine(ih *interactive.Handler) {
+	ih.HandleFunc(interactive.TypeBlockActions, actionRestoreMessage, handleRestoreMessage)
+}
+
+// handleRestoreMessage posts a quarantined message back where it was deleted from when a moderator
+// decides it shouldn't have been. Only its author can post as them, so it is posted by the bot,
+// crediting them.
+func handleRestoreMessage(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+	value := p.Action(actionRestoreMessage).Value.Value
+	parts := strings.SplitN(value, "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed quarantined message reference %q", value)
+	}
+	channel, user, thread := parts[0], parts[1], parts[2]
+	logging.FromContext(ctx).Info("Restoring quarantined message", "message_channel", channel, "author_id", user)
+	_, err := api.New(p.Client()).PostMessage(ctx, api.PostMessageRequest{
+		Channel:  channel,
+		Text:     fmt.Sprintf("A message from <@%s> was removed by mistake, and has been restored:\n%s", user, quote(p.Message.Text)),
+		ThreadTS: thread,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore quarantined message: %v", err)
+	}
+	return nil, resolveEscalation(ctx, p, fmt.Sprintf(":recycle: Restored by <@%s>", p.User.ID))
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// quarantineServer returns a server that records the methods called on it, and the channel, thread
+// and text of the messages posted with them.
+func quarantineServer(calls *[]string, posted *[]api.PostMessageRequest) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := strings.TrimPrefix(r.URL.Path, "/api/")
+		*calls = append(*calls, method)
+		w.Header().Set("Content-Type", "application/json")
+		switch method {
+		case "chat.getPermalink":
+			_, _ = w.Write([]byte(`{"ok": true, "permalink": "https://example.slack.com/archives/C1/p1612790186002000"}`))
+			return
+		case "chat.postMessage":
+			req := api.PostMessageRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			*posted = append(*posted, req)
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+}
+
+func TestQuarantine(t *testing.T) {
+	tests := []struct {
+		name          string
+		quarantine    string
+		expectedCalls []string
+	}{
+		{
+			name:          "quarantined before deleting",
+			quarantine:    "G0QUARANTINE",
+			expectedCalls: []string{"chat.getPermalink", "conversations.list", "chat.postMessage", "chat.delete"},
+		},
+		{
+			name:          "no quarantine channel",
+			expectedCalls: []string{"chat.delete"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var posted []api.PostMessageRequest
+			server := quarantineServer(&calls, &posted)
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			h := newHandler(nil, model.Config{QuarantineChannel: tc.quarantine})
+			h.runActions(context.Background(), client, &match{
+				rules:    h.rules(),
+				steps:    []string{model.ActionDelete},
+				event:    model.Event{Channel: "C1", User: "U1", Text: "free crypto", TS: "1612790186.002000", Files: []model.File{{Name: "claim.txt"}}},
+				triggers: []string{"free crypto"},
+			})
+			if !reflect.DeepEqual(calls, tc.expectedCalls) {
+				t.Errorf("expected calls %v, but got %v", tc.expectedCalls, calls)
+			}
+			if tc.quarantine == "" {
+				return
+			}
+			if len(posted) != 1 || posted[0].Channel != tc.quarantine || posted[0].Text != "free crypto\nclaim.txt" {
+				t.Errorf("expected the message's content to be posted to %s, but posted %+v", tc.quarantine, posted)
+			}
+		})
+	}
+}
+
+func TestRestoreMessage(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		expectedThread string
+	}{
+		{
+			name:  "message in a channel",
+			value: "C1/U1/",
+		},
+		{
+			name:           "reply in a thread",
+			value:          "C1/U1/1612790100.000100",
+			expectedThread: "1612790100.000100",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var posted []api.PostMessageRequest
+			server := quarantineServer(&calls, &posted)
+			defer server.Close()
+			client := testClient(server)
+
+			ih := interactive.NewHandler(slack.NewClientSet(client))
+			handleQuarantine(ih)
+			payload := `{
+				"type": "block_actions",
+				"team": {"id": "T1"},
+				"user": {"id": "U0MOD"},
+				"response_url": "https://hooks.slack.com/actions/T1/1/abc",
+				"message": {"text": "free crypto", "blocks": []},
+				"actions": [{"action_id": "` + actionRestoreMessage + `", "value": "` + tc.value + `"}]
+			}`
+			if _, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := []string{"chat.postMessage", "/actions/T1/1/abc"}
+			if !reflect.DeepEqual(calls, expected) {
+				t.Errorf("expected calls %v, but got %v", expected, calls)
+			}
+			if len(posted) != 1 || posted[0].Channel != "C1" || posted[0].ThreadTS != tc.expectedThread || !strings.Contains(posted[0].Text, "<@U1>") || !strings.Contains(posted[0].Text, "> free crypto") {
+				t.Errorf("expected the message to be restored to C1 in thread %q, but posted %+v", tc.expectedThread, posted)
+			}
+		})
+	}
+}
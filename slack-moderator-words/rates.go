@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// maxTrackedMessages is how many recent messages of each user we count, so that a flood can't use
+// up all our memory. Filters can't usefully limit users to more than this.
+const maxTrackedMessages = 1000
+
+// rates counts how many messages users have posted recently, so that filters can tell when
+// someone is flooding the workspace.
+type rates struct {
+	lock     sync.Mutex
+	times    map[rateKey][]time.Time
+	prunedAt time.Time
+	now      func() time.Time
+}
+
+type rateKey struct {
+	client *slack.Client
+	user   string
+}
+
+func newRates() *rates {
+	return &rates{times: map[rateKey][]time.Time{}, now: time.Now}
+}
+
+// add counts a message posted by user now, remembering it for keep, and returns when it was posted.
+func (r *rates) add(client *slack.Client, user string, keep time.Duration) time.Time {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := r.now()
+	if now.Sub(r.prunedAt) > keep {
+		for k, times := range r.times {
+			if len(times) == 0 || now.Sub(times[len(times)-1]) > keep {
+				delete(r.times, k)
+			}
+		}
+		r.prunedAt = now
+	}
+	key := rateKey{client, user}
+	times := r.times[key]
+	for len(times) > 0 && now.Sub(times[0]) > keep {
+		times = times[1:]
+	}
+	times = append(times, now)
+	if len(times) > maxTrackedMessages {
+		times = times[len(times)-maxTrackedMessages:]
+	}
+	r.times[key] = times
+	return now
+}
+
+// count returns how many messages user posted in the window up to and including the one posted at.
+func (r *rates) count(client *slack.Client, user string, at time.Time, window time.Duration) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	n := 0
+	for _, t := range r.times[rateKey{client, user}] {
+		if !t.After(at) && at.Sub(t) < window {
+			n++
+		}
+	}
+	return n
+}
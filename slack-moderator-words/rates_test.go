@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestRateFlood(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if method := strings.TrimPrefix(r.URL.Path, "/api/"); method == "chat.delete" || method == "chat.postMessage" {
+			calls = append(calls, method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+	config, err := model.ParseConfig([]byte(`
+moderators_channel: C0MODERATORS
+filters:
+- rate: {messages: 3, per: 30s}
+  actions: [delete, escalate]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(nil, config)
+	now := time.Now()
+	h.rates.now = func() time.Time { return now }
+
+	// The fourth message in 30 seconds is escalated, and every one after that is only deleted,
+	// until the user slows down.
+	steps := []struct {
+		after    time.Duration
+		expected []string
+	}{
+		{},
+		{after: time.Second},
+		{after: time.Second},
+		{after: time.Second, expected: []string{"chat.delete", "chat.postMessage"}},
+		{after: time.Second, expected: []string{"chat.delete"}},
+		{after: 40 * time.Second},
+	}
+	for i, step := range steps {
+		calls = nil
+		now = now.Add(step.after)
+		body := fmt.Sprintf(`{"event": {"type": "message", "channel": "C%d", "user": "U1", "text": "hello", "ts": "1612790186.00%d"}}`, i+1, i)
+		if err := h.handleMessage(context.Background(), client, []byte(body)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(calls, step.expected) {
+			t.Errorf("expected message %d to make calls %v, but got %v", i+1, step.expected, calls)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// handleReactionAdded moderates messages that people react to with the emoji of filters with
+// reactions, such as a :report: emoji that members use to tell moderators about a message.
+// Slack Event needed for this: reaction_added
+func (h *handler) handleReactionAdded(ctx context.Context, client *slack.Client, body []byte) error {
+	event := &model.SlackEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
+	}
+	item := event.Event.Item
+	if item == nil || item.Type != "message" || item.Channel == "" {
+		return nil
+	}
+
+	r := h.rules()
+	var message *api.Message
+	channelName, resolved := "", false
+	for i, filter := range r.filters {
+		if filter.Reactions == nil || !filter.Reactions.Includes(event.Event.Reaction) {
+			continue
+		}
+		if filter.Scoped() {
+			if !resolved {
+				channelName, resolved = h.channelName(ctx, client, item.Channel), true
+			}
+			if !filter.AppliesTo(item.Channel, channelName) {
+				continue
+			}
+		}
+		// Reactions don't say who posted the message or what it says, so look it up.
+		if message == nil {
+			m, err := api.New(client).GetReactions(ctx, item.Channel, item.TS)
+			if err != nil {
+				return fmt.Errorf("failed to get reactions to %s: %v", item.TS, err)
+			}
+			message = &m
+		}
+		// Only act on the reaction that brings the message up to the count, rather than each one
+		// after it too.
+		reactors := reactors(*message, *filter.Reactions)
+		if len(reactors) != filter.Reactions.MinCount() {
+			continue
+		}
+		if h.exempt(ctx, client, filter, message.User) {
+			logging.FromContext(ctx).Debug("User is exempt from matching filter", "ts", message.TS, "user_id", message.User, "reactors", reactors)
+			continue
+		}
+		e := model.Event{Type: "message", Channel: item.Channel, User: message.User, Text: message.Text, TS: message.TS, ThreadTS: message.ThreadTS, BotID: message.BotID}
+		m := &match{rules: r, index: i, filter: filter, event: e, triggers: []string{":" + model.ReactionName(event.Event.Reaction) + ":"}}
+		m.steps, m.strikes = h.steps(ctx, m)
+		logging.FromContext(ctx).Info("Message was reacted to", "ts", message.TS, "user_id", message.User, "reaction", event.Event.Reaction, "reactors", reactors, "severity", filter.Severity, "actions", m.steps)
+		h.runActions(ctx, client, m)
+		if m.takes(model.ActionDelete) {
+			break
+		}
+	}
+	return nil
+}
+
+// reactors returns the users, other than its author, who have reacted to the message with any of
+// the emoji in reactions, without duplicates.
+func reactors(message api.Message, reactions model.Reactions) []string {
+	var users []string
+	seen := map[string]bool{message.User: true}
+	for _, r := range message.Reactions {
+		if !reactions.Includes(r.Name) {
+			continue
+		}
+		for _, u := range r.Users {
+			if !seen[u] {
+				seen[u] = true
+				users = append(users, u)
+			}
+		}
+	}
+	return users
+}
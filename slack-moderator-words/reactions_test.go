@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestReactionFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		reaction  string
+		item      string
+		reactions string
+		expected  []string
+	}{
+		{
+			name:      "first report",
+			reaction:  "report",
+			reactions: `[{"name": "report", "users": ["U2"], "count": 1}, {"name": "+1", "users": ["U3", "U4"], "count": 2}]`,
+			expected:  []string{"reactions.get", "chat.getPermalink", "chat.postMessage"},
+		},
+		{
+			name:      "already reported",
+			reaction:  "report",
+			reactions: `[{"name": "report", "users": ["U2", "U3"], "count": 2}]`,
+			expected:  []string{"reactions.get"},
+		},
+		{
+			name:      "author reporting themselves",
+			reaction:  "report",
+			reactions: `[{"name": "report", "users": ["U1"], "count": 1}]`,
+			expected:  []string{"reactions.get"},
+		},
+		{
+			name:      "with a skin tone",
+			reaction:  "report::skin-tone-3",
+			reactions: `[{"name": "report::skin-tone-3", "users": ["U2"], "count": 1}]`,
+			expected:  []string{"reactions.get", "chat.getPermalink", "chat.postMessage"},
+		},
+		{
+			name:     "other emoji",
+			reaction: "tada",
+		},
+		{
+			name:     "reaction to a file",
+			reaction: "report",
+			item:     `{"type": "file", "file": "F1"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.URL.Path {
+				case "/api/users.info", "/api/conversations.list":
+					_, _ = w.Write([]byte(`{"ok": true}`))
+					return
+				case "/api/reactions.get":
+					_ = r.ParseForm()
+					if channel, ts := r.Form.Get("channel"), r.Form.Get("timestamp"); channel != "C1" || ts != "1612790186.002000" {
+						t.Errorf("expected reactions to C1/1612790186.002000, but got %s/%s", channel, ts)
+					}
+					_, _ = w.Write([]byte(`{"ok": true, "type": "message", "channel": "C1", "message": {"type": "message", "user": "U1", "text": "I can help, DM me", "ts": "1612790186.002000", "reactions": ` + tc.reactions + `}}`))
+				default:
+					_, _ = w.Write([]byte(`{"ok": true, "permalink": "https://example.slack.com/archives/C1/p1612790186002000"}`))
+				}
+				calls = append(calls, r.URL.Path[len("/api/"):])
+			}))
+			defer server.Close()
+			client := testClient(server)
+
+			config, err := model.ParseConfig([]byte(`
+moderators_channel: C0MODERATORS
+filters:
+- reactions:
+    emoji: [":report:"]
+  action: escalate
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, config)
+			item := tc.item
+			if item == "" {
+				item = `{"type": "message", "channel": "C1", "ts": "1612790186.002000"}`
+			}
+			body := `{"event": {"type": "reaction_added", "user": "U2", "reaction": "` + tc.reaction + `", "item_user": "U1", "item": ` + item + `}}`
+			if err := h.handleReactionAdded(context.Background(), client, []byte(body)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// How much of the text of messages is logged, for --log-text.
+const (
+	logTextFull     = "full"
+	logTextTruncate = "truncate"
+	logTextHash     = "hash"
+)
+
+// truncatedLength is how many characters of each text are logged with logTextTruncate.
+const truncatedLength = 20
+
+// redactor cuts down the text of messages, and the parts of them that filters matched, before they
+// are logged, for deployments that mustn't keep what people say. The zero redactor logs them in
+// full.
+type redactor string
+
+// newRedactor returns a redactor for one of logTextFull, logTextTruncate or logTextHash.
+func newRedactor(mode string) (redactor, error) {
+	switch mode {
+	case logTextFull, logTextTruncate, logTextHash:
+		return redactor(mode), nil
+	}
+	return "", fmt.Errorf("unknown --log-text %q (expected %s, %s or %s)", mode, logTextFull, logTextTruncate, logTextHash)
+}
+
+// text returns what to log of text. Hashes are the start of the hash the match store keeps, so
+// logged matches can be found in it.
+func (r redactor) text(text string) string {
+	switch r {
+	case logTextTruncate:
+		if utf8.RuneCountInString(text) <= truncatedLength {
+			return text
+		}
+		return string([]rune(text)[:truncatedLength]) + fmt.Sprintf("… (%d characters)", utf8.RuneCountInString(text))
+	case logTextHash:
+		if text == "" {
+			return ""
+		}
+		return "sha256:" + hashText(text)[:12]
+	}
+	return text
+}
+
+// texts returns what to log of each of texts.
+func (r redactor) texts(texts []string) []string {
+	if r == "" || r == logTextFull {
+		return texts
+	}
+	redacted := make([]string, 0, len(texts))
+	for _, t := range texts {
+		redacted = append(redacted, r.text(t))
+	}
+	return redacted
+}
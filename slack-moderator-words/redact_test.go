@@ -0,0 +1,111 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestRedactor(t *testing.T) {
+	long := "Claim your free crypto at https://evil.example/claim"
+	tests := []struct {
+		mode     string
+		expected []string
+	}{
+		{
+			mode:     logTextFull,
+			expected: []string{long, "free crypto", ""},
+		},
+		{
+			mode:     logTextTruncate,
+			expected: []string{"Claim your free cryp… (52 characters)", "free crypto", ""},
+		},
+		{
+			mode:     logTextHash,
+			expected: []string{"sha256:" + hashText(long)[:12], "sha256:" + hashText("free crypto")[:12], ""},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.mode, func(t *testing.T) {
+			r, err := newRedactor(tc.mode)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if actual := r.texts([]string{long, "free crypto", ""}); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRedactorZeroValueLogsInFull(t *testing.T) {
+	var r redactor
+	if actual := r.text("free crypto"); actual != "free crypto" {
+		t.Errorf("expected the text in full, but got %q", actual)
+	}
+}
+
+func TestNewRedactorRejectsUnknownModes(t *testing.T) {
+	if _, err := newRedactor("none"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestHandleMessageRedactsDebugLogs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	config, err := model.ParseConfig([]byte(`filters: [{triggers: [crypto], action: log}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(nil, config)
+	if h.redact, err = newRedactor(logTextHash); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var logs bytes.Buffer
+	ctx := logging.NewContext(context.Background(), slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	text := "Claim your free crypto at https://evil.example/claim"
+	body := []byte(`{"event": {"type": "message", "channel": "C1", "user": "U1", "text": "` + text + `", "ts": "1612790186.002000"}}`)
+	if err := h.handleMessage(ctx, client, body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "Got message") {
+		t.Fatalf("expected the message to be logged at debug level, but got %s", logs.String())
+	}
+	for _, raw := range []string{"Claim your", "evil.example", "crypto"} {
+		if strings.Contains(logs.String(), raw) {
+			t.Errorf("expected %q not to be logged, but got %s", raw, logs.String())
+		}
+	}
+}
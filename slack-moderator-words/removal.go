@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/scim"
+)
+
+// Action IDs of the buttons on requests to confirm a removal.
+const (
+	actionConfirmKick       = "confirm_kick"
+	actionConfirmDeactivate = "confirm_deactivate"
+	actionCancelRemoval     = "cancel_removal"
+)
+
+// remove takes ActionKick or ActionDeactivate against the author of a match, or asks the
+// moderators to confirm it first if the filter config says so.
+func (h *handler) remove(ctx context.Context, client *slack.Client, action string, m *match) error {
+	channel, _ := m.event.Channel.(string)
+	if m.rules.confirms(action, m.filter) {
+		return h.requestConfirmation(ctx, client, action, m)
+	}
+	return removeUser(ctx, client, action, channel, m.event.User)
+}
+
+// removeUser kicks user from channel, or deactivates their account. Both need an admin's user
+// token.
+func removeUser(ctx context.Context, client *slack.Client, action, channel, user string) error {
+	ctx = slack.WithPriority(slack.WithToken(ctx, slack.TokenUser), slack.PriorityHigh)
+	switch action {
+	case model.ActionKick:
+		if err := api.New(client).KickFromConversation(ctx, channel, user); err != nil {
+			return fmt.Errorf("failed to kick user: %v", err)
+		}
+		return nil
+	case model.ActionDeactivate:
+		token, err := client.Token(ctx)
+		if err != nil {
+			return err
+		}
+		if err := scim.New(client, token).DeactivateUser(ctx, user); err != nil {
+			return fmt.Errorf("failed to deactivate user: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%q doesn't remove users", action)
+	}
+}
+
+// requestConfirmation asks the moderators to confirm deleting a matching message, or kicking or
+// deactivating its author. Deletions are confirmed like deleting an escalated message.
+func (h *handler) requestConfirmation(ctx context.Context, client *slack.Client, action string, m *match) error {
+	channel, _ := m.event.Channel.(string)
+	moderators, err := moderatorsChannel(ctx, client, m.rules)
+	if err != nil {
+		return err
+	}
+	var text, actionID, label, value string
+	switch action {
+	case model.ActionDelete:
+		text = fmt.Sprintf("Delete <@%s>'s message in <#%s>?", m.event.User, channel)
+		actionID, label, value = actionDeleteMessage, "Delete", channel+"/"+m.event.TS
+	case model.ActionKick:
+		text = fmt.Sprintf("Kick <@%s> from <#%s>?", m.event.User, channel)
+		actionID, label, value = actionConfirmKick, "Kick", channel+"/"+m.event.User
+	default:
+		text = fmt.Sprintf("Deactivate <@%s>'s account?", m.event.User)
+		actionID, label, value = actionConfirmDeactivate, "Deactivate", channel+"/"+m.event.User
+	}
+	reason := fmt.Sprintf("They posted a message in <#%s> that matched a filter", channel)
+	if len(m.fields) > 0 {
+		reason = fmt.Sprintf("Their %s matched a filter", m.fieldNames())
+	}
+	if m.strikes > 0 {
+		reason += fmt.Sprintf(", and now have %d strikes", m.strikes)
+	}
+	confirm := blocks.Button(actionID, label, value)
+	confirm.Style = blocks.StyleDanger
+	buttons := []blocks.Element{confirm, blocks.Button(actionCancelRemoval, "Cancel", value)}
+	if m.permalink != "" {
+		buttons = append([]blocks.Element{blocks.LinkButton(actionViewMessage, "View message", m.permalink)}, buttons...)
+	}
+	b := []blocks.Block{
+		blocks.Section(blocks.Markdown(":warning: *" + text + "*\n" + reason + ":\n" + quote(m.event.Text))),
+		blocks.Actions(buttons...),
+	}
+	if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: moderators, Text: text, Blocks: b}); err != nil {
+		return fmt.Errorf("failed to ask moderators to confirm %s: %v", action, err)
+	}
+	return nil
+}
+
+// handleRemovals registers the handlers for the buttons on requests to confirm a removal with ih.
+func handleRemovals(ih *interactive.Handler) {
+	ih.HandleFunc(interactive.TypeBlockActions, actionConfirmKick, handleConfirmRemoval(model.ActionKick, actionConfirmKick))
+	ih.HandleFunc(interactive.TypeBlockActions, actionConfirmDeactivate, handleConfirmRemoval(model.ActionDeactivate, actionConfirmDeactivate))
+	ih.HandleFunc(interactive.TypeBlockActions, actionCancelRemoval, func(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+		return nil, resolveEscalation(ctx, p, fmt.Sprintf(":white_check_mark: Cancelled by <@%s>", p.User.ID))
+	})
+}
+
+// handleConfirmRemoval returns a HandlerFunc that takes action once a moderator confirms it with
+// the button with the given action ID.
+func handleConfirmRemoval(action, actionID string) interactive.HandlerFunc {
+	return func(ctx context.Context, p *interactive.Payload) (*interactive.Response, error) {
+		value := p.Action(actionID).Value.Value
+		channel, user, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("malformed removal %q", value)
+		}
+		logging.FromContext(ctx).Info("Removing user after confirmation", "action", action, "removed_user_id", user, "removed_from", channel)
+		if err := removeUser(ctx, p.Client(), action, channel, user); err != nil {
+			return nil, err
+		}
+		note := fmt.Sprintf(":no_entry: <@%s> was deactivated by <@%s>", user, p.User.ID)
+		if action == model.ActionKick {
+			note = fmt.Sprintf(":no_entry: <@%s> was kicked from <#%s> by <@%s>", user, channel, p.User.ID)
+		}
+		return nil, resolveEscalation(ctx, p, note)
+	}
+}
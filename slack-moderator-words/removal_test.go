@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// removalServer returns a server that records the calls made to it as "method path token".
+func removalServer(calls *[]string) *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, r.Method+" "+r.URL.Path+" "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/conversations.list" {
+			_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0MOD", "name": "moderators"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		confirm  bool
+		severity string
+		expected []string
+	}{
+		{
+			name:     "kick",
+			action:   model.ActionKick,
+			expected: []string{"POST /api/conversations.kick xoxp-admin"},
+		},
+		{
+			name:     "deactivate",
+			action:   model.ActionDeactivate,
+			expected: []string{"DELETE /scim/v2/Users/U1 xoxp-admin"},
+		},
+		{
+			name:     "kick with confirmation",
+			action:   model.ActionKick,
+			confirm:  true,
+			expected: []string{"POST /api/conversations.list ", "POST /api/chat.postMessage xoxb-token"},
+		},
+		{
+			name:     "delete with confirmation",
+			action:   model.ActionDelete,
+			confirm:  true,
+			expected: []string{"POST /api/chat.getPermalink ", "POST /api/conversations.list ", "POST /api/chat.postMessage xoxb-token"},
+		},
+		{
+			name:     "delete confirmed for its severity",
+			action:   model.ActionDelete,
+			severity: model.SeverityHigh,
+			expected: []string{"POST /api/chat.getPermalink ", "POST /api/conversations.list ", "POST /api/chat.postMessage xoxb-token"},
+		},
+		{
+			name:     "delete at another severity",
+			action:   model.ActionDelete,
+			severity: model.SeverityCritical,
+			expected: []string{"POST /api/chat.delete xoxp-admin"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := removalServer(&calls)
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			config := model.Config{ModeratorsChannel: "#moderators", ConfirmSeverities: []string{model.SeverityHigh}}
+			if tc.confirm {
+				config.Confirm = []string{tc.action}
+			}
+			h := newHandler(nil, config)
+			m := &match{rules: h.rules(), filter: model.Filter{Severity: tc.severity}, event: model.Event{Channel: "C1", User: "U1", TS: "1612790186.002000"}, steps: []string{tc.action}}
+			h.runActions(context.Background(), client, m)
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestConfirmRemoval(t *testing.T) {
+	tests := []struct {
+		name     string
+		actionID string
+		expected []string
+	}{
+		{
+			name:     "confirm kick",
+			actionID: actionConfirmKick,
+			expected: []string{"POST /api/conversations.kick xoxp-admin", "POST /actions/T1/1/abc xoxb-token"},
+		},
+		{
+			name:     "confirm deactivation",
+			actionID: actionConfirmDeactivate,
+			expected: []string{"DELETE /scim/v2/Users/U1 xoxp-admin", "POST /actions/T1/1/abc xoxb-token"},
+		},
+		{
+			name:     "cancel",
+			actionID: actionCancelRemoval,
+			expected: []string{"POST /actions/T1/1/abc xoxb-token"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := removalServer(&calls)
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			ih := interactive.NewHandler(slack.NewClientSet(client))
+			handleRemovals(ih)
+			payload := `{
+				"type": "block_actions",
+				"team": {"id": "T1"},
+				"user": {"id": "U0MOD"},
+				"response_url": "https://hooks.slack.com/actions/T1/1/abc",
+				"message": {"text": "Kick <@U1>?", "blocks": []},
+				"actions": [{"action_id": "` + tc.actionID + `", "value": "C1/U1"}]
+			}`
+			if _, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"sort"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// rules are the parts of the handler that come from the filter config. They are replaced as a whole
+// when the filter config is reloaded, so that each message is moderated by one version of it.
+type rules struct {
+	// config is the filter config the rules came from, without the triggers from its trigger lists,
+	// which are in filters.
+	config  model.Config
+	filters model.FilterConfig
+	// moderators is the channel, by name or ID, that filters escalate matches to.
+	moderators string
+	// quarantine is the channel, by name or ID, that messages are copied to before they are deleted.
+	quarantine string
+	// audit is the channel, by name or ID, that every action taken is recorded in, if any.
+	audit string
+	// digestInterval is how often the digest of matches is posted.
+	digestInterval time.Duration
+	// strikeTTL is how long each strike against a user counts.
+	strikeTTL time.Duration
+	// confirm are the actions that moderators have to confirm before they are taken, and
+	// confirmSeverities are the severities whose filters have to have all such actions confirmed.
+	confirm           map[string]bool
+	confirmSeverities map[string]bool
+	// appeals offers the authors of deleted messages and warned users a way to appeal to the
+	// moderators.
+	appeals bool
+	// shadowBanned are the users shadow-banned in the filter config.
+	shadowBanned map[string]bool
+	// checksLinks is set if any filter matches links by their domain.
+	checksLinks bool
+	// crossPostWindow is how long messages need to be remembered for filters that look for
+	// cross-posted messages, if there are any.
+	crossPostWindow time.Duration
+	// rateWindow is how long messages need to be counted for filters that limit how fast users
+	// can post, if there are any.
+	rateWindow time.Duration
+	// checksUsers is set if any filter checks users' profiles.
+	checksUsers bool
+	// checksEmoji is set if any filter checks the names of custom emoji.
+	checksEmoji bool
+	// scoreAttributes are the attributes filters need messages scored for, if any.
+	scoreAttributes []string
+}
+
+// newRules returns the rules in a filter config, which must already have been compiled.
+func newRules(config model.Config) *rules {
+	r := &rules{
+		config:            config,
+		filters:           config.Filters,
+		moderators:        config.ModeratorsChannel,
+		quarantine:        config.QuarantineChannel,
+		audit:             config.AuditChannel,
+		appeals:           config.Appeals,
+		strikeTTL:         config.Strikes.StrikeTTL(),
+		digestInterval:    config.DigestEvery(),
+		confirm:           map[string]bool{},
+		confirmSeverities: map[string]bool{},
+		shadowBanned:      map[string]bool{},
+	}
+	for _, a := range config.Confirm {
+		r.confirm[a] = true
+	}
+	for _, s := range config.ConfirmSeverities {
+		r.confirmSeverities[s] = true
+	}
+	for _, u := range config.ShadowBanned {
+		r.shadowBanned[u] = true
+	}
+	attributes := map[string]bool{}
+	for _, f := range config.Filters {
+		r.checksLinks = r.checksLinks || f.ChecksLinks()
+		r.checksUsers = r.checksUsers || f.ChecksUsers()
+		r.checksEmoji = r.checksEmoji || f.ChecksEmoji()
+		if f.CrossPosts != nil && f.CrossPosts.Window() > r.crossPostWindow {
+			r.crossPostWindow = f.CrossPosts.Window()
+		}
+		if f.Rate != nil && f.Rate.Window() > r.rateWindow {
+			r.rateWindow = f.Rate.Window()
+		}
+		if f.Score != nil {
+			attributes[f.Score.Attribute] = true
+		}
+	}
+	for a := range attributes {
+		r.scoreAttributes = append(r.scoreAttributes, a)
+	}
+	sort.Strings(r.scoreAttributes)
+	return r
+}
+
+// confirms returns whether a moderator has to confirm action before filter takes it.
+func (r *rules) confirms(action string, filter model.Filter) bool {
+	return r.confirm[action] || (model.Confirmable(action) && filter.Severity != "" && r.confirmSeverities[filter.Severity])
+}
+
+// rules returns the handler's current rules.
+func (h *handler) rules() *rules {
+	h.rulesLock.RLock()
+	defer h.rulesLock.RUnlock()
+	return h.current
+}
+
+// setRules replaces the handler's rules. Messages already being moderated finish with the old ones.
+func (h *handler) setRules(r *rules) {
+	h.rulesLock.Lock()
+	defer h.rulesLock.Unlock()
+	h.current = r
+}
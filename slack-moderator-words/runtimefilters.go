@@ -0,0 +1,291 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/slashcmd"
+)
+
+// modwordsCommand is the slash command that moderators manage runtime filters with.
+const modwordsCommand = "/modwords"
+
+const modwordsUsage = "Usage: `/modwords add [low|medium|high|critical] <trigger>`, `/modwords remove <trigger>`, `/modwords list` or `/modwords test <message>`"
+
+// defaultRuntimeSeverity is the severity of runtime filters added without one. They have no
+// message to warn with, so at this severity they delete the messages they match.
+const defaultRuntimeSeverity = model.SeverityHigh
+
+// runtimeFilterRefresh is how often the runtime filters are loaded from the match store again, to
+// pick up the ones other replicas have added or removed.
+const runtimeFilterRefresh = time.Minute
+
+// runtimeFilter is a filter added with /modwords, which matches one trigger and takes the actions
+// for its severity, so that moderators can respond to a spam wave without changing the filter
+// config.
+type runtimeFilter struct {
+	Trigger  string
+	Severity string
+	AddedBy  string
+	AddedAt  time.Time
+}
+
+// runtimeFilterConfig returns the filters for runtime filters, named after their triggers.
+func runtimeFilterConfig(runtime []runtimeFilter) model.FilterConfig {
+	filters := make(model.FilterConfig, 0, len(runtime))
+	for _, f := range runtime {
+		filters = append(filters, model.Filter{Name: "runtime: " + f.Trigger, Triggers: []string{f.Trigger}, Severity: f.Severity})
+	}
+	return filters
+}
+
+// RuntimeFilters returns the runtime filters in the store, in the order they were added.
+func (s *matchStore) RuntimeFilters(ctx context.Context) ([]runtimeFilter, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT trigger_text, severity, added_by, added_at FROM runtime_filters ORDER BY added_at, trigger_text`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var filters []runtimeFilter
+	for rows.Next() {
+		var f runtimeFilter
+		var at int64
+		if err := rows.Scan(&f.Trigger, &f.Severity, &f.AddedBy, &at); err != nil {
+			return nil, err
+		}
+		f.AddedAt = time.UnixMilli(at).UTC()
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
+
+// AddRuntimeFilter adds f to the store, replacing any runtime filter with the same trigger.
+func (s *matchStore) AddRuntimeFilter(ctx context.Context, f runtimeFilter) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM runtime_filters WHERE trigger_text = $1`, f.Trigger); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO runtime_filters (trigger_text, severity, added_by, added_at) VALUES ($1, $2, $3, $4)`, f.Trigger, f.Severity, f.AddedBy, f.AddedAt.UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveRuntimeFilter removes the runtime filter with trigger from the store, and returns whether
+// there was one.
+func (s *matchStore) RemoveRuntimeFilter(ctx context.Context, trigger string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM runtime_filters WHERE trigger_text = $1`, trigger)
+	if err != nil {
+		return false, err
+	}
+	removed, err := result.RowsAffected()
+	return removed > 0, err
+}
+
+// setRuntimeFilters replaces the runtime filters, and rebuilds the rules with them and the current
+// filter config, whose trigger lists aren't fetched again. The runtime filters are left alone if
+// the rules can't be built with them. The caller must hold configureLock.
+func (h *handler) setRuntimeFilters(runtime []runtimeFilter) error {
+	config := h.rules().config
+	if err := h.applyConfig(config, h.triggerLists.cached(config.TriggerLists()), runtime); err != nil {
+		return err
+	}
+	h.runtimeFilters = runtime
+	return nil
+}
+
+// loadRuntimeFilters replaces the runtime filters with the ones in the match store, if they have
+// changed.
+func (h *handler) loadRuntimeFilters(ctx context.Context) error {
+	runtime, err := h.store.RuntimeFilters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load runtime filters: %v", err)
+	}
+	h.configureLock.Lock()
+	defer h.configureLock.Unlock()
+	if reflect.DeepEqual(runtime, h.runtimeFilters) {
+		return nil
+	}
+	return h.setRuntimeFilters(runtime)
+}
+
+// loadRuntimeFiltersEvery calls loadRuntimeFilters every interval until ctx is done.
+func (h *handler) loadRuntimeFiltersEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.loadRuntimeFilters(ctx); err != nil {
+				logging.FromContext(ctx).Error("Failed to refresh runtime filters", "error", err)
+			}
+		}
+	}
+}
+
+// handleModwords handles /modwords, which lets members of the moderator usergroup add, remove and
+// list runtime filters, and test messages against every filter. Its responses are only shown to
+// whoever ran it.
+func (h *handler) handleModwords(ctx context.Context, cmd *slashcmd.Command) (*slashcmd.Response, error) {
+	r := h.rules()
+	if r.config.ModeratorUsergroup == "" {
+		return &slashcmd.Response{Text: "Nobody can use " + modwordsCommand + ", since the filter config has no `moderator_usergroup`."}, nil
+	}
+	member, err := h.isModerator(ctx, cmd.Client(), r, cmd.UserID)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to check whether user is a moderator", "usergroup", r.config.ModeratorUsergroup, "error", err)
+		return &slashcmd.Response{Text: "Couldn't check whether you're a moderator. Please try again."}, nil
+	}
+	if !member {
+		return &slashcmd.Response{Text: fmt.Sprintf("Only members of <!subteam^%s> can use %s.", r.config.ModeratorUsergroup, modwordsCommand)}, nil
+	}
+
+	subcommand, text, _ := strings.Cut(strings.TrimSpace(cmd.Text), " ")
+	text = strings.TrimSpace(text)
+	if subcommand == "test" {
+		if text == "" {
+			return &slashcmd.Response{Text: modwordsUsage}, nil
+		}
+		var b bytes.Buffer
+		checkMessage(&b, r.filters, checkOptions{channel: cmd.ChannelID, user: cmd.UserID, strikes: 1}, text)
+		return &slashcmd.Response{Text: "```\n" + strings.TrimSuffix(b.String(), "\n") + "\n```"}, nil
+	}
+	if subcommand != "add" && subcommand != "remove" && subcommand != "list" {
+		return &slashcmd.Response{Text: modwordsUsage}, nil
+	}
+	if h.store == nil {
+		return &slashcmd.Response{Text: "Runtime filters need a `--database` to be kept in."}, nil
+	}
+
+	h.configureLock.Lock()
+	defer h.configureLock.Unlock()
+	switch subcommand {
+	case "add":
+		severity := defaultRuntimeSeverity
+		if first, rest, ok := strings.Cut(text, " "); ok {
+			if _, known := model.DefaultSeverities[first]; known {
+				severity, text = first, strings.TrimSpace(rest)
+			}
+		}
+		if text == "" {
+			return &slashcmd.Response{Text: modwordsUsage}, nil
+		}
+		return h.addRuntimeFilter(ctx, cmd, runtimeFilter{Trigger: text, Severity: severity, AddedBy: cmd.UserID, AddedAt: time.UnixMilli(h.store.now().UnixMilli()).UTC()})
+	case "remove":
+		if text == "" {
+			return &slashcmd.Response{Text: modwordsUsage}, nil
+		}
+		return h.removeRuntimeFilter(ctx, cmd, text)
+	default:
+		return &slashcmd.Response{Text: runtimeFilterList(h.runtimeFilters)}, nil
+	}
+}
+
+// isModerator returns whether user is a member of the moderator usergroup of r. Nobody is if it
+// doesn't have one.
+func (h *handler) isModerator(ctx context.Context, client *slack.Client, r *rules, user string) (bool, error) {
+	if r.config.ModeratorUsergroup == "" {
+		return false, nil
+	}
+	return h.usergroups.isMember(ctx, client, r.config.ModeratorUsergroup, user)
+}
+
+// addRuntimeFilter adds f, replacing any runtime filter with the same trigger, and records who did
+// in the audit channel. The caller must hold configureLock.
+func (h *handler) addRuntimeFilter(ctx context.Context, cmd *slashcmd.Command, f runtimeFilter) (*slashcmd.Response, error) {
+	previous := h.runtimeFilters
+	var runtime []runtimeFilter
+	for _, existing := range previous {
+		if existing.Trigger != f.Trigger {
+			runtime = append(runtime, existing)
+		}
+	}
+	if err := h.setRuntimeFilters(append(runtime, f)); err != nil {
+		return &slashcmd.Response{Text: fmt.Sprintf("Couldn't add `%s`: %v", f.Trigger, err)}, nil
+	}
+	if err := h.store.AddRuntimeFilter(ctx, f); err != nil {
+		logging.FromContext(ctx).Error("Failed to save runtime filter", "trigger", f.Trigger, "error", err)
+		_ = h.setRuntimeFilters(previous)
+		return &slashcmd.Response{Text: fmt.Sprintf("Couldn't save `%s`. Please try again.", f.Trigger)}, nil
+	}
+	logging.FromContext(ctx).Info("Added runtime filter", "trigger", f.Trigger, "severity", f.Severity)
+	h.auditRuntimeFilter(ctx, cmd, fmt.Sprintf(":scroll: <@%s> added the runtime filter `%s` (%s)", cmd.UserID, f.Trigger, f.Severity))
+	return &slashcmd.Response{Text: fmt.Sprintf("Added `%s`. Messages that match it are handled like other %s filters' matches.", f.Trigger, f.Severity)}, nil
+}
+
+// removeRuntimeFilter removes the runtime filter with trigger, and records who did in the audit
+// channel. The caller must hold configureLock.
+func (h *handler) removeRuntimeFilter(ctx context.Context, cmd *slashcmd.Command, trigger string) (*slashcmd.Response, error) {
+	removed, err := h.store.RemoveRuntimeFilter(ctx, trigger)
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to remove runtime filter", "trigger", trigger, "error", err)
+		return &slashcmd.Response{Text: fmt.Sprintf("Couldn't remove `%s`. Please try again.", trigger)}, nil
+	}
+	var runtime []runtimeFilter
+	for _, f := range h.runtimeFilters {
+		if f.Trigger != trigger {
+			runtime = append(runtime, f)
+		}
+	}
+	if !removed && len(runtime) == len(h.runtimeFilters) {
+		return &slashcmd.Response{Text: fmt.Sprintf("There's no runtime filter `%s`.", trigger)}, nil
+	}
+	if err := h.setRuntimeFilters(runtime); err != nil {
+		return &slashcmd.Response{Text: fmt.Sprintf("Couldn't remove `%s`: %v", trigger, err)}, nil
+	}
+	logging.FromContext(ctx).Info("Removed runtime filter", "trigger", trigger)
+	h.auditRuntimeFilter(ctx, cmd, fmt.Sprintf(":scroll: <@%s> removed the runtime filter `%s`", cmd.UserID, trigger))
+	return &slashcmd.Response{Text: fmt.Sprintf("Removed `%s`.", trigger)}, nil
+}
+
+// auditRuntimeFilter records a change to the runtime filters in the audit channel, if there is one.
+func (h *handler) auditRuntimeFilter(ctx context.Context, cmd *slashcmd.Command, text string) {
+	r := h.rules()
+	if r.audit == "" {
+		return
+	}
+	if err := h.audit(ctx, cmd.Client(), r, text); err != nil {
+		logging.FromContext(ctx).Error("Failed to record runtime filter change in audit channel", "error", err)
+	}
+}
+
+// runtimeFilterList returns the list of runtime filters that /modwords list shows.
+func runtimeFilterList(runtime []runtimeFilter) string {
+	if len(runtime) == 0 {
+		return "There are no runtime filters."
+	}
+	lines := []string{"Runtime filters:"}
+	for _, f := range runtime {
+		lines = append(lines, fmt.Sprintf("• `%s` (%s), added by <@%s> at %s", f.Trigger, f.Severity, f.AddedBy, f.AddedAt.Format("2006-01-02 15:04 MST")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/slashcmd"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// runModwords runs /modwords with text as user, and returns the text of the response.
+func runModwords(t *testing.T, sh *slashcmd.Handler, user, text string) string {
+	t.Helper()
+	payload, _ := json.Marshal(map[string]string{"command": modwordsCommand, "text": text, "user_id": user, "channel_id": "C1", "team_id": "T1"})
+	response, err := sh.HandleSocketModeEnvelope(context.Background(), socketmode.TypeSlashCommands, payload)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := slashcmd.Response{}
+	if err := json.Unmarshal(response, &r); err != nil {
+		t.Fatalf("couldn't unmarshal response %s: %v", response, err)
+	}
+	return r.Text
+}
+
+func TestModwords(t *testing.T) {
+	var posted []api.PostMessageRequest
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch strings.TrimPrefix(r.URL.Path, "/api/") {
+		case "usergroups.users.list":
+			_, _ = w.Write([]byte(`{"ok": true, "users": ["U1"]}`))
+			return
+		case "chat.postMessage":
+			req := api.PostMessageRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			posted = append(posted, req)
+		}
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token"})
+
+	store, _ := testMatchStore(t)
+	now := time.Date(2021, 2, 8, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	config := model.Config{ModeratorUsergroup: "S1", AuditChannel: "G0AUDITLOG", Filters: model.FilterConfig{{Triggers: []string{"guys"}, Action: model.ActionLog}}}
+	if err := config.Compile(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(slack.NewClientSet(client), config)
+	h.store = store
+	sh := slashcmd.NewHandler(slack.NewClientSet(client))
+	sh.HandleFunc(modwordsCommand, h.handleModwords)
+
+	if actual := runModwords(t, sh, "U2", "add free nitro"); actual != "Only members of <!subteam^S1> can use /modwords." {
+		t.Errorf("expected non-moderators to be turned away, but got %q", actual)
+	}
+	if actual := runModwords(t, sh, "U1", "add free nitro"); actual != "Added `free nitro`. Messages that match it are handled like other high filters' matches." {
+		t.Errorf("unexpected response to add: %q", actual)
+	}
+	now = now.Add(time.Minute)
+	if actual := runModwords(t, sh, "U1", "add low airdrop"); !strings.HasPrefix(actual, "Added `airdrop`") {
+		t.Errorf("unexpected response to add with a severity: %q", actual)
+	}
+	filters := h.rules().filters
+	if len(filters) != 3 || filters[1].Name != "runtime: free nitro" || len(filters[1].Matches("claim your free nitro")) == 0 {
+		t.Fatalf("expected the runtime filters to follow the filter config's, but got %+v", filters)
+	}
+	if expected := []string{model.ActionDelete}; !reflect.DeepEqual(filters[1].Steps(), expected) {
+		t.Errorf("expected the runtime filter to take %v, but got %v", expected, filters[1].Steps())
+	}
+
+	expectedList := "Runtime filters:\n• `free nitro` (high), added by <@U1> at 2021-02-08 12:00 UTC\n• `airdrop` (low), added by <@U1> at 2021-02-08 12:01 UTC"
+	if actual := runModwords(t, sh, "U1", "list"); actual != expectedList {
+		t.Errorf("expected list %q, but got %q", expectedList, actual)
+	}
+	expectedTest := "```\n\"free nitro for guys\"\n  filter 1 matches [\"guys\"]: log\n  runtime: free nitro matches [\"free nitro\"]: delete\n```"
+	if actual := runModwords(t, sh, "U1", "test free nitro for guys"); actual != expectedTest {
+		t.Errorf("expected test output %q, but got %q", expectedTest, actual)
+	}
+
+	// Another replica, or this one after a restart, loads the runtime filters from the store.
+	other := newHandler(nil, config)
+	other.store = store
+	if err := other.loadRuntimeFilters(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(other.runtimeFilters, h.runtimeFilters) || len(other.rules().filters) != 3 {
+		t.Errorf("expected runtime filters %+v to be loaded, but got %+v", h.runtimeFilters, other.runtimeFilters)
+	}
+
+	if actual := runModwords(t, sh, "U1", "remove free nitro"); actual != "Removed `free nitro`." {
+		t.Errorf("unexpected response to remove: %q", actual)
+	}
+	if actual := runModwords(t, sh, "U1", "remove free nitro"); actual != "There's no runtime filter `free nitro`." {
+		t.Errorf("unexpected response to removing it again: %q", actual)
+	}
+	if filters := h.rules().filters; len(filters) != 2 || filters[1].Name != "runtime: airdrop" {
+		t.Errorf("expected only the remaining runtime filter after the filter config's, but got %+v", filters)
+	}
+	if actual := runModwords(t, sh, "U1", "frobnicate"); actual != modwordsUsage {
+		t.Errorf("expected usage for an unknown subcommand, but got %q", actual)
+	}
+
+	var audited []string
+	for _, p := range posted {
+		audited = append(audited, p.Channel+": "+p.Text)
+	}
+	expectedAudit := []string{
+		"G0AUDITLOG: :scroll: <@U1> added the runtime filter `free nitro` (high)",
+		"G0AUDITLOG: :scroll: <@U1> added the runtime filter `airdrop` (low)",
+		"G0AUDITLOG: :scroll: <@U1> removed the runtime filter `free nitro`",
+	}
+	if !reflect.DeepEqual(audited, expectedAudit) {
+		t.Errorf("expected audit records %q, but got %q", expectedAudit, audited)
+	}
+}
+
+func TestModwordsWithoutModerators(t *testing.T) {
+	h := newHandler(nil, model.Config{})
+	sh := slashcmd.NewHandler(slack.NewClientSet(slack.New(slack.Config{})))
+	sh.HandleFunc(modwordsCommand, h.handleModwords)
+	if actual := runModwords(t, sh, "U1", "list"); actual != "Nobody can use /modwords, since the filter config has no `moderator_usergroup`." {
+		t.Errorf("unexpected response: %q", actual)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+)
+
+const (
+	scorerPerspective = "perspective"
+	scorerHTTP        = "http"
+
+	// perspectiveURL is the Perspective API's comments:analyze method.
+	perspectiveURL = "https://commentanalyzer.googleapis.com/v1alpha1/comments:analyze"
+	// maxCachedScores is how many messages' scores are cached, so that a flood of different
+	// messages can't use up memory.
+	maxCachedScores = 10000
+	// defaultScoreTimeout is how long messages wait to be scored by default.
+	defaultScoreTimeout = 2 * time.Second
+)
+
+// scorer scores text for attributes such as "TOXICITY", from 0 to 1. Attributes it doesn't return
+// a score for are left out of the result.
+type scorer interface {
+	score(ctx context.Context, text string, attributes []string) (map[string]float64, error)
+}
+
+// newScorer returns the scorer of the given kind. The Perspective API needs key, and uses target
+// instead of the real API if it is set; the self-hosted one needs target, and sends key as a bearer
+// token if it is set.
+func newScorer(kind, target, key string) (scorer, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	switch kind {
+	case scorerPerspective:
+		if key == "" {
+			return nil, fmt.Errorf("the Perspective API needs an API key in $SCORER_API_KEY")
+		}
+		if target == "" {
+			target = perspectiveURL
+		}
+		return &perspectiveScorer{client: client, url: target, key: key}, nil
+	case scorerHTTP:
+		if target == "" {
+			return nil, fmt.Errorf("a self-hosted scorer needs --scorer-url")
+		}
+		return &httpScorer{client: client, url: target, key: key}, nil
+	default:
+		return nil, fmt.Errorf("unknown scorer %q, expected %q or %q", kind, scorerPerspective, scorerHTTP)
+	}
+}
+
+// scores returns the scores of content for the attributes the rules' filters need. The scorer only
+// gets scoreTimeout to answer, since messages wait for it, and if it doesn't, or fails, the error is
+// logged and no scores are returned.
+func (h *handler) scores(ctx context.Context, r *rules, content string) map[string]float64 {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.scoreTimeout)
+	defer cancel()
+	scores, err := h.scorer.score(ctx, content, r.scoreAttributes)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to score message", "error", err)
+		return nil
+	}
+	return scores
+}
+
+// perspectiveScorer scores text with Google's Perspective API.
+type perspectiveScorer struct {
+	client *http.Client
+	url    string
+	key    string
+}
+
+func (p *perspectiveScorer) score(ctx context.Context, text string, attributes []string) (map[string]float64, error) {
+	request := struct {
+		Comment struct {
+			Text string `json:"text"`
+		} `json:"comment"`
+		RequestedAttributes map[string]struct{} `json:"requestedAttributes"`
+		DoNotStore          bool                `json:"doNotStore"`
+	}{RequestedAttributes: map[string]struct{}{}, DoNotStore: true}
+	request.Comment.Text = text
+	for _, a := range attributes {
+		request.RequestedAttributes[a] = struct{}{}
+	}
+	response := struct {
+		AttributeScores map[string]struct {
+			SummaryScore struct {
+				Value float64 `json:"value"`
+			} `json:"summaryScore"`
+		} `json:"attributeScores"`
+	}{}
+	if err := postJSON(ctx, p.client, p.url+"?key="+url.QueryEscape(p.key), "", request, &response); err != nil {
+		return nil, err
+	}
+	scores := map[string]float64{}
+	for a, s := range response.AttributeScores {
+		scores[a] = s.SummaryScore.Value
+	}
+	return scores, nil
+}
+
+// httpScorer scores text with a self-hosted classifier, which is sent
+// {"text": "...", "attributes": ["TOXICITY", ...]} and answers {"scores": {"TOXICITY": 0.93, ...}}.
+type httpScorer struct {
+	client *http.Client
+	url    string
+	key    string
+}
+
+func (s *httpScorer) score(ctx context.Context, text string, attributes []string) (map[string]float64, error) {
+	request := struct {
+		Text       string   `json:"text"`
+		Attributes []string `json:"attributes"`
+	}{Text: text, Attributes: attributes}
+	response := struct {
+		Scores map[string]float64 `json:"scores"`
+	}{}
+	if err := postJSON(ctx, s.client, s.url, s.key, request, &response); err != nil {
+		return nil, err
+	}
+	return response.Scores, nil
+}
+
+// postJSON posts request to target as JSON, with token as a bearer token if it is set, and decodes
+// the JSON response into response.
+func postJSON(ctx context.Context, client *http.Client, target, token string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Don't log the Perspective API key, which is in the URL.
+		if e, ok := err.(*url.Error); ok {
+			err = e.Err
+		}
+		return fmt.Errorf("failed to call scorer: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("scorer returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode scores: %v", err)
+	}
+	return nil
+}
+
+// cachedScorer remembers the scores another scorer gave text for ttl, since spam is usually posted
+// several times and the scorer is slow and may charge per request.
+type cachedScorer struct {
+	scorer scorer
+	ttl    time.Duration
+	now    func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]cachedScores
+}
+
+type cachedScores struct {
+	scores  map[string]float64
+	expires time.Time
+}
+
+func newCachedScorer(s scorer, ttl time.Duration) *cachedScorer {
+	return &cachedScorer{scorer: s, ttl: ttl, now: time.Now, entries: map[string]cachedScores{}}
+}
+
+func (c *cachedScorer) score(ctx context.Context, text string, attributes []string) (map[string]float64, error) {
+	key := scoreKey(text, attributes)
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	c.lock.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.scores, nil
+	}
+	scores, err := c.scorer.score(ctx, text, attributes)
+	if err != nil {
+		// Errors aren't cached, so the next copy of the message gets another chance.
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.entries) >= maxCachedScores {
+		now := c.now()
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// Make room by forgetting an arbitrary message if none have expired.
+		for k := range c.entries {
+			if len(c.entries) < maxCachedScores {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedScores{scores: scores, expires: c.now().Add(c.ttl)}
+	return scores, nil
+}
+
+// scoreKey identifies text and the attributes it is scored for in the cache, without keeping the
+// text itself.
+func scoreKey(text string, attributes []string) string {
+	attributes = append([]string(nil), attributes...)
+	sort.Strings(attributes)
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:]) + ":" + strings.Join(attributes, ",")
+}
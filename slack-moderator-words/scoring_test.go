@@ -0,0 +1,291 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestPerspectiveScorer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("key"); key != "api-key" {
+			http.Error(w, `{"error": {"message": "API key not valid"}}`, http.StatusBadRequest)
+			return
+		}
+		request := struct {
+			Comment struct {
+				Text string `json:"text"`
+			} `json:"comment"`
+			RequestedAttributes map[string]struct{} `json:"requestedAttributes"`
+			DoNotStore          bool                `json:"doNotStore"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		if request.Comment.Text != "you are awful" || !request.DoNotStore {
+			t.Errorf("unexpected request %+v", request)
+		}
+		_, _ = w.Write([]byte(`{"attributeScores": {"TOXICITY": {"summaryScore": {"value": 0.93, "type": "PROBABILITY"}}}}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		key           string
+		expected      map[string]float64
+		expectedError string
+	}{
+		{
+			name:     "scores",
+			key:      "api-key",
+			expected: map[string]float64{"TOXICITY": 0.93},
+		},
+		{
+			name:          "bad key",
+			key:           "wrong",
+			expectedError: "scorer returned 400 Bad Request",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := newScorer(scorerPerspective, server.URL, tc.key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			scores, err := s.score(context.Background(), "you are awful", []string{"TOXICITY"})
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error containing %q, but got %v", tc.expectedError, err)
+				}
+				if strings.Contains(err.Error(), tc.key) {
+					t.Errorf("expected error not to contain the API key, but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(scores, tc.expected) {
+				t.Errorf("expected scores %v, but got %v", tc.expected, scores)
+			}
+		})
+	}
+}
+
+func TestHTTPScorer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("expected bearer token, but got %q", auth)
+		}
+		request := struct {
+			Text       string   `json:"text"`
+			Attributes []string `json:"attributes"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		scores := map[string]float64{}
+		for _, a := range request.Attributes {
+			scores[a] = float64(len(request.Text)) / 100
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"scores": scores})
+	}))
+	defer server.Close()
+
+	s, err := newScorer(scorerHTTP, server.URL, "token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scores, err := s.score(context.Background(), "buy now", []string{"SPAM", "TOXICITY"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]float64{"SPAM": 0.07, "TOXICITY": 0.07}; !reflect.DeepEqual(scores, expected) {
+		t.Errorf("expected scores %v, but got %v", expected, scores)
+	}
+}
+
+func TestNewScorer(t *testing.T) {
+	tests := []struct {
+		name          string
+		kind          string
+		url           string
+		key           string
+		expectedError string
+	}{
+		{name: "perspective", kind: scorerPerspective, key: "api-key"},
+		{name: "perspective without a key", kind: scorerPerspective, expectedError: "needs an API key"},
+		{name: "http", kind: scorerHTTP, url: "http://classifier/score"},
+		{name: "http without a URL", kind: scorerHTTP, expectedError: "needs --scorer-url"},
+		{name: "unknown", kind: "openai", key: "api-key", expectedError: `unknown scorer "openai"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newScorer(tc.kind, tc.url, tc.key)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+				t.Errorf("expected error containing %q, but got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
+
+// fakeScorer gives every text the same scores, and counts how often it is asked.
+type fakeScorer struct {
+	scores map[string]float64
+	err    error
+	delay  time.Duration
+	calls  int
+}
+
+func (f *fakeScorer) score(ctx context.Context, text string, attributes []string) (map[string]float64, error) {
+	f.calls++
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return f.scores, f.err
+}
+
+func TestCachedScorer(t *testing.T) {
+	fake := &fakeScorer{scores: map[string]float64{"TOXICITY": 0.5}}
+	c := newCachedScorer(fake, time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	steps := []struct {
+		text          string
+		after         time.Duration
+		expectedCalls int
+	}{
+		{text: "hello", expectedCalls: 1},
+		{text: "hello", after: time.Second, expectedCalls: 1},
+		{text: "goodbye", expectedCalls: 2},
+		{text: "hello", after: time.Minute, expectedCalls: 3},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		if _, err := c.score(context.Background(), step.text, []string{"TOXICITY"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if fake.calls != step.expectedCalls {
+			t.Errorf("expected %d calls to the scorer after step %d, but got %d", step.expectedCalls, i+1, fake.calls)
+		}
+	}
+
+	fake.err = fmt.Errorf("unavailable")
+	for i := 0; i < 2; i++ {
+		if _, err := c.score(context.Background(), "new", []string{"TOXICITY"}); err == nil {
+			t.Errorf("expected an error")
+		}
+	}
+	if fake.calls != 5 {
+		t.Errorf("expected errors not to be cached, but the scorer was called %d times", fake.calls)
+	}
+}
+
+func TestScoreFilters(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if method := strings.TrimPrefix(r.URL.Path, "/api/"); method == "chat.delete" || method == "chat.postEphemeral" {
+			calls = append(calls, method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+	config, err := model.ParseConfig([]byte(`
+filters:
+- score: {attribute: SEVERE_TOXICITY, threshold: 0.9}
+  actions: [delete]
+- score: {attribute: TOXICITY, threshold: 0.8}
+  actions: [warn]
+  message: Please keep it civil.
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		scorer        *fakeScorer
+		expected      []string
+		expectedCalls int
+	}{
+		{
+			name:          "above the threshold",
+			scorer:        &fakeScorer{scores: map[string]float64{"TOXICITY": 0.85, "SEVERE_TOXICITY": 0.4}},
+			expected:      []string{"chat.postEphemeral"},
+			expectedCalls: 1,
+		},
+		{
+			name:          "first filter deletes",
+			scorer:        &fakeScorer{scores: map[string]float64{"TOXICITY": 0.99, "SEVERE_TOXICITY": 0.95}},
+			expected:      []string{"chat.delete"},
+			expectedCalls: 1,
+		},
+		{
+			name:          "below the thresholds",
+			scorer:        &fakeScorer{scores: map[string]float64{"TOXICITY": 0.2, "SEVERE_TOXICITY": 0.1}},
+			expectedCalls: 1,
+		},
+		{
+			name:          "scorer fails",
+			scorer:        &fakeScorer{err: fmt.Errorf("unavailable")},
+			expectedCalls: 1,
+		},
+		{
+			name:          "scorer too slow",
+			scorer:        &fakeScorer{scores: map[string]float64{"TOXICITY": 0.99}, delay: time.Minute},
+			expectedCalls: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls = nil
+			h := newHandler(nil, config)
+			h.scorer, h.scoreTimeout = tc.scorer, 50*time.Millisecond
+			body := `{"event": {"type": "message", "channel": "C1", "user": "U1", "text": "you are awful", "ts": "1612790186.000100"}}`
+			if err := h.handleMessage(context.Background(), client, []byte(body)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+			if tc.scorer.calls != tc.expectedCalls {
+				t.Errorf("expected the message to be scored %d times, but it was scored %d times", tc.expectedCalls, tc.scorer.calls)
+			}
+		})
+	}
+}
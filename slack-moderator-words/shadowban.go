@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// shadowBanStore keeps the users who have been shadow-banned while running, as opposed to in the
+// filter config.
+type shadowBanStore interface {
+	IsBanned(ctx context.Context, user string) (bool, error)
+	Ban(ctx context.Context, user string) error
+	Unban(ctx context.Context, user string) error
+}
+
+// memoryShadowBans is a shadowBanStore that keeps bans in memory. They are lost when the process
+// exits, and aren't shared between replicas.
+type memoryShadowBans struct {
+	lock  sync.Mutex
+	users map[string]bool
+}
+
+func newMemoryShadowBans() *memoryShadowBans {
+	return &memoryShadowBans{users: map[string]bool{}}
+}
+
+// IsBanned implements shadowBanStore.
+func (m *memoryShadowBans) IsBanned(ctx context.Context, user string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.users[user], nil
+}
+
+// Ban implements shadowBanStore.
+func (m *memoryShadowBans) Ban(ctx context.Context, user string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.users[user] = true
+	return nil
+}
+
+// Unban implements shadowBanStore.
+func (m *memoryShadowBans) Unban(ctx context.Context, user string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.users, user)
+	return nil
+}
+
+// redisShadowBans is a shadowBanStore that keeps bans in a Redis set, so that they survive
+// restarts and are shared between replicas.
+type redisShadowBans struct {
+	client redis.UniversalClient
+	key    string
+}
+
+func newRedisShadowBans(client redis.UniversalClient, key string) *redisShadowBans {
+	return &redisShadowBans{client: client, key: key}
+}
+
+// IsBanned implements shadowBanStore.
+func (r *redisShadowBans) IsBanned(ctx context.Context, user string) (bool, error) {
+	return r.client.SIsMember(ctx, r.key, user).Result()
+}
+
+// Ban implements shadowBanStore.
+func (r *redisShadowBans) Ban(ctx context.Context, user string) error {
+	return r.client.SAdd(ctx, r.key, user).Err()
+}
+
+// Unban implements shadowBanStore.
+func (r *redisShadowBans) Unban(ctx context.Context, user string) error {
+	return r.client.SRem(ctx, r.key, user).Err()
+}
+
+// shadowBanned returns whether user is shadow-banned, either in the rules or since. If the store
+// can't be checked, the user is moderated as usual.
+func (h *handler) shadowBanned(ctx context.Context, r *rules, user string) bool {
+	if r.shadowBanned[user] {
+		return true
+	}
+	banned, err := h.shadowBans.IsBanned(ctx, user)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to check whether user is shadow-banned", "user_id", user, "error", err)
+		return false
+	}
+	return banned
+}
+
+// deleteShadowBanned deletes a message from a shadow-banned user, logging it first so that there's
+// a record of what they said.
+func (h *handler) deleteShadowBanned(ctx context.Context, client *slack.Client, event model.Event) error {
+	channel, _ := event.Channel.(string)
+	if h.inShadowMode(ctx) {
+		logging.FromContext(ctx).Info("Shadow mode, not deleting message from shadow-banned user", "ts", event.TS, "user_id", event.User, "text", h.redact.text(event.Text))
+		return nil
+	}
+	logging.FromContext(ctx).Info("Deleting message from shadow-banned user", "ts", event.TS, "user_id", event.User, "text", h.redact.text(event.Text))
+	ctx = slack.WithPriority(slack.WithToken(ctx, slack.TokenUser), slack.PriorityHigh)
+	if err := api.New(client).DeleteMessage(ctx, api.DeleteMessageRequest{Channel: channel, TS: event.TS}); err != nil {
+		return fmt.Errorf("failed to delete message from shadow-banned user: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+func TestShadowBan(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "banned in the config",
+			user:     "U0SPAM",
+			text:     "hello",
+			expected: []string{"chat.delete xoxp-admin"},
+		},
+		{
+			name:     "banned at runtime",
+			user:     "U0LATER",
+			text:     "free crypto",
+			expected: []string{"chat.delete xoxp-admin"},
+		},
+		{
+			name: "not banned",
+			user: "U1",
+			text: "hello",
+		},
+		{
+			name:     "not banned, but matching a filter",
+			user:     "U1",
+			text:     "free crypto",
+			expected: []string{"chat.postEphemeral xoxb-token"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Looking up the user's name for the logs isn't interesting here.
+				if r.URL.Path != "/api/users.info" {
+					calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/")+" "+strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			filters := model.FilterConfig{{Triggers: []string{"free crypto"}, Action: model.ActionWarn, Message: "Please don't."}}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters, ShadowBanned: []string{"U0SPAM"}})
+			if err := h.shadowBans.Ban(context.Background(), "U0LATER"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			body := []byte(`{"event": {"type": "message", "channel": "C1", "user": "` + tc.user + `", "text": "` + tc.text + `", "ts": "1612790186.002000"}}`)
+			if err := h.handleMessage(context.Background(), client, body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
+
+func TestShadowBanFromEscalation(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClient(server)
+
+	h := newHandler(nil, model.Config{})
+	ih := interactive.NewHandler(slack.NewClientSet(client))
+	h.handleEscalations(ih)
+	payload := `{
+		"type": "block_actions",
+		"team": {"id": "T1"},
+		"user": {"id": "U0MOD"},
+		"response_url": "https://hooks.slack.com/actions/T1/1/abc",
+		"message": {"text": "<@U1> posted a message", "blocks": []},
+		"actions": [{"action_id": "shadow_ban_user", "value": "U1"}]
+	}`
+	if _, err := ih.HandleSocketModeEnvelope(context.Background(), socketmode.TypeInteractive, []byte(payload)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !h.shadowBanned(context.Background(), h.rules(), "U1") {
+		t.Errorf("expected U1 to be shadow-banned")
+	}
+}
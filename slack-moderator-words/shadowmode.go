@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// dryRunSetting is the name of the dry run switch in the match store's settings.
+const dryRunSetting = "dry_run"
+
+// dryRunStore keeps the dry run switch that moderators flip on the App Home tab.
+type dryRunStore interface {
+	// DryRun returns whether the switch is on, and whether anyone has switched it yet.
+	DryRun(ctx context.Context) (on, switched bool, err error)
+	SetDryRun(ctx context.Context, on bool) error
+}
+
+// memoryDryRun is a dryRunStore that keeps the switch in memory. It is lost when the process
+// exits, and isn't shared between replicas.
+type memoryDryRun struct {
+	lock     sync.Mutex
+	on       bool
+	switched bool
+}
+
+func newMemoryDryRun() *memoryDryRun {
+	return &memoryDryRun{}
+}
+
+// DryRun implements dryRunStore.
+func (m *memoryDryRun) DryRun(ctx context.Context) (bool, bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.on, m.switched, nil
+}
+
+// SetDryRun implements dryRunStore.
+func (m *memoryDryRun) SetDryRun(ctx context.Context, on bool) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.on, m.switched = on, true
+	return nil
+}
+
+// redisDryRun is a dryRunStore that keeps the switch in a Redis key, so that it survives restarts
+// and is shared between replicas.
+type redisDryRun struct {
+	client redis.UniversalClient
+	key    string
+}
+
+func newRedisDryRun(client redis.UniversalClient, key string) *redisDryRun {
+	return &redisDryRun{client: client, key: key}
+}
+
+// DryRun implements dryRunStore.
+func (r *redisDryRun) DryRun(ctx context.Context) (bool, bool, error) {
+	value, err := r.client.Get(ctx, r.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return value == "on", true, nil
+}
+
+// SetDryRun implements dryRunStore.
+func (r *redisDryRun) SetDryRun(ctx context.Context, on bool) error {
+	return r.client.Set(ctx, r.key, dryRunValue(on), 0).Err()
+}
+
+// DryRun implements dryRunStore.
+func (s *matchStore) DryRun(ctx context.Context) (bool, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE name = $1`, dryRunSetting).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return value == "on", true, nil
+}
+
+// SetDryRun implements dryRunStore.
+func (s *matchStore) SetDryRun(ctx context.Context, on bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM settings WHERE name = $1`, dryRunSetting); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO settings (name, value) VALUES ($1, $2)`, dryRunSetting, dryRunValue(on)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// dryRunValue is how stores that keep strings keep the dry run switch.
+func dryRunValue(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// inShadowMode returns whether the handler only logs matches rather than acting on them: what the
+// dry run switch says, once a moderator has switched it, and --shadow-mode until then. If the
+// switch can't be read, --shadow-mode decides.
+func (h *handler) inShadowMode(ctx context.Context) bool {
+	on, switched, err := h.dryRun.DryRun(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to check the dry run switch", "error", err)
+		return h.shadowMode
+	}
+	if !switched {
+		return h.shadowMode
+	}
+	return on
+}
+
+// review reports a match that shadow mode didn't act on, in the review channel if there is one.
+func (h *handler) review(ctx context.Context, client *slack.Client, m *match) error {
+	logging.FromContext(ctx).Info("Shadow mode, not taking actions", "ts", m.event.TS, "user_id", m.event.User, "actions", m.steps, "message", m.message)
+	if h.reviewChannel == "" {
+		return nil
+	}
+	channel, err := client.Channels().ID(ctx, h.reviewChannel)
+	if err != nil {
+		return fmt.Errorf("failed to find review channel %q: %v", h.reviewChannel, err)
+	}
+	text, b := reviewBlocks(m)
+	if _, err := api.New(client).PostMessage(ctx, api.PostMessageRequest{Channel: channel, Text: text, Blocks: b}); err != nil {
+		return fmt.Errorf("failed to post match for review: %v", err)
+	}
+	return nil
+}
+
+// reviewBlocks returns the notification text and blocks that report m in the review channel.
+func reviewBlocks(m *match) (string, []blocks.Block) {
+	channel, _ := m.event.Channel.(string)
+	text := fmt.Sprintf("A filter matched a message from <@%s> in <#%s>, but wasn't enforced", m.event.User, channel)
+	if len(m.fields) > 0 {
+		text = fmt.Sprintf("A filter matched the %s of <@%s>, but wasn't enforced", m.fieldNames(), m.event.User)
+	}
+	if m.emoji != "" {
+		text = fmt.Sprintf("A filter matched the custom emoji `:%s:`, but wasn't enforced", m.emoji)
+	}
+	triggers := make([]string, 0, len(m.triggers))
+	for _, t := range m.triggers {
+		triggers = append(triggers, "`"+t+"`")
+	}
+	fields := []*blocks.Text{
+		blocks.Markdown("*Matched*\n" + strings.Join(triggers, ", ")),
+		blocks.Markdown("*Would have*\n" + strings.Join(m.steps, ", ")),
+	}
+	if m.strikes > 0 {
+		fields = append(fields, blocks.Markdown(fmt.Sprintf("*Strikes*\n%d", m.strikes)))
+	}
+	b := []blocks.Block{blocks.Section(blocks.Markdown(":eyes: "+text+":\n"+quote(m.event.Text)), fields...)}
+	if m.warns() && m.message != "" {
+		b = append(b, blocks.Context(blocks.Markdown("Warning: "+m.message)))
+	}
+	if m.permalink != "" {
+		b = append(b, blocks.Actions(blocks.LinkButton(actionViewMessage, "View message", m.permalink)))
+	}
+	return text, b
+}
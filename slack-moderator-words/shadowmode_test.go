@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestShadowMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          string
+		reviewChannel string
+		expected      []string
+	}{
+		{
+			name: "only logged",
+			user: "U1",
+		},
+		{
+			name:          "posted for review",
+			user:          "U1",
+			reviewChannel: "C0REVIEWS",
+			expected:      []string{"chat.getPermalink", "conversations.list", "chat.postMessage C0REVIEWS"},
+		},
+		{
+			name: "shadow-banned user",
+			user: "U0SPAM",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				method := strings.TrimPrefix(r.URL.Path, "/api/")
+				switch method {
+				case "users.info":
+					_, _ = w.Write([]byte(`{"ok": true}`))
+					return
+				case "chat.postMessage":
+					body, _ := ioutil.ReadAll(r.Body)
+					req := struct {
+						Channel string `json:"channel"`
+					}{}
+					_ = json.Unmarshal(body, &req)
+					method += " " + req.Channel
+				}
+				calls = append(calls, method)
+				_, _ = w.Write([]byte(`{"ok": true, "permalink": "https://example.slack.com/archives/C1/p1612790186002000"}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			config, err := model.ParseConfig([]byte(`
+shadow_banned: [U0SPAM]
+filters:
+- triggers: [free crypto]
+  action: delete
+  message: "Please don't."
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, config)
+			h.shadowMode, h.reviewChannel = true, tc.reviewChannel
+			body := []byte(`{"event": {"type": "message", "channel": "C1", "user": "` + tc.user + `", "text": "free crypto", "ts": "1612790186.002000"}}`)
+			if err := h.handleMessage(context.Background(), client, body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// strikeStore counts strikes against users.
+type strikeStore interface {
+	// Add records a strike against user, and returns how many strikes they have had in the last
+	// ttl, including this one.
+	Add(ctx context.Context, user string, ttl time.Duration) (int, error)
+}
+
+// memoryStrikes is a strikeStore that keeps strikes in memory. They are lost when the process
+// exits, and aren't shared between replicas.
+type memoryStrikes struct {
+	lock    sync.Mutex
+	strikes map[string][]time.Time
+	now     func() time.Time
+}
+
+func newMemoryStrikes() *memoryStrikes {
+	return &memoryStrikes{strikes: map[string][]time.Time{}, now: time.Now}
+}
+
+// Add implements strikeStore.
+func (m *memoryStrikes) Add(ctx context.Context, user string, ttl time.Duration) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := m.now()
+	// Strikes are added in order, so the expired ones are at the start.
+	strikes := m.strikes[user]
+	for len(strikes) > 0 && now.Sub(strikes[0]) >= ttl {
+		strikes = strikes[1:]
+	}
+	strikes = append(strikes, now)
+	m.strikes[user] = strikes
+	return len(strikes), nil
+}
+
+// redisStrikes is a strikeStore that keeps each user's strikes in a Redis sorted set, scored by
+// when they happened, so that they survive restarts and are shared between replicas.
+type redisStrikes struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+func newRedisStrikes(client redis.UniversalClient, prefix string) *redisStrikes {
+	return &redisStrikes{client: client, prefix: prefix, now: time.Now}
+}
+
+// Add implements strikeStore.
+func (r *redisStrikes) Add(ctx context.Context, user string, ttl time.Duration) (int, error) {
+	key := r.prefix + user
+	now := r.now()
+	var count *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-ttl).UnixMilli(), 10))
+		pipe.ZAdd(ctx, key, &redis.Z{Score: float64(now.UnixMilli()), Member: now.UnixNano()})
+		count = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestMemoryStrikes(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := newMemoryStrikes()
+	s.now = func() time.Time { return now }
+
+	steps := []struct {
+		after    time.Duration
+		user     string
+		expected int
+	}{
+		{user: "U1", expected: 1},
+		{after: time.Hour, user: "U1", expected: 2},
+		{user: "U2", expected: 1},
+		{after: 23 * time.Hour, user: "U1", expected: 2},
+		{after: time.Hour, user: "U1", expected: 2},
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		strikes, err := s.Add(context.Background(), step.user, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if strikes != step.expected {
+			t.Errorf("step %d: expected %s to have %d strikes, but got %d", i+1, step.user, step.expected, strikes)
+		}
+	}
+}
+
+func TestHandleMessageStrikes(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Looking up the user's name for the logs isn't interesting here.
+		if r.URL.Path != "/api/users.info" {
+			calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+	client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+	config, err := model.ParseConfig([]byte(`
+strikes:
+  levels:
+  - strikes: 1
+    actions: [warn]
+  - strikes: 2
+    actions: [delete, warn]
+  - strikes: 3
+    actions: [delete, kick]
+filters:
+- triggers: [free crypto]
+  strikes: true
+  message: "Please don't."
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(nil, config)
+	expected := [][]string{
+		{"chat.postEphemeral"},
+		{"chat.delete", "chat.postEphemeral"},
+		{"chat.delete", "conversations.kick"},
+	}
+	for i, e := range expected {
+		calls = nil
+		body := []byte(`{"event": {"type": "message", "channel": "C1", "user": "U1", "text": "free crypto", "ts": "1612790186.002000"}}`)
+		if err := h.handleMessage(context.Background(), client, body); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(calls, e) {
+			t.Errorf("strike %d: expected calls %v, but got %v", i+1, e, calls)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// topics remembers the last topic and purpose we saw each channel set to, so that a change to them
+// can be reverted. Slack doesn't say what they were before.
+type topics struct {
+	lock   sync.Mutex
+	values map[topicKey]string
+}
+
+type topicKey struct {
+	client  *slack.Client
+	channel string
+	// subtype is model.SubtypeChannelTopic or model.SubtypeChannelPurpose.
+	subtype string
+}
+
+func newTopics() *topics {
+	return &topics{values: map[topicKey]string{}}
+}
+
+// isTopicChange returns whether message is about someone changing a channel's topic or purpose.
+func isTopicChange(message model.Event) bool {
+	return message.Subtype == model.SubtypeChannelTopic || message.Subtype == model.SubtypeChannelPurpose
+}
+
+// topicValue returns the new topic or purpose of a channel in a message about it changing.
+func topicValue(message model.Event) string {
+	if message.Subtype == model.SubtypeChannelPurpose {
+		return message.Purpose
+	}
+	return message.Topic
+}
+
+// swap records the new value of a channel's topic or purpose, and returns the one it replaced, if
+// we saw what that was.
+func (t *topics) swap(client *slack.Client, channel, subtype, value string) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	key := topicKey{client, channel, subtype}
+	previous, ok := t.values[key]
+	t.values[key] = value
+	return previous, ok
+}
+
+// revert changes the topic or purpose a matching message is about back to what it was before. If
+// we haven't seen it change before, that is what the channel cache says it was, if that isn't the
+// new one, and otherwise it is cleared.
+func (h *handler) revert(ctx context.Context, client *slack.Client, m *match) error {
+	channel, _ := m.event.Channel.(string)
+	if !isTopicChange(m.event) {
+		logging.FromContext(ctx).Debug("Not reverting a message that doesn't change the topic or purpose", "ts", m.event.TS)
+		return nil
+	}
+	previous, known := m.previousTopic, m.previousTopicKnown
+	if !known {
+		if c, err := client.Channels().Conversation(ctx, channel); err == nil {
+			previous = c.Topic.Topic
+			if m.event.Subtype == model.SubtypeChannelPurpose {
+				previous = c.Purpose.Purpose
+			}
+		}
+		if previous == topicValue(m.event) {
+			previous = ""
+		}
+	}
+	logging.FromContext(ctx).Info("Reverting channel change", "subtype", m.event.Subtype, "ts", m.event.TS, "user_id", m.event.User, "previous", h.redact.text(previous))
+	c := api.New(client)
+	if m.event.Subtype == model.SubtypeChannelPurpose {
+		if err := c.SetConversationPurpose(ctx, channel, previous); err != nil {
+			return fmt.Errorf("failed to revert channel purpose: %v", err)
+		}
+		return nil
+	}
+	if err := c.SetConversationTopic(ctx, channel, previous); err != nil {
+		return fmt.Errorf("failed to revert channel topic: %v", err)
+	}
+	return nil
+}
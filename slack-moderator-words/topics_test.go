@@ -0,0 +1,118 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestRevertTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		event    string
+		expected []string
+	}{
+		{
+			name:     "scam topic",
+			before:   `{"type": "message", "subtype": "channel_topic", "channel": "C1", "user": "U2", "text": "set the channel topic: Meeting notes", "topic": "Meeting notes", "ts": "1612790100.000100"}`,
+			event:    `{"type": "message", "subtype": "channel_topic", "channel": "C1", "user": "U1", "text": "set the channel topic: Free crypto", "topic": "Free crypto", "ts": "1612790186.002000"}`,
+			expected: []string{"conversations.setTopic topic=Meeting notes"},
+		},
+		{
+			name:     "scam topic in a channel we haven't seen change",
+			event:    `{"type": "message", "subtype": "channel_topic", "channel": "C1", "user": "U1", "text": "set the channel topic: Free crypto", "topic": "Free crypto", "ts": "1612790186.002000"}`,
+			expected: []string{"conversations.list", "conversations.setTopic topic=Welcome"},
+		},
+		{
+			name:     "scam purpose",
+			before:   `{"type": "message", "subtype": "channel_purpose", "channel": "C1", "user": "U2", "text": "set the channel purpose: Talking about Kubernetes", "purpose": "Talking about Kubernetes", "ts": "1612790100.000100"}`,
+			event:    `{"type": "message", "subtype": "channel_purpose", "channel": "C1", "user": "U1", "text": "set the channel purpose: Free crypto", "purpose": "Free crypto", "ts": "1612790186.002000"}`,
+			expected: []string{"conversations.setPurpose purpose=Talking about Kubernetes"},
+		},
+		{
+			name:     "topic changed by a bot",
+			before:   `{"type": "message", "subtype": "channel_topic", "channel": "C1", "bot_id": "B1", "text": "set the channel topic: Meeting notes", "topic": "Meeting notes", "ts": "1612790100.000100"}`,
+			event:    `{"type": "message", "subtype": "channel_topic", "channel": "C1", "user": "U1", "text": "set the channel topic: Free crypto", "topic": "Free crypto", "ts": "1612790186.002000"}`,
+			expected: []string{"conversations.setTopic topic=Meeting notes"},
+		},
+		{
+			name:  "ordinary topic",
+			event: `{"type": "message", "subtype": "channel_topic", "channel": "C1", "user": "U1", "text": "set the channel topic: Meeting notes", "topic": "Meeting notes", "ts": "1612790186.002000"}`,
+		},
+		{
+			name:  "ordinary message",
+			event: `{"type": "message", "channel": "C1", "user": "U1", "text": "Free crypto", "ts": "1612790186.002000"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				call := strings.TrimPrefix(r.URL.Path, "/api/")
+				switch call {
+				case "users.info":
+					_, _ = w.Write([]byte(`{"ok": true}`))
+					return
+				case "conversations.list":
+					calls = append(calls, call)
+					_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C1", "name": "general", "topic": {"value": "Welcome"}, "purpose": {"value": "General chat"}}]}`))
+					return
+				}
+				body := map[string]string{}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				if v, ok := body["topic"]; ok {
+					call += " topic=" + v
+				}
+				if v, ok := body["purpose"]; ok {
+					call += " purpose=" + v
+				}
+				calls = append(calls, call)
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClient(server)
+
+			filters := model.FilterConfig{{Triggers: []string{"free crypto"}, Action: model.ActionRevert}}
+			if err := filters.Compile(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, model.Config{Filters: filters})
+			for _, event := range []string{tc.before, tc.event} {
+				if event == "" {
+					continue
+				}
+				if err := h.handleMessage(context.Background(), client, []byte(`{"event": `+event+`}`)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+// maxTriggerListSize is the most we read of a trigger list, which is far more than any sensible
+// list needs.
+const maxTriggerListSize = 10 << 20
+
+// triggerLists fetches the trigger lists that filters use, and remembers them so that they only
+// have to be downloaded again when they change.
+type triggerLists struct {
+	client *http.Client
+	lock   sync.Mutex
+	lists  map[string]*triggerList
+}
+
+// triggerList is the last version of a trigger list we fetched.
+type triggerList struct {
+	etag     string
+	triggers []string
+}
+
+func newTriggerLists() *triggerLists {
+	return &triggerLists{client: &http.Client{Timeout: 30 * time.Second}, lists: map[string]*triggerList{}}
+}
+
+// fetch brings the lists at urls up to date, and returns the triggers in each of them, and whether
+// any have changed since they were last fetched. Lists that can't be fetched are logged, and keep
+// the triggers they had, if any.
+func (t *triggerLists) fetch(ctx context.Context, urls []string) (map[string][]string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	triggers := map[string][]string{}
+	changed := false
+	for _, u := range urls {
+		list, ok := t.lists[u]
+		if !ok {
+			list = &triggerList{}
+		}
+		updated, err := t.fetchList(ctx, u, list)
+		if err != nil {
+			logging.FromContext(ctx).Error("Failed to fetch trigger list", "url", u, "error", err)
+		}
+		if updated {
+			logging.FromContext(ctx).Info("Fetched trigger list", "url", u, "triggers", len(list.triggers))
+			t.lists[u] = list
+			changed = true
+		}
+		triggers[u] = list.triggers
+	}
+	return triggers, changed
+}
+
+// cached returns the triggers in each of the lists at urls as they were last fetched, without
+// fetching them again.
+func (t *triggerLists) cached(urls []string) map[string][]string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	triggers := map[string][]string{}
+	for _, u := range urls {
+		if list, ok := t.lists[u]; ok {
+			triggers[u] = list.triggers
+		}
+	}
+	return triggers
+}
+
+// fetchList updates list from url, unless its ETag says it hasn't changed, and returns whether it
+// did.
+func (t *triggerLists) fetchList(ctx context.Context, u string, list *triggerList) (bool, error) {
+	location, err := triggerListURL(u)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %v", err)
+	}
+	if list.etag != "" {
+		req.Header.Set("If-None-Match", list.etag)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, fmt.Errorf("got %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTriggerListSize+1))
+	if err != nil {
+		return false, fmt.Errorf("failed to read trigger list: %v", err)
+	}
+	if len(body) > maxTriggerListSize {
+		return false, fmt.Errorf("trigger list is bigger than %d bytes", maxTriggerListSize)
+	}
+	list.etag = resp.Header.Get("ETag")
+	list.triggers = model.ParseTriggerList(body)
+	return true, nil
+}
+
+// triggerListURL returns the https:// URL to fetch a trigger list from. Lists in S3 and GCS buckets,
+// given as s3://bucket/key or gs://bucket/object, have to be publicly readable.
+func triggerListURL(u string) (string, error) {
+	switch {
+	case strings.HasPrefix(u, "https://"), strings.HasPrefix(u, "http://"):
+		return u, nil
+	case strings.HasPrefix(u, "s3://"):
+		bucket, key, ok := strings.Cut(strings.TrimPrefix(u, "s3://"), "/")
+		if !ok || bucket == "" || key == "" {
+			return "", fmt.Errorf("expected s3://<bucket>/<key>, not %q", u)
+		}
+		return "https://" + bucket + ".s3.amazonaws.com/" + key, nil
+	case strings.HasPrefix(u, "gs://"):
+		bucket, object, ok := strings.Cut(strings.TrimPrefix(u, "gs://"), "/")
+		if !ok || bucket == "" || object == "" {
+			return "", fmt.Errorf("expected gs://<bucket>/<object>, not %q", u)
+		}
+		return "https://storage.googleapis.com/" + bucket + "/" + object, nil
+	default:
+		return "", fmt.Errorf("unsupported trigger list URL %q (expected https://, s3:// or gs://)", u)
+	}
+}
+
+// configure makes the handler use config, with the triggers from its trigger lists. It doesn't
+// fail if a trigger list can't be fetched, only if the lists make the config invalid.
+func (h *handler) configure(ctx context.Context, config model.Config) error {
+	h.configureLock.Lock()
+	defer h.configureLock.Unlock()
+	return h.applyTriggerLists(ctx, config, true)
+}
+
+// refreshTriggerLists fetches the trigger lists in the current filter config again, and starts
+// using them if any have changed.
+func (h *handler) refreshTriggerLists(ctx context.Context) error {
+	h.configureLock.Lock()
+	defer h.configureLock.Unlock()
+	return h.applyTriggerLists(ctx, h.rules().config, false)
+}
+
+// applyTriggerLists replaces the handler's rules with config and its trigger lists, unless always
+// isn't set and none of the lists have changed.
+func (h *handler) applyTriggerLists(ctx context.Context, config model.Config, always bool) error {
+	lists, changed := h.triggerLists.fetch(ctx, config.TriggerLists())
+	if !changed && !always {
+		return nil
+	}
+	return h.applyConfig(config, lists, h.runtimeFilters)
+}
+
+// applyConfig replaces the handler's rules with config, the triggers in its trigger lists, and
+// runtime filters after its own. The caller must hold configureLock.
+func (h *handler) applyConfig(config model.Config, lists map[string][]string, runtime []runtimeFilter) error {
+	combined := config
+	if len(runtime) > 0 {
+		combined.Filters = append(append(model.FilterConfig{}, config.Filters...), runtimeFilterConfig(runtime)...)
+	}
+	withLists, err := combined.WithTriggerLists(lists)
+	if err != nil {
+		return fmt.Errorf("invalid filter config with trigger lists: %v", err)
+	}
+	r := newRules(withLists)
+	r.config = config
+	h.setRules(r)
+	return nil
+}
+
+// refreshTriggerListsEvery calls refreshTriggerLists every interval until ctx is done.
+func (h *handler) refreshTriggerListsEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.refreshTriggerLists(ctx); err != nil {
+				logging.FromContext(ctx).Error("Failed to refresh trigger lists", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestTriggerListURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+		err      bool
+	}{
+		{url: "https://raw.githubusercontent.com/example/lists/main/scams.txt", expected: "https://raw.githubusercontent.com/example/lists/main/scams.txt"},
+		{url: "s3://blocklists/scams.txt", expected: "https://blocklists.s3.amazonaws.com/scams.txt"},
+		{url: "gs://blocklists/lists/scams.txt", expected: "https://storage.googleapis.com/blocklists/lists/scams.txt"},
+		{url: "s3://blocklists", err: true},
+		{url: "ftp://example.com/scams.txt", err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			u, err := triggerListURL(tc.url)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error, but got %q", u)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if u != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, u)
+			}
+		})
+	}
+}
+
+func TestRefreshTriggerLists(t *testing.T) {
+	list, etag := "free crypto\n", `"v1"`
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(list))
+	}))
+	defer server.Close()
+
+	config, err := model.ParseConfig([]byte(`
+- trigger_lists: [` + server.URL + `/scams.txt]
+  action: delete
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	h := newHandler(nil, config)
+	if err := h.configure(context.Background(), config); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := h.rules()
+	if matches := r.filters[0].Matches("free crypto"); len(matches) == 0 {
+		t.Errorf("expected the trigger list to match")
+	}
+
+	// The list hasn't changed, so the rules stay the same.
+	if err := h.refreshTriggerLists(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h.rules() != r {
+		t.Errorf("expected the rules not to be replaced")
+	}
+
+	list, etag = "airdrop\n", `"v2"`
+	if err := h.refreshTriggerLists(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter := h.rules().filters[0]
+	if matches := filter.Matches("free crypto"); len(matches) != 0 {
+		t.Errorf("expected the old trigger list not to match, but got %q", matches)
+	}
+	if matches := filter.Matches("airdrop"); len(matches) == 0 {
+		t.Errorf("expected the new trigger list to match")
+	}
+	if expected := []string{"", `"v1"`, `"v1"`}; !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected requests with ETags %q, but got %q", expected, requests)
+	}
+}
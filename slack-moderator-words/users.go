@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// The parts of a user's profile that filters that check users look at.
+const (
+	fieldDisplayName = "display name"
+	fieldRealName    = "real name"
+	// fieldProfile is the rest of the profile that people can write in: the title, the status and
+	// the workspace's custom fields, which is where spammers park links.
+	fieldProfile = "profile"
+)
+
+// nameFields are the profile fields that the names are in, for ActionRename.
+var nameFields = map[string]string{fieldDisplayName: "display_name", fieldRealName: "real_name"}
+
+// renamedRealName is what ActionRename changes a real name to, since Slack requires one. Display
+// names are cleared instead, so that Slack shows the real name.
+const renamedRealName = "Renamed user"
+
+// handleUserChange checks the profiles of users who join or change their profile with the filters
+// that check users, to catch offensive names, people impersonating admins and scam links.
+// Slack Events needed for this: team_join, user_change
+func (h *handler) handleUserChange(ctx context.Context, client *slack.Client, body []byte) error {
+	event := struct {
+		Event struct {
+			Type string     `json:"type"`
+			User slack.User `json:"user"`
+		} `json:"event"`
+	}{}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
+	}
+	user := event.Event.User
+	// Admins and owners are the people impersonators pretend to be, so they can use any name.
+	if user.ID == "" || user.Deleted || user.IsBot || user.IsAdmin || user.IsOwner {
+		return nil
+	}
+	r := h.rules()
+	if !r.checksUsers {
+		return nil
+	}
+	fields := map[string]string{
+		fieldDisplayName: user.Profile.DisplayName,
+		fieldRealName:    user.Profile.RealName,
+		fieldProfile:     profileText(user),
+	}
+	// Slack sends user_change for every change to a profile, so only check what has changed, and
+	// don't act on the same name twice.
+	changed := h.userProfiles.changed(client, user.ID, fields)
+	if len(changed) == 0 {
+		return nil
+	}
+	if changed[fieldProfile] && h.resolveShortLinks && r.checksLinks {
+		if links := h.resolveLinks(ctx, fields[fieldProfile]); links != "" {
+			fields[fieldProfile] += "\n" + links
+		}
+	}
+
+	for i, filter := range r.filters {
+		if !filter.ChecksUsers() {
+			continue
+		}
+		var matches, kinds, matched []string
+		for _, kind := range []string{fieldDisplayName, fieldRealName, fieldProfile} {
+			if !changed[kind] {
+				continue
+			}
+			var n []string
+			if kind == fieldProfile && filter.Profiles {
+				n = filter.Matches(fields[kind])
+			} else if kind != fieldProfile && filter.ChecksNames() {
+				n = filter.MatchesName(fields[kind])
+			}
+			if len(n) > 0 {
+				matches = append(matches, n...)
+				kinds = append(kinds, kind)
+				matched = append(matched, fields[kind])
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		if h.exempt(ctx, client, filter, user.ID) {
+			logging.FromContext(ctx).Debug("User is exempt from matching filter", "user_id", user.ID, "triggers", h.redact.texts(matches))
+			continue
+		}
+		e := model.Event{Type: event.Event.Type, User: user.ID, Text: strings.Join(matched, "\n")}
+		m := &match{rules: r, index: i, filter: filter, event: e, triggers: matches, fields: kinds}
+		m.steps, m.strikes = h.steps(ctx, m)
+		logging.FromContext(ctx).Info("Profile matched triggers", "user_id", user.ID, "fields", kinds, "triggers", h.redact.texts(matches), "severity", filter.Severity, "actions", m.steps)
+		h.runActions(ctx, client, m)
+	}
+	return nil
+}
+
+// profileText returns the parts of user's profile other than their names that filters that check
+// profiles look at, each on its own line: their title, status, and the values of the workspace's
+// custom fields, in the order of the fields' IDs.
+func profileText(user slack.User) string {
+	parts := []string{user.Profile.Title, user.Profile.StatusText}
+	ids := make([]string, 0, len(user.Profile.Fields))
+	for id := range user.Profile.Fields {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		f := user.Profile.Fields[id]
+		parts = append(parts, f.Value, f.Alt)
+	}
+	var text []string
+	for _, p := range parts {
+		if strings.TrimSpace(p) != "" {
+			text = append(text, p)
+		}
+	}
+	return strings.Join(text, "\n")
+}
+
+// fieldNames returns the parts of the profile that matched, such as "display name and real name".
+func (m *match) fieldNames() string {
+	return strings.Join(m.fields, " and ")
+}
+
+// rename resets the names of a user that matched a filter.
+func rename(ctx context.Context, client *slack.Client, m *match) error {
+	profile := map[string]string{}
+	for _, kind := range m.fields {
+		if field, ok := nameFields[kind]; ok {
+			profile[field] = ""
+		}
+	}
+	if len(profile) == 0 {
+		return fmt.Errorf("there is no name to rename")
+	}
+	if _, ok := profile[nameFields[fieldRealName]]; ok {
+		profile[nameFields[fieldRealName]] = renamedRealName
+	}
+	// Only admins can change other people's profiles, so this needs their user token.
+	ctx = slack.WithPriority(slack.WithToken(ctx, slack.TokenUser), slack.PriorityHigh)
+	if err := api.New(client).SetUserProfile(ctx, m.event.User, profile); err != nil {
+		return fmt.Errorf("failed to rename user: %v", err)
+	}
+	return nil
+}
+
+// userProfiles remembers what users' profiles said when they were last checked.
+type userProfiles struct {
+	lock     sync.Mutex
+	profiles map[userProfileKey]map[string]string
+}
+
+type userProfileKey struct {
+	client *slack.Client
+	user   string
+}
+
+func newUserProfiles() *userProfiles {
+	return &userProfiles{profiles: map[userProfileKey]map[string]string{}}
+}
+
+// changed records the fields of user's profile, and returns the ones that are different from the
+// ones recorded last time, or that are set if there weren't any.
+func (u *userProfiles) changed(client *slack.Client, user string, fields map[string]string) map[string]bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	key := userProfileKey{client, user}
+	last := u.profiles[key]
+	u.profiles[key] = fields
+	changed := map[string]bool{}
+	for kind, value := range fields {
+		if value != last[kind] {
+			changed[kind] = true
+		}
+	}
+	return changed
+}
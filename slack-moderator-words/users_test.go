@@ -0,0 +1,155 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestUserChange(t *testing.T) {
+	tests := []struct {
+		name            string
+		user            string
+		then            string
+		expected        []string
+		expectedProfile map[string]string
+	}{
+		{
+			name:            "impersonating display name",
+			user:            `{"id": "U1", "profile": {"display_name": "Kubernetes Adrnin", "real_name": "Jane Doe"}}`,
+			expected:        []string{"users.profile.set", "chat.postMessage"},
+			expectedProfile: map[string]string{"display_name": ""},
+		},
+		{
+			name:            "impersonating real name",
+			user:            `{"id": "U1", "profile": {"display_name": "", "real_name": "kubernetes_admin (official)"}}`,
+			expected:        []string{"users.profile.set", "chat.postMessage"},
+			expectedProfile: map[string]string{"real_name": "Renamed user"},
+		},
+		{
+			name:     "offensive name",
+			user:     `{"id": "U1", "profile": {"display_name": "n4zi", "real_name": "Jane Doe"}}`,
+			expected: []string{"conversations.open", "chat.postMessage"},
+		},
+		{
+			name:     "only warned once",
+			user:     `{"id": "U1", "profile": {"display_name": "n4zi", "real_name": "Jane Doe"}}`,
+			then:     `{"id": "U1", "profile": {"display_name": "n4zi", "real_name": "Jane Doe", "status_text": "lunch"}}`,
+			expected: []string{"conversations.open", "chat.postMessage"},
+		},
+		{
+			name:     "scam link in title",
+			user:     `{"id": "U1", "profile": {"display_name": "jane", "real_name": "Jane Doe", "title": "Claim your airdrop at <https://evil.example/claim>"}}`,
+			expected: []string{"chat.postMessage"},
+		},
+		{
+			name:     "scam link added to custom field",
+			user:     `{"id": "U1", "profile": {"display_name": "jane", "real_name": "Jane Doe", "fields": {"Xf01": {"value": "https://github.com/jane", "alt": ""}}}}`,
+			then:     `{"id": "U1", "profile": {"display_name": "jane", "real_name": "Jane Doe", "fields": {"Xf01": {"value": "https://evil.example/", "alt": "My site"}}}}`,
+			expected: []string{"chat.postMessage"},
+		},
+		{
+			name: "the real admin",
+			user: `{"id": "U1", "is_admin": true, "profile": {"display_name": "Kubernetes Admin", "real_name": "Kubernetes Admin"}}`,
+		},
+		{
+			name: "ordinary name",
+			user: `{"id": "U1", "profile": {"display_name": "jane", "real_name": "Jane Doe"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			var profile map[string]string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				method := r.URL.Path[len("/api/"):]
+				switch method {
+				case "users.info", "conversations.list":
+				case "conversations.open":
+					calls = append(calls, method)
+					_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "D1"}}`))
+					return
+				case "users.profile.set":
+					calls = append(calls, method)
+					body, _ := io.ReadAll(r.Body)
+					req := struct {
+						User    string            `json:"user"`
+						Profile map[string]string `json:"profile"`
+					}{}
+					if err := json.Unmarshal(body, &req); err != nil {
+						t.Errorf("failed to unmarshal profile: %v", err)
+					}
+					if req.User != "U1" {
+						t.Errorf("expected to rename U1, but renamed %q", req.User)
+					}
+					profile = req.Profile
+				default:
+					calls = append(calls, method)
+				}
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+			client := testClientWithConfig(server, slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-admin"})
+
+			config, err := model.ParseConfig([]byte(`
+moderators_channel: C0MODERATORS
+filters:
+- protected_names: [Kubernetes Admin]
+  actions: [rename, escalate]
+- names: true
+  triggers: [nazi]
+  match: word
+  action: warn
+  message: Please pick another name.
+- profiles: true
+  domains: [evil.example]
+  action: escalate
+`))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			h := newHandler(nil, config)
+			body := []byte(`{"event": {"type": "user_change", "user": ` + tc.user + `}}`)
+			if err := h.handleUserChange(context.Background(), client, body); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.then != "" {
+				body := []byte(`{"event": {"type": "user_change", "user": ` + tc.then + `}}`)
+				if err := h.handleUserChange(context.Background(), client, body); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+			if !reflect.DeepEqual(profile, tc.expectedProfile) {
+				t.Errorf("expected profile %v, but got %v", tc.expectedProfile, profile)
+			}
+		})
+	}
+}
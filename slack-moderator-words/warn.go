@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+	"sigs.k8s.io/slack-infra/slack/api"
+)
+
+// warn shows the filter's message to the author of the matching message, delivered the way the
+// filter asks.
+func (h *handler) warn(ctx context.Context, client *slack.Client, m *match) error {
+	channel, _ := m.event.Channel.(string)
+	c := api.New(client)
+	delivery := m.filter.DeliversBy()
+	if len(m.fields) > 0 {
+		// There's no channel to warn someone about their profile in.
+		delivery = model.DeliveryDM
+	}
+	switch delivery {
+	case model.DeliveryDM:
+		dm, err := c.OpenConversation(ctx, m.event.User)
+		if err != nil {
+			return fmt.Errorf("failed to open DM with %s: %v", m.event.User, err)
+		}
+		_, err = c.PostMessage(ctx, api.PostMessageRequest{Channel: dm, Text: m.message})
+		return err
+	case model.DeliveryThread:
+		threadTS := m.event.ThreadTS
+		if threadTS == "" {
+			if m.deleted {
+				// There's no message left to start a thread on, so only tell the author.
+				return warnEphemeral(ctx, c, channel, m)
+			}
+			threadTS = m.event.TS
+		}
+		_, err := c.PostMessage(ctx, api.PostMessageRequest{Channel: channel, Text: m.message, ThreadTS: threadTS})
+		return err
+	case model.DeliveryChannel:
+		_, err := c.PostMessage(ctx, api.PostMessageRequest{Channel: channel, Text: m.message, ThreadTS: m.event.ThreadTS})
+		return err
+	default:
+		return warnEphemeral(ctx, c, channel, m)
+	}
+}
+
+func warnEphemeral(ctx context.Context, c *api.Client, channel string, m *match) error {
+	_, err := c.PostEphemeral(ctx, api.PostEphemeralRequest{
+		Channel:  channel,
+		User:     m.event.User,
+		Text:     m.message,
+		ThreadTS: m.event.ThreadTS,
+	})
+	return err
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack-moderator-words/model"
+)
+
+func TestWarn(t *testing.T) {
+	tests := []struct {
+		name     string
+		delivery string
+		threadTS string
+		deleted  bool
+		expected []string
+	}{
+		{
+			name:     "ephemeral by default",
+			expected: []string{"chat.postEphemeral C1 "},
+		},
+		{
+			name:     "DM",
+			delivery: model.DeliveryDM,
+			expected: []string{"conversations.open  ", "chat.postMessage D1 "},
+		},
+		{
+			name:     "thread reply",
+			delivery: model.DeliveryThread,
+			expected: []string{"chat.postMessage C1 1612790186.002000"},
+		},
+		{
+			name:     "thread reply to a message in a thread",
+			delivery: model.DeliveryThread,
+			threadTS: "1612790100.001000",
+			expected: []string{"chat.postMessage C1 1612790100.001000"},
+		},
+		{
+			name:     "thread reply to a deleted message",
+			delivery: model.DeliveryThread,
+			deleted:  true,
+			expected: []string{"chat.postEphemeral C1 "},
+		},
+		{
+			name:     "channel",
+			delivery: model.DeliveryChannel,
+			expected: []string{"chat.postMessage C1 "},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				req := struct {
+					Channel  string `json:"channel"`
+					ThreadTS string `json:"thread_ts"`
+				}{}
+				_ = json.Unmarshal(body, &req)
+				calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/")+" "+req.Channel+" "+req.ThreadTS)
+				w.Header().Set("Content-Type", "application/json")
+				if r.URL.Path == "/api/conversations.open" {
+					_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "D1"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+
+			h := newHandler(nil, model.Config{})
+			m := &match{
+				filter:  model.Filter{Delivery: tc.delivery},
+				message: "Please don't.",
+				event:   model.Event{Channel: "C1", User: "U1", TS: "1612790186.002000", ThreadTS: tc.threadTS},
+				deleted: tc.deleted,
+			}
+			if err := h.warn(context.Background(), testClient(server), m); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tc.expected) {
+				t.Errorf("expected calls %v, but got %v", tc.expected, calls)
+			}
+		})
+	}
+}
@@ -17,17 +17,20 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/interactive"
 )
 
-func (h *handler) removeUserContent(interaction slackInteraction, duration time.Duration, targetUser string) (removedFiles, remainingFiles, removedMessages, remainingMessages int, err error) {
+func (h *handler) removeUserContent(interaction *interactive.Payload, duration time.Duration, targetUser string) (removedFiles, remainingFiles, removedMessages, remainingMessages int, err error) {
 	start := time.Now().Add(-duration)
 
 	wg := sync.WaitGroup{}
@@ -37,7 +40,7 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 		var err error
 		removedFiles, remainingFiles, err = h.removeFilesFromUser(targetUser, start)
 		if err != nil {
-			log.Printf("Couldn't remove files: %v", err)
+			slog.Error("Couldn't remove files", "user", targetUser, "error", err)
 		}
 	}()
 	go func() {
@@ -45,7 +48,7 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 		var err error
 		removedMessages, remainingMessages, err = h.removeMessagesFromUser(targetUser, start)
 		if err != nil {
-			log.Printf("Couldn't remove messages: %v", err)
+			slog.Error("Couldn't remove messages", "user", targetUser, "error", err)
 		}
 	}()
 	wg.Wait()
@@ -55,27 +58,17 @@ func (h *handler) removeUserContent(interaction slackInteraction, duration time.
 }
 
 func (h *handler) removeFilesFromUser(targetUser string, since time.Time) (removed, remaining int, err error) {
-	page := 1
-	var files []string
-	for {
-		f, hasMore, err := h.searchForFiles(targetUser, since, page)
-		if err != nil {
-			if len(files) == 0 {
-				return 0, 0, err
-			}
-			log.Printf("Failed to fetch more files (already got %d): %v\n", len(files), err)
-			break
-		}
-		files = append(files, f...)
-		if !hasMore {
-			break
+	files, err := h.searchForFiles(targetUser, since)
+	if err != nil {
+		if len(files) == 0 {
+			return 0, 0, err
 		}
-		page++
+		slog.Error("Failed to fetch more files", "already_got", len(files), "error", err)
 	}
-	log.Printf("Got %d files to remove...\n", len(files))
+	slog.Info("Got files to remove", "user", targetUser, "count", len(files))
 	for _, v := range files {
 		if err := h.removeFile(v); err != nil {
-			log.Printf("Failed to remove file %s: %v\n", v, err)
+			slog.Error("Failed to remove file", "file", v, "error", err)
 			remaining++
 		} else {
 			removed++
@@ -85,7 +78,7 @@ func (h *handler) removeFilesFromUser(targetUser string, since time.Time) (remov
 }
 
 func (h *handler) removeFile(id string) error {
-	log.Printf("Removing file %s\n", id)
+	slog.Info("Removing file", "file", id)
 
 	for {
 		err := h.client.CallMethod("files.delete", map[string]string{"file": id}, nil)
@@ -93,7 +86,7 @@ func (h *handler) removeFile(id string) error {
 			return nil
 		}
 		if timeout, ok := err.(slack.ErrRateLimit); ok {
-			log.Printf("Slack is rate limiting us, trying again in %s...\n", timeout.Wait)
+			slog.Warn("Slack is rate limiting us, trying again", "wait", timeout.Wait)
 			time.Sleep(timeout.Wait)
 			continue
 		}
@@ -101,46 +94,27 @@ func (h *handler) removeFile(id string) error {
 	}
 }
 
-func (h *handler) searchForFiles(targetUser string, since time.Time, page int) ([]string, bool, error) {
-	args := map[string]string{
-		"query":    fmt.Sprintf("from:<@%s> after:%s", targetUser, dateBefore(since)),
-		"count":    "100",
-		"sort":     "timestamp",
-		"sort_dir": "desc",
-		"page":     strconv.Itoa(page),
-	}
-	log.Printf("Searching files for %q", args["query"])
-
-	result := struct {
-		Files struct {
-			Matches []struct {
-				ID      string `json:"id"`
-				Created int64  `json:"created"`
-				User    string `json:"user"`
-			} `json:"matches"`
-			Pagination struct {
-				PageCount int `json:"page_count"`
-			} `json:"pagination"`
-		} `json:"files"`
-	}{}
+func (h *handler) searchForFiles(targetUser string, since time.Time) ([]string, error) {
+	query := api.SearchQuery{From: targetUser, After: searchAfter(since)}.String()
+	slog.Info("Searching files", "query", query)
 
-	if err := h.client.CallOldMethod("search.files", args, &result); err != nil {
-		return nil, false, fmt.Errorf("failed to find files: %v", err)
-	}
-
-	files := make([]string, 0, len(result.Files.Matches))
-	for _, v := range result.Files.Matches {
+	matches, err := api.New(h.client).SearchFiles(context.Background(), query)
+	files := make([]string, 0, len(matches))
+	for _, v := range matches {
 		if v.User != targetUser {
-			log.Printf("Got unexpected file %s from user %s instead of target user %s", v.ID, v.User, targetUser)
+			slog.Warn("Got unexpected file from another user", "file", v.ID, "user", v.User, "target_user", targetUser)
 			continue
 		}
 		if time.Unix(v.Created, 0).Before(since) {
-			log.Printf("Got unexpected file %s created at %s, which is before %s", v.ID, time.Unix(v.Created, 0), since)
+			slog.Warn("Got unexpected file created too early", "file", v.ID, "created", time.Unix(v.Created, 0), "since", since)
 			break
 		}
 		files = append(files, v.ID)
 	}
-	return files, result.Files.Pagination.PageCount > page, nil
+	if err != nil {
+		return files, fmt.Errorf("failed to find files: %v", err)
+	}
+	return files, nil
 }
 
 type messageID struct {
@@ -149,27 +123,17 @@ type messageID struct {
 }
 
 func (h *handler) removeMessagesFromUser(targetUser string, since time.Time) (removed, remaining int, err error) {
-	page := 1
-	var messages []messageID
-	for {
-		m, hasMore, err := h.searchForMessages(targetUser, since, page)
-		if err != nil {
-			if len(messages) == 0 {
-				return 0, 0, err
-			}
-			log.Printf("Failed to fetch more messages (already got %d): %v\n", len(messages), err)
-			break
-		}
-		messages = append(messages, m...)
-		if !hasMore {
-			break
+	messages, err := h.searchForMessages(targetUser, since)
+	if err != nil {
+		if len(messages) == 0 {
+			return 0, 0, err
 		}
-		page++
+		slog.Error("Failed to fetch more messages", "already_got", len(messages), "error", err)
 	}
-	log.Printf("Got %d messages to remove...\n", len(messages))
+	slog.Info("Got messages to remove", "user", targetUser, "count", len(messages))
 	for _, v := range messages {
 		if err := h.removeMessage(v); err != nil {
-			log.Printf("Failed to remove message %s: %v\n", v, err)
+			slog.Error("Failed to remove message", "channel", v.channel, "ts", v.ts, "error", err)
 			remaining++
 		} else {
 			removed++
@@ -185,7 +149,7 @@ func (h *handler) removeMessage(message messageID) error {
 		"as_user": true,
 	}
 
-	log.Printf("Removing message %s\n", message)
+	slog.Info("Removing message", "channel", message.channel, "ts", message.ts)
 
 	for {
 		err := h.client.CallMethod("chat.delete", req, nil)
@@ -194,11 +158,11 @@ func (h *handler) removeMessage(message messageID) error {
 		}
 		switch e := err.(type) {
 		case slack.ErrRateLimit:
-			log.Printf("Slack is rate limiting us, trying again in %s...\n", e.Wait)
+			slog.Warn("Slack is rate limiting us, trying again", "wait", e.Wait)
 			time.Sleep(e.Wait)
-		case slack.ErrSlack:
-			if e.Type == "message_not_found" {
-				log.Printf("Message to delete not found, probably already deleted.\n")
+		case slack.APIError:
+			if e.Code == "message_not_found" {
+				slog.Info("Message to delete not found, probably already deleted", "channel", message.channel, "ts", message.ts)
 				return nil
 			}
 			return err
@@ -208,55 +172,32 @@ func (h *handler) removeMessage(message messageID) error {
 	}
 }
 
-// Because slack search can only search for messages *after* a specific date
-// Then subtract another day because the timezone behaviour is wildly unclear.
-func dateBefore(when time.Time) string {
-	return when.Add(-2 * 24 * time.Hour).Format("2006-01-02")
+// searchAfter returns the day to search from to find everything since when. Slack search can only
+// search for messages *after* a specific date, so subtract a day, then subtract another day because
+// the timezone behaviour is wildly unclear.
+func searchAfter(when time.Time) time.Time {
+	return when.Add(-2 * 24 * time.Hour)
 }
 
-func (h *handler) searchForMessages(targetUser string, since time.Time, page int) ([]messageID, bool, error) {
-	args := map[string]string{
-		"query":    fmt.Sprintf("from:<@%s> after:%s", targetUser, dateBefore(since)),
-		"count":    "100",
-		"sort":     "timestamp",
-		"sort_dir": "desc",
-		"page":     strconv.Itoa(page),
-	}
-	log.Printf("Searching messages for %q", args["query"])
-
-	result := struct {
-		Messages struct {
-			Matches []struct {
-				Channel struct {
-					ID string `json:"id"`
-				} `json:"channel"`
-				TS   string `json:"ts"`
-				User string `json:"user"`
-			} `json:"matches"`
-			Pagination struct {
-				PageCount int `json:"page_count"`
-			} `json:"pagination"`
-		} `json:"messages"`
-	}{}
+func (h *handler) searchForMessages(targetUser string, since time.Time) ([]messageID, error) {
+	query := api.SearchQuery{From: targetUser, After: searchAfter(since)}.String()
+	slog.Info("Searching messages", "query", query)
 
-	if err := h.client.CallOldMethod("search.messages", args, &result); err != nil {
-		return nil, false, fmt.Errorf("failed to find messages: %v", err)
-	}
-
-	messages := make([]messageID, 0, len(result.Messages.Matches))
-	for _, v := range result.Messages.Matches {
+	matches, err := api.New(h.client).SearchMessages(context.Background(), query)
+	messages := make([]messageID, 0, len(matches))
+	for _, v := range matches {
 		if v.User != targetUser {
-			log.Printf("Unexpected message %s/%s from user %s, not target user %s\n", v.Channel, v.TS, v.User, targetUser)
+			slog.Warn("Got unexpected message from another user", "channel", v.Channel.ID, "ts", v.TS, "user", v.User, "target_user", targetUser)
 			continue
 		}
 		ts := strings.SplitN(v.TS, ".", 2)[0]
 		t, err := strconv.ParseInt(ts, 10, 64)
 		if err != nil {
-			log.Printf("Failed to parse timestamp %s: %v\n", ts, err)
+			slog.Warn("Failed to parse timestamp", "ts", ts, "error", err)
 			continue
 		}
 		if time.Unix(t, 0).Before(since) {
-			log.Printf("Got message %s/%s posted %s, which is before %s, assuming we're done.", v.Channel, v.TS, time.Unix(t, 0), since)
+			slog.Info("Got message posted too early, assuming we're done", "channel", v.Channel.ID, "ts", v.TS, "posted", time.Unix(t, 0), "since", since)
 			break
 		}
 		messages = append(messages, messageID{
@@ -264,5 +205,8 @@ func (h *handler) searchForMessages(targetUser string, since time.Time, page int
 			channel: v.Channel.ID,
 		})
 	}
-	return messages, result.Messages.Pagination.PageCount > page, nil
+	if err != nil {
+		return messages, fmt.Errorf("failed to find messages: %v", err)
+	}
+	return messages, nil
 }
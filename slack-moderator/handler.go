@@ -17,18 +17,37 @@ limitations under the License.
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
+	"sync"
+
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/interactive"
 )
 
 type handler struct {
 	client     *slack.Client
 	adminToken string
+	// pending tracks moderation actions that are still running in the background.
+	pending sync.WaitGroup
+}
+
+// wait waits for background moderation actions to finish, or for ctx to be done.
+func (h *handler) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting for moderation actions: %v", ctx.Err())
+	}
 }
 
 // ServeHTTP handles Slack webhook requests.
@@ -42,19 +61,9 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		logError(rw, "Failed validation: %v", err)
 		return
 	}
-	f, err := url.ParseQuery(string(body))
+	interaction, err := interactive.Parse(body)
 	if err != nil {
-		logError(rw, "Failed to parse incoming content: %v", err)
-		return
-	}
-	content := f.Get("payload")
-	if content == "" {
-		logError(rw, "Payload was blank.")
-		return
-	}
-	interaction := slackInteraction{}
-	if err := json.Unmarshal([]byte(content), &interaction); err != nil {
-		logError(rw, "Failed to unmarshal payload: %v", err)
+		logError(rw, "%v", err)
 		return
 	}
 	if interaction.Type == "message_action" && interaction.CallbackID == "report_message" {
@@ -69,45 +78,21 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 			h.handleReportSubmission(interaction, rw)
 		case "moderate_user":
 			// Spin this off because it takes longer than Slack is willing to wait for a response.
-			go h.handleModerateSubmission(interaction)
+			h.pending.Add(1)
+			go func() {
+				defer h.pending.Done()
+				h.handleModerateSubmission(interaction)
+			}()
 		}
 	}
 }
 
 func logError(rw http.ResponseWriter, format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
-	log.Println(s)
+	slog.Error(s)
 	http.Error(rw, s, 500)
 }
 
-type slackInteraction struct {
-	Token       string `json:"token"`
-	CallbackID  string `json:"callback_id"`
-	Type        string `json:"type"`
-	TriggerID   string `json:"trigger_id"`
-	ResponseURL string `json:"response_url"`
-	Team        struct {
-		ID     string `json:"id"`
-		Domain string `json:"string"`
-	} `json:"team"`
-	Channel struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"channel"`
-	User struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"user"`
-	Message struct {
-		Type      string `json:"type"`
-		User      string `json:"user"`
-		Timestamp string `json:"ts"`
-		Text      string `json:"text"`
-	}
-	Submission map[string]string `json:"submission"`
-	State      string            `json:"state"`
-}
-
 // shortenString returns the first N slice of a string.
 func shortenString(str string, n int) string {
 	if len(str) <= n {
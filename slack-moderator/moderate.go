@@ -18,17 +18,18 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/interactive"
 )
 
 const maxRemovalDuration = 8760 * time.Hour
 
-func (h *handler) handleModerateMessage(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handleModerateMessage(interaction *interactive.Payload, rw http.ResponseWriter) {
 	targetUser, err := h.getDisplayName(interaction.Message.User)
 	if err != nil {
 		targetUser = "<error>"
@@ -104,10 +105,10 @@ func (h *handler) handleModerateMessage(interaction slackInteraction, rw http.Re
 	}
 }
 
-func (h *handler) handleModerateSubmission(interaction slackInteraction) {
+func (h *handler) handleModerateSubmission(interaction *interactive.Payload) {
 	isMod, err := h.userHasModerationPowers(interaction.User.ID)
 	if err != nil || !isMod {
-		log.Printf("User %s (%s) does not seem to be a mod: %v\n", interaction.User.ID, interaction.User.Name, err)
+		slog.Warn("User does not seem to be a mod", "user_id", interaction.User.ID, "user_name", interaction.User.Name, "error", err)
 		return
 	}
 	var messages []string
@@ -122,13 +123,13 @@ func (h *handler) handleModerateSubmission(interaction slackInteraction) {
 		"response_type":    "ephemeral",
 		"replace_original": false,
 	}
-	if h.client.CallMethod(interaction.ResponseURL, quickResponse, nil) != nil {
-		log.Printf("Failed to send quick response: %v.\n", err)
+	if err := h.client.CallMethod(interaction.ResponseURL, quickResponse, nil); err != nil {
+		slog.Error("Failed to send quick response", "error", err)
 	}
 
 	modMessage := fmt.Sprintf("<@%s> triggered moderation on <@%s>. Deactivate: %s, remove content: %s", interaction.User.ID, targetUser, interaction.Submission["deactivate"], interaction.Submission["remove_content"])
-	if h.client.CallMethod(h.client.Config.WebhookURL, map[string]string{"text": modMessage}, nil) != nil {
-		log.Printf("Failed to send quick response: %v.\n", err)
+	if err := h.client.CallMethod(h.client.CurrentConfig().WebhookURL, map[string]string{"text": modMessage}, nil); err != nil {
+		slog.Error("Failed to send quick response", "error", err)
 	}
 
 	if interaction.Submission["deactivate"] == "yes" {
@@ -185,10 +186,10 @@ respond:
 		"replace_original": true,
 	}
 
-	if h.client.CallMethod(interaction.ResponseURL, response, nil) != nil {
-		log.Printf("Failed to send response: %v.\n", err)
+	if err := h.client.CallMethod(interaction.ResponseURL, response, nil); err != nil {
+		slog.Error("Failed to send response", "error", err)
 	}
-	if h.client.CallMethod(h.client.Config.WebhookURL, map[string]string{"text": strings.Join(messages, "\n")}, nil) != nil {
-		log.Printf("Failed to send quick response: %v.\n", err)
+	if err := h.client.CallMethod(h.client.CurrentConfig().WebhookURL, map[string]string{"text": strings.Join(messages, "\n")}, nil); err != nil {
+		slog.Error("Failed to send quick response", "error", err)
 	}
 }
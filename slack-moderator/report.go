@@ -19,14 +19,15 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/interactive"
 )
 
-func (h *handler) handleReportMessage(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handleReportMessage(interaction *interactive.Payload, rw http.ResponseWriter) {
 	textArea := slack.TextArea{
 		Name:  "message",
 		Label: "Why are you reporting this message?",
@@ -78,7 +79,7 @@ func (h *handler) handleReportMessage(interaction slackInteraction, rw http.Resp
 	}
 }
 
-func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handleReportSubmission(interaction *interactive.Payload, rw http.ResponseWriter) {
 	anonymous := interaction.Submission["anonymous"] == "yes"
 	message := interaction.Submission["message"]
 	state := dialogState{}
@@ -115,7 +116,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 	if interaction.Channel.Name != "directmessage" {
 		permalink, err := h.getPermalink(interaction.Channel.ID, state.TS)
 		if err != nil {
-			log.Printf("Failed to get a permalink: %v.", err)
+			slog.Error("Failed to get a permalink", "error", err)
 		} else {
 			messageLink = fmt.Sprintf("<%s|message they reported>", permalink)
 		}
@@ -126,7 +127,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 		author = fmt.Sprintf("<@%s|%s>", state.Sender, senderName)
 	} else {
 		author = fmt.Sprintf("<@%s>", state.Sender)
-		log.Printf("Failed to look up sender: %v", err)
+		slog.Error("Failed to look up sender", "user_id", state.Sender, "error", err)
 	}
 
 	report := map[string]interface{}{
@@ -148,7 +149,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 			},
 		},
 	}
-	if err := h.client.CallMethod(h.client.Config.WebhookURL, report, nil); err != nil {
+	if err := h.client.CallMethod(h.client.CurrentConfig().WebhookURL, report, nil); err != nil {
 		logError(rw, "Failed to send report: %v.", err)
 		return
 	}
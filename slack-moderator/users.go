@@ -17,13 +17,13 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/interactive"
+	"sigs.k8s.io/slack-infra/slack/scim"
 )
 
 func (h *handler) getDisplayName(id string) (string, error) {
@@ -48,28 +48,15 @@ func (h *handler) getUserInfo(id string) (slack.User, error) {
 func (h *handler) userHasModerationPowers(id string) (bool, error) {
 	user, err := h.getUserInfo(id)
 	if err != nil {
-		log.Printf("Failed to look up moderation powers: %v\n", err)
+		slog.Error("Failed to look up moderation powers", "user_id", id, "error", err)
 		return false, err
 	}
 	return user.IsAdmin || user.IsOwner || user.IsPrimaryOwner, nil
 }
 
-func (h *handler) deactivateUser(interaction slackInteraction, targetUser string) error {
-	result := struct {
-		Ok    bool   `json:"ok"`
-		Error string `json:"error,omitempty"`
-	}{}
-
-	resp, err := http.Post("https://slack.com/api/users.admin.setInactive", "application/x-www-form-urlencoded", bytes.NewBufferString("token="+h.adminToken+"&user="+targetUser))
-	if err != nil {
+func (h *handler) deactivateUser(interaction *interactive.Payload, targetUser string) error {
+	if err := scim.New(h.client, h.adminToken).DeactivateUser(context.Background(), targetUser); err != nil {
 		return fmt.Errorf("couldn't deactivate user %s: %v", targetUser, err)
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return fmt.Errorf("failed to decode json: %v", err)
-	}
-	if !result.Ok {
-		return fmt.Errorf("couldn't update membership: %s", result.Error)
-	}
-
 	return nil
 }
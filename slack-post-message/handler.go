@@ -17,15 +17,18 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
+	"time"
 
 	"go4.org/sort"
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
 )
 
 type handler struct {
@@ -35,7 +38,7 @@ type handler struct {
 
 func logError(rw http.ResponseWriter, format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
-	log.Println(s)
+	slog.Error(s)
 	http.Error(rw, s, 500)
 }
 
@@ -65,11 +68,15 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		logError(rw, "Failed to unmarshal payload: %v", err)
 		return
 	}
-	if h.handlePermissionCheck(interaction, rw) {
+	if h.handlePermissionCheck(r.Context(), interaction, rw) {
 		if interaction.Type == "shortcut" && interaction.CallbackID == "write_message" {
 			h.handleWriteMessage(interaction, rw)
 		} else if interaction.Type == "view_submission" && interaction.View.CallbackID == "post_message" {
-			h.handlePostMessage(interaction, rw)
+			h.handlePostMessage(r.Context(), interaction, rw)
+		} else if interaction.Type == "shortcut" && interaction.CallbackID == "scheduled_messages" {
+			h.handleListScheduledMessages(r.Context(), interaction, rw)
+		} else if interaction.Type == "block_actions" && interaction.View.CallbackID == "scheduled_messages" {
+			h.handleCancelScheduledMessage(r.Context(), interaction, rw)
 		}
 	} else {
 		h.handleNotInGroupError(interaction, rw)
@@ -77,26 +84,13 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 }
 
 // Checks if the user using the bot has permissions
-func (h *handler) handlePermissionCheck(interaction slackInteraction, rw http.ResponseWriter) bool {
-	for _, value := range h.userGroups {
-		result := struct {
-			Ok    bool     `json:"ok"`
-			Users []string `json:"users"`
-		}{}
-		args := map[string]string{
-			"usergroup": value,
-		}
-		if err := h.client.CallOldMethod("usergroups.users.list", args, &result); err != nil {
-			logError(rw, "Failed to call usergroups.users.list: %v", err)
-			return false
-		}
-		for _, user := range result.Users {
-			if user == interaction.User.ID {
-				return true
-			}
-		}
+func (h *handler) handlePermissionCheck(ctx context.Context, interaction slackInteraction, rw http.ResponseWriter) bool {
+	ok, err := api.New(h.client).IsUsergroupMember(ctx, interaction.User.ID, h.userGroups...)
+	if err != nil {
+		logError(rw, "Failed to call usergroups.users.list: %v", err)
+		return false
 	}
-	return false
+	return ok
 }
 
 // Shows a error message, if user using the bot doesn't have permissions
@@ -177,6 +171,19 @@ func (h *handler) handleWriteMessage(interaction slackInteraction, rw http.Respo
 		},
 		Element: &messageInput,
 	}
+	inputBlock3 := InputBlock{
+		BlockID: "schedule-block",
+		Label: TextObject{
+			Type: "plain_text",
+			Text: "Post at",
+		},
+		Hint: TextObject{
+			Type: "plain_text",
+			Text: "Leave empty to post now",
+		},
+		Element:  &DatetimePickerElement{ActionID: "post-at"},
+		Optional: true,
+	}
 	view := View{
 		Type:       "modal",
 		CallbackID: "post_message",
@@ -192,7 +199,7 @@ func (h *handler) handleWriteMessage(interaction slackInteraction, rw http.Respo
 			Type: "plain_text",
 			Text: "Cancel",
 		},
-		Blocks: []interface{}{sectionBlock, dividerBlock, inputBlock1, inputBlock2},
+		Blocks: []interface{}{sectionBlock, dividerBlock, inputBlock1, inputBlock2, inputBlock3},
 	}
 	args := map[string]interface{}{
 		"trigger_id": interaction.TriggerID,
@@ -205,25 +212,35 @@ func (h *handler) handleWriteMessage(interaction slackInteraction, rw http.Respo
 }
 
 // Posts messages in the channels chosen by the user
-func (h *handler) handlePostMessage(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handlePostMessage(ctx context.Context, interaction slackInteraction, rw http.ResponseWriter) {
 	channels := interaction.View.State.Values.Block1.Element.SelectedChannels
 	message := interaction.View.State.Values.Block2.Element.Value
-	result := struct {
-		Ok       bool `json:"ok"`
-		Channels []struct {
-			ID        string `json:"id"`
-			Name      string `json:"name"`
-			IsChannel bool   `json:"is_channel"`
-		} `json:"channels"`
-	}{}
-	if err := h.client.CallMethod("users.conversations", nil, &result); err != nil {
-		logError(rw, "Failed to send users.conversations: %v.", err)
+	postAt := interaction.View.State.Values.Block3.Element.SelectedDateTime
+	if postAt != 0 && !time.Unix(postAt, 0).After(time.Now()) {
+		writeErrors(rw, map[string]string{"schedule-block": "Please pick a time in the future"})
+		return
 	}
 	channelsJoined := []string{}
-	for i := 0; i < len(result.Channels); i++ {
-		if result.Channels[i].IsChannel {
-			channelsJoined = append(channelsJoined, result.Channels[i].ID)
+	err := h.client.CallMethodPaged(ctx, "users.conversations", map[string]string{"limit": "200"}, func(page []byte) error {
+		result := struct {
+			Channels []struct {
+				ID        string `json:"id"`
+				Name      string `json:"name"`
+				IsChannel bool   `json:"is_channel"`
+			} `json:"channels"`
+		}{}
+		if err := json.Unmarshal(page, &result); err != nil {
+			return err
+		}
+		for _, c := range result.Channels {
+			if c.IsChannel {
+				channelsJoined = append(channelsJoined, c.ID)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		logError(rw, "Failed to send users.conversations: %v.", err)
 	}
 	sort.Strings(channelsJoined)
 	for i := 0; i < len(channels); i++ {
@@ -233,6 +250,10 @@ func (h *handler) handlePostMessage(interaction slackInteraction, rw http.Respon
 			return
 		}
 	}
+	if postAt != 0 {
+		h.scheduleMessages(ctx, rw, channels, message, time.Unix(postAt, 0))
+		return
+	}
 	for i := 0; i < len(channels); i++ {
 		args := map[string]interface{}{
 			"channel": channels[i],
@@ -261,11 +282,16 @@ func (h *handler) handleNotInChannelError(interaction slackInteraction, rw http.
 	if err := h.client.CallOldMethod("conversations.info", args, &result); err != nil {
 		logError(rw, "Failed to send conversations.info: %v.", err)
 	}
+	writeErrors(rw, map[string]string{
+		"message-input": "Please add bot to the channel '" + result.Channel.Name + "' before posting a message",
+	})
+}
+
+// writeErrors responds to a view submission by showing errors next to the given blocks.
+func writeErrors(rw http.ResponseWriter, errors map[string]string) {
 	quickResponse := map[string]interface{}{
 		"response_action": "errors",
-		"errors": map[string]interface{}{
-			"message-input": "Please add bot to the channel '" + result.Channel.Name + "' before posting a message",
-		},
+		"errors":          errors,
 	}
 	json, err := json.Marshal(quickResponse)
 	if err != nil {
@@ -301,9 +327,14 @@ type slackInteraction struct {
 		Text      string `json:"text"`
 	}
 	Submission map[string]string `json:"submission"`
-	View       struct {
+	Actions    []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	View struct {
 		ViewID     string `json:"id"`
 		CallbackID string `json:"callback_id"`
+		Hash       string `json:"hash"`
 		State      struct {
 			Values struct {
 				Block1 struct {
@@ -318,6 +349,12 @@ type slackInteraction struct {
 						Value string `json:"value"`
 					} `json:"channel-block"`
 				} `json:"message-block"`
+				Block3 struct {
+					Element struct {
+						Type             string `json:"type"`
+						SelectedDateTime int64  `json:"selected_date_time"`
+					} `json:"post-at"`
+				} `json:"schedule-block"`
 			} `json:"values"`
 		} `json:"state"`
 	} `json:"view"`
@@ -17,44 +17,40 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"net/http"
 	"os"
 
+	"sigs.k8s.io/slack-infra/httpserver"
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 )
 
 type options struct {
 	configPath string
+	text       string
+	blocksPath string
+	logging    logging.Options
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	flag.StringVar(&o.text, "text", "", "Post this message to the configured incoming webhook and exit, instead of serving")
+	flag.StringVar(&o.blocksPath, "blocks-path", "", "Path to a JSON file of Block Kit blocks to post to the configured incoming webhook and exit, instead of serving")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
-func handleHealthz(w http.ResponseWriter, r *http.Request) {
-	_, _ = w.Write([]byte("ok"))
-}
-
 func runServer(h *handler) error {
-	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Printf("Defaulting to port %s", port)
-	}
-
-	log.Printf("Listening on port %s", port)
-	return http.ListenAndServe(fmt.Sprintf(":%s", port), nil)
+	srv := httpserver.New()
+	srv.AddReadinessCheck("slack", httpserver.SlackAuthCheck(func() []*slack.Client { return []*slack.Client{h.client} }))
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+	return srv.ListenAndServe()
 }
 
 func loadAuthorizedUserGroups(path string) ([]string, error) {
@@ -76,15 +72,29 @@ func loadAuthorizedUserGroups(path string) ([]string, error) {
 
 func main() {
 	o := parseFlags()
+	if _, err := logging.Setup(o.logging, "slack-post-message"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
 	c, err := slack.LoadConfig(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to load config", "path", o.configPath, "error", err)
+	}
+	if o.text != "" || o.blocksPath != "" {
+		if err := postToWebhook(context.Background(), slack.New(c), o); err != nil {
+			logging.Fatal("Failed to post to webhook", "error", err)
+		}
+		return
 	}
 	userGroups, err := loadAuthorizedUserGroups(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load user group from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to load user group", "path", o.configPath, "error", err)
 	}
 	s := slack.New(c)
+	go slack.WatchConfig(context.Background(), o.configPath, func() error {
+		return s.Reload(o.configPath)
+	})
 	h := &handler{client: s, userGroups: userGroups}
-	log.Fatal(runServer(h))
+	if err := runServer(h); err != nil {
+		logging.Fatal("Failed to serve", "error", err)
+	}
 }
@@ -122,3 +122,15 @@ type dividerBlockType string
 func (dividerBlockType) MarshalJSON() ([]byte, error) {
 	return json.Marshal("divider")
 }
+
+// DatetimePickerElement represents a DatetimePickerElement
+type DatetimePickerElement struct {
+	Type            datetimePickerElementType `json:"type"`
+	ActionID        string                    `json:"action_id"`
+	InitialDateTime int64                     `json:"initial_date_time,omitempty"`
+}
+type datetimePickerElementType string
+
+func (datetimePickerElementType) MarshalJSON() ([]byte, error) {
+	return json.Marshal("datetimepicker")
+}
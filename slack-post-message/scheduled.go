@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// maxListedMessages is how many scheduled messages the management modal shows, so that it stays
+// within Slack's limit of 100 blocks per view.
+const maxListedMessages = 50
+
+// maxPreviewLength is how much of each scheduled message the management modal shows.
+const maxPreviewLength = 300
+
+// Schedules the message to be posted in each of the channels at postAt
+func (h *handler) scheduleMessages(ctx context.Context, rw http.ResponseWriter, channels []string, message string, postAt time.Time) {
+	for _, channel := range channels {
+		req := api.ScheduleMessageRequest{Channel: channel, PostAt: postAt, Text: message}
+		if _, err := api.New(h.client).ScheduleMessage(ctx, req); err != nil {
+			if slack.ErrorCode(err) == "time_too_far" {
+				writeErrors(rw, map[string]string{"schedule-block": "Messages can only be scheduled up to 120 days ahead"})
+				return
+			}
+			logError(rw, "Failed to send chat.scheduleMessage: %v.", err)
+			return
+		}
+	}
+}
+
+// Opens a slack Modal listing the messages waiting to be posted, each with a button to cancel it
+func (h *handler) handleListScheduledMessages(ctx context.Context, interaction slackInteraction, rw http.ResponseWriter) {
+	view, err := h.scheduledMessagesView(ctx)
+	if err != nil {
+		logError(rw, "Failed to list scheduled messages: %v", err)
+		return
+	}
+	if _, err := api.New(h.client).OpenView(ctx, interaction.TriggerID, view); err != nil {
+		logError(rw, "Failed to call views.open: %v", err)
+		return
+	}
+}
+
+// Cancels the scheduled message whose button was clicked, and refreshes the list
+func (h *handler) handleCancelScheduledMessage(ctx context.Context, interaction slackInteraction, rw http.ResponseWriter) {
+	for _, action := range interaction.Actions {
+		if action.ActionID != "cancel_scheduled_message" {
+			continue
+		}
+		parts := strings.SplitN(action.Value, "/", 2)
+		if len(parts) != 2 {
+			logError(rw, "Malformed scheduled message %q", action.Value)
+			return
+		}
+		if err := api.New(h.client).DeleteScheduledMessage(ctx, parts[0], parts[1]); err != nil && slack.ErrorCode(err) != "invalid_scheduled_message_id" {
+			logError(rw, "Failed to send chat.deleteScheduledMessage: %v", err)
+			return
+		}
+	}
+	view, err := h.scheduledMessagesView(ctx)
+	if err != nil {
+		logError(rw, "Failed to list scheduled messages: %v", err)
+		return
+	}
+	req := api.UpdateViewRequest{View: view, ViewID: interaction.View.ViewID, Hash: interaction.View.Hash}
+	if _, err := api.New(h.client).UpdateView(ctx, req); err != nil {
+		logError(rw, "Failed to call views.update: %v", err)
+		return
+	}
+}
+
+// scheduledMessagesView returns the modal listing the messages waiting to be posted, soonest first.
+func (h *handler) scheduledMessagesView(ctx context.Context) (*blocks.View, error) {
+	messages, err := api.New(h.client).ListScheduledMessages(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].PostAt < messages[j].PostAt })
+
+	var b []blocks.Block
+	if len(messages) == 0 {
+		b = append(b, blocks.Section(blocks.PlainText("There are no scheduled messages.")))
+	}
+	for i, m := range messages {
+		if i == maxListedMessages {
+			b = append(b, blocks.Context(blocks.PlainText(fmt.Sprintf("And %d more.", len(messages)-maxListedMessages))))
+			break
+		}
+		when := fmt.Sprintf("<!date^%d^{date_short_pretty} at {time}|%s>", m.PostAt, m.PostTime().UTC().Format(time.RFC1123))
+		text := m.Text
+		if r := []rune(text); len(r) > maxPreviewLength {
+			text = string(r[:maxPreviewLength]) + "…"
+		}
+		section := blocks.Section(blocks.Markdown(fmt.Sprintf("<#%s> %s\n%s", m.Channel, when, slack.EscapeMessage(text))))
+		cancel := blocks.Button("cancel_scheduled_message", "Cancel", m.Channel+"/"+m.ID)
+		cancel.Style = blocks.StyleDanger
+		section.Accessory = cancel
+		b = append(b, section)
+	}
+	view := blocks.Modal("scheduled_messages", "Scheduled Messages", b...)
+	view.Close = blocks.PlainText("Done")
+	return view, nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// postToWebhook posts the message given on the command line to the incoming webhook in the config,
+// for teams that have a webhook but no app to open the modal with.
+func postToWebhook(ctx context.Context, client *slack.Client, o options) error {
+	msg := slack.WebhookMessage{Text: o.text}
+	if o.blocksPath != "" {
+		content, err := ioutil.ReadFile(o.blocksPath)
+		if err != nil {
+			return fmt.Errorf("couldn't open blocks: %v", err)
+		}
+		if msg.Blocks, err = blocks.ParseBlocks(content); err != nil {
+			return err
+		}
+	}
+	return client.SendWebhookMessage(ctx, msg)
+}
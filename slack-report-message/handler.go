@@ -20,12 +20,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
 	"net/http"
-	"net/url"
 	"strconv"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/interactive"
 )
 
 type handler struct {
@@ -34,7 +34,7 @@ type handler struct {
 
 func logError(rw http.ResponseWriter, format string, args ...interface{}) {
 	s := fmt.Sprintf(format, args...)
-	log.Println(s)
+	slog.Error(s)
 	http.Error(rw, s, 500)
 }
 
@@ -49,19 +49,9 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 		logError(rw, "Failed validation: %v", err)
 		return
 	}
-	f, err := url.ParseQuery(string(body))
+	interaction, err := interactive.Parse(body)
 	if err != nil {
-		logError(rw, "Failed to parse incoming content: %v", err)
-		return
-	}
-	content := f.Get("payload")
-	if content == "" {
-		logError(rw, "Payload was blank.")
-		return
-	}
-	interaction := slackInteraction{}
-	if err := json.Unmarshal([]byte(content), &interaction); err != nil {
-		logError(rw, "Failed to unmarshal payload: %v", err)
+		logError(rw, "%v", err)
 		return
 	}
 	if interaction.Type == "message_action" && interaction.CallbackID == "report_message" {
@@ -71,7 +61,7 @@ func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *handler) handleReportMessage(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handleReportMessage(interaction *interactive.Payload, rw http.ResponseWriter) {
 	textArea := slack.TextArea{
 		Name:  "message",
 		Label: "Why are you reporting this message?",
@@ -123,7 +113,7 @@ func (h *handler) handleReportMessage(interaction slackInteraction, rw http.Resp
 	}
 }
 
-func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.ResponseWriter) {
+func (h *handler) handleReportSubmission(interaction *interactive.Payload, rw http.ResponseWriter) {
 	anonymous := interaction.Submission["anonymous"] == "yes"
 	message := interaction.Submission["message"]
 	state := dialogState{}
@@ -160,7 +150,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 	if interaction.Channel.Name != "directmessage" {
 		permalink, err := h.getPermalink(interaction.Channel.ID, state.TS)
 		if err != nil {
-			log.Printf("Failed to get a permalink: %v.", err)
+			slog.Error("Failed to get a permalink", "error", err)
 		} else {
 			messageLink = fmt.Sprintf("<%s|message they reported>", permalink)
 		}
@@ -171,7 +161,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 		author = fmt.Sprintf("<@%s|%s>", state.Sender, senderName)
 	} else {
 		author = fmt.Sprintf("<@%s>", state.Sender)
-		log.Printf("Failed to look up sender: %v", err)
+		slog.Error("Failed to look up sender", "error", err)
 	}
 
 	report := map[string]interface{}{
@@ -193,7 +183,7 @@ func (h *handler) handleReportSubmission(interaction slackInteraction, rw http.R
 			},
 		},
 	}
-	if err := h.client.CallMethod(h.client.Config.WebhookURL, report, nil); err != nil {
+	if err := h.client.CallMethod(h.client.CurrentConfig().WebhookURL, report, nil); err != nil {
 		logError(rw, "Failed to send report: %v.", err)
 		return
 	}
@@ -246,34 +236,6 @@ type dialogState struct {
 	Content string `json:"c"`
 }
 
-type slackInteraction struct {
-	Token       string `json:"token"`
-	CallbackID  string `json:"callback_id"`
-	Type        string `json:"type"`
-	TriggerID   string `json:"trigger_id"`
-	ResponseURL string `json:"response_url"`
-	Team        struct {
-		ID     string `json:"id"`
-		Domain string `json:"string"`
-	} `json:"team"`
-	Channel struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"channel"`
-	User struct {
-		ID   string `json:"id"`
-		Name string `json:"name"`
-	} `json:"user"`
-	Message struct {
-		Type      string `json:"type"`
-		User      string `json:"user"`
-		Timestamp string `json:"ts"`
-		Text      string `json:"text"`
-	}
-	Submission map[string]string `json:"submission"`
-	State      string            `json:"state"`
-}
-
 // shortenString returns the first N slice of a string.
 func shortenString(str string, n int) string {
 	if len(str) <= n {
@@ -17,52 +17,51 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"log"
-	"net/http"
 	"os"
 
+	"sigs.k8s.io/slack-infra/httpserver"
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 )
 
 type options struct {
 	configPath string
+	logging    logging.Options
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
-func handleHealthz(w http.ResponseWriter, r *http.Request) {
-	_, _ = w.Write([]byte("ok"))
-}
-
 func runServer(sl *slack.Client) {
 	h := &handler{client: sl}
 
-	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Printf("Defaulting to port %s", port)
+	srv := httpserver.New()
+	srv.AddReadinessCheck("slack", httpserver.SlackAuthCheck(func() []*slack.Client { return []*slack.Client{sl} }))
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+	if err := srv.ListenAndServe(); err != nil {
+		logging.Fatal("Failed to serve", "error", err)
 	}
-
-	log.Printf("Listening on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
 }
 
 func main() {
 	o := parseFlags()
+	if _, err := logging.Setup(o.logging, "slack-report-message"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
 	c, err := slack.LoadConfig(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to load config", "path", o.configPath, "error", err)
 	}
 	s := slack.New(c)
+	go slack.WatchConfig(context.Background(), o.configPath, func() error {
+		return s.Reload(o.configPath)
+	})
 	runServer(s)
 }
@@ -17,136 +17,64 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"net/http"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
+	"sigs.k8s.io/slack-infra/slack/events"
 )
 
 type handler struct {
-	client      *slack.Client
 	messagePath string
 }
 
-func logError(rw http.ResponseWriter, format string, args ...interface{}) {
-	s := fmt.Sprintf(format, args...)
-	log.Println(s)
-	http.Error(rw, s, 500)
+// newDispatcher returns a Dispatcher that welcomes new users from every workspace in clients.
+func newDispatcher(clients *slack.ClientSet, messagePath string) *events.Dispatcher {
+	h := &handler{messagePath: messagePath}
+	d := events.NewDispatcher(clients)
+	d.HandleFunc("team_join", h.handleTeamJoin)
+	return d
 }
 
-type handlerFunc func(body []byte) ([]byte, error)
-
-// ServeHTTP handles Slack webhook requests.
-func (h *handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	body, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		logError(rw, "Failed to read incoming request body: %v", err)
-		return
-	}
-	if err := h.client.VerifySignature(body, r.Header); err != nil {
-		logError(rw, "Failed validation: %v", err)
-		return
-	}
-	response, err := h.handleMessage(body)
-	if err != nil {
-		logError(rw, "Failed to handle message: %v", err)
-		return
-	}
-
-	rw.Header().Set("Content-Type", "application/json")
-	_, _ = rw.Write(response)
-}
-
-func (h *handler) handleMessage(body []byte) ([]byte, error) {
-	t := struct {
-		Type string `json:"type"`
-	}{}
-	if err := json.Unmarshal(body, &t); err != nil {
-		return nil, err
-	}
-
-	messageMapping := map[string]handlerFunc{
-		"url_verification": h.handleURLVerification,
-		"event_callback":   h.handleEvent,
-	}
-
-	fn, ok := messageMapping[t.Type]
-	if !ok {
-		return nil, fmt.Errorf("unknown event type %q", t.Type)
-	}
-	output, err := fn(body)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %v", t.Type, err)
-	}
-	return output, nil
-}
-
-func (h *handler) handleURLVerification(body []byte) ([]byte, error) {
-	request := struct {
-		Challenge string `json:"challenge"`
-	}{}
-	if err := json.Unmarshal(body, &request); err != nil {
-		return nil, fmt.Errorf("error parsing request: %v", err)
-	}
-	response := map[string]string{"challenge": request.Challenge}
-	return json.Marshal(response)
-}
-
-func (h *handler) handleEvent(body []byte) ([]byte, error) {
+func (h *handler) handleTeamJoin(ctx context.Context, client *slack.Client, body []byte) error {
 	event := struct {
 		Event struct {
-			Type string     `json:"type"`
 			User slack.User `json:"user"`
 		} `json:"event"`
 	}{}
 	if err := json.Unmarshal(body, &event); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %v", err)
-	}
-
-	// We should only be getting team_join events, but be sure to filter out anything else.
-	// We don't consider this an error, because Slack might get upset if we did.
-	if event.Event.Type != "team_join" {
-		return []byte{}, nil
+		return fmt.Errorf("failed to parse JSON: %v", err)
 	}
 
-	if err := h.sendWelcome(event.Event.User.ID); err != nil {
-		return nil, fmt.Errorf("failed to send welcome: %v", err)
+	if err := h.sendWelcome(ctx, client, event.Event.User.ID); err != nil {
+		return fmt.Errorf("failed to send welcome: %v", err)
 	}
-	return []byte{}, nil
+	return nil
 }
 
-func (h *handler) sendWelcome(uid string) error {
+func (h *handler) sendWelcome(ctx context.Context, client *slack.Client, uid string) error {
 	welcome, err := h.getWelcome()
 	if err != nil {
 		return fmt.Errorf("couldn't get welcome: %v", err)
 	}
 
 	// Slack requires that we first open an "IM channel" that we can then use to actually send messages.
-	response := struct {
-		Channel struct {
-			ID string `json:"id"`
-		} `json:"channel"`
-	}{}
-	if err := h.client.CallMethod("im.open", map[string]string{"user": uid}, &response); err != nil {
+	c := api.New(client)
+	channel, err := c.OpenConversation(ctx, uid)
+	if err != nil {
 		return fmt.Errorf("couldn't open IM channel: %v", err)
 	}
-	channel := response.Channel.ID
 
-	message := struct {
-		Channel   string `json:"channel"`
-		Text      string `json:"text"`
-		AsUser    bool   `json:"as_user"`
-		LinkNames bool   `json:"link_names"`
-	}{
+	message := api.PostMessageRequest{
 		Channel:   channel,
 		Text:      welcome,
 		AsUser:    true, // Send messages as the bot user, rather than as the app (a very subtle distinction)
 		LinkNames: true, // Parse @names and #names in the welcome message but still allow other fancy formatting.
 	}
-	if err := h.client.CallMethod("chat.postMessage", message, nil); err != nil {
+	if _, err := c.PostMessage(ctx, message); err != nil {
 		return fmt.Errorf("failed to send message: %v", err)
 	}
 	return nil
@@ -17,54 +17,85 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
-	"log"
-	"net/http"
 	"os"
 
+	"sigs.k8s.io/slack-infra/httpserver"
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/events"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+	"sigs.k8s.io/slack-infra/tracing"
 )
 
 type options struct {
 	configPath  string
 	messagePath string
+	workers     int
+	queueSize   int
+	socketMode  bool
+	logging     logging.Options
 }
 
 func parseFlags() options {
 	o := options{}
 	flag.StringVar(&o.configPath, "config-path", "config.json", "Path to a file containing the slack config")
 	flag.StringVar(&o.messagePath, "message-path", "welcome.md", "Path to a file containing the welcome message")
+	flag.BoolVar(&o.socketMode, "socket-mode", false, "Receive events using Socket Mode instead of the webhook (requires an appToken)")
+	flag.IntVar(&o.workers, "workers", 4, "Number of events to handle concurrently")
+	flag.IntVar(&o.queueSize, "queue-size", 100, "Number of events that can wait to be handled before Slack is asked to retry later")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
-func handleHealthz(w http.ResponseWriter, r *http.Request) {
-	_, _ = w.Write([]byte("ok"))
-}
-
-func runServer(sl *slack.Client, o options) {
-	h := &handler{client: sl, messagePath: o.messagePath}
-
-	http.HandleFunc("/healthz", handleHealthz)
-	http.Handle(os.Getenv("PATH_PREFIX")+"/webhook", h)
+func runServer(clients *slack.ClientSet, c slack.Config, o options) {
+	d := newDispatcher(clients, o.messagePath)
+	d.Async(o.workers, o.queueSize)
+	if o.socketMode {
+		go runSocketMode(c, d)
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-		log.Printf("Defaulting to port %s", port)
+	srv := httpserver.New()
+	srv.AddReadinessCheck("slack", httpserver.SlackAuthCheck(clients.All))
+	// Finish handling the events we have already acknowledged before exiting.
+	srv.OnShutdown(d.Shutdown)
+	srv.Handle(os.Getenv("PATH_PREFIX")+"/webhook", tracing.Handler("webhook", d))
+	if err := srv.ListenAndServe(); err != nil {
+		logging.Fatal("Failed to serve", "error", err)
 	}
+}
 
-	log.Printf("Listening on port %s", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
+func runSocketMode(c slack.Config, d *events.Dispatcher) {
+	sm, err := socketmode.New(c)
+	if err != nil {
+		logging.Fatal("Failed to set up socket mode", "error", err)
+	}
+	logging.Fatal("Socket mode stopped", "error", sm.Run(context.Background(), d.HandleSocketModeEnvelope))
 }
 
 func main() {
 	o := parseFlags()
+	if _, err := logging.Setup(o.logging, "slack-welcomer"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	shutdownTracing, err := tracing.Setup(context.Background(), "slack-welcomer")
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() { _ = shutdownTracing(context.Background()) }()
+
 	c, err := slack.LoadConfig(o.configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config from %s: %v", o.configPath, err)
+		logging.Fatal("Failed to load config", "path", o.configPath, "error", err)
+	}
+	clients, err := slack.LoadClientSet(o.configPath)
+	if err != nil {
+		logging.Fatal("Failed to load workspaces", "path", o.configPath, "error", err)
 	}
-	s := slack.New(c)
-	runServer(s, o)
+	go slack.WatchConfig(context.Background(), o.configPath, func() error {
+		return clients.Reload(o.configPath)
+	})
+	runServer(clients, c, o)
 }
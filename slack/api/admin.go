@@ -0,0 +1,105 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// The methods in this file call Enterprise Grid admin.* methods, which are always made with the
+// org token from slack.Config.OrgToken.
+
+// AdminUser is a user as returned by admin.users.list.
+type AdminUser struct {
+	ID                string `json:"id"`
+	Email             string `json:"email"`
+	IsAdmin           bool   `json:"is_admin"`
+	IsOwner           bool   `json:"is_owner"`
+	IsPrimaryOwner    bool   `json:"is_primary_owner"`
+	IsRestricted      bool   `json:"is_restricted"`
+	IsUltraRestricted bool   `json:"is_ultra_restricted"`
+	IsBot             bool   `json:"is_bot"`
+	Expiration        int64  `json:"expiration_ts"`
+}
+
+// AdminListUsers returns the users of the workspace with the given team ID.
+func (c *Client) AdminListUsers(ctx context.Context, teamID string) ([]AdminUser, error) {
+	var users []AdminUser
+	args := map[string]string{"team_id": teamID, "limit": "100"}
+	err := c.slack.CallMethodPaged(orgContext(ctx), "admin.users.list", args, func(page []byte) error {
+		resp := struct {
+			Users []AdminUser `json:"users"`
+		}{}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal users: %v", err)
+		}
+		users = append(users, resp.Users...)
+		return nil
+	})
+	return users, err
+}
+
+// AdminRemoveUser removes a user from the workspace with the given team ID.
+func (c *Client) AdminRemoveUser(ctx context.Context, teamID, userID string) error {
+	req := struct {
+		TeamID string `json:"team_id"`
+		UserID string `json:"user_id"`
+	}{teamID, userID}
+	return c.slack.CallMethodContext(orgContext(ctx), "admin.users.remove", req, nil)
+}
+
+// AdminResetUserSessions signs a user out of all their sessions, in every workspace of the org.
+func (c *Client) AdminResetUserSessions(ctx context.Context, userID string) error {
+	req := struct {
+		UserID string `json:"user_id"`
+	}{userID}
+	return c.slack.CallMethodContext(orgContext(ctx), "admin.users.session.reset", req, nil)
+}
+
+// AdminArchiveConversation archives a public or private channel, in any workspace of the org.
+func (c *Client) AdminArchiveConversation(ctx context.Context, channelID string) error {
+	req := struct {
+		ChannelID string `json:"channel_id"`
+	}{channelID}
+	return c.slack.CallMethodContext(orgContext(ctx), "admin.conversations.archive", req, nil)
+}
+
+// AdminInviteToConversation adds users to a channel, even if the caller isn't a member of it.
+func (c *Client) AdminInviteToConversation(ctx context.Context, channelID string, userIDs []string) error {
+	req := struct {
+		ChannelID string `json:"channel_id"`
+		UserIDs   string `json:"user_ids"`
+	}{channelID, strings.Join(userIDs, ",")}
+	return c.slack.CallMethodContext(orgContext(ctx), "admin.conversations.invite", req, nil)
+}
+
+// AdminRemoveEmoji removes a custom emoji from every workspace of the org.
+func (c *Client) AdminRemoveEmoji(ctx context.Context, name string) error {
+	req := struct {
+		Name string `json:"name"`
+	}{name}
+	return c.slack.CallMethodContext(orgContext(ctx), "admin.emoji.remove", req, nil)
+}
+
+func orgContext(ctx context.Context) context.Context {
+	return slack.WithToken(ctx, slack.TokenOrg)
+}
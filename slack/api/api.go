@@ -0,0 +1,34 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api provides typed wrappers around the Slack API methods our tools use most often.
+package api
+
+import (
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// Client calls Slack API methods using typed requests and responses.
+// Errors are returned exactly as the slack package produced them, so callers can still check for
+// slack.ErrRateLimit and slack.APIError.
+type Client struct {
+	slack *slack.Client
+}
+
+// New returns a new Client that makes its calls using the given slack.Client.
+func New(client *slack.Client) *Client {
+	return &Client{slack: client}
+}
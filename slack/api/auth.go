@@ -0,0 +1,38 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import "context"
+
+// AuthTestResponse describes the token a Client is using, as returned by auth.test.
+type AuthTestResponse struct {
+	URL    string `json:"url"`
+	Team   string `json:"team"`
+	User   string `json:"user"`
+	TeamID string `json:"team_id"`
+	UserID string `json:"user_id"`
+	BotID  string `json:"bot_id"`
+}
+
+// AuthTest checks that the Client's token works, and returns who it belongs to.
+func (c *Client) AuthTest(ctx context.Context) (AuthTestResponse, error) {
+	resp := AuthTestResponse{}
+	if err := c.slack.CallOldMethodContext(ctx, "auth.test", nil, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
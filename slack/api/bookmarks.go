@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+)
+
+// Bookmark is a link bookmarked in a channel's header, such as a meeting link or agenda.
+type Bookmark struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	Emoji     string `json:"emoji"`
+	Type      string `json:"type"`
+	Created   int64  `json:"date_created"`
+	Updated   int64  `json:"date_updated"`
+}
+
+// AddBookmarkRequest is the request to bookmarks.add.
+type AddBookmarkRequest struct {
+	ChannelID string `json:"channel_id"`
+	Title     string `json:"title"`
+	Link      string `json:"link"`
+	// Emoji, such as ":calendar:", is shown next to the title.
+	Emoji string `json:"emoji,omitempty"`
+}
+
+// EditBookmarkRequest is the request to bookmarks.edit. Fields left empty are not changed.
+type EditBookmarkRequest struct {
+	ChannelID  string `json:"channel_id"`
+	BookmarkID string `json:"bookmark_id"`
+	Title      string `json:"title,omitempty"`
+	Link       string `json:"link,omitempty"`
+	Emoji      string `json:"emoji,omitempty"`
+}
+
+// AddBookmark adds a link bookmark to a channel, and returns it.
+func (c *Client) AddBookmark(ctx context.Context, req AddBookmarkRequest) (Bookmark, error) {
+	args := struct {
+		AddBookmarkRequest
+		Type string `json:"type"`
+	}{req, "link"}
+	return c.callBookmark(ctx, "bookmarks.add", args)
+}
+
+// EditBookmark changes a channel bookmark, and returns the result.
+func (c *Client) EditBookmark(ctx context.Context, req EditBookmarkRequest) (Bookmark, error) {
+	return c.callBookmark(ctx, "bookmarks.edit", req)
+}
+
+// ListBookmarks returns the bookmarks of the channel with the given ID.
+func (c *Client) ListBookmarks(ctx context.Context, channel string) ([]Bookmark, error) {
+	resp := struct {
+		Bookmarks []Bookmark `json:"bookmarks"`
+	}{}
+	if err := c.slack.CallOldMethodContext(ctx, "bookmarks.list", map[string]string{"channel_id": channel}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Bookmarks, nil
+}
+
+// RemoveBookmark removes a bookmark from a channel.
+func (c *Client) RemoveBookmark(ctx context.Context, channel, id string) error {
+	req := struct {
+		ChannelID  string `json:"channel_id"`
+		BookmarkID string `json:"bookmark_id"`
+	}{channel, id}
+	return c.slack.CallMethodContext(ctx, "bookmarks.remove", req, nil)
+}
+
+func (c *Client) callBookmark(ctx context.Context, method string, args interface{}) (Bookmark, error) {
+	resp := struct {
+		Bookmark Bookmark `json:"bookmark"`
+	}{}
+	if err := c.slack.CallMethodContext(ctx, method, args, &resp); err != nil {
+		return resp.Bookmark, err
+	}
+	return resp.Bookmark, nil
+}
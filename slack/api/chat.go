@@ -0,0 +1,183 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// PostMessageRequest is the request to chat.postMessage.
+type PostMessageRequest struct {
+	Channel        string         `json:"channel"`
+	Text           string         `json:"text,omitempty"`
+	Blocks         []blocks.Block `json:"blocks,omitempty"`
+	ThreadTS       string         `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool           `json:"reply_broadcast,omitempty"`
+	AsUser         bool           `json:"as_user,omitempty"`
+	LinkNames      bool           `json:"link_names,omitempty"`
+	UnfurlLinks    bool           `json:"unfurl_links,omitempty"`
+	UnfurlMedia    bool           `json:"unfurl_media,omitempty"`
+}
+
+// PostMessageResponse is the response from chat.postMessage.
+type PostMessageResponse struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+// PostMessage posts a message to a channel.
+func (c *Client) PostMessage(ctx context.Context, req PostMessageRequest) (PostMessageResponse, error) {
+	resp := PostMessageResponse{}
+	if err := c.slack.CallMethodContext(ctx, "chat.postMessage", req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// PostEphemeralRequest is the request to chat.postEphemeral.
+type PostEphemeralRequest struct {
+	Channel   string         `json:"channel"`
+	User      string         `json:"user"`
+	Text      string         `json:"text,omitempty"`
+	Blocks    []blocks.Block `json:"blocks,omitempty"`
+	ThreadTS  string         `json:"thread_ts,omitempty"`
+	AsUser    bool           `json:"as_user,omitempty"`
+	LinkNames bool           `json:"link_names,omitempty"`
+}
+
+// PostEphemeralResponse is the response from chat.postEphemeral.
+type PostEphemeralResponse struct {
+	MessageTS string `json:"message_ts"`
+}
+
+// PostEphemeral posts a message to a channel that only the given user can see.
+func (c *Client) PostEphemeral(ctx context.Context, req PostEphemeralRequest) (PostEphemeralResponse, error) {
+	resp := PostEphemeralResponse{}
+	if err := c.slack.CallMethodContext(ctx, "chat.postEphemeral", req, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// DeleteMessageRequest is the request to chat.delete.
+type DeleteMessageRequest struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+	AsUser  bool   `json:"as_user,omitempty"`
+}
+
+// DeleteMessage deletes a message.
+func (c *Client) DeleteMessage(ctx context.Context, req DeleteMessageRequest) error {
+	return c.slack.CallMethodContext(ctx, "chat.delete", req, nil)
+}
+
+// GetPermalink returns a permanent link to the message identified by channel and ts.
+func (c *Client) GetPermalink(ctx context.Context, channel, ts string) (string, error) {
+	resp := struct {
+		Permalink string `json:"permalink"`
+	}{}
+	args := map[string]string{"channel": channel, "message_ts": ts}
+	if err := c.slack.CallOldMethodContext(ctx, "chat.getPermalink", args, &resp); err != nil {
+		return "", err
+	}
+	return resp.Permalink, nil
+}
+
+// ScheduleMessageRequest is the request to chat.scheduleMessage.
+type ScheduleMessageRequest struct {
+	Channel        string         `json:"channel"`
+	PostAt         time.Time      `json:"-"`
+	Text           string         `json:"text,omitempty"`
+	Blocks         []blocks.Block `json:"blocks,omitempty"`
+	ThreadTS       string         `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool           `json:"reply_broadcast,omitempty"`
+	LinkNames      bool           `json:"link_names,omitempty"`
+	UnfurlLinks    bool           `json:"unfurl_links,omitempty"`
+	UnfurlMedia    bool           `json:"unfurl_media,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r ScheduleMessageRequest) MarshalJSON() ([]byte, error) {
+	type request ScheduleMessageRequest
+	return json.Marshal(struct {
+		request
+		PostAt int64 `json:"post_at"`
+	}{request(r), r.PostAt.Unix()})
+}
+
+// ScheduledMessage is a message waiting to be posted.
+type ScheduledMessage struct {
+	ID          string `json:"id"`
+	Channel     string `json:"channel_id"`
+	Text        string `json:"text"`
+	PostAt      int64  `json:"post_at"`
+	DateCreated int64  `json:"date_created"`
+}
+
+// PostTime returns the time the message will be posted.
+func (m ScheduledMessage) PostTime() time.Time {
+	return time.Unix(m.PostAt, 0)
+}
+
+// ScheduleMessage schedules a message to be posted to a channel at req.PostAt, which must be
+// within the next 120 days.
+func (c *Client) ScheduleMessage(ctx context.Context, req ScheduleMessageRequest) (ScheduledMessage, error) {
+	resp := struct {
+		ID      string `json:"scheduled_message_id"`
+		Channel string `json:"channel"`
+		PostAt  int64  `json:"post_at"`
+	}{}
+	if err := c.slack.CallMethodContext(ctx, "chat.scheduleMessage", req, &resp); err != nil {
+		return ScheduledMessage{}, err
+	}
+	return ScheduledMessage{ID: resp.ID, Channel: resp.Channel, Text: req.Text, PostAt: resp.PostAt}, nil
+}
+
+// ListScheduledMessages returns the messages scheduled by the app. If channel is not empty, only
+// messages scheduled for that channel are returned.
+func (c *Client) ListScheduledMessages(ctx context.Context, channel string) ([]ScheduledMessage, error) {
+	var messages []ScheduledMessage
+	args := map[string]string{"limit": "100"}
+	if channel != "" {
+		args["channel"] = channel
+	}
+	err := c.slack.CallMethodPaged(ctx, "chat.scheduledMessages.list", args, func(page []byte) error {
+		resp := struct {
+			ScheduledMessages []ScheduledMessage `json:"scheduled_messages"`
+		}{}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal scheduled messages: %v", err)
+		}
+		messages = append(messages, resp.ScheduledMessages...)
+		return nil
+	})
+	return messages, err
+}
+
+// DeleteScheduledMessage deletes a scheduled message before it is posted.
+func (c *Client) DeleteScheduledMessage(ctx context.Context, channel, id string) error {
+	req := struct {
+		Channel            string `json:"channel"`
+		ScheduledMessageID string `json:"scheduled_message_id"`
+	}{channel, id}
+	return c.slack.CallMethodContext(ctx, "chat.deleteScheduledMessage", req, nil)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// JoinConversation joins the channel with the given ID, and returns it.
+func (c *Client) JoinConversation(ctx context.Context, channel string) (slack.Conversation, error) {
+	resp := struct {
+		Channel slack.Conversation `json:"channel"`
+	}{}
+	req := struct {
+		Channel string `json:"channel"`
+	}{channel}
+	if err := c.slack.CallMethodContext(ctx, "conversations.join", req, &resp); err != nil {
+		return resp.Channel, err
+	}
+	return resp.Channel, nil
+}
+
+// KickFromConversation removes user from the channel with the given ID.
+func (c *Client) KickFromConversation(ctx context.Context, channel, user string) error {
+	req := struct {
+		Channel string `json:"channel"`
+		User    string `json:"user"`
+	}{channel, user}
+	return c.slack.CallMethodContext(ctx, "conversations.kick", req, nil)
+}
+
+// ConversationInfo looks up the channel with the given ID.
+func (c *Client) ConversationInfo(ctx context.Context, channel string) (slack.Conversation, error) {
+	resp := struct {
+		Channel slack.Conversation `json:"channel"`
+	}{}
+	if err := c.slack.CallOldMethodContext(ctx, "conversations.info", map[string]string{"channel": channel}, &resp); err != nil {
+		return resp.Channel, err
+	}
+	return resp.Channel, nil
+}
+
+// OpenConversation opens (or resumes) a direct message or multi-person direct message with the
+// given users, and returns its channel ID.
+func (c *Client) OpenConversation(ctx context.Context, users ...string) (string, error) {
+	resp := struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}{}
+	req := struct {
+		Users string `json:"users"`
+	}{strings.Join(users, ",")}
+	if err := c.slack.CallMethodContext(ctx, "conversations.open", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Channel.ID, nil
+}
+
+// SetConversationTopic changes the topic of the channel with the given ID.
+func (c *Client) SetConversationTopic(ctx context.Context, channel, topic string) error {
+	req := struct {
+		Channel string `json:"channel"`
+		Topic   string `json:"topic"`
+	}{channel, topic}
+	return c.slack.CallMethodContext(ctx, "conversations.setTopic", req, nil)
+}
+
+// SetConversationPurpose changes the purpose of the channel with the given ID.
+func (c *Client) SetConversationPurpose(ctx context.Context, channel, purpose string) error {
+	req := struct {
+		Channel string `json:"channel"`
+		Purpose string `json:"purpose"`
+	}{channel, purpose}
+	return c.slack.CallMethodContext(ctx, "conversations.setPurpose", req, nil)
+}
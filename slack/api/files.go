@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// UploadURL is the response from files.getUploadURLExternal.
+type UploadURL struct {
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+// GetUploadURLExternal reserves a file ID and returns the URL that a file called filename,
+// length bytes long, should be uploaded to.
+func (c *Client) GetUploadURLExternal(ctx context.Context, filename string, length int) (UploadURL, error) {
+	resp := UploadURL{}
+	args := map[string]string{
+		"filename": filename,
+		"length":   strconv.Itoa(length),
+	}
+	if err := c.slack.CallOldMethodContext(ctx, "files.getUploadURLExternal", args, &resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// UploadedFile identifies a file that has been uploaded to the URL from GetUploadURLExternal.
+type UploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// CompleteUploadRequest is the request to files.completeUploadExternal. If ChannelID is empty,
+// the files are uploaded but not shared anywhere.
+type CompleteUploadRequest struct {
+	Files          []UploadedFile
+	ChannelID      string
+	InitialComment string
+	ThreadTS       string
+}
+
+// File is a file stored in Slack.
+type File struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	Mimetype  string `json:"mimetype"`
+	Filetype  string `json:"filetype"`
+	Size      int    `json:"size"`
+	Permalink string `json:"permalink"`
+	// Mode is "snippet" for snippets, and Preview is the start of a snippet or text file.
+	Mode    string `json:"mode"`
+	Preview string `json:"preview"`
+	// URLPrivateDownload is where the file can be downloaded from, with a token that has the
+	// files:read scope.
+	URLPrivateDownload string `json:"url_private_download"`
+}
+
+// GetFileInfo returns the file with the given ID. It needs the files:read scope.
+func (c *Client) GetFileInfo(ctx context.Context, id string) (File, error) {
+	resp := struct {
+		File File `json:"file"`
+	}{}
+	if err := c.slack.CallOldMethodContext(ctx, "files.info", map[string]string{"file": id}, &resp); err != nil {
+		return File{}, err
+	}
+	return resp.File, nil
+}
+
+// CompleteUploadExternal finishes uploading files, and shares them to a channel if requested.
+func (c *Client) CompleteUploadExternal(ctx context.Context, req CompleteUploadRequest) ([]File, error) {
+	resp := struct {
+		Files []File `json:"files"`
+	}{}
+	files, err := json.Marshal(req.Files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal files: %v", err)
+	}
+	args := map[string]string{"files": string(files)}
+	if req.ChannelID != "" {
+		args["channel_id"] = req.ChannelID
+	}
+	if req.InitialComment != "" {
+		args["initial_comment"] = req.InitialComment
+	}
+	if req.ThreadTS != "" {
+		args["thread_ts"] = req.ThreadTS
+	}
+	if err := c.slack.CallOldMethodContext(ctx, "files.completeUploadExternal", args, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Files, nil
+}
+
+// UploadFileRequest describes a single file to upload with UploadFile.
+type UploadFileRequest struct {
+	Filename       string
+	Title          string
+	Content        []byte
+	ChannelID      string
+	InitialComment string
+	ThreadTS       string
+}
+
+// UploadFile uploads a file using the external upload flow, and shares it to a channel if
+// ChannelID is set.
+func (c *Client) UploadFile(ctx context.Context, req UploadFileRequest) (File, error) {
+	upload, err := c.GetUploadURLExternal(ctx, req.Filename, len(req.Content))
+	if err != nil {
+		return File{}, err
+	}
+	if err := c.slack.UploadContent(ctx, upload.UploadURL, req.Content); err != nil {
+		return File{}, err
+	}
+	files, err := c.CompleteUploadExternal(ctx, CompleteUploadRequest{
+		Files:          []UploadedFile{{ID: upload.FileID, Title: req.Title}},
+		ChannelID:      req.ChannelID,
+		InitialComment: req.InitialComment,
+		ThreadTS:       req.ThreadTS,
+	})
+	if err != nil {
+		return File{}, err
+	}
+	if len(files) == 0 {
+		return File{ID: upload.FileID}, nil
+	}
+	return files[0], nil
+}
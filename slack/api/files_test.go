@@ -0,0 +1,65 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestUploadFileDryRun(t *testing.T) {
+	var calls []string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/files.getUploadURLExternal":
+			_, _ = w.Write([]byte(`{"ok": true, "upload_url": "https://files.slack.com/upload/v1/abc", "file_id": "F123"}`))
+		default:
+			t.Errorf("unexpected call to %s in dry-run mode", r.URL.Path)
+			_, _ = w.Write([]byte(`{"ok": true}`))
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	s := slack.New(slack.Config{AccessToken: "xoxb-token", DryRun: true})
+	s.HTTPClient = server.Client()
+	s.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+
+	file, err := New(s).UploadFile(context.Background(), UploadFileRequest{Filename: "report.txt", Content: []byte("hello"), ChannelID: "C1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if file.ID != "F123" {
+		t.Errorf("expected file F123, but got %q", file.ID)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected only files.getUploadURLExternal to be called, but got %v", calls)
+	}
+}
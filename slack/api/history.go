@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is a message from a channel's history or a thread.
+type Message struct {
+	Type       string `json:"type"`
+	Subtype    string `json:"subtype,omitempty"`
+	User       string `json:"user,omitempty"`
+	BotID      string `json:"bot_id,omitempty"`
+	Text       string `json:"text"`
+	TS         string `json:"ts"`
+	ThreadTS   string `json:"thread_ts,omitempty"`
+	ReplyCount int    `json:"reply_count,omitempty"`
+	Edited     *struct {
+		User string `json:"user"`
+		TS   string `json:"ts"`
+	} `json:"edited,omitempty"`
+	Files []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"files,omitempty"`
+	// Reactions are only included by GetReactions.
+	Reactions []Reaction `json:"reactions,omitempty"`
+}
+
+// Time returns when the message was posted.
+func (m Message) Time() time.Time {
+	return ParseTS(m.TS)
+}
+
+// IsThreadParent returns whether the message started a thread that has replies.
+func (m Message) IsThreadParent() bool {
+	return m.ReplyCount > 0 && m.ThreadTS == m.TS
+}
+
+// ParseTS converts a Slack message timestamp, such as "1610000000.000200", to a time. It returns
+// the zero time if ts is malformed.
+func ParseTS(ts string) time.Time {
+	parts := strings.SplitN(ts, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	var usec int64
+	if len(parts) == 2 {
+		if usec, err = strconv.ParseInt((parts[1] + "000000")[:6], 10, 64); err != nil {
+			return time.Time{}
+		}
+	}
+	return time.Unix(sec, usec*1000)
+}
+
+// FormatTS converts t to a Slack message timestamp.
+func FormatTS(t time.Time) string {
+	return fmt.Sprintf("%d.%06d", t.Unix(), t.Nanosecond()/1000)
+}
+
+// HistoryRequest selects the messages returned by ConversationHistory.
+type HistoryRequest struct {
+	Channel string
+	// Oldest and Latest bound the messages returned, inclusively. Zero values leave the range
+	// open at that end.
+	Oldest time.Time
+	Latest time.Time
+	// IncludeReplies also fetches the replies in every thread, within the same bounds.
+	IncludeReplies bool
+}
+
+func (r HistoryRequest) args() map[string]string {
+	args := map[string]string{"channel": r.Channel, "limit": "200", "inclusive": "true"}
+	if !r.Oldest.IsZero() {
+		args["oldest"] = FormatTS(r.Oldest)
+	}
+	if !r.Latest.IsZero() {
+		args["latest"] = FormatTS(r.Latest)
+	}
+	return args
+}
+
+// ConversationHistory returns the messages in a channel between two times, oldest first. If the
+// request includes replies, each thread's replies follow the message that started it. Pages are
+// fetched within the Client's rate limits, and rate limited pages are retried until ctx is done.
+func (c *Client) ConversationHistory(ctx context.Context, req HistoryRequest) ([]Message, error) {
+	var messages []Message
+	err := c.slack.CallMethodPaged(ctx, "conversations.history", req.args(), func(page []byte) error {
+		resp := struct {
+			Messages []Message `json:"messages"`
+		}{}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal messages: %v", err)
+		}
+		messages = append(messages, resp.Messages...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Slack returns the newest messages first.
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	if !req.IncludeReplies {
+		return messages, nil
+	}
+
+	var result []Message
+	for _, m := range messages {
+		result = append(result, m)
+		if !m.IsThreadParent() {
+			continue
+		}
+		replies, err := c.ConversationReplies(ctx, req, m.TS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replies to %s: %v", m.TS, err)
+		}
+		result = append(result, replies...)
+	}
+	return result, nil
+}
+
+// ConversationReplies returns the replies in the thread started by the message with the given
+// timestamp, oldest first, within the bounds of req. The message that started the thread is not
+// included.
+func (c *Client) ConversationReplies(ctx context.Context, req HistoryRequest, threadTS string) ([]Message, error) {
+	args := req.args()
+	args["ts"] = threadTS
+	var replies []Message
+	err := c.slack.CallMethodPaged(ctx, "conversations.replies", args, func(page []byte) error {
+		resp := struct {
+			Messages []Message `json:"messages"`
+		}{}
+		if err := json.Unmarshal(page, &resp); err != nil {
+			return fmt.Errorf("failed to unmarshal replies: %v", err)
+		}
+		for _, m := range resp.Messages {
+			if m.TS != threadTS {
+				replies = append(replies, m)
+			}
+		}
+		return nil
+	})
+	return replies, err
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestParseTS(t *testing.T) {
+	tests := []struct {
+		name     string
+		ts       string
+		expected time.Time
+	}{
+		{
+			name:     "message timestamp",
+			ts:       "1610000000.000200",
+			expected: time.Unix(1610000000, 200000),
+		},
+		{
+			name:     "short fraction",
+			ts:       "1610000000.5",
+			expected: time.Unix(1610000000, 500000000),
+		},
+		{
+			name:     "no fraction",
+			ts:       "1610000000",
+			expected: time.Unix(1610000000, 0),
+		},
+		{
+			name: "malformed",
+			ts:   "yesterday",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := ParseTS(tc.ts); !actual.Equal(tc.expected) {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatTS(t *testing.T) {
+	ts := "1610000000.000200"
+	if actual := FormatTS(ParseTS(ts)); actual != ts {
+		t.Errorf("expected %q, but got %q", ts, actual)
+	}
+}
+
+func TestConversationHistory(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if oldest, latest := r.Form.Get("oldest"), r.Form.Get("latest"); oldest != "1600000000.000000" || latest != "1700000000.000000" {
+			t.Errorf("expected the range 1600000000-1700000000, but got %s-%s", oldest, latest)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/conversations.history":
+			if r.Form.Get("cursor") == "" {
+				_, _ = w.Write([]byte(`{"ok": true, "messages": [{"ts": "1600000004.000000", "text": "four"}, {"ts": "1600000003.000000", "text": "three", "thread_ts": "1600000003.000000", "reply_count": 1}], "response_metadata": {"next_cursor": "page2"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"ok": true, "messages": [{"ts": "1600000001.000000", "text": "one"}]}`))
+		case "/api/conversations.replies":
+			if ts := r.Form.Get("ts"); ts != "1600000003.000000" {
+				t.Errorf("unexpected thread %q", ts)
+			}
+			_, _ = w.Write([]byte(`{"ok": true, "messages": [{"ts": "1600000003.000000", "text": "three", "thread_ts": "1600000003.000000", "reply_count": 1}, {"ts": "1600000005.000000", "text": "reply", "thread_ts": "1600000003.000000"}]}`))
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	s := slack.New(slack.Config{AccessToken: "xoxb-token"})
+	s.HTTPClient = server.Client()
+	s.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		replies  bool
+		expected []string
+	}{
+		{
+			name:     "channel messages only",
+			expected: []string{"one", "three", "four"},
+		},
+		{
+			name:     "with thread replies",
+			replies:  true,
+			expected: []string{"one", "three", "reply", "four"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			messages, err := New(s).ConversationHistory(context.Background(), HistoryRequest{
+				Channel:        "C1",
+				Oldest:         time.Unix(1600000000, 0),
+				Latest:         time.Unix(1700000000, 0),
+				IncludeReplies: tc.replies,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var actual []string
+			for _, m := range messages {
+				actual = append(actual, m.Text)
+			}
+			if !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
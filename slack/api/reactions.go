@@ -0,0 +1,38 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import "context"
+
+// Reaction is an emoji reaction to a message, and the users who reacted with it.
+type Reaction struct {
+	Name  string   `json:"name"`
+	Users []string `json:"users"`
+	Count int      `json:"count"`
+}
+
+// GetReactions returns the message identified by channel and ts, with all of its reactions.
+func (c *Client) GetReactions(ctx context.Context, channel, ts string) (Message, error) {
+	resp := struct {
+		Message Message `json:"message"`
+	}{}
+	args := map[string]string{"channel": channel, "timestamp": ts, "full": "true"}
+	if err := c.slack.CallOldMethodContext(ctx, "reactions.get", args, &resp); err != nil {
+		return Message{}, err
+	}
+	return resp.Message, nil
+}
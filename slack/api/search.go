@@ -0,0 +1,169 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// SearchQuery builds a query for search.messages or search.files using Slack's search modifiers. Empty fields are
+// left out.
+type SearchQuery struct {
+	// Text is searched for as individual words.
+	Text string
+	// Phrase is searched for exactly, such as the text of a spam message.
+	Phrase string
+	// From restricts results to messages from the user with this ID.
+	From string
+	// In restricts results to the channel with this ID.
+	In string
+	// After and Before restrict results to messages sent after or before the given day. Slack
+	// only compares dates, not times, and excludes the days themselves.
+	After  time.Time
+	Before time.Time
+}
+
+// String returns the query in Slack's search syntax.
+func (q SearchQuery) String() string {
+	var parts []string
+	if q.Text != "" {
+		parts = append(parts, q.Text)
+	}
+	if q.Phrase != "" {
+		// There is no way to escape quotes within a phrase.
+		parts = append(parts, `"`+strings.ReplaceAll(q.Phrase, `"`, "")+`"`)
+	}
+	if q.From != "" {
+		parts = append(parts, "from:<@"+q.From+">")
+	}
+	if q.In != "" {
+		parts = append(parts, "in:<#"+q.In+">")
+	}
+	if !q.After.IsZero() {
+		parts = append(parts, "after:"+q.After.Format("2006-01-02"))
+	}
+	if !q.Before.IsZero() {
+		parts = append(parts, "before:"+q.Before.Format("2006-01-02"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SearchMatch is a message found by search.messages.
+type SearchMatch struct {
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+	User      string `json:"user"`
+	Username  string `json:"username"`
+	TS        string `json:"ts"`
+	Text      string `json:"text"`
+	Permalink string `json:"permalink"`
+}
+
+// SearchMessages returns every message matching query, newest first, following search.messages'
+// pagination. search.messages can't be called with a bot token, so the call is made with the user
+// token from slack.Config.UserToken. If fetching a page fails, the matches found so far are
+// returned along with the error. Like slack.Client.CallMethodPaged, pages that are rate limited
+// are retried until ctx is done.
+func (c *Client) SearchMessages(ctx context.Context, query string) ([]SearchMatch, error) {
+	var matches []SearchMatch
+	for page := 1; ; page++ {
+		resp := struct {
+			Messages struct {
+				Matches    []SearchMatch    `json:"matches"`
+				Pagination searchPagination `json:"pagination"`
+			} `json:"messages"`
+		}{}
+		if err := c.search(ctx, "search.messages", query, page, &resp); err != nil {
+			return matches, err
+		}
+		matches = append(matches, resp.Messages.Matches...)
+		if page >= resp.Messages.Pagination.PageCount {
+			return matches, nil
+		}
+	}
+}
+
+// SearchFile is a file found by search.files.
+type SearchFile struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Title     string `json:"title"`
+	User      string `json:"user"`
+	Created   int64  `json:"created"`
+	Permalink string `json:"permalink"`
+}
+
+// SearchFiles returns every file matching query, newest first. It is called and paginated just
+// like SearchMessages, including the use of the user token.
+func (c *Client) SearchFiles(ctx context.Context, query string) ([]SearchFile, error) {
+	var files []SearchFile
+	for page := 1; ; page++ {
+		resp := struct {
+			Files struct {
+				Matches    []SearchFile     `json:"matches"`
+				Pagination searchPagination `json:"pagination"`
+			} `json:"files"`
+		}{}
+		if err := c.search(ctx, "search.files", query, page, &resp); err != nil {
+			return files, err
+		}
+		files = append(files, resp.Files.Matches...)
+		if page >= resp.Files.Pagination.PageCount {
+			return files, nil
+		}
+	}
+}
+
+type searchPagination struct {
+	PageCount int `json:"page_count"`
+}
+
+// search fetches a page of results for query from method with the user token, retrying while it is
+// rate limited.
+func (c *Client) search(ctx context.Context, method, query string, page int, ret interface{}) error {
+	if query == "" {
+		return fmt.Errorf("search query is empty")
+	}
+	ctx = slack.WithToken(ctx, slack.TokenUser)
+	args := map[string]string{
+		"query":    query,
+		"count":    "100",
+		"sort":     "timestamp",
+		"sort_dir": "desc",
+		"page":     strconv.Itoa(page),
+	}
+	for {
+		err := c.slack.CallOldMethodContext(ctx, method, args, ret)
+		if e, ok := err.(slack.ErrRateLimit); ok {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(e.Wait):
+			}
+			continue
+		}
+		return err
+	}
+}
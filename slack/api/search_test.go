@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestSearchQuery(t *testing.T) {
+	day := time.Date(2021, 3, 4, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		query    SearchQuery
+		expected string
+	}{
+		{
+			name:     "text only",
+			query:    SearchQuery{Text: "free crypto"},
+			expected: "free crypto",
+		},
+		{
+			name:     "phrase quotes are dropped",
+			query:    SearchQuery{Phrase: `click "here"`},
+			expected: `"click here"`,
+		},
+		{
+			name:     "everything",
+			query:    SearchQuery{Text: "spam", Phrase: "buy now", From: "U123", In: "C456", After: day, Before: day.AddDate(0, 0, 7)},
+			expected: `spam "buy now" from:<@U123> in:<#C456> after:2021-03-04 before:2021-03-11`,
+		},
+		{
+			name:  "empty",
+			query: SearchQuery{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.query.String(); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSearchMessages(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if token := r.Form.Get("token"); token != "xoxp-user" {
+			t.Errorf("expected the user token, but got %q", token)
+		}
+		if query := r.Form.Get("query"); query != "from:<@U123>" {
+			t.Errorf("unexpected query %q", query)
+		}
+		page := r.Form.Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"ok": true, "messages": {"matches": [{"ts": "%s.0001", "channel": {"id": "C1"}}], "pagination": {"page_count": 3}}}`, page)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	s := slack.New(slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-user"})
+	s.HTTPClient = server.Client()
+	s.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+
+	matches, err := New(s).SearchMessages(context.Background(), SearchQuery{From: "U123"}.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("expected a match from each of 3 pages, but got %d", len(matches))
+	}
+	for i, m := range matches {
+		if expected := fmt.Sprintf("%d.0001", i+1); m.TS != expected || m.Channel.ID != "C1" {
+			t.Errorf("expected match %d to be %s in C1, but got %s in %s", i, expected, m.TS, m.Channel.ID)
+		}
+	}
+
+	if _, err := New(s).SearchMessages(context.Background(), ""); err == nil {
+		t.Errorf("expected an error for an empty query")
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if r.URL.Path != "/api/search.files" {
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+		if token := r.Form.Get("token"); token != "xoxp-user" {
+			t.Errorf("expected the user token, but got %q", token)
+		}
+		page := r.Form.Get("page")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"ok": true, "files": {"matches": [{"id": "F%s", "user": "U123"}], "pagination": {"page_count": 2}}}`, page)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	s := slack.New(slack.Config{AccessToken: "xoxb-token", UserToken: "xoxp-user"})
+	s.HTTPClient = server.Client()
+	s.Use(func(next slack.RoundTripFunc) slack.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+
+	files, err := New(s).SearchFiles(context.Background(), SearchQuery{From: "U123"}.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 2 || files[0].ID != "F1" || files[1].ID != "F2" {
+		t.Errorf("expected F1 and F2, but got %+v", files)
+	}
+}
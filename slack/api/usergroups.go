@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// ListUsergroupsRequest is the request to usergroups.list.
+type ListUsergroupsRequest struct {
+	IncludeUsers    bool
+	IncludeDisabled bool
+	IncludeCount    bool
+}
+
+// ListUsergroups returns the workspace's usergroups.
+func (c *Client) ListUsergroups(ctx context.Context, req ListUsergroupsRequest) ([]slack.Subteam, error) {
+	resp := struct {
+		Usergroups []slack.Subteam `json:"usergroups"`
+	}{}
+	args := map[string]string{
+		"include_users":    strconv.FormatBool(req.IncludeUsers),
+		"include_disabled": strconv.FormatBool(req.IncludeDisabled),
+		"include_count":    strconv.FormatBool(req.IncludeCount),
+	}
+	if err := c.slack.CallOldMethodContext(ctx, "usergroups.list", args, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Usergroups, nil
+}
+
+// UsergroupMembers returns the IDs of the members of the usergroup with the given ID.
+func (c *Client) UsergroupMembers(ctx context.Context, usergroup string) ([]string, error) {
+	resp := struct {
+		Users []string `json:"users"`
+	}{}
+	if err := c.slack.CallOldMethodContext(ctx, "usergroups.users.list", map[string]string{"usergroup": usergroup}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Users, nil
+}
+
+// UpdateUsergroupMembers replaces the members of the usergroup with the given ID, and returns
+// the updated usergroup.
+func (c *Client) UpdateUsergroupMembers(ctx context.Context, usergroup string, users []string) (slack.Subteam, error) {
+	resp := struct {
+		Usergroup slack.Subteam `json:"usergroup"`
+	}{}
+	req := struct {
+		Usergroup string `json:"usergroup"`
+		Users     string `json:"users"`
+	}{usergroup, strings.Join(users, ",")}
+	if err := c.slack.CallMethodContext(ctx, "usergroups.users.update", req, &resp); err != nil {
+		return resp.Usergroup, err
+	}
+	return resp.Usergroup, nil
+}
+
+// IsUsergroupMember reports whether user is a member of any of the usergroups with the given IDs.
+func (c *Client) IsUsergroupMember(ctx context.Context, user string, usergroups ...string) (bool, error) {
+	for _, g := range usergroups {
+		members, err := c.UsergroupMembers(ctx, g)
+		if err != nil {
+			return false, err
+		}
+		for _, m := range members {
+			if m == user {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
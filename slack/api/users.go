@@ -0,0 +1,44 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// UserInfo looks up the user with the given ID.
+func (c *Client) UserInfo(ctx context.Context, user string) (slack.User, error) {
+	resp := struct {
+		User slack.User `json:"user"`
+	}{}
+	if err := c.slack.CallOldMethodContext(ctx, "users.info", map[string]string{"user": user}, &resp); err != nil {
+		return resp.User, err
+	}
+	return resp.User, nil
+}
+
+// SetUserProfile changes the given fields, such as "display_name", of user's profile. Changing
+// other users' profiles needs an admin's user token.
+func (c *Client) SetUserProfile(ctx context.Context, user string, profile map[string]string) error {
+	req := struct {
+		User    string            `json:"user"`
+		Profile map[string]string `json:"profile"`
+	}{User: user, Profile: profile}
+	return c.slack.CallMethodContext(ctx, "users.profile.set", req, nil)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// ViewInfo describes a view that Slack is displaying.
+type ViewInfo struct {
+	ID         string `json:"id"`
+	Hash       string `json:"hash"`
+	ExternalID string `json:"external_id"`
+	RootViewID string `json:"root_view_id"`
+	// PreviousViewID is set for views that were pushed on top of another view.
+	PreviousViewID string `json:"previous_view_id"`
+}
+
+// UpdateViewRequest is the request to views.update. Exactly one of ViewID and ExternalID
+// identifies the view to replace. If Hash is set, the update fails with "hash_conflict" if the
+// view has changed since the hash was obtained.
+type UpdateViewRequest struct {
+	View       *blocks.View `json:"view"`
+	ViewID     string       `json:"view_id,omitempty"`
+	ExternalID string       `json:"external_id,omitempty"`
+	Hash       string       `json:"hash,omitempty"`
+}
+
+// OpenView opens a modal in response to the interaction that produced triggerID.
+func (c *Client) OpenView(ctx context.Context, triggerID string, view *blocks.View) (ViewInfo, error) {
+	args := struct {
+		TriggerID string       `json:"trigger_id"`
+		View      *blocks.View `json:"view"`
+	}{triggerID, view}
+	return c.callView(ctx, "views.open", args)
+}
+
+// PushView pushes a modal on top of the modal that produced triggerID.
+func (c *Client) PushView(ctx context.Context, triggerID string, view *blocks.View) (ViewInfo, error) {
+	args := struct {
+		TriggerID string       `json:"trigger_id"`
+		View      *blocks.View `json:"view"`
+	}{triggerID, view}
+	return c.callView(ctx, "views.push", args)
+}
+
+// UpdateView replaces the contents of a modal that is already open.
+func (c *Client) UpdateView(ctx context.Context, req UpdateViewRequest) (ViewInfo, error) {
+	return c.callView(ctx, "views.update", req)
+}
+
+// PublishView sets the App Home tab that the given user sees to view, which must be a home view.
+// If hash is not empty, publishing fails with "hash_conflict" if the tab has changed since the
+// hash was obtained, such as from the view in an app_home_opened event.
+func (c *Client) PublishView(ctx context.Context, userID string, view *blocks.View, hash string) (ViewInfo, error) {
+	args := struct {
+		UserID string       `json:"user_id"`
+		View   *blocks.View `json:"view"`
+		Hash   string       `json:"hash,omitempty"`
+	}{userID, view, hash}
+	return c.callView(ctx, "views.publish", args)
+}
+
+func (c *Client) callView(ctx context.Context, method string, args interface{}) (ViewInfo, error) {
+	resp := struct {
+		View ViewInfo `json:"view"`
+	}{}
+	if err := c.slack.CallMethodContext(ctx, method, args, &resp); err != nil {
+		return resp.View, err
+	}
+	return resp.View, nil
+}
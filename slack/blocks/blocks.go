@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package blocks builds Slack Block Kit layouts, as documented at https://api.slack.com/block-kit.
+// The types marshal to the JSON Slack expects, so they can be passed directly as the blocks of a
+// message or a view.
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Block is a single block in a message or view.
+type Block interface {
+	block()
+}
+
+// Element is something that can be placed inside a block, such as a button or a piece of text.
+type Element interface {
+	element()
+}
+
+// Text types.
+const (
+	TypePlainText = "plain_text"
+	TypeMarkdown  = "mrkdwn"
+)
+
+// Text is a text object.
+type Text struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Emoji    bool   `json:"emoji,omitempty"`
+	Verbatim bool   `json:"verbatim,omitempty"`
+}
+
+func (*Text) element() {}
+
+// PlainText returns a plain text object.
+func PlainText(text string) *Text {
+	return &Text{Type: TypePlainText, Text: text, Emoji: true}
+}
+
+// Markdown returns a text object formatted with Slack's mrkdwn.
+func Markdown(text string) *Text {
+	return &Text{Type: TypeMarkdown, Text: text}
+}
+
+// SectionBlock displays text, optionally alongside fields and an accessory element.
+type SectionBlock struct {
+	Text      *Text   `json:"text,omitempty"`
+	BlockID   string  `json:"block_id,omitempty"`
+	Fields    []*Text `json:"fields,omitempty"`
+	Accessory Element `json:"accessory,omitempty"`
+}
+
+func (*SectionBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b *SectionBlock) MarshalJSON() ([]byte, error) {
+	type section SectionBlock
+	return marshalTyped("section", (*section)(b))
+}
+
+// Section returns a section block displaying text, with any given fields shown below it in
+// two columns.
+func Section(text *Text, fields ...*Text) *SectionBlock {
+	return &SectionBlock{Text: text, Fields: fields}
+}
+
+// ContextBlock displays small images and text, for secondary information.
+type ContextBlock struct {
+	Elements []Element `json:"elements"`
+	BlockID  string    `json:"block_id,omitempty"`
+}
+
+func (*ContextBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b *ContextBlock) MarshalJSON() ([]byte, error) {
+	type context ContextBlock
+	return marshalTyped("context", (*context)(b))
+}
+
+// Context returns a context block containing the given elements.
+func Context(elements ...Element) *ContextBlock {
+	return &ContextBlock{Elements: elements}
+}
+
+// ActionsBlock holds interactive elements, such as buttons.
+type ActionsBlock struct {
+	Elements []Element `json:"elements"`
+	BlockID  string    `json:"block_id,omitempty"`
+}
+
+func (*ActionsBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b *ActionsBlock) MarshalJSON() ([]byte, error) {
+	type actions ActionsBlock
+	return marshalTyped("actions", (*actions)(b))
+}
+
+// Actions returns an actions block containing the given elements.
+func Actions(elements ...Element) *ActionsBlock {
+	return &ActionsBlock{Elements: elements}
+}
+
+// DividerBlock separates other blocks with a horizontal line.
+type DividerBlock struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+func (*DividerBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b *DividerBlock) MarshalJSON() ([]byte, error) {
+	type divider DividerBlock
+	return marshalTyped("divider", (*divider)(b))
+}
+
+// Divider returns a divider block.
+func Divider() *DividerBlock {
+	return &DividerBlock{}
+}
+
+// RawBlock is a block that is already encoded as JSON, such as one designed in Block Kit Builder.
+type RawBlock json.RawMessage
+
+func (RawBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b RawBlock) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(b).MarshalJSON()
+}
+
+// ParseBlocks parses a JSON array of blocks, or an object with the array in its "blocks" field as
+// Block Kit Builder exports them, into RawBlocks.
+func ParseBlocks(data []byte) ([]Block, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		wrapped := struct {
+			Blocks []json.RawMessage `json:"blocks"`
+		}{}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("failed to parse blocks: %v", err)
+		}
+		raw = wrapped.Blocks
+	}
+	result := make([]Block, 0, len(raw))
+	for _, b := range raw {
+		result = append(result, RawBlock(b))
+	}
+	return result, nil
+}
+
+// Button styles. The zero value is Slack's default style.
+const (
+	StylePrimary = "primary"
+	StyleDanger  = "danger"
+)
+
+// ButtonElement is a button the user can click, either to send an interaction payload to the
+// app or to open a URL.
+type ButtonElement struct {
+	Text     *Text  `json:"text"`
+	ActionID string `json:"action_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+func (*ButtonElement) element() {}
+
+// MarshalJSON implements json.Marshaler.
+func (e *ButtonElement) MarshalJSON() ([]byte, error) {
+	type button ButtonElement
+	return marshalTyped("button", (*button)(e))
+}
+
+// Button returns a button with the given label. actionID identifies the button in the
+// interaction payload sent when it is clicked, along with value.
+func Button(actionID, label, value string) *ButtonElement {
+	return &ButtonElement{Text: PlainText(label), ActionID: actionID, Value: value}
+}
+
+// LinkButton returns a button that opens url when clicked.
+func LinkButton(actionID, label, url string) *ButtonElement {
+	return &ButtonElement{Text: PlainText(label), ActionID: actionID, URL: url}
+}
+
+// marshalTyped marshals v, which should be a pointer to a struct without a MarshalJSON method,
+// with an additional "type" field set to t.
+func marshalTyped(t string, v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	typ, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(`{"type":`), typ...)
+	if len(b) > 2 {
+		out = append(out, ',')
+	}
+	return append(out, b[1:]...), nil
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blocks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		blocks   []Block
+		expected string
+	}{
+		{
+			name:     "divider",
+			blocks:   []Block{Divider()},
+			expected: `[{"type":"divider"}]`,
+		},
+		{
+			name:     "section with fields",
+			blocks:   []Block{Section(Markdown("*hello*"), PlainText("a"), PlainText("b"))},
+			expected: `[{"type":"section","text":{"type":"mrkdwn","text":"*hello*"},"fields":[{"type":"plain_text","text":"a","emoji":true},{"type":"plain_text","text":"b","emoji":true}]}]`,
+		},
+		{
+			name: "section with a button accessory",
+			blocks: []Block{&SectionBlock{
+				Text:      Markdown("hi"),
+				BlockID:   "intro",
+				Accessory: LinkButton("docs", "Docs", "https://example.com"),
+			}},
+			expected: `[{"type":"section","text":{"type":"mrkdwn","text":"hi"},"block_id":"intro","accessory":{"type":"button","text":{"type":"plain_text","text":"Docs","emoji":true},"action_id":"docs","url":"https://example.com"}}]`,
+		},
+		{
+			name:     "context",
+			blocks:   []Block{Context(Markdown("posted by someone"))},
+			expected: `[{"type":"context","elements":[{"type":"mrkdwn","text":"posted by someone"}]}]`,
+		},
+		{
+			name: "actions with styled buttons",
+			blocks: []Block{Actions(
+				&ButtonElement{Text: PlainText("Approve"), ActionID: "approve", Value: "1", Style: StylePrimary},
+				Button("deny", "Deny", "1"),
+			)},
+			expected: `[{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Approve","emoji":true},"action_id":"approve","value":"1","style":"primary"},{"type":"button","text":{"type":"plain_text","text":"Deny","emoji":true},"action_id":"deny","value":"1"}]}]`,
+		},
+		{
+			name: "inputs",
+			blocks: []Block{
+				Input("reason", "Reason", StaticSelect("reason-input", NewOption("Spam", "spam"))),
+				&InputBlock{Label: PlainText("Details"), Element: &PlainTextInputElement{ActionID: "details", Multiline: true}, Optional: true},
+				Input("channels", "Channels", MultiChannelsSelect("channel-input")),
+				Input("when", "Post at", DatetimePicker("post-at")),
+			},
+			expected: `[{"type":"input","label":{"type":"plain_text","text":"Reason","emoji":true},"element":{"type":"static_select","action_id":"reason-input","options":[{"text":{"type":"plain_text","text":"Spam","emoji":true},"value":"spam"}]},"block_id":"reason"},` +
+				`{"type":"input","label":{"type":"plain_text","text":"Details","emoji":true},"element":{"type":"plain_text_input","action_id":"details","multiline":true},"optional":true},` +
+				`{"type":"input","label":{"type":"plain_text","text":"Channels","emoji":true},"element":{"type":"multi_channels_select","action_id":"channel-input"},"block_id":"channels"},` +
+				`{"type":"input","label":{"type":"plain_text","text":"Post at","emoji":true},"element":{"type":"datetimepicker","action_id":"post-at"},"block_id":"when"}]`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.blocks)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tc.expected {
+				t.Errorf("expected %s, but got %s", tc.expected, string(b))
+			}
+		})
+	}
+}
+
+func TestViewMetadata(t *testing.T) {
+	type metadata struct {
+		Channel string `json:"c"`
+		TS      string `json:"t"`
+	}
+	v := Modal("report", "Report", Section(PlainText("hi")))
+	if err := v.SetMetadata(metadata{Channel: "C1", TS: "123.456"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"type":"modal","title":{"type":"plain_text","text":"Report","emoji":true},"blocks":[{"type":"section","text":{"type":"plain_text","text":"hi","emoji":true}}],"callback_id":"report","private_metadata":"{\"c\":\"C1\",\"t\":\"123.456\"}"}`
+	if string(b) != expected {
+		t.Errorf("expected %s, but got %s", expected, string(b))
+	}
+
+	got := metadata{}
+	if err := UnmarshalMetadata(v.PrivateMetadata, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Channel != "C1" || got.TS != "123.456" {
+		t.Errorf("expected metadata to round trip, but got %+v", got)
+	}
+
+	if err := v.SetMetadata(strings.Repeat("a", MaxPrivateMetadata)); err == nil {
+		t.Errorf("expected an error setting metadata longer than %d bytes", MaxPrivateMetadata)
+	}
+}
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "array",
+			data:     `[{"type": "divider"}, {"type": "section", "text": {"type": "mrkdwn", "text": "hi"}}]`,
+			expected: `[{"type":"divider"},{"type":"section","text":{"type":"mrkdwn","text":"hi"}}]`,
+		},
+		{
+			name:     "Block Kit Builder export",
+			data:     `{"blocks": [{"type": "divider"}]}`,
+			expected: `[{"type":"divider"}]`,
+		},
+		{
+			name:        "not JSON",
+			data:        `*hello*`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := ParseBlocks([]byte(tc.data))
+			if err != nil {
+				if !tc.expectError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tc.expectError {
+				t.Fatalf("expected an error, but got %v", parsed)
+			}
+			b, err := json.Marshal(parsed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(b) != tc.expected {
+				t.Errorf("expected %s, but got %s", tc.expected, string(b))
+			}
+		})
+	}
+}
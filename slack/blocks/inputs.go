@@ -0,0 +1,137 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blocks
+
+// InputBlock collects a value from the user in a modal, using an input element such as a
+// PlainTextInputElement.
+type InputBlock struct {
+	Label    *Text   `json:"label"`
+	Element  Element `json:"element"`
+	BlockID  string  `json:"block_id,omitempty"`
+	Hint     *Text   `json:"hint,omitempty"`
+	Optional bool    `json:"optional,omitempty"`
+}
+
+func (*InputBlock) block() {}
+
+// MarshalJSON implements json.Marshaler.
+func (b *InputBlock) MarshalJSON() ([]byte, error) {
+	type input InputBlock
+	return marshalTyped("input", (*input)(b))
+}
+
+// Input returns an input block with the given label. blockID identifies the block in the state
+// of the submitted view.
+func Input(blockID, label string, element Element) *InputBlock {
+	return &InputBlock{BlockID: blockID, Label: PlainText(label), Element: element}
+}
+
+// PlainTextInputElement is a free-form text field.
+type PlainTextInputElement struct {
+	ActionID     string `json:"action_id,omitempty"`
+	Placeholder  *Text  `json:"placeholder,omitempty"`
+	InitialValue string `json:"initial_value,omitempty"`
+	Multiline    bool   `json:"multiline,omitempty"`
+	MinLength    int    `json:"min_length,omitempty"`
+	MaxLength    int    `json:"max_length,omitempty"`
+}
+
+func (*PlainTextInputElement) element() {}
+
+// MarshalJSON implements json.Marshaler.
+func (e *PlainTextInputElement) MarshalJSON() ([]byte, error) {
+	type input PlainTextInputElement
+	return marshalTyped("plain_text_input", (*input)(e))
+}
+
+// PlainTextInput returns a single-line text field.
+func PlainTextInput(actionID string) *PlainTextInputElement {
+	return &PlainTextInputElement{ActionID: actionID}
+}
+
+// Option is a choice in a select menu.
+type Option struct {
+	Text  *Text  `json:"text"`
+	Value string `json:"value"`
+}
+
+// NewOption returns an option labeled text, which is reported as value when selected.
+func NewOption(text, value string) *Option {
+	return &Option{Text: PlainText(text), Value: value}
+}
+
+// StaticSelectElement is a menu of fixed options.
+type StaticSelectElement struct {
+	ActionID      string    `json:"action_id,omitempty"`
+	Placeholder   *Text     `json:"placeholder,omitempty"`
+	Options       []*Option `json:"options"`
+	InitialOption *Option   `json:"initial_option,omitempty"`
+}
+
+func (*StaticSelectElement) element() {}
+
+// MarshalJSON implements json.Marshaler.
+func (e *StaticSelectElement) MarshalJSON() ([]byte, error) {
+	type selectElement StaticSelectElement
+	return marshalTyped("static_select", (*selectElement)(e))
+}
+
+// StaticSelect returns a menu of the given options.
+func StaticSelect(actionID string, options ...*Option) *StaticSelectElement {
+	return &StaticSelectElement{ActionID: actionID, Options: options}
+}
+
+// MultiChannelsSelectElement is a menu that lets the user pick any number of public channels.
+type MultiChannelsSelectElement struct {
+	ActionID         string   `json:"action_id,omitempty"`
+	Placeholder      *Text    `json:"placeholder,omitempty"`
+	InitialChannels  []string `json:"initial_channels,omitempty"`
+	MaxSelectedItems int      `json:"max_selected_items,omitempty"`
+}
+
+func (*MultiChannelsSelectElement) element() {}
+
+// MarshalJSON implements json.Marshaler.
+func (e *MultiChannelsSelectElement) MarshalJSON() ([]byte, error) {
+	type selectElement MultiChannelsSelectElement
+	return marshalTyped("multi_channels_select", (*selectElement)(e))
+}
+
+// MultiChannelsSelect returns a menu of public channels.
+func MultiChannelsSelect(actionID string) *MultiChannelsSelectElement {
+	return &MultiChannelsSelectElement{ActionID: actionID}
+}
+
+// DatetimePickerElement lets the user pick a date and time, in their own time zone.
+type DatetimePickerElement struct {
+	ActionID string `json:"action_id,omitempty"`
+	// InitialDateTime is a Unix timestamp.
+	InitialDateTime int64 `json:"initial_date_time,omitempty"`
+}
+
+func (*DatetimePickerElement) element() {}
+
+// MarshalJSON implements json.Marshaler.
+func (e *DatetimePickerElement) MarshalJSON() ([]byte, error) {
+	type pickerElement DatetimePickerElement
+	return marshalTyped("datetimepicker", (*pickerElement)(e))
+}
+
+// DatetimePicker returns a date and time picker.
+func DatetimePicker(actionID string) *DatetimePickerElement {
+	return &DatetimePickerElement{ActionID: actionID}
+}
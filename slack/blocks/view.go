@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blocks
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// View types.
+const (
+	ViewModal = "modal"
+	ViewHome  = "home"
+)
+
+// MaxPrivateMetadata is the longest private_metadata Slack accepts on a view.
+const MaxPrivateMetadata = 3000
+
+// View is a modal or App Home tab.
+// See https://api.slack.com/reference/surfaces/views
+type View struct {
+	Type            string  `json:"type"`
+	Title           *Text   `json:"title,omitempty"`
+	Blocks          []Block `json:"blocks"`
+	Submit          *Text   `json:"submit,omitempty"`
+	Close           *Text   `json:"close,omitempty"`
+	CallbackID      string  `json:"callback_id,omitempty"`
+	ExternalID      string  `json:"external_id,omitempty"`
+	PrivateMetadata string  `json:"private_metadata,omitempty"`
+	ClearOnClose    bool    `json:"clear_on_close,omitempty"`
+	NotifyOnClose   bool    `json:"notify_on_close,omitempty"`
+}
+
+// Modal returns a modal with the given title and blocks. callbackID identifies the modal in the
+// view_submission payload sent when it is submitted.
+func Modal(callbackID, title string, blocks ...Block) *View {
+	return &View{Type: ViewModal, CallbackID: callbackID, Title: PlainText(title), Blocks: blocks}
+}
+
+// Home returns an App Home tab with the given blocks, for publishing with views.publish.
+func Home(blocks ...Block) *View {
+	return &View{Type: ViewHome, Blocks: blocks}
+}
+
+// SetMetadata stores metadata, marshalled as JSON, in the view's private_metadata, which Slack sends
+// back unchanged in interaction payloads from the view. Use UnmarshalMetadata to read it.
+func (v *View) SetMetadata(metadata interface{}) error {
+	b, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal private metadata: %v", err)
+	}
+	if len(b) > MaxPrivateMetadata {
+		return fmt.Errorf("private metadata is %d bytes, but may only be %d", len(b), MaxPrivateMetadata)
+	}
+	v.PrivateMetadata = string(b)
+	return nil
+}
+
+// UnmarshalMetadata unmarshals private_metadata stored by SetMetadata into v.
+func UnmarshalMetadata(privateMetadata string, v interface{}) error {
+	if err := json.Unmarshal([]byte(privateMetadata), v); err != nil {
+		return fmt.Errorf("failed to unmarshal private metadata: %v", err)
+	}
+	return nil
+}
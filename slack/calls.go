@@ -17,9 +17,10 @@ limitations under the License.
 package slack
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
-	"time"
 )
 
 type ConversationType string
@@ -32,47 +33,33 @@ const (
 )
 
 func (c *Client) GetConversations(types []ConversationType) ([]Conversation, error) {
+	return c.GetConversationsContext(context.Background(), types)
+}
+
+// GetConversationsContext is like GetConversations, but the call is aborted when ctx is done.
+func (c *Client) GetConversationsContext(ctx context.Context, types []ConversationType) ([]Conversation, error) {
 	t := make([]string, 0, len(types))
 	for _, v := range types {
 		t = append(t, string(v))
 	}
 
 	var conversations []Conversation
-	cursor := ""
-	for {
-		args := map[string]string{
-			"limit": "100",
-			"types": strings.Join(t, ","),
-		}
-		if cursor != "" {
-			args["cursor"] = cursor
-		}
-
+	args := map[string]string{
+		"limit": "100",
+		"types": strings.Join(t, ","),
+	}
+	err := c.CallMethodPaged(ctx, "conversations.list", args, func(page []byte) error {
 		ret := struct {
 			Channels []Conversation `json:"channels"`
-			Metadata struct {
-				NextCursor string `json:"next_cursor"`
-			} `json:"response_metadata"`
 		}{}
-
-		for {
-			if err := c.CallOldMethod("conversations.list", args, &ret); err != nil {
-				switch e := err.(type) {
-				case ErrRateLimit:
-					time.Sleep(e.Wait)
-					continue
-				default:
-					return nil, fmt.Errorf("failed to list conversations: %v", err)
-				}
-			}
-			break
+		if err := json.Unmarshal(page, &ret); err != nil {
+			return err
 		}
-
 		conversations = append(conversations, ret.Channels...)
-		if ret.Metadata.NextCursor == "" {
-			break
-		}
-		cursor = ret.Metadata.NextCursor
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %v", err)
 	}
 	return conversations, nil
 }
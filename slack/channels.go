@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultChannelCacheTTL is how long a ChannelCache trusts its list of channels.
+	defaultChannelCacheTTL = time.Hour
+	// minChannelListInterval limits how often looking up an unknown channel name can cause the
+	// list of channels to be fetched again.
+	minChannelListInterval = time.Minute
+)
+
+// ChannelCache maps channel names to IDs and back, so that configuration can refer to channels
+// like #kubernetes-dev rather than C0123ABCD. The full list of channels is fetched with
+// conversations.list when the cache is first used and again once it is older than TTL; channels
+// that don't appear in it are looked up individually with conversations.info.
+type ChannelCache struct {
+	// TTL is how long the list of channels is used before being fetched again.
+	TTL time.Duration
+	// Types are the types of conversation to list. Listing private channels requires the
+	// groups:read scope.
+	Types []ConversationType
+
+	client   *Client
+	lock     sync.Mutex
+	byID     map[string]Conversation
+	byName   map[string]string
+	listedAt time.Time
+}
+
+// NewChannelCache returns a ChannelCache that makes its calls using client.
+func NewChannelCache(client *Client) *ChannelCache {
+	return &ChannelCache{
+		TTL:    defaultChannelCacheTTL,
+		Types:  []ConversationType{ConversationTypePublicChannel},
+		client: client,
+		byID:   map[string]Conversation{},
+		byName: map[string]string{},
+	}
+}
+
+// Channels returns the Client's ChannelCache, creating it the first time it is needed, so every
+// user of a Client shares the same cache.
+func (c *Client) Channels() *ChannelCache {
+	c.cacheOnce.Do(c.initCaches)
+	return c.channels
+}
+
+// ID returns the ID of the channel called name. name may start with a #, and if it is already a
+// channel ID it is returned unchanged.
+func (cc *ChannelCache) ID(ctx context.Context, name string) (string, error) {
+	name = strings.TrimPrefix(name, "#")
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if err := cc.ensureListedLocked(ctx, false); err != nil {
+		return "", err
+	}
+	if id, ok := cc.byName[name]; ok {
+		return id, nil
+	}
+	if _, ok := cc.byID[name]; ok {
+		return name, nil
+	}
+	// The channel may have been created or renamed since we last looked.
+	if time.Since(cc.listedAt) > minChannelListInterval {
+		if err := cc.ensureListedLocked(ctx, true); err != nil {
+			return "", err
+		}
+		if id, ok := cc.byName[name]; ok {
+			return id, nil
+		}
+	}
+	if looksLikeChannelID(name) {
+		return name, nil
+	}
+	return "", fmt.Errorf("no channel called #%s", name)
+}
+
+// Name returns the name of the channel with the given ID, without a leading #.
+func (cc *ChannelCache) Name(ctx context.Context, id string) (string, error) {
+	conversation, err := cc.Conversation(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return conversation.Name, nil
+}
+
+// Conversation returns the channel with the given ID.
+func (cc *ChannelCache) Conversation(ctx context.Context, id string) (Conversation, error) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if err := cc.ensureListedLocked(ctx, false); err != nil {
+		return Conversation{}, err
+	}
+	if conversation, ok := cc.byID[id]; ok {
+		return conversation, nil
+	}
+	resp := struct {
+		Channel Conversation `json:"channel"`
+	}{}
+	if err := cc.client.CallOldMethodContext(ctx, "conversations.info", map[string]string{"channel": id}, &resp); err != nil {
+		return Conversation{}, err
+	}
+	cc.addLocked(resp.Channel)
+	return resp.Channel, nil
+}
+
+// Invalidate forgets everything in the cache, so it is fetched again when next used.
+func (cc *ChannelCache) Invalidate() {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	cc.listedAt = time.Time{}
+	cc.byID = map[string]Conversation{}
+	cc.byName = map[string]string{}
+}
+
+// ensureListedLocked fetches the list of channels if it has expired, or if force is set.
+// lock must be held.
+func (cc *ChannelCache) ensureListedLocked(ctx context.Context, force bool) error {
+	if !force && !cc.listedAt.IsZero() && time.Since(cc.listedAt) < cc.TTL {
+		return nil
+	}
+	conversations, err := cc.client.GetConversationsContext(ctx, cc.Types)
+	if err != nil {
+		return err
+	}
+	cc.byID = make(map[string]Conversation, len(conversations))
+	cc.byName = make(map[string]string, len(conversations))
+	for _, conversation := range conversations {
+		cc.addLocked(conversation)
+	}
+	cc.listedAt = time.Now()
+	return nil
+}
+
+func (cc *ChannelCache) addLocked(conversation Conversation) {
+	cc.byID[conversation.ID] = conversation
+	if conversation.Name != "" {
+		cc.byName[conversation.Name] = conversation.ID
+	}
+}
+
+// looksLikeChannelID returns true if s has the form of a public channel, private channel or
+// direct message ID.
+func looksLikeChannelID(s string) bool {
+	if len(s) < 9 || (s[0] != 'C' && s[0] != 'G' && s[0] != 'D') {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestClient returns a Client whose calls to the Slack API are sent to handler instead.
+func newTestClient(handler http.HandlerFunc) (*Client, func()) {
+	server := httptest.NewTLSServer(handler)
+	serverURL, _ := url.Parse(server.URL)
+	c := New(Config{AccessToken: "xoxb-token"})
+	c.HTTPClient = server.Client()
+	c.Use(func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.URL.Scheme = serverURL.Scheme
+			req.URL.Host = serverURL.Host
+			return next(req)
+		}
+	})
+	return c, server.Close
+}
+
+func TestChannelCache(t *testing.T) {
+	calls := map[string]int{}
+	c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/conversations.list":
+			_, _ = w.Write([]byte(`{"ok": true, "channels": [{"id": "C0123ABCD", "name": "kubernetes-dev"}, {"id": "C0456EFGH", "name": "sig-contribex"}]}`))
+		case "/api/conversations.info":
+			_ = r.ParseForm()
+			if r.Form.Get("channel") != "G0789IJKL" {
+				_, _ = w.Write([]byte(`{"ok": false, "error": "channel_not_found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"ok": true, "channel": {"id": "G0789IJKL", "name": "secret-plans"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	defer done()
+	cache := c.Channels()
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		lookup    func() (string, error)
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "name with a #",
+			lookup:   func() (string, error) { return cache.ID(ctx, "#kubernetes-dev") },
+			expected: "C0123ABCD",
+		},
+		{
+			name:     "name without a #",
+			lookup:   func() (string, error) { return cache.ID(ctx, "sig-contribex") },
+			expected: "C0456EFGH",
+		},
+		{
+			name:     "ID is returned unchanged",
+			lookup:   func() (string, error) { return cache.ID(ctx, "C0123ABCD") },
+			expected: "C0123ABCD",
+		},
+		{
+			name:      "unknown name",
+			lookup:    func() (string, error) { return cache.ID(ctx, "#nope") },
+			expectErr: true,
+		},
+		{
+			name:     "listed channel name",
+			lookup:   func() (string, error) { return cache.Name(ctx, "C0456EFGH") },
+			expected: "sig-contribex",
+		},
+		{
+			name:     "unlisted channel name is looked up",
+			lookup:   func() (string, error) { return cache.Name(ctx, "G0789IJKL") },
+			expected: "secret-plans",
+		},
+		{
+			name:     "looked up channel name is cached by ID",
+			lookup:   func() (string, error) { return cache.ID(ctx, "secret-plans") },
+			expected: "G0789IJKL",
+		},
+		{
+			name:      "unknown channel ID",
+			lookup:    func() (string, error) { return cache.Name(ctx, "C9999ZZZZ") },
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := tc.lookup()
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, but got %q", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, result)
+			}
+		})
+	}
+
+	if calls["/api/conversations.list"] != 1 {
+		t.Errorf("expected channels to be listed once, but they were listed %d times", calls["/api/conversations.list"])
+	}
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ClientSet holds a Client for each Slack workspace we serve, keyed by team ID, so one deployment
+// can handle events from several workspaces.
+type ClientSet struct {
+	lock          sync.RWMutex
+	defaultClient *Client
+	clients       map[string]*Client
+}
+
+// NewClientSet returns a ClientSet that uses defaultClient for any team without its own Client.
+// defaultClient may be nil, in which case events from unknown teams are rejected.
+func NewClientSet(defaultClient *Client) *ClientSet {
+	return &ClientSet{defaultClient: defaultClient, clients: map[string]*Client{}}
+}
+
+// Add registers the Client to use for the given team.
+func (s *ClientSet) Add(teamID string, client *Client) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.clients[teamID] = client
+}
+
+// ForTeam returns the Client for the given team.
+func (s *ClientSet) ForTeam(teamID string) (*Client, error) {
+	if s == nil {
+		return nil, fmt.Errorf("no slack clients configured")
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if c, ok := s.clients[teamID]; ok {
+		return c, nil
+	}
+	if s.defaultClient != nil {
+		return s.defaultClient, nil
+	}
+	return nil, fmt.Errorf("no slack client configured for team %q", teamID)
+}
+
+// ForPayload returns the Client for the team that sent body, which may be an Events API callback,
+// a form-encoded interactivity payload, or a slash command.
+func (s *ClientSet) ForPayload(body []byte) (*Client, error) {
+	return s.ForTeam(payloadTeamID(body))
+}
+
+func payloadTeamID(body []byte) string {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return ""
+		}
+		if form.Get("team_id") != "" {
+			return form.Get("team_id")
+		}
+		body = []byte(form.Get("payload"))
+	}
+	ids := struct {
+		TeamID string `json:"team_id"`
+		Team   struct {
+			ID string `json:"id"`
+		} `json:"team"`
+	}{}
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return ""
+	}
+	if ids.TeamID != "" {
+		return ids.TeamID
+	}
+	return ids.Team.ID
+}
+
+// Default returns the Client used for teams without their own Client, which may be nil.
+func (s *ClientSet) Default() *Client {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.defaultClient
+}
+
+// All returns every distinct Client in the set, ordered by team ID with the default Client first.
+func (s *ClientSet) All() []*Client {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var result []*Client
+	if s.defaultClient != nil {
+		result = append(result, s.defaultClient)
+	}
+	teams := make([]string, 0, len(s.clients))
+	for t := range s.clients {
+		teams = append(teams, t)
+	}
+	sort.Strings(teams)
+	for _, t := range teams {
+		if s.clients[t] != s.defaultClient {
+			result = append(result, s.clients[t])
+		}
+	}
+	return result
+}
+
+// LoadClientSet loads a ClientSet from a JSON file. The file is a normal Config, which is used as
+// the default, optionally with a "workspaces" object mapping team IDs to their own Config:
+//
+//	{
+//	  "signingSecret": "...",
+//	  "accessToken": "...",
+//	  "workspaces": {
+//	    "T0123ABCD": {"signingSecret": "...", "accessToken": "..."}
+//	  }
+//	}
+//
+// If the top-level Config has no access token, and isn't only an incoming webhook, only the listed
+// workspaces are served.
+func LoadClientSet(path string) (*ClientSet, error) {
+	config, workspaces, err := loadWorkspaces(path)
+	if err != nil {
+		return nil, err
+	}
+	var defaultClient *Client
+	if isDefault(config) {
+		defaultClient = New(config)
+	}
+	s := NewClientSet(defaultClient)
+	for team, c := range workspaces {
+		s.Add(team, New(c))
+	}
+	if defaultClient == nil && len(s.clients) == 0 {
+		return nil, fmt.Errorf("no accessToken, webhook or workspaces configured")
+	}
+	return s, nil
+}
+
+// Reload reads the file at path again, and updates the credentials of every Client in the set to
+// match it. Clients are added and removed as workspaces are added to and removed from the file.
+func (s *ClientSet) Reload(path string) error {
+	config, workspaces, err := loadWorkspaces(path)
+	if err != nil {
+		return err
+	}
+	if !isDefault(config) && len(workspaces) == 0 {
+		return fmt.Errorf("no accessToken, webhook or workspaces configured")
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !isDefault(config) {
+		s.defaultClient = nil
+	} else if s.defaultClient == nil {
+		s.defaultClient = New(config)
+	} else {
+		s.defaultClient.UpdateConfig(config)
+	}
+	for team := range s.clients {
+		if _, ok := workspaces[team]; !ok {
+			delete(s.clients, team)
+		}
+	}
+	for team, c := range workspaces {
+		if existing, ok := s.clients[team]; ok {
+			existing.UpdateConfig(c)
+		} else {
+			s.clients[team] = New(c)
+		}
+	}
+	return nil
+}
+
+// isDefault returns whether the top-level Config of a file makes a default Client: it has an
+// access token, or is only an incoming webhook.
+func isDefault(config Config) bool {
+	return config.AccessToken != "" || config.WebhookOnly()
+}
+
+// loadWorkspaces loads the default Config and the Config of each workspace from path.
+func loadWorkspaces(path string) (Config, map[string]Config, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return config, nil, err
+	}
+	workspaces := struct {
+		Workspaces map[string]Config `json:"workspaces"`
+	}{}
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return config, nil, fmt.Errorf("couldn't open file: %v", err)
+		}
+		if err := json.Unmarshal(content, &workspaces); err != nil {
+			return config, nil, fmt.Errorf("couldn't parse workspaces: %v", err)
+		}
+	}
+	return config, workspaces.Workspaces, nil
+}
@@ -17,27 +17,134 @@ limitations under the License.
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
 )
 
 // Config is the information needed to communicate with Slack.
 type Config struct {
 	SigningSecret string `json:"signingSecret"`
-	WebhookURL    string `json:"webhook"`
-	AccessToken   string `json:"accessToken"`
+	// SigningSecrets are accepted in addition to SigningSecret, so that the secret can be rotated
+	// without downtime: add the new secret here, switch the Slack app over, then remove the old one.
+	SigningSecrets []string `json:"signingSecrets,omitempty"`
+	WebhookURL     string   `json:"webhook"`
+	AccessToken    string   `json:"accessToken"`
+	// AppToken is an app-level token, which is only needed to connect using Socket Mode.
+	AppToken string `json:"appToken,omitempty"`
+	// OrgToken is an org-level user token, which is only needed to call Enterprise Grid admin.*
+	// methods. Calls only use it when their context selects it with WithToken.
+	OrgToken string `json:"orgToken,omitempty"`
+	// UserToken is a user token, for methods such as search.messages that bots can't call. Calls
+	// only use it when their context selects it with WithToken.
+	UserToken string `json:"userToken,omitempty"`
+
+	// RefreshToken, ClientID and ClientSecret enable token rotation: the access token is refreshed
+	// using oauth.v2.access shortly before it expires. Rotated tokens are written to
+	// TokenStorePath, if set, so that they survive restarts.
+	RefreshToken   string `json:"refreshToken,omitempty"`
+	ClientID       string `json:"clientID,omitempty"`
+	ClientSecret   string `json:"clientSecret,omitempty"`
+	TokenStorePath string `json:"tokenStorePath,omitempty"`
+
+	// DryRun makes the Client log the write calls it would make, such as chat.postMessage or
+	// conversations.join, instead of making them; the scim package skips its writes too. Calls
+	// that only read from Slack still happen, as do requests made directly with Client.Do.
+	// Skipped calls return no error and leave their result untouched.
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Transport configures the HTTP connections used to talk to Slack.
+	Transport TransportConfig `json:"transport,omitempty"`
+
+	// Secrets optionally fetches AccessToken and SigningSecret from a secret manager.
+	Secrets SecretsConfig `json:"secrets,omitempty"`
+}
+
+// signingSecrets returns every signing secret that VerifySignature should accept.
+func (c Config) signingSecrets() []string {
+	var secrets []string
+	if c.SigningSecret != "" {
+		secrets = append(secrets, c.SigningSecret)
+	}
+	for _, s := range c.SigningSecrets {
+		if s != "" {
+			secrets = append(secrets, s)
+		}
+	}
+	return secrets
+}
+
+// envVars maps the environment variables that can be used to configure a Client to the Config
+// fields they set.
+var envVars = []struct {
+	name  string
+	field func(c *Config) *string
+}{
+	{"SLACK_SIGNING_SECRET", func(c *Config) *string { return &c.SigningSecret }},
+	{"SLACK_WEBHOOK_URL", func(c *Config) *string { return &c.WebhookURL }},
+	{"SLACK_ACCESS_TOKEN", func(c *Config) *string { return &c.AccessToken }},
+	{"SLACK_APP_TOKEN", func(c *Config) *string { return &c.AppToken }},
+	{"SLACK_ORG_TOKEN", func(c *Config) *string { return &c.OrgToken }},
+	{"SLACK_USER_TOKEN", func(c *Config) *string { return &c.UserToken }},
+	{"SLACK_REFRESH_TOKEN", func(c *Config) *string { return &c.RefreshToken }},
+	{"SLACK_CLIENT_ID", func(c *Config) *string { return &c.ClientID }},
+	{"SLACK_CLIENT_SECRET", func(c *Config) *string { return &c.ClientSecret }},
+	{"SLACK_TOKEN_STORE_PATH", func(c *Config) *string { return &c.TokenStorePath }},
 }
 
-// LoadConfig loads a Config from a JSON file.
+// applyEnv overrides fields of config with any of the SLACK_* environment variables that are set.
+// SLACK_SIGNING_SECRETS is a comma-separated list of additional signing secrets, and SLACK_DRY_RUN
+// is a boolean.
+func applyEnv(config *Config, getenv func(string) string) error {
+	for _, v := range envVars {
+		if value := getenv(v.name); value != "" {
+			*v.field(config) = value
+		}
+	}
+	if value := getenv("SLACK_SIGNING_SECRETS"); value != "" {
+		config.SigningSecrets = strings.Split(value, ",")
+	}
+	if value := getenv("SLACK_DRY_RUN"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid SLACK_DRY_RUN %q: %v", value, err)
+		}
+		config.DryRun = dryRun
+	}
+	return nil
+}
+
+// LoadConfig loads a Config from a JSON file, then applies any SLACK_* environment variables on
+// top of it, and finally fetches secrets from a secret manager if one is configured. If path is
+// empty, the Config is built from the environment alone.
 func LoadConfig(path string) (Config, error) {
 	config := Config{}
-	content, err := ioutil.ReadFile(path)
-	if err != nil {
-		return config, fmt.Errorf("couldn't open file: %v", err)
+	if path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("couldn't open file: %v", err)
+		}
+		if err := json.Unmarshal(content, &config); err != nil {
+			return config, fmt.Errorf("couldn't parse config: %v", err)
+		}
+	}
+	if err := applyEnv(&config, os.Getenv); err != nil {
+		return config, err
+	}
+	if _, err := NewHTTPClient(config.Transport); err != nil {
+		return config, fmt.Errorf("invalid transport config: %v", err)
+	}
+	if _, err := config.Secrets.refreshInterval(); err != nil {
+		return config, fmt.Errorf("invalid secrets config: %v", err)
 	}
-	if err := json.Unmarshal(content, &config); err != nil {
-		return config, fmt.Errorf("couldn't parse config: %v", err)
+	if config.Secrets.enabled() {
+		if err := config.Secrets.fetch(context.Background(), &config); err != nil {
+			return config, err
+		}
 	}
 	return config, nil
 }
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		env       map[string]string
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:     "no environment variables leaves the config alone",
+			config:   Config{AccessToken: "xoxb-file", SigningSecret: "file-secret"},
+			expected: Config{AccessToken: "xoxb-file", SigningSecret: "file-secret"},
+		},
+		{
+			name:   "environment variables override the config file",
+			config: Config{AccessToken: "xoxb-file", SigningSecret: "file-secret", WebhookURL: "https://hooks.slack.com/file"},
+			env: map[string]string{
+				"SLACK_ACCESS_TOKEN":   "xoxb-env",
+				"SLACK_SIGNING_SECRET": "env-secret",
+			},
+			expected: Config{AccessToken: "xoxb-env", SigningSecret: "env-secret", WebhookURL: "https://hooks.slack.com/file"},
+		},
+		{
+			name: "the config can come entirely from the environment",
+			env: map[string]string{
+				"SLACK_ACCESS_TOKEN":    "xoxb-env",
+				"SLACK_SIGNING_SECRET":  "env-secret",
+				"SLACK_SIGNING_SECRETS": "old-secret,older-secret",
+				"SLACK_WEBHOOK_URL":     "https://hooks.slack.com/env",
+				"SLACK_APP_TOKEN":       "xapp-env",
+			},
+			expected: Config{
+				AccessToken:    "xoxb-env",
+				SigningSecret:  "env-secret",
+				SigningSecrets: []string{"old-secret", "older-secret"},
+				WebhookURL:     "https://hooks.slack.com/env",
+				AppToken:       "xapp-env",
+			},
+		},
+		{
+			name:     "dry run can be turned on from the environment",
+			config:   Config{AccessToken: "xoxb-file"},
+			env:      map[string]string{"SLACK_DRY_RUN": "true"},
+			expected: Config{AccessToken: "xoxb-file", DryRun: true},
+		},
+		{
+			name:     "dry run can be turned off from the environment",
+			config:   Config{AccessToken: "xoxb-file", DryRun: true},
+			env:      map[string]string{"SLACK_DRY_RUN": "0"},
+			expected: Config{AccessToken: "xoxb-file"},
+		},
+		{
+			name:      "an invalid dry run setting is an error",
+			env:       map[string]string{"SLACK_DRY_RUN": "maybe"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			err := applyEnv(&config, func(name string) string { return tc.env[name] })
+			if err != nil {
+				if !tc.expectErr {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if tc.expectErr {
+				t.Fatalf("expected an error, but got none")
+			}
+			if !reflect.DeepEqual(config, tc.expected) {
+				t.Errorf("expected config %+v, but got %+v", tc.expected, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"strings"
+)
+
+// readOnlyVerbs are the final components of the names of API methods that don't change anything
+// in the workspace, like the "list" in conversations.list.
+var readOnlyVerbs = map[string]bool{
+	"get":           true,
+	"getPermalink":  true,
+	"history":       true,
+	"info":          true,
+	"list":          true,
+	"lookupByEmail": true,
+	"members":       true,
+	"replies":       true,
+	"test":          true,
+}
+
+// readOnlyMethods are API methods that don't change the workspace, despite their names.
+var readOnlyMethods = map[string]bool{
+	// Connecting over Socket Mode and rotating our own token have to work in dry-run mode too.
+	"apps.connections.open": true,
+	"oauth.v2.access":       true,
+	"oauth.v2.exchange":     true,
+	// Getting an upload URL only reserves it; the upload itself, and sharing the file with
+	// files.completeUploadExternal, are skipped.
+	"files.getUploadURLExternal": true,
+}
+
+// isWrite returns whether calling api could change something in Slack. Complete URLs, such as
+// webhooks and response URLs, are only ever used to post messages, so they are writes.
+func isWrite(api string) bool {
+	method := methodLabel(api)
+	if method == "url" {
+		return true
+	}
+	if readOnlyMethods[method] || strings.HasPrefix(method, "search.") {
+		return false
+	}
+	return !readOnlyVerbs[method[strings.LastIndex(method, ".")+1:]]
+}
+
+// skipDryRun returns whether a call to api should be skipped because the Client is in dry-run
+// mode, logging the call that would have been made if so. payload must not contain the token.
+func (c *Client) skipDryRun(ctx context.Context, api string, payload string) bool {
+	if !c.CurrentConfig().DryRun || !isWrite(api) {
+		return false
+	}
+	c.logger(ctx).Info("Dry run: not calling Slack", "method", methodLabel(api), "payload", payload)
+	return true
+}
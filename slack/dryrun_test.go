@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	tests := []struct {
+		name         string
+		api          string
+		old          bool
+		expectCalled bool
+	}{
+		{name: "chat.postMessage is skipped", api: "chat.postMessage"},
+		{name: "chat.delete is skipped", api: "chat.delete", old: true},
+		{name: "conversations.join is skipped", api: "conversations.join"},
+		{name: "webhooks are skipped", api: "https://hooks.slack.com/services/T1/B1/secret"},
+		{name: "conversations.list is called", api: "conversations.list", expectCalled: true},
+		{name: "users.info is called", api: "users.info", old: true, expectCalled: true},
+		{name: "search.messages is called", api: "search.messages", old: true, expectCalled: true},
+		{name: "apps.connections.open is called", api: "apps.connections.open", expectCalled: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			})
+			defer done()
+			c.Config.DryRun = true
+
+			var err error
+			if tc.old {
+				err = c.CallOldMethodContext(context.Background(), tc.api, map[string]string{"channel": "C1"}, nil)
+			} else {
+				err = c.CallMethodContext(context.Background(), tc.api, map[string]string{"channel": "C1"}, nil)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if called != tc.expectCalled {
+				t.Errorf("expected called to be %v, but got %v", tc.expectCalled, called)
+			}
+		})
+	}
+}
+
+func TestIsWrite(t *testing.T) {
+	for api, expected := range map[string]bool{
+		"chat.postMessage":                       true,
+		"chat.scheduleMessage":                   true,
+		"usergroups.users.update":                true,
+		"admin.users.remove":                     true,
+		"https://hooks.slack.com/actions/T1/...": true,
+		"chat.getPermalink":                      false,
+		"usergroups.users.list":                  false,
+		"users.lookupByEmail":                    false,
+		"https://slack.com/api/auth.test":        false,
+	} {
+		if actual := isWrite(api); actual != expected {
+			t.Errorf("expected isWrite(%q) to be %v, but got %v", api, expected, actual)
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package slack
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -29,11 +30,35 @@ func (e ErrRateLimit) Error() string {
 	return fmt.Sprintf("slack has rate limited us for the next %s", e.Wait)
 }
 
-type ErrSlack struct {
-	Type     string
+// APIError is returned when Slack responds to a call with "ok": false.
+type APIError struct {
+	// Code is Slack's error code, such as "channel_not_found" or "missing_scope".
+	Code string
+	// Warnings and Messages come from the response's metadata; see ResponseMetadata. Messages
+	// often explain what was wrong with the request.
 	Warnings []string
+	Messages []string
+	// Needed and Provided are the comma-separated OAuth scopes that the method requires and that
+	// our token has. Slack only sets them for missing_scope errors.
+	Needed   string
+	Provided string
 }
 
-func (e ErrSlack) Error() string {
-	return fmt.Sprintf("slack call failed: %s (%v)", e.Type, e.Warnings)
+func (e APIError) Error() string {
+	if e.Needed != "" {
+		return fmt.Sprintf("slack call failed: %s (needed %q, provided %q)", e.Code, e.Needed, e.Provided)
+	}
+	if len(e.Messages) > 0 {
+		return fmt.Sprintf("slack call failed: %s (%v)", e.Code, e.Messages)
+	}
+	return fmt.Sprintf("slack call failed: %s", e.Code)
+}
+
+// ErrorCode returns the Slack error code of err, or an empty string if err is not an APIError.
+func ErrorCode(err error) string {
+	var e APIError
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
 }
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// App Home tabs, as reported in AppHomeOpened.Tab.
+const (
+	TabHome     = "home"
+	TabMessages = "messages"
+)
+
+// AppHomeOpened is an app_home_opened event, sent when a user opens one of the app's App Home
+// tabs.
+// See https://api.slack.com/events/app_home_opened
+type AppHomeOpened struct {
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+	Tab     string `json:"tab"`
+	EventTS string `json:"event_ts"`
+	// View is the Home tab currently published for the user, if there is one. Passing its Hash
+	// to views.publish avoids overwriting a newer tab published in the meantime.
+	View *struct {
+		ID   string `json:"id"`
+		Hash string `json:"hash"`
+	} `json:"view,omitempty"`
+}
+
+// AppHomeOpenedFunc handles an app_home_opened event.
+type AppHomeOpenedFunc func(ctx context.Context, client *slack.Client, event AppHomeOpened) error
+
+// HandleAppHomeOpened registers fn to handle app_home_opened events for the Home tab, which is
+// typically done by publishing a view with views.publish. Events for the Messages tab are
+// ignored. Like HandleFunc, it must be called before the Dispatcher starts receiving events.
+func (d *Dispatcher) HandleAppHomeOpened(fn AppHomeOpenedFunc) {
+	d.HandleFunc("app_home_opened", func(ctx context.Context, client *slack.Client, body []byte) error {
+		payload := struct {
+			Event AppHomeOpened `json:"event"`
+		}{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal app_home_opened event: %v", err)
+		}
+		if payload.Event.Tab != TabHome {
+			return nil
+		}
+		return fn(ctx, client, payload.Event)
+	})
+}
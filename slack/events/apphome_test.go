@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func TestHandleAppHomeOpened(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectCalled bool
+		expectedHash string
+	}{
+		{
+			name:         "first visit to the home tab",
+			body:         `{"type": "event_callback", "team_id": "T1", "event": {"type": "app_home_opened", "user": "U1", "channel": "D1", "tab": "home"}}`,
+			expectCalled: true,
+		},
+		{
+			name:         "home tab with a published view",
+			body:         `{"type": "event_callback", "team_id": "T1", "event": {"type": "app_home_opened", "user": "U1", "channel": "D1", "tab": "home", "view": {"id": "V1", "hash": "123.abc"}}}`,
+			expectCalled: true,
+			expectedHash: "123.abc",
+		},
+		{
+			name: "messages tab is ignored",
+			body: `{"type": "event_callback", "team_id": "T1", "event": {"type": "app_home_opened", "user": "U1", "channel": "D1", "tab": "messages"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			d := NewDispatcher(slack.NewClientSet(slack.New(slack.Config{})))
+			d.HandleAppHomeOpened(func(ctx context.Context, client *slack.Client, event AppHomeOpened) error {
+				called = true
+				if event.User != "U1" {
+					t.Errorf("expected user U1, but got %q", event.User)
+				}
+				hash := ""
+				if event.View != nil {
+					hash = event.View.Hash
+				}
+				if hash != tc.expectedHash {
+					t.Errorf("expected hash %q, but got %q", tc.expectedHash, hash)
+				}
+				return nil
+			})
+			if err := d.Dispatch(context.Background(), []byte(tc.body)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if called != tc.expectCalled {
+				t.Errorf("expected called to be %v, but got %v", tc.expectCalled, called)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// DefaultDedupTTL is how long events are remembered by default. Slack retries an event up to
+// three times over about an hour and a half, so this catches every retry.
+const DefaultDedupTTL = 2 * time.Hour
+
+const defaultDedupSize = 10000
+
+// Deduplicator remembers which events have already been received, so that events Slack retries
+// are only handled once.
+type Deduplicator interface {
+	// Seen records that the event with the given ID was received, and returns true if it had
+	// already been received before.
+	Seen(ctx context.Context, eventID string) (bool, error)
+	// Forget removes a record made by Seen, for an event that was not handled after all.
+	Forget(ctx context.Context, eventID string) error
+}
+
+// MemoryDeduplicator is a Deduplicator that remembers the most recent events in memory. It is
+// only suitable when a single replica receives all events.
+type MemoryDeduplicator struct {
+	size    int
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type dedupEntry struct {
+	id   string
+	seen time.Time
+}
+
+// NewMemoryDeduplicator returns a MemoryDeduplicator that remembers up to size events, for at
+// most ttl each.
+func NewMemoryDeduplicator(size int, ttl time.Duration) *MemoryDeduplicator {
+	return &MemoryDeduplicator{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// Seen implements Deduplicator.
+func (m *MemoryDeduplicator) Seen(ctx context.Context, eventID string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	if e, ok := m.entries[eventID]; ok {
+		entry := e.Value.(*dedupEntry)
+		if now.Sub(entry.seen) < m.ttl {
+			return true, nil
+		}
+		entry.seen = now
+		m.order.MoveToFront(e)
+		return false, nil
+	}
+	m.entries[eventID] = m.order.PushFront(&dedupEntry{id: eventID, seen: now})
+	for m.size > 0 && m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*dedupEntry).id)
+	}
+	return false, nil
+}
+
+// Forget implements Deduplicator.
+func (m *MemoryDeduplicator) Forget(ctx context.Context, eventID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if e, ok := m.entries[eventID]; ok {
+		m.order.Remove(e)
+		delete(m.entries, eventID)
+	}
+	return nil
+}
+
+// RedisDeduplicator is a Deduplicator that stores event IDs in Redis, so that it works across
+// several replicas.
+type RedisDeduplicator struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisDeduplicator returns a RedisDeduplicator that stores event IDs in client under keys
+// starting with prefix, and forgets them after ttl.
+func NewRedisDeduplicator(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisDeduplicator {
+	return &RedisDeduplicator{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Seen implements Deduplicator.
+func (r *RedisDeduplicator) Seen(ctx context.Context, eventID string) (bool, error) {
+	set, err := r.client.SetNX(ctx, r.prefix+eventID, 1, r.ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	return !set, nil
+}
+
+// Forget implements Deduplicator.
+func (r *RedisDeduplicator) Forget(ctx context.Context, eventID string) error {
+	return r.client.Del(ctx, r.prefix+eventID).Err()
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryDeduplicator(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryDeduplicator(2, time.Hour)
+	steps := []struct {
+		id       string
+		expected bool
+	}{
+		{id: "Ev1", expected: false},
+		{id: "Ev1", expected: true},
+		{id: "Ev2", expected: false},
+		{id: "Ev3", expected: false},
+		// Ev1 was the least recently seen, so it has been evicted.
+		{id: "Ev1", expected: false},
+		{id: "Ev3", expected: true},
+	}
+	for i, s := range steps {
+		seen, err := m.Seen(ctx, s.id)
+		if err != nil {
+			t.Fatalf("step %d: unexpected error: %v", i, err)
+		}
+		if seen != s.expected {
+			t.Errorf("step %d: expected Seen(%q) to be %v, but got %v", i, s.id, s.expected, seen)
+		}
+	}
+
+	m = NewMemoryDeduplicator(10, time.Nanosecond)
+	_, _ = m.Seen(ctx, "Ev1")
+	time.Sleep(time.Millisecond)
+	if seen, _ := m.Seen(ctx, "Ev1"); seen {
+		t.Errorf("expected an expired event not to be seen")
+	}
+}
@@ -0,0 +1,364 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events receives Slack Events API callbacks, over HTTP or Socket Mode, and routes each
+// event to the function registered for its type.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// HandlerFunc handles a single event. body is the complete event_callback payload, including the
+// envelope, and client is the Client for the workspace the event came from.
+type HandlerFunc func(ctx context.Context, client *slack.Client, body []byte) error
+
+// Dispatcher verifies incoming Events API requests and calls the HandlerFunc registered for each
+// event's type. Events without a HandlerFunc are acknowledged and ignored.
+type Dispatcher struct {
+	// Deduplicator is used to skip events that Slack sends more than once. NewDispatcher sets it
+	// to a MemoryDeduplicator; set it to nil to handle every delivery.
+	Deduplicator Deduplicator
+
+	clients  *slack.ClientSet
+	handlers map[string]HandlerFunc
+	queue    chan job
+	workers  sync.WaitGroup
+
+	// lock is held for reading while sending to queue, and for writing to close it.
+	lock      sync.RWMutex
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// job is an event waiting to be handled.
+type job struct {
+	ctx       context.Context
+	client    *slack.Client
+	fn        HandlerFunc
+	eventType string
+	body      []byte
+}
+
+// errShuttingDown is returned for events received after Shutdown is called.
+var errShuttingDown = errors.New("dispatcher is shutting down")
+
+// enqueueTimeout is how long a webhook delivery waits for space in a full queue before it is
+// rejected. Slack expects a response within three seconds.
+var enqueueTimeout = time.Second
+
+// NewDispatcher returns a Dispatcher that verifies events from each workspace using the
+// corresponding Client in clients.
+func NewDispatcher(clients *slack.ClientSet) *Dispatcher {
+	return &Dispatcher{
+		Deduplicator: NewMemoryDeduplicator(defaultDedupSize, DefaultDedupTTL),
+		clients:      clients,
+		handlers:     map[string]HandlerFunc{},
+	}
+}
+
+// HandleFunc registers fn to handle events of the given type, such as "channel_created". It must
+// be called before the Dispatcher starts receiving events.
+func (d *Dispatcher) HandleFunc(eventType string, fn HandlerFunc) {
+	d.handlers[eventType] = fn
+}
+
+// Async makes the Dispatcher handle events on a pool of workers goroutines, rather than in the
+// goroutine that received each event, so that slow handlers never delay acknowledging events.
+// Up to queueSize events wait for a free worker. When the queue is full, webhook deliveries are
+// rejected with a 503 so that Slack sends them again later, and Socket Mode events wait for space.
+// Async must be called at most once, before the Dispatcher starts receiving events.
+func (d *Dispatcher) Async(workers, queueSize int) {
+	d.queue = make(chan job, queueSize)
+	d.closing = make(chan struct{})
+	eventQueueCapacity.Set(float64(queueSize))
+	for i := 0; i < workers; i++ {
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+			for j := range d.queue {
+				eventQueueDepth.Dec()
+				d.handle(j)
+			}
+		}()
+	}
+}
+
+// Shutdown stops an asynchronous Dispatcher from accepting events, and waits until every queued
+// event has been handled or ctx is done. Events received after Shutdown is called are rejected,
+// so that Slack sends them again, hopefully to another replica.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d.queue == nil {
+		return nil
+	}
+	d.closeOnce.Do(func() {
+		// Wake up anything waiting for space in the queue, then wait for it to give up.
+		close(d.closing)
+		d.lock.Lock()
+		close(d.queue)
+		d.lock.Unlock()
+	})
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting for %d queued events: %v", len(d.queue), ctx.Err())
+	}
+}
+
+// envelope is the part of every Events API payload that the Dispatcher needs to route it.
+type envelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+	TeamID    string `json:"team_id"`
+	Event     struct {
+		Type    string          `json:"type"`
+		Channel json.RawMessage `json:"channel"`
+	} `json:"event"`
+}
+
+// logContext returns a context whose logger tags everything with the event's details.
+func (e envelope) logContext(ctx context.Context) context.Context {
+	args := []interface{}{"team_id", e.TeamID, "event_type", e.Event.Type, "event_id", e.EventID}
+	if channel := e.channel(); channel != "" {
+		args = append(args, "channel", channel)
+	}
+	return logging.With(ctx, args...)
+}
+
+// channel returns the ID of the channel the event is about, if any. Most events have the ID as a
+// string, but some, like channel_created, have the whole channel object.
+func (e envelope) channel() string {
+	var id string
+	if err := json.Unmarshal(e.Event.Channel, &id); err == nil {
+		return id
+	}
+	var channel struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(e.Event.Channel, &channel); err == nil {
+		return channel.ID
+	}
+	return ""
+}
+
+// ServeHTTP handles Events API requests. Events are acknowledged before they are handled, so that
+// slow handlers don't cause Slack to time out and send the event again.
+func (d *Dispatcher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError(rw, "Failed to read incoming request body: %v", err)
+		return
+	}
+	defer r.Body.Close()
+
+	client, err := d.clients.ForPayload(body)
+	if err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+	if err := client.VerifySignature(body, r.Header); err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+
+	e := envelope{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		logError(rw, "Failed to unmarshal payload: %v", err)
+		return
+	}
+
+	switch e.Type {
+	case "url_verification":
+		// This is used when the Events API URL is first configured.
+		response, err := json.Marshal(map[string]string{"challenge": e.Challenge})
+		if err != nil {
+			logError(rw, "Failed to marshal challenge payload: %v", err)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(response)
+	case "event_callback":
+		if retry := r.Header.Get("X-Slack-Retry-Num"); retry != "" {
+			reason := r.Header.Get("X-Slack-Retry-Reason")
+			eventsRetried.WithLabelValues(reason).Inc()
+			logging.FromContext(e.logContext(r.Context())).Info("Slack retried event", "attempt", retry, "reason", reason)
+		}
+		j, ok := d.newJob(r.Context(), client, e, body)
+		if !ok {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		if d.queue == nil {
+			rw.WriteHeader(http.StatusOK)
+			if f, ok := rw.(http.Flusher); ok {
+				f.Flush()
+			}
+			d.handle(j)
+			return
+		}
+		// The request's context ends as soon as we respond, but handling the event shouldn't.
+		j.ctx = logging.NewContext(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(r.Context())), logging.FromContext(j.ctx))
+		timeout, cancel := context.WithTimeout(r.Context(), enqueueTimeout)
+		defer cancel()
+		if err := d.enqueue(timeout, j); err != nil {
+			d.forget(e.EventID)
+			eventsRejected.Inc()
+			logging.FromContext(j.ctx).Warn("Rejecting event", "error", err)
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	default:
+		slog.Info("Ignoring unknown payload type", "type", e.Type)
+		rw.WriteHeader(http.StatusOK)
+	}
+}
+
+// Dispatch handles an event_callback payload that has already been verified.
+func (d *Dispatcher) Dispatch(ctx context.Context, body []byte) error {
+	client, err := d.clients.ForPayload(body)
+	if err != nil {
+		return err
+	}
+	e := envelope{}
+	if err := json.Unmarshal(body, &e); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v", err)
+	}
+	if e.Type != "event_callback" {
+		return fmt.Errorf("unexpected payload type %q", e.Type)
+	}
+	return d.dispatch(ctx, client, e, body)
+}
+
+// HandleSocketModeEnvelope is a socketmode.Handler that dispatches events received over Socket
+// Mode, which carry the same payload as the Events API webhook.
+func (d *Dispatcher) HandleSocketModeEnvelope(ctx context.Context, envelopeType string, payload []byte) ([]byte, error) {
+	if envelopeType != socketmode.TypeEventsAPI {
+		return nil, nil
+	}
+	return nil, d.Dispatch(ctx, payload)
+}
+
+// dispatch handles an event, or queues it to be handled if the Dispatcher is asynchronous.
+func (d *Dispatcher) dispatch(ctx context.Context, client *slack.Client, e envelope, body []byte) error {
+	j, ok := d.newJob(ctx, client, e, body)
+	if !ok {
+		return nil
+	}
+	if d.queue == nil {
+		return d.handle(j)
+	}
+	if err := d.enqueue(ctx, j); err != nil {
+		d.forget(e.EventID)
+		return err
+	}
+	return nil
+}
+
+// newJob returns the job for an event, or false if the event should be ignored because nothing
+// handles it or it has already been received.
+func (d *Dispatcher) newJob(ctx context.Context, client *slack.Client, e envelope, body []byte) (job, bool) {
+	fn, ok := d.handlers[e.Event.Type]
+	if !ok {
+		return job{}, false
+	}
+	ctx = e.logContext(ctx)
+	if d.Deduplicator != nil && e.EventID != "" {
+		seen, err := d.Deduplicator.Seen(ctx, e.EventID)
+		if err != nil {
+			// Handling an event twice is better than not handling it at all.
+			logging.FromContext(ctx).Error("Failed to check whether event is a duplicate", "error", err)
+		} else if seen {
+			eventsDuplicate.Inc()
+			logging.FromContext(ctx).Info("Ignoring duplicate event")
+			return job{}, false
+		}
+	}
+	return job{ctx: ctx, client: client, fn: fn, eventType: e.Event.Type, body: body}, true
+}
+
+// enqueue adds j to the queue, waiting for space until ctx is done.
+func (d *Dispatcher) enqueue(ctx context.Context, j job) error {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	select {
+	case <-d.closing:
+		return errShuttingDown
+	default:
+	}
+	select {
+	case d.queue <- j:
+		eventQueueDepth.Inc()
+		return nil
+	case <-d.closing:
+		return errShuttingDown
+	case <-ctx.Done():
+		return fmt.Errorf("event queue is full: %v", ctx.Err())
+	}
+}
+
+// forget tells the Deduplicator that an event we couldn't accept hasn't been seen, so that it is
+// handled when Slack sends it again.
+func (d *Dispatcher) forget(eventID string) {
+	if d.Deduplicator == nil || eventID == "" {
+		return
+	}
+	if err := d.Deduplicator.Forget(context.Background(), eventID); err != nil {
+		slog.Error("Failed to forget event", "event_id", eventID, "error", err)
+	}
+}
+
+// handle calls the HandlerFunc for j.
+func (d *Dispatcher) handle(j job) error {
+	start := time.Now()
+	err := j.fn(j.ctx, j.client, j.body)
+	eventHandleDuration.WithLabelValues(j.eventType).Observe(time.Since(start).Seconds())
+	if err != nil {
+		eventsHandled.WithLabelValues(j.eventType, statusError).Inc()
+		logging.FromContext(j.ctx).Error("Handling event failed", "error", err)
+		err = fmt.Errorf("%s: %v", j.eventType, err)
+		return err
+	}
+	eventsHandled.WithLabelValues(j.eventType, statusOK).Inc()
+	return nil
+}
+
+func logError(rw http.ResponseWriter, format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	slog.Error(s)
+	http.Error(rw, s, http.StatusInternalServerError)
+}
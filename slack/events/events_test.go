@@ -0,0 +1,277 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+const testSecret = "secret"
+
+func newRequest(body string, secret string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest("POST", "/webhook", strings.NewReader(body))
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	return req
+}
+
+func TestDispatcher(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		secret         string
+		expectedStatus int
+		expectedBody   string
+		expectedEvent  string
+	}{
+		{
+			name:           "url verification",
+			body:           `{"type": "url_verification", "challenge": "honk"}`,
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"challenge":"honk"}`,
+		},
+		{
+			name:           "registered event",
+			body:           `{"type": "event_callback", "team_id": "T1", "event": {"type": "channel_created"}}`,
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+			expectedEvent:  "channel_created",
+		},
+		{
+			name:           "unregistered event is ignored",
+			body:           `{"type": "event_callback", "team_id": "T1", "event": {"type": "emoji_changed"}}`,
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "bad signature",
+			body:           `{"type": "event_callback", "team_id": "T1", "event": {"type": "channel_created"}}`,
+			secret:         "wrong",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "invalid JSON",
+			body:           `{"type": `,
+			secret:         testSecret,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled string
+			d := NewDispatcher(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+			d.HandleFunc("channel_created", func(ctx context.Context, client *slack.Client, body []byte) error {
+				handled = "channel_created"
+				return nil
+			})
+
+			rr := httptest.NewRecorder()
+			d.ServeHTTP(rr, newRequest(tc.body, tc.secret))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, but got %d", tc.expectedStatus, rr.Code)
+			}
+			if tc.expectedBody != "" && rr.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, but got %q", tc.expectedBody, rr.Body.String())
+			}
+			if handled != tc.expectedEvent {
+				t.Errorf("expected event %q to be handled, but got %q", tc.expectedEvent, handled)
+			}
+		})
+	}
+}
+
+func TestDispatcherDeduplicates(t *testing.T) {
+	handled := 0
+	d := NewDispatcher(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+	d.HandleFunc("message", func(ctx context.Context, client *slack.Client, body []byte) error {
+		handled++
+		return nil
+	})
+
+	retries := testutil.ToFloat64(eventsRetried.WithLabelValues("http_timeout"))
+	duplicates := testutil.ToFloat64(eventsDuplicate)
+	body := `{"type": "event_callback", "team_id": "T1", "event_id": "Ev1", "event": {"type": "message"}}`
+	for i := 0; i < 3; i++ {
+		req := newRequest(body, testSecret)
+		if i > 0 {
+			req.Header.Set("X-Slack-Retry-Num", strconv.Itoa(i))
+			req.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+		}
+		rr := httptest.NewRecorder()
+		d.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected delivery %d to be acknowledged, but got status %d", i, rr.Code)
+		}
+	}
+	if handled != 1 {
+		t.Errorf("expected the event to be handled once, but it was handled %d times", handled)
+	}
+	if actual := testutil.ToFloat64(eventsRetried.WithLabelValues("http_timeout")) - retries; actual != 2 {
+		t.Errorf("expected 2 retries to be counted, but got %v", actual)
+	}
+	if actual := testutil.ToFloat64(eventsDuplicate) - duplicates; actual != 2 {
+		t.Errorf("expected 2 duplicates to be counted, but got %v", actual)
+	}
+
+	d.ServeHTTP(httptest.NewRecorder(), newRequest(`{"type": "event_callback", "team_id": "T1", "event_id": "Ev2", "event": {"type": "message"}}`, testSecret))
+	if handled != 2 {
+		t.Errorf("expected a different event to be handled, but %d events were handled", handled)
+	}
+}
+
+func TestDispatcherAsync(t *testing.T) {
+	enqueueTimeout = 10 * time.Millisecond
+	defer func() { enqueueTimeout = time.Second }()
+
+	unblock := make(chan struct{})
+	handled := make(chan string, 10)
+	d := NewDispatcher(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+	d.HandleFunc("message", func(ctx context.Context, client *slack.Client, body []byte) error {
+		<-unblock
+		handled <- string(body)
+		return nil
+	})
+	d.Async(1, 1)
+
+	deliver := func(id string) int {
+		rr := httptest.NewRecorder()
+		d.ServeHTTP(rr, newRequest(`{"type": "event_callback", "team_id": "T1", "event_id": "`+id+`", "event": {"type": "message"}}`, testSecret))
+		return rr.Code
+	}
+
+	// The first event occupies the only worker, and the second fills the queue.
+	if code := deliver("Ev1"); code != http.StatusOK {
+		t.Errorf("expected the first event to be accepted, but got status %d", code)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if code := deliver("Ev2"); code != http.StatusOK {
+		t.Errorf("expected the second event to be queued, but got status %d", code)
+	}
+	if code := deliver("Ev3"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected the third event to be rejected, but got status %d", code)
+	}
+
+	close(unblock)
+	for i := 0; i < 2; i++ {
+		<-handled
+	}
+	// Slack sends the rejected event again, and this time it should be handled.
+	if code := deliver("Ev3"); code != http.StatusOK {
+		t.Errorf("expected the retried event to be accepted, but got status %d", code)
+	}
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Errorf("expected the retried event to be handled")
+	}
+}
+
+func TestDispatcherShutdown(t *testing.T) {
+	unblock := make(chan struct{})
+	var handled int32
+	d := NewDispatcher(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+	d.HandleFunc("message", func(ctx context.Context, client *slack.Client, body []byte) error {
+		<-unblock
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	d.Async(1, 10)
+
+	deliver := func(id string) int {
+		rr := httptest.NewRecorder()
+		d.ServeHTTP(rr, newRequest(`{"type": "event_callback", "team_id": "T1", "event_id": "`+id+`", "event": {"type": "message"}}`, testSecret))
+		return rr.Code
+	}
+	for _, id := range []string{"Ev1", "Ev2", "Ev3"} {
+		if code := deliver(id); code != http.StatusOK {
+			t.Fatalf("expected event %s to be accepted, but got status %d", id, code)
+		}
+	}
+
+	// Shutdown gives up if the queued events take too long.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Shutdown(ctx); err == nil {
+		t.Errorf("expected Shutdown to time out while events are blocked")
+	}
+	if code := deliver("Ev4"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected events received during shutdown to be rejected, but got status %d", code)
+	}
+
+	close(unblock)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&handled); n != 3 {
+		t.Errorf("expected all 3 queued events to be handled before Shutdown returned, but %d were", n)
+	}
+}
+
+func TestEnvelopeChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected string
+	}{
+		{
+			name:     "channel ID",
+			payload:  `{"type":"event_callback","event":{"type":"message","channel":"C1"}}`,
+			expected: "C1",
+		},
+		{
+			name:     "channel object",
+			payload:  `{"type":"event_callback","event":{"type":"channel_created","channel":{"id":"C2","name":"general"}}}`,
+			expected: "C2",
+		},
+		{
+			name:    "no channel",
+			payload: `{"type":"event_callback","event":{"type":"team_join"}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			e := envelope{}
+			if err := json.Unmarshal([]byte(tc.payload), &e); err != nil {
+				t.Fatalf("Failed to unmarshal payload: %v", err)
+			}
+			if channel := e.channel(); channel != tc.expected {
+				t.Errorf("Expected channel %q, but got %q", tc.expected, channel)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	eventsHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "events_handled_total",
+		Help:      "Number of Slack events handled, by event type and outcome.",
+	}, []string{"type", "status"})
+	eventHandleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "slack",
+		Name:      "event_handle_duration_seconds",
+		Help:      "Time taken to handle Slack events, by event type.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"type"})
+	eventQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "slack",
+		Name:      "event_queue_depth",
+		Help:      "Number of Slack events waiting for a worker.",
+	})
+	eventQueueCapacity = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "slack",
+		Name:      "event_queue_capacity",
+		Help:      "Maximum number of Slack events that can wait for a worker.",
+	})
+	eventsRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "events_rejected_total",
+		Help:      "Number of Slack event deliveries rejected because the queue was full.",
+	})
+	eventsRetried = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "events_retried_total",
+		Help:      "Number of Slack event deliveries that were retries, by the reason Slack gave for retrying.",
+	}, []string{"reason"})
+	eventsDuplicate = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "events_duplicate_total",
+		Help:      "Number of Slack event deliveries ignored because the event had already been received.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(eventsHandled, eventHandleDuration, eventQueueDepth, eventQueueCapacity, eventsRejected, eventsRetried, eventsDuplicate)
+}
+
+// Values of the status label on slack_events_handled_total.
+const (
+	statusOK    = "ok"
+	statusError = "error"
+)
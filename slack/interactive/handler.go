@@ -0,0 +1,188 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interactive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// Response actions for view_submission payloads.
+// See https://api.slack.com/surfaces/modals/using#handling_submissions
+const (
+	ResponseErrors = "errors"
+	ResponseUpdate = "update"
+	ResponsePush   = "push"
+	ResponseClear  = "clear"
+)
+
+// Response is the immediate response to a view_submission payload. Other payload types ignore it.
+type Response struct {
+	ResponseAction string `json:"response_action"`
+	// Errors maps block IDs to the error to show next to them, for ResponseErrors.
+	Errors map[string]string `json:"errors,omitempty"`
+	// View is the view to show, for ResponseUpdate and ResponsePush.
+	View *blocks.View `json:"view,omitempty"`
+}
+
+// HandlerFunc handles a single payload. The returned Response, if any, is sent as the immediate
+// response to the payload; return nil to just acknowledge it.
+type HandlerFunc func(ctx context.Context, p *Payload) (*Response, error)
+
+// Handler verifies incoming interactivity payloads and calls the HandlerFunc registered for each.
+type Handler struct {
+	clients  *slack.ClientSet
+	handlers map[route]HandlerFunc
+}
+
+type route struct {
+	payloadType string
+	id          string
+}
+
+// NewHandler returns a Handler that verifies payloads from each workspace using the
+// corresponding Client in clients.
+func NewHandler(clients *slack.ClientSet) *Handler {
+	return &Handler{clients: clients, handlers: map[route]HandlerFunc{}}
+}
+
+// HandleFunc registers fn to handle payloads of the given type with the given ID. The ID is the
+// action ID for block_actions payloads, the view's callback ID for view_submission and
+// view_closed payloads, and the callback ID for everything else. An empty ID matches any payload
+// of that type that has no more specific HandlerFunc. HandleFunc must be called before the
+// Handler starts receiving payloads.
+func (h *Handler) HandleFunc(payloadType, id string, fn HandlerFunc) {
+	h.handlers[route{payloadType, id}] = fn
+}
+
+// ServeHTTP handles interactivity requests.
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError(rw, "Failed to read incoming request body: %v", err)
+		return
+	}
+	defer r.Body.Close()
+
+	client, err := h.clients.ForPayload(body)
+	if err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+	if err := client.VerifySignature(body, r.Header); err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+
+	response, err := h.handle(r.Context(), client, body)
+	if err != nil {
+		logError(rw, "%v", err)
+		return
+	}
+	if response == nil {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(response)
+}
+
+// HandleSocketModeEnvelope is a socketmode.Handler that handles payloads received over Socket
+// Mode, sending the immediate response in the acknowledgement.
+func (h *Handler) HandleSocketModeEnvelope(ctx context.Context, envelopeType string, payload []byte) ([]byte, error) {
+	if envelopeType != socketmode.TypeInteractive {
+		return nil, nil
+	}
+	client, err := h.clients.ForPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	return h.handle(ctx, client, payload)
+}
+
+// handle parses a verified payload and runs its HandlerFuncs, returning the marshalled immediate
+// response, if there is one.
+func (h *Handler) handle(ctx context.Context, client *slack.Client, body []byte) ([]byte, error) {
+	p, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	p.client = client
+	ctx = logging.With(ctx, "team_id", p.Team.ID, "user_id", p.User.ID, "channel", p.Channel.ID, "type", p.Type)
+
+	var response *Response
+	for _, id := range routeIDs(p) {
+		fn := h.handlers[route{p.Type, id}]
+		if fn == nil {
+			fn = h.handlers[route{p.Type, ""}]
+		}
+		if fn == nil {
+			logging.FromContext(ctx).Info("Ignoring payload with no handler", "id", id)
+			continue
+		}
+		r, err := fn(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to handle %s payload %q: %v", p.Type, id, err)
+		}
+		if r != nil {
+			response = r
+		}
+	}
+	if response == nil {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %v", err)
+	}
+	return marshalled, nil
+}
+
+// routeIDs returns the IDs that p should be routed by: one for each action in a block_actions
+// payload, or a single callback ID for any other payload.
+func routeIDs(p *Payload) []string {
+	switch p.Type {
+	case TypeBlockActions:
+		ids := make([]string, 0, len(p.Actions))
+		for _, a := range p.Actions {
+			ids = append(ids, a.ActionID)
+		}
+		return ids
+	case TypeViewSubmission, TypeViewClosed:
+		if p.View == nil {
+			return []string{""}
+		}
+		return []string{p.View.CallbackID}
+	default:
+		return []string{p.CallbackID}
+	}
+}
+
+func logError(rw http.ResponseWriter, format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	slog.Error(s)
+	http.Error(rw, s, http.StatusInternalServerError)
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interactive
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+const testSecret = "secret"
+
+func newRequest(payload string, secret string) *http.Request {
+	body := url.Values{"payload": {payload}}.Encode()
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest("POST", "/interactive", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	return req
+}
+
+func TestParse(t *testing.T) {
+	blockActions := `{
+		"type": "block_actions",
+		"team": {"id": "T1", "domain": "kubernetes"},
+		"user": {"id": "U1", "name": "honk"},
+		"actions": [{"type": "button", "action_id": "resolve", "block_id": "b1", "value": "report-1"}]
+	}`
+	p, err := Parse([]byte(url.Values{"payload": {blockActions}}.Encode()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Type != TypeBlockActions || p.Team.ID != "T1" || p.Team.Domain != "kubernetes" || p.User.ID != "U1" {
+		t.Errorf("unexpected payload %+v", p)
+	}
+	a := p.Action("resolve")
+	if a == nil || a.Value.Value != "report-1" || a.BlockID != "b1" {
+		t.Errorf("expected the resolve action with value report-1, but got %+v", a)
+	}
+	if p.Action("other") != nil {
+		t.Errorf("expected no action with ID other")
+	}
+
+	viewSubmission := `{
+		"type": "view_submission",
+		"team": {"id": "T1"},
+		"view": {
+			"id": "V1",
+			"callback_id": "post_message",
+			"private_metadata": "meta",
+			"state": {"values": {
+				"channels": {"channel-input": {"type": "multi_channels_select", "selected_channels": ["C1", "C2"]}},
+				"reason": {"reason-input": {"type": "static_select", "selected_option": {"text": {"type": "plain_text", "text": "Spam"}, "value": "spam"}}}
+			}}
+		}
+	}`
+	p, err = Parse([]byte(viewSubmission))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.View == nil || p.View.CallbackID != "post_message" || p.View.PrivateMetadata != "meta" {
+		t.Fatalf("unexpected view %+v", p.View)
+	}
+	channels, ok := p.View.Value("channels", "channel-input")
+	if !ok || !reflect.DeepEqual(channels.SelectedChannels, []string{"C1", "C2"}) {
+		t.Errorf("expected channels C1 and C2, but got %+v", channels)
+	}
+	reason, ok := p.View.Value("reason", "reason-input")
+	if !ok || reason.SelectedOption == nil || reason.SelectedOption.Value != "spam" {
+		t.Errorf("expected reason spam, but got %+v", reason)
+	}
+	if _, ok := p.View.Value("reason", "missing"); ok {
+		t.Errorf("expected no value for a missing element")
+	}
+
+	for _, body := range []string{"", "payload=", `{"team": {"id": "T1"}}`, `{"type": `} {
+		if _, err := Parse([]byte(body)); err == nil {
+			t.Errorf("expected an error parsing %q", body)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name            string
+		payload         string
+		secret          string
+		expectedStatus  int
+		expectedBody    string
+		expectedHandled []string
+	}{
+		{
+			name:            "block action",
+			payload:         `{"type": "block_actions", "team": {"id": "T1"}, "actions": [{"action_id": "resolve"}]}`,
+			secret:          testSecret,
+			expectedStatus:  http.StatusOK,
+			expectedHandled: []string{"resolve"},
+		},
+		{
+			name:            "block action without a specific handler",
+			payload:         `{"type": "block_actions", "team": {"id": "T1"}, "actions": [{"action_id": "resolve"}, {"action_id": "other"}]}`,
+			secret:          testSecret,
+			expectedStatus:  http.StatusOK,
+			expectedHandled: []string{"resolve", "any action"},
+		},
+		{
+			name:            "view submission with errors",
+			payload:         `{"type": "view_submission", "team": {"id": "T1"}, "view": {"callback_id": "report"}}`,
+			secret:          testSecret,
+			expectedStatus:  http.StatusOK,
+			expectedBody:    `{"response_action":"errors","errors":{"reason":"Give a reason"}}`,
+			expectedHandled: []string{"report"},
+		},
+		{
+			name:           "unhandled shortcut",
+			payload:        `{"type": "shortcut", "team": {"id": "T1"}, "callback_id": "other"}`,
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "bad signature",
+			payload:        `{"type": "block_actions", "team": {"id": "T1"}, "actions": [{"action_id": "resolve"}]}`,
+			secret:         "wrong",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var handled []string
+			h := NewHandler(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+			h.HandleFunc(TypeBlockActions, "resolve", func(ctx context.Context, p *Payload) (*Response, error) {
+				handled = append(handled, "resolve")
+				return nil, nil
+			})
+			h.HandleFunc(TypeBlockActions, "", func(ctx context.Context, p *Payload) (*Response, error) {
+				handled = append(handled, "any action")
+				return nil, nil
+			})
+			h.HandleFunc(TypeViewSubmission, "report", func(ctx context.Context, p *Payload) (*Response, error) {
+				handled = append(handled, "report")
+				return &Response{ResponseAction: ResponseErrors, Errors: map[string]string{"reason": "Give a reason"}}, nil
+			})
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, newRequest(tc.payload, tc.secret))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, but got %d", tc.expectedStatus, rr.Code)
+			}
+			if tc.expectedStatus == http.StatusOK && rr.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, but got %q", tc.expectedBody, rr.Body.String())
+			}
+			if !reflect.DeepEqual(handled, tc.expectedHandled) {
+				t.Errorf("expected %v to be handled, but got %v", tc.expectedHandled, handled)
+			}
+		})
+	}
+}
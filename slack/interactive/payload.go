@@ -0,0 +1,196 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package interactive receives Slack interactivity payloads, such as button clicks and modal
+// submissions, over HTTP or Socket Mode, and routes each one to the function registered for it.
+package interactive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// Payload types.
+// See https://api.slack.com/reference/interaction-payloads
+const (
+	TypeBlockActions     = "block_actions"
+	TypeViewSubmission   = "view_submission"
+	TypeViewClosed       = "view_closed"
+	TypeShortcut         = "shortcut"
+	TypeMessageAction    = "message_action"
+	TypeDialogSubmission = "dialog_submission"
+)
+
+// Payload is an interactivity payload. Which fields are set depends on Type.
+type Payload struct {
+	Type        string `json:"type"`
+	CallbackID  string `json:"callback_id"`
+	TriggerID   string `json:"trigger_id"`
+	ResponseURL string `json:"response_url"`
+	APIAppID    string `json:"api_app_id"`
+	Team        struct {
+		ID     string `json:"id"`
+		Domain string `json:"domain"`
+	} `json:"team"`
+	Channel struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"channel"`
+	User struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+	Message Message `json:"message"`
+	// Actions are the elements the user interacted with, for block_actions payloads.
+	Actions []Action `json:"actions"`
+	// View is the modal or App Home view, for block_actions from a view and view_* payloads.
+	View *View `json:"view"`
+	// Submission and State are the submitted values and state of a legacy dialog, for
+	// dialog_submission payloads.
+	Submission map[string]string `json:"submission"`
+	State      string            `json:"state"`
+
+	client *slack.Client
+}
+
+// Message is the message a payload came from, for message_action and block_actions payloads.
+type Message struct {
+	Type            string `json:"type"`
+	User            string `json:"user"`
+	Timestamp       string `json:"ts"`
+	ThreadTimestamp string `json:"thread_ts"`
+	Text            string `json:"text"`
+	// Blocks are the message's blocks, as Slack encoded them.
+	Blocks []json.RawMessage `json:"blocks"`
+}
+
+// Option is a selected option of a select or checkbox element.
+type Option struct {
+	Text  blocks.Text `json:"text"`
+	Value string      `json:"value"`
+}
+
+// Value is the current value of an interactive element. Which fields are set depends on Type.
+type Value struct {
+	Type                  string   `json:"type"`
+	Value                 string   `json:"value"`
+	SelectedOption        *Option  `json:"selected_option"`
+	SelectedOptions       []Option `json:"selected_options"`
+	SelectedUser          string   `json:"selected_user"`
+	SelectedUsers         []string `json:"selected_users"`
+	SelectedChannel       string   `json:"selected_channel"`
+	SelectedChannels      []string `json:"selected_channels"`
+	SelectedConversation  string   `json:"selected_conversation"`
+	SelectedConversations []string `json:"selected_conversations"`
+	SelectedDate          string   `json:"selected_date"`
+	// SelectedDateTime is a Unix timestamp.
+	SelectedDateTime int64 `json:"selected_date_time"`
+}
+
+// Action is an element the user interacted with.
+type Action struct {
+	Value
+	ActionID string `json:"action_id"`
+	BlockID  string `json:"block_id"`
+	ActionTS string `json:"action_ts"`
+}
+
+// View is a modal or App Home view.
+type View struct {
+	ID              string `json:"id"`
+	Type            string `json:"type"`
+	CallbackID      string `json:"callback_id"`
+	PrivateMetadata string `json:"private_metadata"`
+	Hash            string `json:"hash"`
+	State           struct {
+		// Values maps block IDs to action IDs to the value of each input element.
+		Values map[string]map[string]Value `json:"values"`
+	} `json:"state"`
+}
+
+// Value returns the value of the input element with the given block and action IDs, and whether
+// the view has such an element.
+func (v *View) Value(blockID, actionID string) (Value, bool) {
+	if v == nil {
+		return Value{}, false
+	}
+	value, ok := v.State.Values[blockID][actionID]
+	return value, ok
+}
+
+// Metadata unmarshals the view's private_metadata, as stored by blocks.View.SetMetadata, into v.
+func (v *View) Metadata(metadata interface{}) error {
+	if v == nil {
+		return fmt.Errorf("payload has no view")
+	}
+	return blocks.UnmarshalMetadata(v.PrivateMetadata, metadata)
+}
+
+// Parse parses an interactivity payload, which is a form-encoded "payload" field when it comes
+// from a webhook and plain JSON when it comes over Socket Mode. It does not verify the payload.
+func Parse(body []byte) (*Payload, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse incoming content: %v", err)
+		}
+		body = []byte(form.Get("payload"))
+		if len(body) == 0 {
+			return nil, fmt.Errorf("payload was blank")
+		}
+	}
+	p := &Payload{}
+	if err := json.Unmarshal(body, p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %v", err)
+	}
+	if p.Type == "" {
+		return nil, fmt.Errorf("payload has no type")
+	}
+	return p, nil
+}
+
+// Client returns the Client for the workspace the payload came from. It is nil for payloads
+// returned by Parse.
+func (p *Payload) Client() *slack.Client {
+	return p.client
+}
+
+// Action returns the action with the given action ID, or nil if the payload has none.
+func (p *Payload) Action(actionID string) *Action {
+	for i := range p.Actions {
+		if p.Actions[i].ActionID == actionID {
+			return &p.Actions[i]
+		}
+	}
+	return nil
+}
+
+// Respond sends message, such as a slashcmd.Response, to the payload's response_url.
+func (p *Payload) Respond(ctx context.Context, message interface{}) error {
+	if p.client == nil {
+		return fmt.Errorf("%s payload has no client to respond with", p.Type)
+	}
+	if p.ResponseURL == "" {
+		return fmt.Errorf("%s payload has no response_url", p.Type)
+	}
+	return p.client.CallMethodContext(ctx, p.ResponseURL, message, nil)
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// ResponseMetadata holds the warnings and messages that Slack attaches to responses, such as the
+// missing_charset warning, which often announce deprecations long before anything breaks.
+type ResponseMetadata struct {
+	// Warnings are warning codes, such as "missing_charset" or "superfluous_charset".
+	Warnings []string `json:"warnings,omitempty"`
+	// Messages are human-readable explanations of the warnings or of an error.
+	Messages []string `json:"messages,omitempty"`
+}
+
+type responseMetadataKey struct{}
+
+// WithResponseMetadata returns a context that makes calls using it store the response metadata of
+// each response they receive in md. The metadata of every call is also logged at warn level when
+// it has warnings.
+func WithResponseMetadata(ctx context.Context, md *ResponseMetadata) context.Context {
+	return context.WithValue(ctx, responseMetadataKey{}, md)
+}
+
+// responseEnvelope is the part of every Web API response that the Client interprets itself.
+type responseEnvelope struct {
+	OK       bool             `json:"ok"`
+	Error    string           `json:"error"`
+	Warning  string           `json:"warning"`
+	Needed   string           `json:"needed"`
+	Provided string           `json:"provided"`
+	Metadata ResponseMetadata `json:"response_metadata"`
+}
+
+// metadata returns the response's metadata. Slack sends warnings both in response_metadata and,
+// comma separated, in the top-level warning field; they are merged.
+func (e responseEnvelope) metadata() ResponseMetadata {
+	md := e.Metadata
+	if e.Warning == "" {
+		return md
+	}
+	seen := map[string]bool{}
+	for _, w := range md.Warnings {
+		seen[w] = true
+	}
+	for _, w := range strings.Split(e.Warning, ",") {
+		if w != "" && !seen[w] {
+			seen[w] = true
+			md.Warnings = append(md.Warnings, w)
+		}
+	}
+	return md
+}
+
+// handleMetadata exposes the metadata of a response to req to whoever asked for it with
+// WithResponseMetadata, and reports any warnings.
+func (c *Client) handleMetadata(req *http.Request, method string, md ResponseMetadata) {
+	ctx := req.Context()
+	if p, ok := ctx.Value(responseMetadataKey{}).(*ResponseMetadata); ok {
+		*p = md
+	}
+	if len(md.Warnings) == 0 {
+		return
+	}
+	for _, w := range md.Warnings {
+		apiWarnings.WithLabelValues(method, w).Inc()
+	}
+	c.logger(ctx).Warn("Slack returned warnings", "method", method, "warnings", md.Warnings, "messages", md.Messages)
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	apiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "api_calls_total",
+		Help:      "Number of Slack API requests made, by method and outcome.",
+	}, []string{"method", "status"})
+	apiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "slack",
+		Name:      "api_call_duration_seconds",
+		Help:      "Latency of Slack API requests, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	apiWarnings = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "slack",
+		Name:      "api_warnings_total",
+		Help:      "Number of warnings in Slack API responses, by method and warning.",
+	}, []string{"method", "warning"})
+)
+
+func init() {
+	prometheus.MustRegister(apiCalls, apiCallDuration, apiWarnings)
+}
+
+// Values of the status label on slack_api_calls_total.
+const (
+	statusOK          = "ok"
+	statusSlackError  = "slack_error"
+	statusRateLimited = "rate_limited"
+	statusError       = "error"
+)
+
+// methodLabel returns the metric label for an API method. Complete URLs, such as webhooks and
+// response URLs, contain secrets and are unbounded, so they are all reported as "url".
+func methodLabel(api string) string {
+	if !strings.HasPrefix(api, "https://") {
+		return api
+	}
+	if m := strings.TrimPrefix(api, "https://slack.com/api/"); m != api {
+		return m
+	}
+	return "url"
+}
+
+// observeCall records the outcome of a single request to Slack.
+func observeCall(api string, start time.Time, err error) {
+	method := methodLabel(api)
+	status := statusOK
+	switch err.(type) {
+	case nil:
+	case ErrRateLimit:
+		status = statusRateLimited
+	case APIError:
+		status = statusSlackError
+	default:
+		status = statusError
+	}
+	apiCalls.WithLabelValues(method, status).Inc()
+	apiCallDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
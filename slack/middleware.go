@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import "net/http"
+
+// RoundTripFunc sends a single HTTP request to Slack and returns its response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc, for example to log, measure or modify requests and responses.
+// Middleware sees every attempt separately, so a retried call passes through it more than once.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use adds middleware to every request the Client makes. The first middleware added is the
+// outermost, i.e. it sees the request first and the response last.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// roundTrip sends req through the middleware chain and then to Slack.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	rt := RoundTripFunc(client.Do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		rt = c.middleware[i](rt)
+	}
+	return rt(req)
+}
+
+// Do sends req through the Client's middleware and HTTP client, for APIs hosted by Slack that
+// aren't Web API methods, such as SCIM. Unlike the Call* methods, it adds no token and doesn't
+// interpret the response, retry or wait for rate limits.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.roundTrip(req)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// tokenRefreshMargin is how long before a rotating token expires that we refresh it.
+const tokenRefreshMargin = 5 * time.Minute
+
+// Token is a rotating access token, as returned by oauth.v2.access.
+type Token struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// TokenStore persists rotated tokens, so that a restarted service doesn't try to use a
+// refresh token that has already been exchanged.
+type TokenStore interface {
+	// LoadToken returns the stored token, or a zero Token if none has been stored yet.
+	LoadToken() (Token, error)
+	// SaveToken stores the token, replacing any previous one.
+	SaveToken(token Token) error
+}
+
+// FileTokenStore is a TokenStore that keeps the token in a JSON file.
+type FileTokenStore struct {
+	Path string
+}
+
+// LoadToken implements TokenStore.
+func (f FileTokenStore) LoadToken() (Token, error) {
+	token := Token{}
+	content, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return token, nil
+	}
+	if err != nil {
+		return token, fmt.Errorf("couldn't open file: %v", err)
+	}
+	if err := json.Unmarshal(content, &token); err != nil {
+		return token, fmt.Errorf("couldn't parse token: %v", err)
+	}
+	return token, nil
+}
+
+// SaveToken implements TokenStore.
+func (f FileTokenStore) SaveToken(token Token) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal token: %v", err)
+	}
+	// Write to a temporary file and rename it into place, so a crash can't leave us without a token.
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), ".token")
+	if err != nil {
+		return fmt.Errorf("couldn't create temporary file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("couldn't write token: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("couldn't write token: %v", err)
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// canRotateToken returns true if the config has everything needed to refresh the access token.
+func (c Config) canRotateToken() bool {
+	return c.RefreshToken != "" && c.ClientID != "" && c.ClientSecret != ""
+}
+
+// accessToken returns the token that should be used to authenticate calls, refreshing it first
+// if token rotation is configured and the token is about to expire.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	config := c.CurrentConfig()
+	if !config.canRotateToken() {
+		return config.AccessToken, nil
+	}
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if err := c.initToken(); err != nil {
+		return "", err
+	}
+	if !c.token.Expiry.IsZero() && time.Until(c.token.Expiry) < tokenRefreshMargin {
+		if err := c.refreshTokenLocked(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.token.AccessToken, nil
+}
+
+// refreshToken exchanges the refresh token for a new access token, regardless of whether the
+// current one appears to have expired.
+func (c *Client) refreshToken(ctx context.Context) error {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if err := c.initToken(); err != nil {
+		return err
+	}
+	return c.refreshTokenLocked(ctx)
+}
+
+// initToken populates c.token from the TokenStore or the config. tokenLock must be held.
+func (c *Client) initToken() error {
+	if c.token.AccessToken != "" {
+		return nil
+	}
+	if c.TokenStore != nil {
+		stored, err := c.TokenStore.LoadToken()
+		if err != nil {
+			return fmt.Errorf("failed to load stored token: %v", err)
+		}
+		if stored.RefreshToken != "" {
+			c.token = stored
+			return nil
+		}
+	}
+	config := c.CurrentConfig()
+	c.token = Token{AccessToken: config.AccessToken, RefreshToken: config.RefreshToken}
+	return nil
+}
+
+// refreshTokenLocked calls oauth.v2.access to rotate the token. tokenLock must be held.
+func (c *Client) refreshTokenLocked(ctx context.Context) error {
+	config := c.CurrentConfig()
+	vs := url.Values{}
+	vs.Set("grant_type", "refresh_token")
+	vs.Set("refresh_token", c.token.RefreshToken)
+	vs.Set("client_id", config.ClientID)
+	vs.Set("client_secret", config.ClientSecret)
+	q := vs.Encode()
+
+	result := struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}{}
+	err := c.callWithRetries(ctx, "oauth.v2.access", func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", slackMethodToURL("oauth.v2.access"), bytes.NewBufferString(q))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		return req, nil
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("failed to refresh access token: %v", err)
+	}
+
+	token := Token{AccessToken: result.AccessToken, RefreshToken: result.RefreshToken}
+	if result.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = c.token.RefreshToken
+	}
+	c.token = token
+	if c.TokenStore != nil {
+		if err := c.TokenStore.SaveToken(token); err != nil {
+			return fmt.Errorf("refreshed access token, but failed to store it: %v", err)
+		}
+	}
+	return nil
+}
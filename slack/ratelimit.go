@@ -0,0 +1,242 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tier is one of Slack's rate limit tiers, as listed for each method at
+// https://api.slack.com/docs/rate-limits.
+type Tier int
+
+const (
+	Tier1 Tier = iota + 1
+	Tier2
+	Tier3
+	Tier4
+)
+
+// TierBudget is how many calls to the methods in a tier the RateLimiter allows.
+type TierBudget struct {
+	// PerMinute is the sustained number of calls per minute.
+	PerMinute int
+	// Burst is how many calls can be made at once after a quiet period.
+	Burst int
+}
+
+// DefaultTierBudgets stay comfortably within the limits Slack documents for each tier.
+var DefaultTierBudgets = map[Tier]TierBudget{
+	Tier1: {PerMinute: 1, Burst: 1},
+	Tier2: {PerMinute: 20, Burst: 5},
+	Tier3: {PerMinute: 50, Burst: 10},
+	Tier4: {PerMinute: 100, Burst: 20},
+}
+
+// MethodTiers maps the Slack methods we use to their tier. Methods that are not listed, including
+// webhooks, are not limited by the RateLimiter. apps.connections.open is left out on purpose:
+// Slack asks Socket Mode clients to reconnect several times an hour, and waiting up to a minute
+// for a Tier 1 token would drop events in the meantime.
+var MethodTiers = map[string]Tier{
+	"admin.conversations.archive":  Tier2,
+	"admin.conversations.invite":   Tier2,
+	"admin.emoji.remove":           Tier2,
+	"admin.users.list":             Tier2,
+	"admin.users.remove":           Tier2,
+	"admin.users.session.reset":    Tier2,
+	"bookmarks.add":                Tier2,
+	"bookmarks.edit":               Tier2,
+	"bookmarks.list":               Tier2,
+	"bookmarks.remove":             Tier2,
+	"conversations.archive":        Tier2,
+	"conversations.create":         Tier2,
+	"conversations.list":           Tier2,
+	"conversations.rename":         Tier2,
+	"conversations.setPurpose":     Tier2,
+	"conversations.setTopic":       Tier2,
+	"conversations.unarchive":      Tier2,
+	"pins.add":                     Tier2,
+	"search.files":                 Tier2,
+	"search.messages":              Tier2,
+	"usergroups.create":            Tier2,
+	"usergroups.disable":           Tier2,
+	"usergroups.enable":            Tier2,
+	"usergroups.list":              Tier2,
+	"usergroups.update":            Tier2,
+	"usergroups.users.list":        Tier2,
+	"usergroups.users.update":      Tier2,
+	"users.list":                   Tier2,
+	"chat.delete":                  Tier3,
+	"chat.deleteScheduledMessage":  Tier3,
+	"chat.scheduleMessage":         Tier3,
+	"chat.scheduledMessages.list":  Tier3,
+	"conversations.history":        Tier3,
+	"conversations.info":           Tier3,
+	"conversations.join":           Tier3,
+	"conversations.kick":           Tier3,
+	"conversations.members":        Tier3,
+	"conversations.replies":        Tier3,
+	"conversations.open":           Tier3,
+	"files.delete":                 Tier3,
+	"users.conversations":          Tier3,
+	"chat.getPermalink":            Tier4,
+	"chat.postEphemeral":           Tier4,
+	"chat.postMessage":             Tier4,
+	"dialog.open":                  Tier4,
+	"files.completeUploadExternal": Tier4,
+	"files.getUploadURLExternal":   Tier4,
+	"files.info":                   Tier4,
+	"users.info":                   Tier4,
+	"views.open":                   Tier4,
+	"views.publish":                Tier4,
+}
+
+// Priority orders calls waiting for the RateLimiter. Waiting calls with a higher priority are
+// made first; calls with the same priority are made in the order they were attempted.
+type Priority int
+
+const (
+	PriorityNormal Priority = 0
+	// PriorityHigh is intended for moderation actions, which shouldn't queue behind bulk work.
+	PriorityHigh Priority = 10
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context that gives calls made with it the given Priority.
+func WithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+func priorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// RateLimiter throttles calls to each tier of Slack methods with a token bucket, so that bursts of
+// calls queue up locally instead of being rejected by Slack.
+type RateLimiter struct {
+	lock    sync.Mutex
+	budgets map[Tier]TierBudget
+	buckets map[Tier]*bucket
+	seq     int64
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	queue   []*waiter
+	timer   *time.Timer
+}
+
+type waiter struct {
+	priority Priority
+	seq      int64
+	ready    chan struct{}
+}
+
+// NewRateLimiter returns a RateLimiter with the given budgets. Tiers without a budget are not
+// limited.
+func NewRateLimiter(budgets map[Tier]TierBudget) *RateLimiter {
+	return &RateLimiter{budgets: budgets, buckets: map[Tier]*bucket{}}
+}
+
+// Wait blocks until a call to method may be made, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, method string) error {
+	if l == nil {
+		return nil
+	}
+	tier, ok := MethodTiers[methodLabel(method)]
+	if !ok {
+		return nil
+	}
+	l.lock.Lock()
+	budget, ok := l.budgets[tier]
+	if !ok || budget.PerMinute <= 0 {
+		l.lock.Unlock()
+		return nil
+	}
+	b := l.buckets[tier]
+	if b == nil {
+		b = &bucket{tokens: float64(maxInt(budget.Burst, 1)), updated: time.Now()}
+		l.buckets[tier] = b
+	}
+	l.seq++
+	w := &waiter{priority: priorityFromContext(ctx), seq: l.seq, ready: make(chan struct{})}
+	b.queue = append(b.queue, w)
+	sort.SliceStable(b.queue, func(i, j int) bool {
+		if b.queue[i].priority != b.queue[j].priority {
+			return b.queue[i].priority > b.queue[j].priority
+		}
+		return b.queue[i].seq < b.queue[j].seq
+	})
+	l.dispatchLocked(b, budget)
+	l.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for i, q := range b.queue {
+		if q == w {
+			b.queue = append(b.queue[:i], b.queue[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// We were given a token just as ctx was cancelled, so make the call anyway.
+	return nil
+}
+
+// dispatchLocked hands out any available tokens to waiting calls, and schedules itself to run
+// again when the next token is due. l.lock must be held.
+func (l *RateLimiter) dispatchLocked(b *bucket, budget TierBudget) {
+	rate := float64(budget.PerMinute) / time.Minute.Seconds()
+	now := time.Now()
+	b.tokens = math.Min(float64(maxInt(budget.Burst, 1)), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	for len(b.queue) > 0 && b.tokens >= 1 {
+		b.tokens--
+		close(b.queue[0].ready)
+		b.queue = b.queue[1:]
+	}
+	if len(b.queue) == 0 || b.timer != nil {
+		return
+	}
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	b.timer = time.AfterFunc(wait, func() {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		b.timer = nil
+		l.dispatchLocked(b, budget)
+	})
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	// One token every 10ms, and no burst beyond a single call.
+	l := NewRateLimiter(map[Tier]TierBudget{Tier4: {PerMinute: 6000, Burst: 1}})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, "users.info"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("expected three calls to take about 20ms, but they took %s", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 10; i++ {
+		if err := l.Wait(ctx, "auth.revoke"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Millisecond {
+		t.Errorf("expected methods without a tier not to be limited, but they took %s", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = l.Wait(ctx, "users.info")
+	if err := l.Wait(cancelled, "users.info"); err == nil {
+		t.Errorf("expected waiting with a cancelled context to fail")
+	}
+}
+
+func TestRateLimiterPriority(t *testing.T) {
+	l := NewRateLimiter(map[Tier]TierBudget{Tier4: {PerMinute: 600, Burst: 1}})
+	ctx := context.Background()
+	// Use up the only token, so every call below has to queue.
+	_ = l.Wait(ctx, "chat.postMessage")
+
+	var lock sync.Mutex
+	var order []Priority
+	wg := sync.WaitGroup{}
+	for _, p := range []Priority{PriorityNormal, PriorityNormal, PriorityHigh} {
+		wg.Add(1)
+		go func(p Priority) {
+			defer wg.Done()
+			_ = l.Wait(WithPriority(ctx, p), "chat.postMessage")
+			lock.Lock()
+			order = append(order, p)
+			lock.Unlock()
+		}(p)
+		// Make sure the calls queue in order.
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+	if order[0] != PriorityHigh {
+		t.Errorf("expected the high priority call to be made first, but the order was %v", order)
+	}
+}
+
+func TestRateLimiterSocketModeReconnects(t *testing.T) {
+	l := NewRateLimiter(DefaultTierBudgets)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// Slack asks for a reconnect with a disconnect message, and can ask again soon after.
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, "apps.connections.open"); err != nil {
+			t.Fatalf("expected reconnect %d not to wait for the rate limiter, but got %v", i+1, err)
+		}
+	}
+}
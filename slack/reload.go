@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+)
+
+// configPollInterval is how often WatchConfig checks whether the config file has changed.
+const configPollInterval = 10 * time.Second
+
+// UpdateConfig replaces the credentials used by the Client with those in config, so that rotated
+// tokens and secrets take effect without a restart. Settings that are only used when the Client is
+// created, such as Transport and TokenStorePath, are left unchanged.
+func (c *Client) UpdateConfig(config Config) {
+	c.secretsLock.Lock()
+	old := c.Config
+	c.Config.SigningSecret = config.SigningSecret
+	c.Config.SigningSecrets = config.SigningSecrets
+	c.Config.WebhookURL = config.WebhookURL
+	c.Config.AccessToken = config.AccessToken
+	c.Config.AppToken = config.AppToken
+	c.Config.OrgToken = config.OrgToken
+	c.Config.UserToken = config.UserToken
+	c.Config.RefreshToken = config.RefreshToken
+	c.Config.ClientID = config.ClientID
+	c.Config.ClientSecret = config.ClientSecret
+	c.Config.Secrets = config.Secrets
+	c.Config.DryRun = config.DryRun
+	c.secretsLock.Unlock()
+
+	if old.AccessToken != config.AccessToken || old.RefreshToken != config.RefreshToken {
+		// Forget the rotated token we were using, so the new one is picked up.
+		c.tokenLock.Lock()
+		c.token = Token{}
+		c.tokenLock.Unlock()
+	}
+}
+
+// CurrentConfig returns a copy of the Client's Config. Unlike reading the Config field directly,
+// this is safe while secrets are being refreshed or the config is being reloaded.
+func (c *Client) CurrentConfig() Config {
+	c.secretsLock.RLock()
+	defer c.secretsLock.RUnlock()
+	return c.Config
+}
+
+// Reload reads the config at path again, as LoadConfig does, and updates the Client's credentials
+// to match it.
+func (c *Client) Reload(path string) error {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	c.UpdateConfig(config)
+	return nil
+}
+
+// WatchConfig calls reload whenever the process receives SIGHUP or the file at path is modified,
+// until ctx is done. Errors from reload are logged, and the previous config stays in use.
+// Nothing is watched if path is empty, because the config then comes from the environment.
+func WatchConfig(ctx context.Context, path string, reload func() error) {
+	if path == "" {
+		return
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+
+	lastModified := modTime(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logging.FromContext(ctx).Info("Got SIGHUP, reloading config", "path", path)
+		case <-ticker.C:
+			m := modTime(path)
+			if m.Equal(lastModified) {
+				continue
+			}
+			logging.FromContext(ctx).Info("Config file changed, reloading it", "path", path)
+		}
+		lastModified = modTime(path)
+		if err := reload(); err != nil {
+			logging.FromContext(ctx).Error("Failed to reload config", "path", path, "error", err)
+		}
+	}
+}
+
+// modTime returns when the file at path was last modified, following symlinks so that updates
+// to Kubernetes secret and ConfigMap volumes are noticed.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClientSetReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slack-config")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	write(`{"accessToken": "xoxb-old", "workspaces": {"T1": {"accessToken": "xoxb-t1"}, "T2": {"accessToken": "xoxb-t2"}}}`)
+	clients, err := LoadClientSet(path)
+	if err != nil {
+		t.Fatalf("failed to load clients: %v", err)
+	}
+	defaultClient := clients.Default()
+	t1, _ := clients.ForTeam("T1")
+
+	write(`{"accessToken": "xoxb-new", "signingSecret": "new-secret", "workspaces": {"T1": {"accessToken": "xoxb-t1-new"}, "T3": {"accessToken": "xoxb-t3"}}}`)
+	if err := clients.Reload(path); err != nil {
+		t.Fatalf("failed to reload clients: %v", err)
+	}
+
+	if clients.Default() != defaultClient {
+		t.Errorf("expected the default client to be updated in place")
+	}
+	if config := defaultClient.CurrentConfig(); config.AccessToken != "xoxb-new" || config.SigningSecret != "new-secret" {
+		t.Errorf("expected the default client to have the new credentials, but got %+v", config)
+	}
+	if c, _ := clients.ForTeam("T1"); c != t1 || c.CurrentConfig().AccessToken != "xoxb-t1-new" {
+		t.Errorf("expected T1's client to be updated in place with the new token")
+	}
+	if c, _ := clients.ForTeam("T2"); c != defaultClient {
+		t.Errorf("expected the removed workspace T2 to fall back to the default client")
+	}
+	if c, _ := clients.ForTeam("T3"); c == defaultClient || c.CurrentConfig().AccessToken != "xoxb-t3" {
+		t.Errorf("expected the new workspace T3 to get its own client")
+	}
+
+	write(`{"workspaces": `)
+	if err := clients.Reload(path); err == nil {
+		t.Errorf("expected reloading an invalid config to fail")
+	}
+	if defaultClient.CurrentConfig().AccessToken != "xoxb-new" {
+		t.Errorf("expected a failed reload to keep the previous config")
+	}
+}
+
+func TestClientSetWebhookOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slack-config")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	write := func(content string) {
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	write(`{"signingSecret": "secret", "webhook": "https://hooks.slack.com/services/T1/B1/old"}`)
+	clients, err := LoadClientSet(path)
+	if err != nil {
+		t.Fatalf("failed to load a webhook-only config: %v", err)
+	}
+	defaultClient := clients.Default()
+	if defaultClient == nil || defaultClient.CurrentConfig().WebhookURL != "https://hooks.slack.com/services/T1/B1/old" {
+		t.Fatalf("expected a default client with the webhook")
+	}
+
+	write(`{"signingSecret": "secret", "webhook": "https://hooks.slack.com/services/T1/B1/new"}`)
+	if err := clients.Reload(path); err != nil {
+		t.Fatalf("failed to reload a webhook-only config: %v", err)
+	}
+	if clients.Default() != defaultClient || defaultClient.CurrentConfig().WebhookURL != "https://hooks.slack.com/services/T1/B1/new" {
+		t.Errorf("expected the default client to be updated in place with the new webhook")
+	}
+
+	write(`{"signingSecret": "secret"}`)
+	if err := clients.Reload(path); err == nil {
+		t.Errorf("expected reloading a config without a token or webhook to fail")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how calls that fail for transient reasons, such as network errors or
+// Slack returning a 5xx status, are retried. Rate limiting is handled separately. Only calls to
+// the methods in idempotentMethods are retried after a failure that Slack might already have acted
+// on; other calls, such as those that post messages, are only retried if the request never got
+// to Slack.
+type RetryPolicy struct {
+	// MaxRetries is how many times a failed call is retried. Zero disables retries.
+	MaxRetries int
+	// InitialBackoff is the longest we wait before the first retry. The limit doubles after each
+	// attempt, and the actual wait is chosen at random below it to avoid retrying in lockstep.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by calls that don't specify their own RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// NoRetries is a RetryPolicy that never retries, for calls where repeating a request that may
+// already have been processed would be worse than failing.
+var NoRetries = RetryPolicy{}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context that makes calls made with it use policy instead of the
+// Client's RetryPolicy.
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicy returns the RetryPolicy that applies to a call made with ctx.
+func (c *Client) retryPolicy(ctx context.Context) RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return p
+	}
+	return c.RetryPolicy
+}
+
+// backoff returns how long to wait before the given retry, starting at zero.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	limit := p.InitialBackoff
+	for i := 0; i < retry && limit < p.MaxBackoff; i++ {
+		limit *= 2
+	}
+	if p.MaxBackoff > 0 && limit > p.MaxBackoff {
+		limit = p.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit)))
+}
+
+// idempotentMethods are the methods that can safely be called again after a failure that Slack
+// might already have acted on, because they only read, or because doing what they do twice is no
+// different from doing it once.
+var idempotentMethods = map[string]bool{
+	"admin.users.list":            true,
+	"apps.connections.open":       true,
+	"auth.test":                   true,
+	"bookmarks.list":              true,
+	"chat.getPermalink":           true,
+	"chat.scheduledMessages.list": true,
+	"conversations.history":       true,
+	"conversations.info":          true,
+	"conversations.list":          true,
+	"conversations.members":       true,
+	"conversations.open":          true,
+	"conversations.replies":       true,
+	"files.info":                  true,
+	"reactions.get":               true,
+	"search.files":                true,
+	"search.messages":             true,
+	"usergroups.list":             true,
+	"usergroups.users.list":       true,
+	"users.conversations":         true,
+	"users.info":                  true,
+	"users.list":                  true,
+	"users.lookupByEmail":         true,
+	"views.publish":               true,
+}
+
+// retriesSafely returns whether a call to api that failed with err can be tried again without
+// risking doing what it does twice.
+func retriesSafely(api string, err transientError) bool {
+	return err.unsent || idempotentMethods[methodLabel(api)]
+}
+
+// transientError is returned when a request fails in a way that might not happen again. unsent is
+// set if the request was never written to the connection, so Slack can't have acted on it.
+type transientError struct {
+	err    error
+	unsent bool
+}
+
+func (e transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e transientError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scim is a client for Slack's SCIM API, which provisions and deactivates users on paid
+// plans. It is the only supported way to deactivate accounts programmatically.
+// See https://api.slack.com/admins/scim
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// Version is a version of the SCIM API.
+type Version string
+
+const (
+	V1 Version = "v1"
+	V2 Version = "v2"
+)
+
+// baseURL is where the SCIM API lives; it is a variable so that tests can replace it.
+var baseURL = "https://api.slack.com/scim/"
+
+// Client calls the SCIM API.
+type Client struct {
+	// Version is the SCIM API version to use. New sets it to V2.
+	Version Version
+
+	slack *slack.Client
+	token string
+}
+
+// New returns a Client that sends its requests through client, authenticating with token. The
+// token must be a user token with the admin scope, belonging to an Owner or Admin.
+func New(client *slack.Client, token string) *Client {
+	return &Client{Version: V2, slack: client, token: token}
+}
+
+// Email is one of a user's email addresses.
+type Email struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Name is a user's full name.
+type Name struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// User is a SCIM user.
+type User struct {
+	Schemas     []string `json:"schemas,omitempty"`
+	ID          string   `json:"id,omitempty"`
+	UserName    string   `json:"userName"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Name        *Name    `json:"name,omitempty"`
+	Emails      []Email  `json:"emails,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Active      bool     `json:"active"`
+}
+
+// PrimaryEmail returns the user's primary email address, or their first one if none is primary.
+func (u User) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// Error is an error returned by the SCIM API.
+type Error struct {
+	Status int
+	Detail string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("SCIM request failed with status %d: %s", e.Status, e.Detail)
+}
+
+// GetUser looks up the user with the given Slack user ID.
+func (c *Client) GetUser(ctx context.Context, id string) (User, error) {
+	user := User{}
+	err := c.do(ctx, "GET", "Users/"+url.PathEscape(id), nil, &user)
+	return user, err
+}
+
+// FindUserByEmail looks up the user with the given email address. It returns an Error with
+// status 404 if there is no such user.
+func (c *Client) FindUserByEmail(ctx context.Context, email string) (User, error) {
+	resp := struct {
+		Resources []User `json:"Resources"`
+	}{}
+	query := url.Values{"filter": {fmt.Sprintf("email eq %s", strconv.Quote(email))}}
+	if err := c.do(ctx, "GET", "Users?"+query.Encode(), nil, &resp); err != nil {
+		return User{}, err
+	}
+	if len(resp.Resources) == 0 {
+		return User{}, Error{Status: http.StatusNotFound, Detail: fmt.Sprintf("no user has email %s", email)}
+	}
+	return resp.Resources[0], nil
+}
+
+// CreateUser provisions a new user, and returns it as created.
+func (c *Client) CreateUser(ctx context.Context, user User) (User, error) {
+	user.Schemas = []string{c.userSchema()}
+	user.Active = true
+	created := User{}
+	err := c.do(ctx, "POST", "Users", user, &created)
+	return created, err
+}
+
+// DeactivateUser deactivates the user with the given Slack user ID, signing them out everywhere.
+// Their messages and files are kept.
+func (c *Client) DeactivateUser(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "Users/"+url.PathEscape(id), nil, nil)
+}
+
+// ReactivateUser reactivates a deactivated user.
+func (c *Client) ReactivateUser(ctx context.Context, id string) error {
+	var patch interface{}
+	if c.Version == V1 {
+		patch = map[string]interface{}{
+			"schemas": []string{c.userSchema()},
+			"active":  true,
+		}
+	} else {
+		patch = map[string]interface{}{
+			"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:PatchOp"},
+			"Operations": []map[string]interface{}{
+				{"op": "replace", "value": map[string]bool{"active": true}},
+			},
+		}
+	}
+	return c.do(ctx, "PATCH", "Users/"+url.PathEscape(id), patch, nil)
+}
+
+func (c *Client) userSchema() string {
+	if c.Version == V1 {
+		return "urn:scim:schemas:core:1.0"
+	}
+	return "urn:ietf:params:scim:schemas:core:2.0:User"
+}
+
+// do makes a SCIM request, marshalling body as JSON if it isn't nil, and unmarshals the
+// response into ret if it isn't nil.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, ret interface{}) error {
+	var b []byte
+	if body != nil {
+		var err error
+		b, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal SCIM request: %v", err)
+		}
+	}
+	if method != http.MethodGet && c.slack.CurrentConfig().DryRun {
+		logging.FromContext(ctx).Info("Dry run: not calling SCIM", "method", method, "path", path, "payload", string(b))
+		return nil
+	}
+	var reader io.Reader
+	if b != nil {
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+string(c.Version)+"/"+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := c.slack.Do(req)
+	if err != nil {
+		return fmt.Errorf("SCIM request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, err := strconv.ParseInt(resp.Header.Get("Retry-After"), 10, 64)
+		if err != nil {
+			return fmt.Errorf("slack has rate limited us for %q seconds, but we can't parse that", resp.Header.Get("Retry-After"))
+		}
+		return slack.ErrRateLimit{Wait: time.Duration(retryAfter) * time.Second}
+	}
+	if resp.StatusCode >= 300 {
+		return parseError(resp.StatusCode, content)
+	}
+	if ret != nil {
+		if err := json.Unmarshal(content, ret); err != nil {
+			return fmt.Errorf("SCIM request succeeded, but failed to unmarshal result: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseError extracts the error from a failed response, which looks different in each version.
+func parseError(status int, content []byte) error {
+	e := struct {
+		// v1
+		Errors struct {
+			Description string `json:"description"`
+		} `json:"Errors"`
+		// v2
+		Detail string `json:"detail"`
+	}{}
+	_ = json.Unmarshal(content, &e)
+	detail := e.Detail
+	if detail == "" {
+		detail = e.Errors.Description
+	}
+	if detail == "" {
+		detail = http.StatusText(status)
+	}
+	return Error{Status: status, Detail: detail}
+}
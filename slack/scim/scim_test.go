@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+func newTestClient(t *testing.T, version Version, handler http.HandlerFunc) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxp-admin" {
+			t.Errorf("expected the admin token, but got %q", r.Header.Get("Authorization"))
+		}
+		handler(w, r)
+	}))
+	oldBaseURL := baseURL
+	baseURL = server.URL + "/scim/"
+	c := New(slack.New(slack.Config{}), "xoxp-admin")
+	c.Version = version
+	return c, func() {
+		baseURL = oldBaseURL
+		server.Close()
+	}
+}
+
+func TestFindUserByEmail(t *testing.T) {
+	c, done := newTestClient(t, V2, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/scim/v2/Users" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("filter") == `email eq "spammer@example.com"` {
+			_, _ = w.Write([]byte(`{"totalResults": 1, "Resources": [{"id": "U1", "userName": "spammer", "active": true, "emails": [{"value": "other@example.com"}, {"value": "spammer@example.com", "primary": true}]}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"totalResults": 0, "Resources": []}`))
+	})
+	defer done()
+
+	user, err := c.FindUserByEmail(context.Background(), "spammer@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != "U1" || !user.Active || user.PrimaryEmail() != "spammer@example.com" {
+		t.Errorf("unexpected user %+v", user)
+	}
+
+	_, err = c.FindUserByEmail(context.Background(), "nobody@example.com")
+	if e, ok := err.(Error); !ok || e.Status != http.StatusNotFound {
+		t.Errorf("expected a not found error, but got %v", err)
+	}
+}
+
+func TestDeactivateAndReactivateUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       Version
+		expectedPatch string
+	}{
+		{
+			name:          "v1",
+			version:       V1,
+			expectedPatch: `{"active":true,"schemas":["urn:scim:schemas:core:1.0"]}`,
+		},
+		{
+			name:          "v2",
+			version:       V2,
+			expectedPatch: `{"Operations":[{"op":"replace","value":{"active":true}}],"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []string
+			var patch string
+			c, done := newTestClient(t, tc.version, func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				if r.Method == "PATCH" {
+					body, _ := ioutil.ReadAll(r.Body)
+					patch = string(body)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			defer done()
+
+			if err := c.DeactivateUser(context.Background(), "U1"); err != nil {
+				t.Fatalf("unexpected error deactivating: %v", err)
+			}
+			if err := c.ReactivateUser(context.Background(), "U1"); err != nil {
+				t.Fatalf("unexpected error reactivating: %v", err)
+			}
+			prefix := "/scim/" + string(tc.version)
+			expected := []string{"DELETE " + prefix + "/Users/U1", "PATCH " + prefix + "/Users/U1"}
+			if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+				t.Errorf("expected requests %v, but got %v", expected, requests)
+			}
+			if patch != tc.expectedPatch {
+				t.Errorf("expected patch %s, but got %s", tc.expectedPatch, patch)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		version        Version
+		status         int
+		body           string
+		expectedDetail string
+	}{
+		{
+			name:           "v1",
+			version:        V1,
+			status:         http.StatusNotFound,
+			body:           `{"Errors": {"description": "user_not_found", "code": 404}}`,
+			expectedDetail: "user_not_found",
+		},
+		{
+			name:           "v2",
+			version:        V2,
+			status:         http.StatusForbidden,
+			body:           `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:Error"], "detail": "missing_scope", "status": 403}`,
+			expectedDetail: "missing_scope",
+		},
+		{
+			name:           "no body",
+			version:        V2,
+			status:         http.StatusBadGateway,
+			expectedDetail: "Bad Gateway",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, done := newTestClient(t, tc.version, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			})
+			defer done()
+
+			_, err := c.GetUser(context.Background(), "U1")
+			e, ok := err.(Error)
+			if !ok {
+				t.Fatalf("expected an Error, but got %v", err)
+			}
+			if e.Status != tc.status || e.Detail != tc.expectedDetail {
+				t.Errorf("expected status %d and detail %q, but got %+v", tc.status, tc.expectedDetail, e)
+			}
+		})
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	c, done := newTestClient(t, V2, func(w http.ResponseWriter, r *http.Request) {
+		user := User{}
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			return
+		}
+		if len(user.Schemas) != 1 || user.Schemas[0] != "urn:ietf:params:scim:schemas:core:2.0:User" || !user.Active {
+			t.Errorf("unexpected request %+v", user)
+		}
+		user.ID = "U2"
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(user)
+	})
+	defer done()
+
+	user, err := c.CreateUser(context.Background(), User{UserName: "newbie", Emails: []Email{{Value: "newbie@example.com", Primary: true}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.ID != "U2" || user.UserName != "newbie" {
+		t.Errorf("unexpected user %+v", user)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var methods []string
+	c, done := newTestClient(t, V2, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		_, _ = w.Write([]byte(`{"id": "U1", "userName": "spammer", "active": true}`))
+	})
+	defer done()
+	c.slack.Config.DryRun = true
+
+	if _, err := c.GetUser(context.Background(), "U1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.DeactivateUser(context.Background(), "U1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 || methods[0] != http.MethodGet {
+		t.Errorf("expected only the GET to be sent, but got %v", methods)
+	}
+}
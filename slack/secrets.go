@@ -0,0 +1,344 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// SecretsConfig fetches the access token and signing secret from a secret manager instead of
+// reading them from the config file. Each secret is named by a reference, which is one of:
+//
+//	vault://<path>#<key>               a key of a Vault KV secret, e.g. vault://secret/data/slack#token
+//	awssm://<secret id>[#<key>]        an AWS Secrets Manager secret, or a key of a JSON secret
+//	gcpsm://projects/<p>/secrets/<s>   a GCP Secret Manager secret (optionally with /versions/<v>)
+//
+// Secrets fetched this way replace AccessToken and SigningSecret from the config file.
+type SecretsConfig struct {
+	AccessToken   string `json:"accessToken,omitempty"`
+	SigningSecret string `json:"signingSecret,omitempty"`
+	// RefreshInterval, if set, is how often secrets are fetched again, as a duration like "10m".
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+
+	Vault VaultConfig `json:"vault,omitempty"`
+	AWS   AWSConfig   `json:"aws,omitempty"`
+}
+
+// VaultConfig configures access to Vault.
+type VaultConfig struct {
+	// Address is the URL of the Vault server. Defaults to $VAULT_ADDR.
+	Address string `json:"address,omitempty"`
+	// TokenPath is a file containing the Vault token. Defaults to reading $VAULT_TOKEN.
+	TokenPath string `json:"tokenPath,omitempty"`
+}
+
+// AWSConfig configures access to AWS Secrets Manager. Credentials are read from
+// $AWS_ACCESS_KEY_ID, $AWS_SECRET_ACCESS_KEY and, optionally, $AWS_SESSION_TOKEN.
+type AWSConfig struct {
+	// Region is the AWS region the secrets are stored in. Defaults to $AWS_REGION.
+	Region string `json:"region,omitempty"`
+}
+
+// GCP Secret Manager is authenticated using the default service account of the instance, from
+// the metadata server.
+const (
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+	gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+)
+
+var secretsHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+func (s SecretsConfig) enabled() bool {
+	return s.AccessToken != "" || s.SigningSecret != ""
+}
+
+func (s SecretsConfig) refreshInterval() (time.Duration, error) {
+	if s.RefreshInterval == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s.RefreshInterval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid refreshInterval: %v", err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("refreshInterval must be positive")
+	}
+	return d, nil
+}
+
+// fetch fetches every configured secret, and stores them in config.
+func (s SecretsConfig) fetch(ctx context.Context, config *Config) error {
+	if s.AccessToken != "" {
+		token, err := s.fetchSecret(ctx, s.AccessToken)
+		if err != nil {
+			return fmt.Errorf("failed to fetch access token: %v", err)
+		}
+		config.AccessToken = token
+	}
+	if s.SigningSecret != "" {
+		secret, err := s.fetchSecret(ctx, s.SigningSecret)
+		if err != nil {
+			return fmt.Errorf("failed to fetch signing secret: %v", err)
+		}
+		config.SigningSecret = secret
+	}
+	return nil
+}
+
+// fetchSecret fetches the secret named by ref.
+func (s SecretsConfig) fetchSecret(ctx context.Context, ref string) (string, error) {
+	parts := strings.SplitN(ref, "://", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", fmt.Errorf("invalid secret reference %q", ref)
+	}
+	name, key := parts[1], ""
+	if i := strings.LastIndex(name, "#"); i != -1 {
+		name, key = name[:i], name[i+1:]
+	}
+	switch parts[0] {
+	case "vault":
+		if key == "" {
+			return "", fmt.Errorf("vault secret reference %q has no #key", ref)
+		}
+		return s.Vault.fetch(ctx, name, key)
+	case "awssm":
+		return s.AWS.fetch(ctx, name, key)
+	case "gcpsm":
+		return fetchGCPSecret(ctx, name)
+	default:
+		return "", fmt.Errorf("unknown secret manager %q", parts[0])
+	}
+}
+
+func (v VaultConfig) fetch(ctx context.Context, path, key string) (string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", fmt.Errorf("no vault address configured")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if v.TokenPath != "" {
+		content, err := ioutil.ReadFile(v.TokenPath)
+		if err != nil {
+			return "", fmt.Errorf("couldn't read vault token: %v", err)
+		}
+		token = strings.TrimSpace(string(content))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(address, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("X-Vault-Token", token)
+	result := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", err
+	}
+	// KV version 2 nests the secret's keys inside another data field.
+	data := result.Data
+	if nested, ok := data["data"]; ok {
+		kv := map[string]json.RawMessage{}
+		if err := json.Unmarshal(nested, &kv); err == nil {
+			data = kv
+		}
+	}
+	return stringKey(data, key)
+}
+
+func (a AWSConfig) fetch(ctx context.Context, id, key string) (string, error) {
+	region := a.Region
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		return "", fmt.Errorf("no AWS region configured")
+	}
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://secretsmanager."+region+".amazonaws.com/", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if err := signAWSRequest(req, body, region, "secretsmanager", time.Now()); err != nil {
+		return "", err
+	}
+	result := struct {
+		SecretString string `json:"SecretString"`
+	}{}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", err
+	}
+	if key == "" {
+		return result.SecretString, nil
+	}
+	data := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(result.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %v", err)
+	}
+	return stringKey(data, key)
+}
+
+// signAWSRequest signs req using AWS Signature Version 4 with credentials from the environment.
+func signAWSRequest(req *http.Request, body []byte, region, service string, now time.Time) error {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	payloadHash := sha256.Sum256(body)
+	signedHeaders := "content-type;host;x-amz-date;x-amz-target"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-date:" + amzDate + "\n" +
+		"x-amz-target:" + req.Header.Get("X-Amz-Target") + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, s := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func fetchGCPSecret(ctx context.Context, name string) (string, error) {
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	tokenReq, err := http.NewRequestWithContext(ctx, "GET", gcpMetadataTokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	tokenReq.Header.Set("Metadata-Flavor", "Google")
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := doSecretRequest(tokenReq, &token); err != nil {
+		return "", fmt.Errorf("failed to get GCP access token: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", gcpSecretManagerURL+name+":access", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	result := struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}{}
+	if err := doSecretRequest(req, &result); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %v", err)
+	}
+	return string(data), nil
+}
+
+func doSecretRequest(req *http.Request, ret interface{}) error {
+	resp, err := secretsHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, ret); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %v", err)
+	}
+	return nil
+}
+
+func stringKey(data map[string]json.RawMessage, key string) (string, error) {
+	raw, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %q", key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return "", fmt.Errorf("secret key %q is not a string", key)
+	}
+	return s, nil
+}
+
+// refreshSecrets fetches the configured secrets every interval, forever.
+func (c *Client) refreshSecrets(interval time.Duration) {
+	for range time.Tick(interval) {
+		config := c.CurrentConfig()
+		if err := config.Secrets.fetch(context.Background(), &config); err != nil {
+			c.logger(context.Background()).Error("Failed to refresh secrets", "error", err)
+			continue
+		}
+		c.secretsLock.Lock()
+		c.Config.AccessToken = config.AccessToken
+		c.Config.SigningSecret = config.SigningSecret
+		c.secretsLock.Unlock()
+	}
+}
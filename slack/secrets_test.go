@@ -0,0 +1,102 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchVaultSecret(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/slack":
+			_, _ = w.Write([]byte(`{"data": {"data": {"token": "xoxb-v2"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/slack":
+			_, _ = w.Write([]byte(`{"data": {"token": "xoxb-v1"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	os.Setenv("VAULT_TOKEN", "vault-token")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	tests := []struct {
+		name      string
+		ref       string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "KV version 2 secret",
+			ref:      "vault://secret/data/slack#token",
+			expected: "xoxb-v2",
+		},
+		{
+			name:     "KV version 1 secret",
+			ref:      "vault://kv/slack#token",
+			expected: "xoxb-v1",
+		},
+		{
+			name:      "missing key",
+			ref:       "vault://kv/slack#nope",
+			expectErr: true,
+		},
+		{
+			name:      "missing secret",
+			ref:       "vault://kv/nope#token",
+			expectErr: true,
+		},
+		{
+			name:      "reference without a key",
+			ref:       "vault://kv/slack",
+			expectErr: true,
+		},
+		{
+			name:      "unknown secret manager",
+			ref:       "keepass://slack",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := SecretsConfig{Vault: VaultConfig{Address: server.URL}}
+			secret, err := s.fetchSecret(context.Background(), tc.ref)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error, but got secret %q", secret)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if secret != tc.expected {
+				t.Errorf("expected secret %q, but got %q", tc.expected, secret)
+			}
+		})
+	}
+}
@@ -19,63 +19,270 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"math"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"sigs.k8s.io/slack-infra/logging"
+)
+
+const (
+	defaultMaxRateLimitRetries = 3
+	defaultMaxRateLimitWait    = time.Minute
+	defaultMaxSignatureAge     = 5 * time.Minute
 )
 
 // Client has methods for interacting with Slack.
 type Client struct {
 	Config Config
+
+	// MaxRateLimitRetries is how many times a call that Slack rate limits will be retried
+	// before the ErrRateLimit is returned to the caller. Zero disables retries.
+	MaxRateLimitRetries int
+	// MaxRateLimitWait is the longest Retry-After we are willing to sleep for. If Slack asks us
+	// to wait longer than this, the ErrRateLimit is returned immediately.
+	MaxRateLimitWait time.Duration
+	// MaxSignatureAge is how far X-Slack-Request-Timestamp may be from the current time before
+	// VerifySignature rejects the request, to prevent captured requests from being replayed.
+	MaxSignatureAge time.Duration
+	// TokenStore persists rotated access tokens. It is only used if token rotation is configured.
+	TokenStore TokenStore
+	// RetryPolicy controls retries after transient failures. It can be overridden for a single
+	// call using WithRetryPolicy.
+	RetryPolicy RetryPolicy
+	// RateLimiter throttles calls to stay within Slack's per-tier rate limits. It is shared by
+	// every call made with the Client; set it to nil to disable client-side throttling.
+	RateLimiter *RateLimiter
+	// HTTPClient is used to make all requests to Slack. It can be replaced to customize
+	// proxies, TLS or connection pooling beyond what Config.Transport allows.
+	HTTPClient *http.Client
+	// Logger is used for messages about calls whose context doesn't carry a logger (see
+	// logging.NewContext), and for background work such as refreshing secrets. If it is nil, the
+	// default slog logger is used. It must be set before the Client is used.
+	Logger *slog.Logger
+
+	middleware  []Middleware
+	tokenLock   sync.Mutex
+	token       Token
+	secretsLock sync.RWMutex
+	cacheOnce   sync.Once
+	channels    *ChannelCache
+	users       *UserCache
 }
 
 // New returns a new Client.
 func New(config Config) *Client {
-	return &Client{Config: config}
+	c := &Client{
+		Config:              config,
+		MaxRateLimitRetries: defaultMaxRateLimitRetries,
+		MaxRateLimitWait:    defaultMaxRateLimitWait,
+		MaxSignatureAge:     defaultMaxSignatureAge,
+		RetryPolicy:         DefaultRetryPolicy,
+		RateLimiter:         NewRateLimiter(DefaultTierBudgets),
+	}
+	if config.TokenStorePath != "" {
+		c.TokenStore = FileTokenStore{Path: config.TokenStorePath}
+	}
+	httpClient, err := NewHTTPClient(config.Transport)
+	if err != nil {
+		c.logger(context.Background()).Error("Ignoring invalid transport config", "error", err)
+		httpClient, _ = NewHTTPClient(TransportConfig{})
+	}
+	c.HTTPClient = httpClient
+	if interval, err := config.Secrets.refreshInterval(); err != nil {
+		c.logger(context.Background()).Error("Not refreshing secrets", "error", err)
+	} else if interval > 0 && config.Secrets.enabled() {
+		go c.refreshSecrets(interval)
+	}
+	return c
+}
+
+// logger returns the logger for messages about a call made with ctx.
+func (c *Client) logger(ctx context.Context) *slog.Logger {
+	return logging.FromContextOr(ctx, c.Logger)
+}
+
+// initCaches creates the caches shared by users of the Client.
+func (c *Client) initCaches() {
+	c.channels = NewChannelCache(c)
+	c.users = NewUserCache(c)
 }
 
 // CallMethod calls most Slack API methods by name. If the API is normal but the URL is weird,
 // providing a complete https:// URL as the API name also works.
 func (c *Client) CallMethod(api string, args interface{}, ret interface{}) error {
+	return c.CallMethodContext(context.Background(), api, args, ret)
+}
+
+// CallMethodContext is like CallMethod, but the call is aborted (including any wait for a rate
+// limit to expire) when ctx is done.
+func (c *Client) CallMethodContext(ctx context.Context, api string, args interface{}, ret interface{}) error {
 	marshalled, err := json.Marshal(args)
 	if err != nil {
 		return fmt.Errorf("failed to marshal slack message: %v", err)
 	}
-	b := bytes.NewBuffer(marshalled)
-	req, err := http.NewRequest("POST", slackMethodToURL(api), b)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %v", err)
+	if c.skipDryRun(ctx, api, string(marshalled)) {
+		return nil
 	}
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Header.Set("Authorization", "Bearer "+c.Config.AccessToken)
-	return handleSlackRequest(req, ret)
+	return c.callWithRetries(ctx, api, func() (*http.Request, error) {
+		token, err := c.tokenFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", slackMethodToURL(api), bytes.NewBuffer(marshalled))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req, nil
+	}, ret)
 }
 
 // CallOldMethod calls Slack API methods that for some reason continue to not support JSON requests.
 func (c *Client) CallOldMethod(api string, args map[string]string, ret interface{}) error {
+	return c.CallOldMethodContext(context.Background(), api, args, ret)
+}
+
+// CallOldMethodContext is like CallOldMethod, but the call is aborted when ctx is done.
+func (c *Client) CallOldMethodContext(ctx context.Context, api string, args map[string]string, ret interface{}) error {
 	vs := url.Values{}
 	for k, v := range args {
 		vs[k] = []string{v}
 	}
-	vs["token"] = []string{c.Config.AccessToken}
-	q := vs.Encode()
-	b := bytes.NewBufferString(q)
+	if c.skipDryRun(ctx, api, vs.Encode()) {
+		return nil
+	}
 	u := slackMethodToURL(api)
-	req, err := http.NewRequest("POST", u, b)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %v", err)
+	return c.callWithRetries(ctx, api, func() (*http.Request, error) {
+		token, err := c.tokenFor(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vs["token"] = []string{token}
+		req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBufferString(vs.Encode()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		return req, nil
+	}, ret)
+}
+
+// callWithRetries performs the request built by newRequest, sleeping and trying again
+// whenever Slack rate limits us, up to the limits configured on the Client, and backing off and
+// trying again after transient failures according to the RetryPolicy, if that is safe for api
+// (see retriesSafely). If Slack tells us our rotating token has expired, it is refreshed and the
+// request is tried again once. newRequest is called once per attempt, because a request body can
+// only be read once.
+func (c *Client) callWithRetries(ctx context.Context, api string, newRequest func() (*http.Request, error), ret interface{}) error {
+	refreshed := false
+	policy := c.retryPolicy(ctx)
+	rateLimitRetries, transientRetries := 0, 0
+	for {
+		if err := c.RateLimiter.Wait(ctx, api); err != nil {
+			return err
+		}
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		err = c.tracedSlackRequest(ctx, api, req, ret)
+		observeCall(api, start, err)
+		c.logger(ctx).Debug("Called Slack API", "method", methodLabel(api), "duration", time.Since(start), "error", err)
+		if e, ok := err.(APIError); ok && e.Code == "token_expired" && !refreshed && tokenType(ctx) == TokenBot && c.CurrentConfig().canRotateToken() {
+			refreshed = true
+			if err := c.refreshToken(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+		if e, ok := err.(transientError); ok {
+			if transientRetries >= policy.MaxRetries || ctx.Err() != nil || !retriesSafely(api, e) {
+				return e.err
+			}
+			if err := sleepContext(ctx, policy.backoff(transientRetries)); err != nil {
+				return err
+			}
+			transientRetries++
+			continue
+		}
+		rateLimit, ok := err.(ErrRateLimit)
+		if !ok || rateLimitRetries >= c.MaxRateLimitRetries || rateLimit.Wait > c.MaxRateLimitWait {
+			return err
+		}
+		c.logger(ctx).Warn("Slack is rate limiting us", "method", methodLabel(api), "wait", rateLimit.Wait)
+		if err := sleepContext(ctx, rateLimit.Wait); err != nil {
+			return err
+		}
+		rateLimitRetries++
+	}
+}
+
+// sleepContext sleeps for d, or until ctx is done, whichever happens first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// CallMethodPaged calls a cursor-paginated Slack API method, such as conversations.list or
+// users.list, and calls fn with the raw JSON response for every page. It follows
+// response_metadata.next_cursor until Slack reports that there are no more pages, or fn returns
+// an error. Unlike other calls, pages that are rate limited are retried until ctx is done.
+func (c *Client) CallMethodPaged(ctx context.Context, api string, args map[string]string, fn func(page []byte) error) error {
+	pageArgs := make(map[string]string, len(args)+1)
+	for k, v := range args {
+		pageArgs[k] = v
+	}
+	for {
+		var page json.RawMessage
+		if err := c.CallOldMethodContext(ctx, api, pageArgs, &page); err != nil {
+			if e, ok := err.(ErrRateLimit); ok {
+				if err := sleepContext(ctx, e.Wait); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		cursor := struct {
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}{}
+		if err := json.Unmarshal(page, &cursor); err != nil {
+			return fmt.Errorf("failed to decode pagination cursor: %v", err)
+		}
+		if cursor.Metadata.NextCursor == "" {
+			return nil
+		}
+		pageArgs["cursor"] = cursor.Metadata.NextCursor
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
-	return handleSlackRequest(req, ret)
 }
 
 func slackMethodToURL(method string) string {
@@ -85,12 +292,21 @@ func slackMethodToURL(method string) string {
 	return method
 }
 
-func handleSlackRequest(req *http.Request, ret interface{}) error {
-	client := http.Client{}
-	response, err := client.Do(req)
+func (c *Client) handleSlackRequest(req *http.Request, ret interface{}) error {
+	method := methodLabel(req.URL.String())
+	var wrote atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			if info.Err == nil {
+				wrote.Store(true)
+			}
+		},
+	}))
+	response, err := c.roundTrip(req)
 	if err != nil {
-		return fmt.Errorf("failed to POST message to Slack: %v", err)
+		return transientError{err: fmt.Errorf("failed to POST message to Slack: %v", err), unsent: !wrote.Load()}
 	}
+	defer response.Body.Close()
 	if response.StatusCode != http.StatusOK {
 		if response.StatusCode == http.StatusTooManyRequests {
 			retryAfter, err := strconv.ParseInt(response.Header.Get("Retry-After"), 10, 64)
@@ -99,16 +315,19 @@ func handleSlackRequest(req *http.Request, ret interface{}) error {
 			}
 			return ErrRateLimit{Wait: time.Duration(retryAfter) * time.Second}
 		}
-		return fmt.Errorf("sending message to Slack failed")
+		if response.StatusCode >= 500 {
+			return transientError{err: fmt.Errorf("sending message to Slack failed: %s", response.Status)}
+		}
+		// Incoming webhooks explain rejected messages with a plain text error code.
+		if strings.HasPrefix(response.Header.Get("Content-Type"), "text/plain") {
+			if body, err := ioutil.ReadAll(io.LimitReader(response.Body, 1024)); err == nil && len(bytes.TrimSpace(body)) > 0 {
+				return APIError{Code: string(bytes.TrimSpace(body))}
+			}
+		}
+		return fmt.Errorf("sending message to Slack failed: %s", response.Status)
 	}
 	if strings.HasPrefix(response.Header.Get("Content-Type"), "application/json") {
-		result := struct {
-			OK       bool   `json:"ok"`
-			Error    string `json:"error"`
-			Metadata struct {
-				Messages []string `json:"messages"`
-			} `json:"response_metadata"`
-		}{}
+		result := responseEnvelope{}
 		body, err := ioutil.ReadAll(response.Body)
 		if err != nil {
 			return fmt.Errorf("failed to read body: %v", err)
@@ -116,8 +335,16 @@ func handleSlackRequest(req *http.Request, ret interface{}) error {
 		if err := json.Unmarshal(body, &result); err != nil {
 			return fmt.Errorf("failed to decode JSON response: %v", err)
 		}
+		md := result.metadata()
+		c.handleMetadata(req, method, md)
 		if !result.OK {
-			return ErrSlack{Type: result.Error, Warnings: result.Metadata.Messages}
+			return APIError{
+				Code:     result.Error,
+				Warnings: md.Warnings,
+				Messages: md.Messages,
+				Needed:   result.Needed,
+				Provided: result.Provided,
+			}
 		}
 		if ret != nil {
 			if err := json.Unmarshal(body, ret); err != nil {
@@ -130,12 +357,14 @@ func handleSlackRequest(req *http.Request, ret interface{}) error {
 	return nil
 }
 
-// SendMessage sends a simple message to Slack.
+// SendMessage sends a simple message to Slack, using the incoming webhook in the config.
 func (c *Client) SendMessage(message string) error {
-	toSend := struct {
-		Text string `json:"text"`
-	}{message}
-	return c.CallMethod(c.Config.WebhookURL, toSend, nil)
+	return c.SendMessageContext(context.Background(), message)
+}
+
+// SendMessageContext is like SendMessage, but the call is aborted when ctx is done.
+func (c *Client) SendMessageContext(ctx context.Context, message string) error {
+	return c.SendWebhookMessage(ctx, WebhookMessage{Text: message})
 }
 
 // VerifySignature verifies the signature on a message from Slack to ensure it is real.
@@ -163,19 +392,29 @@ func (c *Client) VerifySignature(body []byte, headers http.Header) error {
 	ts := time.Unix(tsInt, 0)
 	now := time.Now()
 	diff := now.Sub(ts)
-	if math.Abs(diff.Minutes()) > 5 {
+	maxAge := c.MaxSignatureAge
+	if maxAge <= 0 {
+		maxAge = defaultMaxSignatureAge
+	}
+	if math.Abs(diff.Seconds()) > maxAge.Seconds() {
 		return fmt.Errorf("clock difference %s too high", diff)
 	}
 
 	// Step 3
+	secrets := c.CurrentConfig().signingSecrets()
+	if len(secrets) == 0 {
+		return fmt.Errorf("no signing secret configured")
+	}
 	sigBase := append([]byte("v0:"+tsHeader+":"), body...)
-	h := hmac.New(sha256.New, []byte(c.Config.SigningSecret))
-	_, _ = h.Write(sigBase)
-	ourSignature := h.Sum(nil)
-	if !hmac.Equal(ourSignature, expectedSignatureBytes) {
-		return fmt.Errorf("signature mismatch")
+	for _, secret := range secrets {
+		h := hmac.New(sha256.New, []byte(secret))
+		_, _ = h.Write(sigBase)
+		ourSignature := h.Sum(nil)
+		if hmac.Equal(ourSignature, expectedSignatureBytes) {
+			return nil
+		}
 	}
-	return nil
+	return fmt.Errorf("signature mismatch")
 }
 
 // EscapeMessage escapes special characters in Slack messages.
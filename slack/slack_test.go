@@ -0,0 +1,453 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret string, ts time.Time, body []byte) http.Header {
+	tsHeader := strconv.FormatInt(ts.Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write(append([]byte("v0:"+tsHeader+":"), body...))
+	headers := http.Header{}
+	headers.Set("X-Slack-Request-Timestamp", tsHeader)
+	headers.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	return headers
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"type":"event_callback"}`)
+	tests := []struct {
+		name      string
+		config    Config
+		secret    string
+		timestamp time.Time
+		maxAge    time.Duration
+		expectErr bool
+	}{
+		{
+			name:      "a fresh request with the right secret is accepted",
+			secret:    "secret",
+			timestamp: time.Now(),
+		},
+		{
+			name:      "a request signed with the wrong secret is rejected",
+			secret:    "wrong",
+			timestamp: time.Now(),
+			expectErr: true,
+		},
+		{
+			name:      "a request signed with a rotated secret is accepted",
+			config:    Config{SigningSecret: "old", SigningSecrets: []string{"secret"}},
+			secret:    "secret",
+			timestamp: time.Now(),
+		},
+		{
+			name:      "a request signed with the primary secret is accepted during rotation",
+			config:    Config{SigningSecret: "secret", SigningSecrets: []string{"new"}},
+			secret:    "secret",
+			timestamp: time.Now(),
+		},
+		{
+			name:      "a request is rejected when no secret is configured",
+			config:    Config{SigningSecrets: []string{""}},
+			secret:    "",
+			timestamp: time.Now(),
+			expectErr: true,
+		},
+		{
+			name:      "a request older than the default window is rejected",
+			secret:    "secret",
+			timestamp: time.Now().Add(-6 * time.Minute),
+			expectErr: true,
+		},
+		{
+			name:      "a request from the future is rejected",
+			secret:    "secret",
+			timestamp: time.Now().Add(6 * time.Minute),
+			expectErr: true,
+		},
+		{
+			name:      "a request inside a custom window is accepted",
+			secret:    "secret",
+			timestamp: time.Now().Add(-6 * time.Minute),
+			maxAge:    10 * time.Minute,
+		},
+		{
+			name:      "a request outside a custom window is rejected",
+			secret:    "secret",
+			timestamp: time.Now().Add(-90 * time.Second),
+			maxAge:    time.Minute,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := tc.config
+			if config.SigningSecret == "" && len(config.SigningSecrets) == 0 {
+				config.SigningSecret = "secret"
+			}
+			c := New(config)
+			if tc.maxAge != 0 {
+				c.MaxSignatureAge = tc.maxAge
+			}
+			err := c.VerifySignature(body, sign(tc.secret, tc.timestamp, body))
+			if tc.expectErr && err == nil {
+				t.Errorf("expected an error, but got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCallMethodRateLimitRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		rateLimited   int
+		maxRetries    int
+		retryAfter    string
+		expectErr     bool
+		expectedCalls int
+	}{
+		{
+			name:          "a call that isn't rate limited is made once",
+			maxRetries:    3,
+			expectedCalls: 1,
+		},
+		{
+			name:          "a rate limited call is retried until it succeeds",
+			rateLimited:   2,
+			maxRetries:    3,
+			retryAfter:    "0",
+			expectedCalls: 3,
+		},
+		{
+			name:          "a call is given up on after the maximum number of retries",
+			rateLimited:   5,
+			maxRetries:    2,
+			retryAfter:    "0",
+			expectErr:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "a call is not retried if Slack wants us to wait too long",
+			rateLimited:   1,
+			maxRetries:    3,
+			retryAfter:    "3600",
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tc.rateLimited {
+					w.Header().Set("Retry-After", tc.retryAfter)
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+
+			c := New(Config{AccessToken: "xoxb-token"})
+			c.HTTPClient = server.Client()
+			c.MaxRateLimitRetries = tc.maxRetries
+			err := c.CallMethod(server.URL+"/chat.postMessage", map[string]string{"text": "honk"}, nil)
+			if tc.expectErr && err == nil {
+				t.Errorf("expected an error, but got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, but got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestCallMethodTransientRetries(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+	tests := []struct {
+		name          string
+		method        string
+		status        int
+		failures      int
+		policy        *RetryPolicy
+		expectErr     bool
+		expectedCalls int
+	}{
+		{
+			name:          "a server error is retried until the call succeeds",
+			method:        "conversations.history",
+			status:        http.StatusInternalServerError,
+			failures:      2,
+			expectedCalls: 3,
+		},
+		{
+			name:          "a server error is given up on after the maximum number of retries",
+			method:        "conversations.history",
+			status:        http.StatusBadGateway,
+			failures:      5,
+			expectErr:     true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "a client error is not retried",
+			method:        "conversations.history",
+			status:        http.StatusBadRequest,
+			failures:      1,
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "the retry policy can be overridden per call",
+			method:        "conversations.history",
+			status:        http.StatusServiceUnavailable,
+			failures:      1,
+			policy:        &NoRetries,
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "a server error posting a message is not retried, since it may have been posted",
+			method:        "chat.postMessage",
+			status:        http.StatusInternalServerError,
+			failures:      1,
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tc.failures {
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+
+			c := New(Config{AccessToken: "xoxb-token"})
+			c.HTTPClient = server.Client()
+			c.RetryPolicy = policy
+			serverURL, _ := url.Parse(server.URL)
+			c.Use(func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					req.URL.Scheme, req.URL.Host = serverURL.Scheme, serverURL.Host
+					return next(req)
+				}
+			})
+			ctx := context.Background()
+			if tc.policy != nil {
+				ctx = WithRetryPolicy(ctx, *tc.policy)
+			}
+			err := c.CallMethodContext(ctx, tc.method, map[string]string{"text": "honk"}, nil)
+			if tc.expectErr && err == nil {
+				t.Errorf("expected an error, but got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, but got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestCallMethodConnectionErrorRetries(t *testing.T) {
+	tests := []struct {
+		name          string
+		sent          bool
+		expectedCalls int
+	}{
+		{
+			name:          "a message that never got to Slack is retried",
+			expectedCalls: 3,
+		},
+		{
+			name:          "a message that was sent before the connection failed is not retried",
+			sent:          true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"ok": true}`))
+			}))
+			defer server.Close()
+
+			c := New(Config{AccessToken: "xoxb-token"})
+			c.HTTPClient = server.Client()
+			c.RetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+			c.Use(func(next RoundTripFunc) RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					calls++
+					if tc.sent {
+						if resp, err := next(req); err == nil {
+							resp.Body.Close()
+						}
+					}
+					return nil, fmt.Errorf("connection reset")
+				}
+			})
+			err := c.CallMethodContext(context.Background(), server.URL+"/chat.postMessage", map[string]string{"text": "honk"}, nil)
+			if err == nil {
+				t.Errorf("expected an error, but got none")
+			}
+			if calls != tc.expectedCalls {
+				t.Errorf("expected %d calls, but got %d", tc.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestCallMethodAPIError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": false, "error": "missing_scope", "needed": "chat:write", "provided": "channels:read"}`))
+	}))
+	defer server.Close()
+
+	c := New(Config{AccessToken: "xoxb-token"})
+	c.HTTPClient = server.Client()
+	err := c.CallMethod(server.URL+"/chat.postMessage", map[string]string{"text": "honk"}, nil)
+	expected := APIError{Code: "missing_scope", Needed: "chat:write", Provided: "channels:read"}
+	if e, ok := err.(APIError); !ok || !reflect.DeepEqual(e, expected) {
+		t.Errorf("expected error %#v, but got %#v", expected, err)
+	}
+	if code := ErrorCode(fmt.Errorf("wrapped: %w", err)); code != "missing_scope" {
+		t.Errorf("expected ErrorCode to return missing_scope, but got %q", code)
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	var order []string
+	record := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				resp, err := next(req)
+				order = append(order, name+" response")
+				return resp, err
+			}
+		}
+	}
+
+	c := New(Config{AccessToken: "xoxb-token"})
+	c.HTTPClient = server.Client()
+	c.Use(record("outer"), record("inner"))
+	if err := c.CallMethod(server.URL+"/auth.test", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"outer request", "inner request", "inner response", "outer response"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected middleware to run in order %v, but got %v", expected, order)
+	}
+}
+
+func TestResponseMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		expected    ResponseMetadata
+		expectedErr string
+	}{
+		{
+			name:     "no metadata",
+			response: `{"ok": true}`,
+		},
+		{
+			name:     "warnings in both places are merged",
+			response: `{"ok": true, "warning": "missing_charset,superfluous_charset", "response_metadata": {"warnings": ["missing_charset"], "messages": ["[WARN] A Content-Type HTTP header was presented but did not declare a charset, such as a 'utf-8'"]}}`,
+			expected: ResponseMetadata{
+				Warnings: []string{"missing_charset", "superfluous_charset"},
+				Messages: []string{"[WARN] A Content-Type HTTP header was presented but did not declare a charset, such as a 'utf-8'"},
+			},
+		},
+		{
+			name:        "errors carry their metadata",
+			response:    `{"ok": false, "error": "invalid_arguments", "response_metadata": {"messages": ["[ERROR] missing required field: channel"]}}`,
+			expected:    ResponseMetadata{Messages: []string{"[ERROR] missing required field: channel"}},
+			expectedErr: "slack call failed: invalid_arguments ([[ERROR] missing required field: channel])",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			})
+			defer done()
+
+			md := ResponseMetadata{}
+			err := c.CallMethodContext(WithResponseMetadata(context.Background(), &md), "chat.postMessage", nil, nil)
+			if tc.expectedErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Fatalf("expected error %q, but got %v", tc.expectedErr, err)
+				}
+				if e, ok := err.(APIError); !ok || !reflect.DeepEqual(e.Messages, tc.expected.Messages) {
+					t.Errorf("expected the error to carry messages %v, but got %#v", tc.expected.Messages, err)
+				}
+			}
+			if !reflect.DeepEqual(md, tc.expected) {
+				t.Errorf("expected metadata %+v, but got %+v", tc.expected, md)
+			}
+		})
+	}
+}
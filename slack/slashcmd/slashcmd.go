@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package slashcmd receives Slack slash commands, over HTTP or Socket Mode, and routes each
+// command to the function registered for it.
+package slashcmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/blocks"
+	"sigs.k8s.io/slack-infra/slack/socketmode"
+)
+
+// Response types, which control who can see a Response.
+const (
+	// Ephemeral responses are only shown to the user who ran the command. This is the default.
+	Ephemeral = "ephemeral"
+	// InChannel responses are posted to the channel the command was run in.
+	InChannel = "in_channel"
+)
+
+// Command is a slash command invocation.
+// See https://api.slack.com/interactivity/slash-commands#app_command_handling
+type Command struct {
+	Command      string `json:"command"`
+	Text         string `json:"text"`
+	ResponseURL  string `json:"response_url"`
+	TriggerID    string `json:"trigger_id"`
+	UserID       string `json:"user_id"`
+	UserName     string `json:"user_name"`
+	ChannelID    string `json:"channel_id"`
+	ChannelName  string `json:"channel_name"`
+	TeamID       string `json:"team_id"`
+	TeamDomain   string `json:"team_domain"`
+	EnterpriseID string `json:"enterprise_id"`
+	APIAppID     string `json:"api_app_id"`
+
+	client *slack.Client
+}
+
+// Response is a message sent in reply to a Command, either as the immediate response or later
+// using Respond.
+type Response struct {
+	ResponseType    string         `json:"response_type,omitempty"`
+	Text            string         `json:"text,omitempty"`
+	Blocks          []blocks.Block `json:"blocks,omitempty"`
+	ReplaceOriginal bool           `json:"replace_original,omitempty"`
+	DeleteOriginal  bool           `json:"delete_original,omitempty"`
+}
+
+// Parse parses a slash command payload, which is form-encoded when it comes from a webhook and
+// JSON when it comes over Socket Mode. It does not verify the payload.
+func Parse(body []byte) (*Command, error) {
+	cmd := &Command{}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		if err := json.Unmarshal(body, cmd); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal command: %v", err)
+		}
+	} else {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse command: %v", err)
+		}
+		*cmd = Command{
+			Command:      form.Get("command"),
+			Text:         form.Get("text"),
+			ResponseURL:  form.Get("response_url"),
+			TriggerID:    form.Get("trigger_id"),
+			UserID:       form.Get("user_id"),
+			UserName:     form.Get("user_name"),
+			ChannelID:    form.Get("channel_id"),
+			ChannelName:  form.Get("channel_name"),
+			TeamID:       form.Get("team_id"),
+			TeamDomain:   form.Get("team_domain"),
+			EnterpriseID: form.Get("enterprise_id"),
+			APIAppID:     form.Get("api_app_id"),
+		}
+	}
+	if cmd.Command == "" {
+		return nil, fmt.Errorf("payload has no command")
+	}
+	return cmd, nil
+}
+
+// Client returns the Client for the workspace the command came from. It is nil for commands
+// returned by Parse.
+func (cmd *Command) Client() *slack.Client {
+	return cmd.client
+}
+
+// Respond sends r to the command's response_url. Slack accepts up to five responses within 30
+// minutes of the command being run, so handlers that take longer than Slack's three second
+// timeout can return nil and respond from another goroutine instead.
+func (cmd *Command) Respond(ctx context.Context, r Response) error {
+	if cmd.client == nil {
+		return fmt.Errorf("command %s has no client to respond with", cmd.Command)
+	}
+	if cmd.ResponseURL == "" {
+		return fmt.Errorf("command %s has no response_url", cmd.Command)
+	}
+	return cmd.client.CallMethodContext(ctx, cmd.ResponseURL, r, nil)
+}
+
+// HandlerFunc handles a single command. The returned Response, if any, is sent as the immediate
+// response to the command; return nil to acknowledge the command without a visible response.
+type HandlerFunc func(ctx context.Context, cmd *Command) (*Response, error)
+
+// Handler verifies incoming slash commands and calls the HandlerFunc registered for each one.
+type Handler struct {
+	clients  *slack.ClientSet
+	handlers map[string]HandlerFunc
+}
+
+// NewHandler returns a Handler that verifies commands from each workspace using the
+// corresponding Client in clients.
+func NewHandler(clients *slack.ClientSet) *Handler {
+	return &Handler{clients: clients, handlers: map[string]HandlerFunc{}}
+}
+
+// HandleFunc registers fn to handle the given command, such as "/filters". It must be called
+// before the Handler starts receiving commands.
+func (h *Handler) HandleFunc(command string, fn HandlerFunc) {
+	h.handlers[command] = fn
+}
+
+// ServeHTTP handles slash command requests.
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		logError(rw, "Failed to read incoming request body: %v", err)
+		return
+	}
+	defer r.Body.Close()
+
+	client, err := h.clients.ForPayload(body)
+	if err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+	if err := client.VerifySignature(body, r.Header); err != nil {
+		logError(rw, "Failed validation: %v", err)
+		return
+	}
+
+	response, err := h.handle(r.Context(), client, body)
+	if err != nil {
+		logError(rw, "%v", err)
+		return
+	}
+	if response == nil {
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(response)
+}
+
+// HandleSocketModeEnvelope is a socketmode.Handler that handles commands received over Socket
+// Mode, sending the immediate response in the acknowledgement.
+func (h *Handler) HandleSocketModeEnvelope(ctx context.Context, envelopeType string, payload []byte) ([]byte, error) {
+	if envelopeType != socketmode.TypeSlashCommands {
+		return nil, nil
+	}
+	client, err := h.clients.ForPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	return h.handle(ctx, client, payload)
+}
+
+// handle parses a verified command and runs its HandlerFunc, returning the marshalled immediate
+// response, if there is one.
+func (h *Handler) handle(ctx context.Context, client *slack.Client, body []byte) ([]byte, error) {
+	cmd, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	cmd.client = client
+	ctx = logging.With(ctx, "team_id", cmd.TeamID, "user_id", cmd.UserID, "channel", cmd.ChannelID, "command", cmd.Command)
+	fn, ok := h.handlers[cmd.Command]
+	if !ok {
+		return nil, fmt.Errorf("no handler for command %s", cmd.Command)
+	}
+	response, err := fn(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to handle command %s: %v", cmd.Command, err)
+	}
+	if response == nil {
+		return nil, nil
+	}
+	marshalled, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response: %v", err)
+	}
+	return marshalled, nil
+}
+
+func logError(rw http.ResponseWriter, format string, args ...interface{}) {
+	s := fmt.Sprintf(format, args...)
+	slog.Error(s)
+	http.Error(rw, s, http.StatusInternalServerError)
+}
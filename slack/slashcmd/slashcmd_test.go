@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slashcmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+const testSecret = "secret"
+
+func newRequest(body string, secret string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = h.Write([]byte("v0:" + ts + ":" + body))
+	req := httptest.NewRequest("POST", "/command", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", ts)
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(h.Sum(nil)))
+	return req
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expected    Command
+		expectError bool
+	}{
+		{
+			name: "form encoded",
+			body: "command=%2Ffilters&text=list+all&user_id=U1&channel_id=C1&team_id=T1&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2F1&trigger_id=123.456",
+			expected: Command{
+				Command:     "/filters",
+				Text:        "list all",
+				UserID:      "U1",
+				ChannelID:   "C1",
+				TeamID:      "T1",
+				ResponseURL: "https://hooks.slack.com/commands/1",
+				TriggerID:   "123.456",
+			},
+		},
+		{
+			name: "json from socket mode",
+			body: `{"command": "/filters", "text": "list", "user_id": "U1", "team_id": "T1"}`,
+			expected: Command{
+				Command: "/filters",
+				Text:    "list",
+				UserID:  "U1",
+				TeamID:  "T1",
+			},
+		},
+		{
+			name:        "no command",
+			body:        "text=list&user_id=U1",
+			expectError: true,
+		},
+		{
+			name:        "invalid json",
+			body:        `{"command": `,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd, err := Parse([]byte(tc.body))
+			if tc.expectError {
+				if err == nil {
+					t.Errorf("expected an error, but got %+v", cmd)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *cmd != tc.expected {
+				t.Errorf("expected %+v, but got %+v", tc.expected, *cmd)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		secret         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "response",
+			body:           "command=%2Fecho&text=honk&team_id=T1",
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"response_type":"ephemeral","text":"honk"}`,
+		},
+		{
+			name:           "no response",
+			body:           "command=%2Fquiet&team_id=T1",
+			secret:         testSecret,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown command",
+			body:           "command=%2Fother&team_id=T1",
+			secret:         testSecret,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "bad signature",
+			body:           "command=%2Fecho&text=honk&team_id=T1",
+			secret:         "wrong",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHandler(slack.NewClientSet(slack.New(slack.Config{SigningSecret: testSecret})))
+			h.HandleFunc("/echo", func(ctx context.Context, cmd *Command) (*Response, error) {
+				return &Response{ResponseType: Ephemeral, Text: cmd.Text}, nil
+			})
+			h.HandleFunc("/quiet", func(ctx context.Context, cmd *Command) (*Response, error) {
+				return nil, nil
+			})
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, newRequest(tc.body, tc.secret))
+			if rr.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, but got %d", tc.expectedStatus, rr.Code)
+			}
+			if tc.expectedStatus == http.StatusOK && rr.Body.String() != tc.expectedBody {
+				t.Errorf("expected body %q, but got %q", tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	var received Response
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("failed to unmarshal response: %v", err)
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := slack.New(slack.Config{SigningSecret: testSecret, AccessToken: "xoxb-test"})
+	client.HTTPClient = server.Client()
+	h := NewHandler(slack.NewClientSet(client))
+	done := make(chan error)
+	h.HandleFunc("/slow", func(ctx context.Context, cmd *Command) (*Response, error) {
+		go func() {
+			done <- cmd.Respond(context.Background(), Response{ResponseType: InChannel, Text: "done"})
+		}()
+		return nil, nil
+	})
+
+	body := "command=%2Fslow&team_id=T1&response_url=" + server.URL + "%2Fcommands%2F1"
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, newRequest(body, testSecret))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, but got %d", rr.Code)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error responding: %v", err)
+	}
+	if received.ResponseType != InChannel || received.Text != "done" {
+		t.Errorf("expected an in_channel response saying done, but got %+v", received)
+	}
+}
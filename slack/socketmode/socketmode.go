@@ -0,0 +1,185 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package socketmode receives Slack payloads over a Socket Mode WebSocket connection, which lets
+// our tools run without a publicly reachable HTTPS endpoint.
+package socketmode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/slack-infra/logging"
+	"sigs.k8s.io/slack-infra/slack"
+)
+
+// Envelope types sent by Slack over Socket Mode.
+const (
+	TypeEventsAPI     = "events_api"
+	TypeInteractive   = "interactive"
+	TypeSlashCommands = "slash_commands"
+	typeHello         = "hello"
+	typeDisconnect    = "disconnect"
+)
+
+const instrumentationName = "sigs.k8s.io/slack-infra/slack/socketmode"
+
+// reconnectDelay is how long we wait before opening a new connection after one fails.
+const reconnectDelay = 5 * time.Second
+
+// Handler handles the payload of a Socket Mode envelope. The payload is the same JSON that Slack
+// would have sent to a webhook. If Slack accepts a response payload for the envelope, any bytes
+// returned are sent back as that response.
+type Handler func(ctx context.Context, envelopeType string, payload []byte) ([]byte, error)
+
+// Client maintains a Socket Mode connection to Slack.
+type Client struct {
+	// apps.connections.open must be called with an app-level token, rather than the bot token.
+	app    *slack.Client
+	dialer *websocket.Dialer
+}
+
+// New returns a new Client using the app-level token in config.
+func New(config slack.Config) (*Client, error) {
+	if config.AppToken == "" {
+		return nil, fmt.Errorf("socket mode requires an appToken in the slack config")
+	}
+	return &Client{
+		app:    slack.New(slack.Config{AccessToken: config.AppToken}),
+		dialer: websocket.DefaultDialer,
+	}, nil
+}
+
+type envelope struct {
+	EnvelopeID             string          `json:"envelope_id"`
+	Type                   string          `json:"type"`
+	Payload                json.RawMessage `json:"payload"`
+	AcceptsResponsePayload bool            `json:"accepts_response_payload"`
+	RetryAttempt           int             `json:"retry_attempt"`
+	Reason                 string          `json:"reason"`
+}
+
+type ack struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+}
+
+// Run connects to Slack and passes every envelope received to h, reconnecting whenever Slack
+// closes the connection, until ctx is done.
+func (c *Client) Run(ctx context.Context, h Handler) error {
+	for {
+		if err := c.runConnection(ctx, h); err != nil {
+			logging.FromContext(ctx).Error("Socket mode connection failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (c *Client) openURL(ctx context.Context) (string, error) {
+	resp := struct {
+		URL string `json:"url"`
+	}{}
+	if err := c.app.CallMethodContext(ctx, "apps.connections.open", nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to open connection: %v", err)
+	}
+	return resp.URL, nil
+}
+
+func (c *Client) runConnection(ctx context.Context, h Handler) error {
+	url, err := c.openURL(ctx)
+	if err != nil {
+		return err
+	}
+	conn, _, err := c.dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %v", url, err)
+	}
+	defer conn.Close()
+
+	// Unblock ReadJSON if we are asked to stop.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	// gorilla/websocket allows only one concurrent writer.
+	var writeLock sync.Mutex
+	send := func(a ack) {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+		if err := conn.WriteJSON(a); err != nil {
+			logging.FromContext(ctx).Error("Failed to acknowledge envelope", "envelope_id", a.EnvelopeID, "error", err)
+		}
+	}
+
+	for {
+		e := envelope{}
+		if err := conn.ReadJSON(&e); err != nil {
+			return fmt.Errorf("failed to read envelope: %v", err)
+		}
+		switch e.Type {
+		case typeHello:
+			logging.FromContext(ctx).Info("Socket mode connection established")
+			continue
+		case typeDisconnect:
+			logging.FromContext(ctx).Info("Slack asked us to reconnect", "reason", e.Reason)
+			return nil
+		}
+
+		// Slack expects an acknowledgement within three seconds. If it doesn't care about the
+		// response we ack immediately, otherwise we have to wait for the handler.
+		if !e.AcceptsResponsePayload {
+			send(ack{EnvelopeID: e.EnvelopeID})
+		}
+		go func(e envelope) {
+			ctx, span := otel.Tracer(instrumentationName).Start(ctx, "socketmode "+e.Type,
+				trace.WithSpanKind(trace.SpanKindConsumer),
+				trace.WithAttributes(attribute.Int("slack.retry_attempt", e.RetryAttempt)))
+			defer span.End()
+			response, err := h(ctx, e.Type, e.Payload)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				logging.FromContext(ctx).Error("Failed to handle envelope", "type", e.Type, "envelope_id", e.EnvelopeID, "error", err)
+			}
+			if e.AcceptsResponsePayload {
+				a := ack{EnvelopeID: e.EnvelopeID}
+				if len(response) > 0 {
+					a.Payload = response
+				}
+				send(a)
+			}
+		}(e)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"fmt"
+)
+
+// TokenType selects which of the Client's tokens a call is made with.
+type TokenType int
+
+const (
+	// TokenBot is the access token from Config.AccessToken, normally a bot token. Calls use it
+	// unless told otherwise.
+	TokenBot TokenType = iota
+	// TokenOrg is the org-level user token from Config.OrgToken, which Enterprise Grid admin.*
+	// methods require.
+	TokenOrg
+	// TokenUser is the user token from Config.UserToken, which methods that act as a user, such
+	// as search.messages, require. If no UserToken is configured, the access token is used, which
+	// works if it is itself a user token.
+	TokenUser
+)
+
+type tokenTypeKey struct{}
+
+// WithToken returns a context that makes calls using it authenticate with the given TokenType.
+func WithToken(ctx context.Context, t TokenType) context.Context {
+	return context.WithValue(ctx, tokenTypeKey{}, t)
+}
+
+// tokenType returns the TokenType selected by ctx.
+func tokenType(ctx context.Context) TokenType {
+	t, _ := ctx.Value(tokenTypeKey{}).(TokenType)
+	return t
+}
+
+// Token returns the token that a call made with ctx authenticates with, for APIs such as SCIM that
+// are called with Do rather than CallMethodContext.
+func (c *Client) Token(ctx context.Context) (string, error) {
+	return c.tokenFor(ctx)
+}
+
+// tokenFor returns the token that a call made with ctx should use.
+func (c *Client) tokenFor(ctx context.Context) (string, error) {
+	switch tokenType(ctx) {
+	case TokenOrg:
+		token := c.CurrentConfig().OrgToken
+		if token == "" {
+			return "", fmt.Errorf("call requires an org token, but no orgToken is configured")
+		}
+		return token, nil
+	case TokenUser:
+		if token := c.CurrentConfig().UserToken; token != "" {
+			return token, nil
+		}
+	}
+	return c.accessToken(ctx)
+}
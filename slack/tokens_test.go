@@ -0,0 +1,69 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestWithToken(t *testing.T) {
+	var authorization string
+	c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	})
+	defer done()
+
+	if err := c.CallMethodContext(context.Background(), "admin.users.list", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authorization != "Bearer xoxb-token" {
+		t.Errorf("expected the bot token by default, but got %q", authorization)
+	}
+
+	ctx := WithToken(context.Background(), TokenOrg)
+	if err := c.CallMethodContext(ctx, "admin.users.list", nil, nil); err == nil {
+		t.Errorf("expected an error selecting an org token that isn't configured")
+	}
+
+	c.UpdateConfig(Config{AccessToken: "xoxb-token", OrgToken: "xoxp-org"})
+	if err := c.CallMethodContext(ctx, "admin.users.list", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authorization != "Bearer xoxp-org" {
+		t.Errorf("expected the org token, but got %q", authorization)
+	}
+
+	ctx = WithToken(context.Background(), TokenUser)
+	if err := c.CallMethodContext(ctx, "search.messages", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authorization != "Bearer xoxb-token" {
+		t.Errorf("expected the access token without a user token, but got %q", authorization)
+	}
+
+	c.UpdateConfig(Config{AccessToken: "xoxb-token", UserToken: "xoxp-user"})
+	if err := c.CallMethodContext(ctx, "search.messages", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if authorization != "Bearer xoxp-user" {
+		t.Errorf("expected the user token, but got %q", authorization)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "sigs.k8s.io/slack-infra/slack"
+
+// tracedSlackRequest performs a single request to Slack inside a span that is a child of any span
+// in ctx. The span is a no-op unless a tracer provider has been installed.
+func (c *Client) tracedSlackRequest(ctx context.Context, api string, req *http.Request, ret interface{}) error {
+	method := methodLabel(api)
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, "slack "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("slack.method", method)))
+	defer span.End()
+
+	err := c.handleSlackRequest(req.WithContext(ctx), ret)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		switch e := err.(type) {
+		case APIError:
+			span.SetAttributes(attribute.String("slack.error", e.Code))
+		case ErrRateLimit:
+			span.SetAttributes(attribute.String("slack.retry_after", e.Wait.String()))
+		}
+	}
+	return err
+}
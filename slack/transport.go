@@ -0,0 +1,104 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig configures how we connect to the Slack API. The zero value behaves like
+// http.DefaultTransport, including honoring the HTTPS_PROXY and NO_PROXY environment variables.
+type TransportConfig struct {
+	// ProxyURL is the proxy to send all requests through, overriding the environment.
+	ProxyURL string `json:"proxyURL,omitempty"`
+	// CABundle is the path to a PEM file of certificate authorities to trust in addition to the
+	// system ones.
+	CABundle string `json:"caBundle,omitempty"`
+	// MaxIdleConns and MaxIdleConnsPerHost tune the connection pool for high-volume callers.
+	MaxIdleConns        int `json:"maxIdleConns,omitempty"`
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost,omitempty"`
+	// IdleConnTimeout is how long idle connections are kept open, e.g. "90s".
+	IdleConnTimeout string `json:"idleConnTimeout,omitempty"`
+	// Timeout limits how long a single request may take, e.g. "30s". Zero means no limit.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// NewHTTPClient returns an *http.Client configured according to t.
+func NewHTTPClient(t TransportConfig) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if t.ProxyURL != "" {
+		u, err := url.Parse(t.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse proxy URL %q: %v", t.ProxyURL, err)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+	if t.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := ioutil.ReadFile(t.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read CA bundle: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", t.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	if t.MaxIdleConns != 0 {
+		transport.MaxIdleConns = t.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	if t.IdleConnTimeout != "" {
+		d, err := time.ParseDuration(t.IdleConnTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse idleConnTimeout: %v", err)
+		}
+		transport.IdleConnTimeout = d
+	}
+	client := &http.Client{Transport: transport}
+	if t.Timeout != "" {
+		d, err := time.ParseDuration(t.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse timeout: %v", err)
+		}
+		client.Timeout = d
+	}
+	return client, nil
+}
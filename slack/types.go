@@ -42,6 +42,12 @@ type User struct {
 		Image192         string `json:"image_192"`
 		Image512         string `json:"image_512"`
 		Team             string `json:"team"`
+		Title            string `json:"title"`
+		// Fields are the workspace's custom profile fields, by ID.
+		Fields map[string]struct {
+			Value string `json:"value"`
+			Alt   string `json:"alt"`
+		} `json:"fields"`
 	} `json:"profile"`
 	IsAdmin           bool   `json:"is_admin"`
 	IsOwner           bool   `json:"is_owner"`
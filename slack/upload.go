@@ -0,0 +1,46 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// uploadContentMethod labels uploads to the URLs returned by files.getUploadURLExternal in metrics,
+// rate limiting and dry-run logs. It isn't a real method; files.upload is a different, deprecated
+// one.
+const uploadContentMethod = "files.uploadContent"
+
+// UploadContent uploads content to an upload URL returned by files.getUploadURLExternal. The URL
+// is already authorized, so no token is sent with the request. Rate limits and transient
+// failures are retried just like any other call, and it is skipped in dry-run mode.
+func (c *Client) UploadContent(ctx context.Context, uploadURL string, content []byte) error {
+	if c.skipDryRun(ctx, uploadContentMethod, fmt.Sprintf("%d bytes", len(content))) {
+		return nil
+	}
+	return c.callWithRetries(ctx, uploadContentMethod, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/octet-stream")
+		return req, nil
+	}, nil)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUserCacheTTL     = 15 * time.Minute
+	defaultUserCacheMaxSize = 10000
+)
+
+// UserCache caches users.info lookups by user ID, so that log lines and notifications can include
+// users' names without calling the Slack API for every event.
+type UserCache struct {
+	// TTL is how long a user is cached before being looked up again.
+	TTL time.Duration
+	// MaxSize is the most users the cache holds. The least recently used users are evicted first.
+	MaxSize int
+
+	client  *Client
+	lock    sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type userCacheEntry struct {
+	user    User
+	fetched time.Time
+}
+
+// NewUserCache returns a UserCache that looks users up using client.
+func NewUserCache(client *Client) *UserCache {
+	return &UserCache{
+		TTL:     defaultUserCacheTTL,
+		MaxSize: defaultUserCacheMaxSize,
+		client:  client,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// Users returns the Client's UserCache, creating it the first time it is needed, so every user
+// of a Client shares the same cache.
+func (c *Client) Users() *UserCache {
+	c.cacheOnce.Do(c.initCaches)
+	return c.users
+}
+
+// Get returns the user with the given ID, calling users.info if it isn't cached or has expired.
+func (uc *UserCache) Get(ctx context.Context, id string) (User, error) {
+	uc.lock.Lock()
+	if e, ok := uc.entries[id]; ok {
+		entry := e.Value.(*userCacheEntry)
+		if time.Since(entry.fetched) < uc.TTL {
+			uc.order.MoveToFront(e)
+			uc.lock.Unlock()
+			return entry.user, nil
+		}
+	}
+	uc.lock.Unlock()
+
+	resp := struct {
+		User User `json:"user"`
+	}{}
+	if err := uc.client.CallOldMethodContext(ctx, "users.info", map[string]string{"user": id}, &resp); err != nil {
+		return User{}, err
+	}
+	uc.Store(resp.User)
+	return resp.User, nil
+}
+
+// DisplayName returns the name the user with the given ID is shown as in Slack, falling back to
+// the ID itself if they can't be looked up. It is intended for log lines, where a failed lookup
+// shouldn't stop anything.
+func (uc *UserCache) DisplayName(ctx context.Context, id string) string {
+	user, err := uc.Get(ctx, id)
+	if err != nil {
+		return id
+	}
+	switch {
+	case user.Profile.DisplayName != "":
+		return user.Profile.DisplayName
+	case user.Profile.RealName != "":
+		return user.Profile.RealName
+	case user.Name != "":
+		return user.Name
+	default:
+		return id
+	}
+}
+
+// Store adds user to the cache, replacing any previous version. It can be used to keep the cache
+// up to date from user_change events.
+func (uc *UserCache) Store(user User) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+	entry := &userCacheEntry{user: user, fetched: time.Now()}
+	if e, ok := uc.entries[user.ID]; ok {
+		e.Value = entry
+		uc.order.MoveToFront(e)
+		return
+	}
+	uc.entries[user.ID] = uc.order.PushFront(entry)
+	for uc.MaxSize > 0 && uc.order.Len() > uc.MaxSize {
+		oldest := uc.order.Back()
+		uc.order.Remove(oldest)
+		delete(uc.entries, oldest.Value.(*userCacheEntry).user.ID)
+	}
+}
+
+// Invalidate removes the user with the given ID from the cache.
+func (uc *UserCache) Invalidate(id string) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+	if e, ok := uc.entries[id]; ok {
+		uc.order.Remove(e)
+		delete(uc.entries, id)
+	}
+}
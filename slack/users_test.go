@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestUserCache(t *testing.T) {
+	lookups := map[string]int{}
+	c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		id := r.Form.Get("user")
+		lookups[id]++
+		w.Header().Set("Content-Type", "application/json")
+		if id == "UNOPE" {
+			_, _ = w.Write([]byte(`{"ok": false, "error": "user_not_found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok": true, "user": {"id": "` + id + `", "name": "name-` + id + `", "profile": {"display_name": "display-` + id + `"}}}`))
+	})
+	defer done()
+	ctx := context.Background()
+	cache := NewUserCache(c)
+	cache.MaxSize = 2
+
+	if name := cache.DisplayName(ctx, "U1"); name != "display-U1" {
+		t.Errorf("expected display name display-U1, but got %q", name)
+	}
+	if _, err := cache.Get(ctx, "U1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if lookups["U1"] != 1 {
+		t.Errorf("expected U1 to be looked up once, but it was looked up %d times", lookups["U1"])
+	}
+
+	// U1 is the least recently used, so it should be evicted to make room for U3.
+	_, _ = cache.Get(ctx, "U2")
+	_, _ = cache.Get(ctx, "U3")
+	_, _ = cache.Get(ctx, "U1")
+	if lookups["U1"] != 2 {
+		t.Errorf("expected U1 to be evicted and looked up again, but it was looked up %d times", lookups["U1"])
+	}
+
+	cache.TTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	_, _ = cache.Get(ctx, "U1")
+	if lookups["U1"] != 3 {
+		t.Errorf("expected expired U1 to be looked up again, but it was looked up %d times", lookups["U1"])
+	}
+
+	cache.Store(User{ID: "U4", Name: "stored"})
+	cache.TTL = time.Hour
+	if name := cache.DisplayName(ctx, "U4"); name != "stored" || lookups["U4"] != 0 {
+		t.Errorf("expected stored user U4 to be returned without a lookup, but got %q after %d lookups", name, lookups["U4"])
+	}
+
+	if name := cache.DisplayName(ctx, "UNOPE"); name != "UNOPE" {
+		t.Errorf("expected an unknown user's display name to be their ID, but got %q", name)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+// WebhookMessage is a message posted to an incoming webhook. Incoming webhooks always post to the
+// channel chosen when they were created, so there is no channel to set.
+type WebhookMessage struct {
+	// Text is shown in notifications, and in the message itself unless there are Blocks.
+	Text   string         `json:"text"`
+	Blocks []blocks.Block `json:"blocks,omitempty"`
+	// ThreadTS posts the message as a reply in a thread of the webhook's channel.
+	ThreadTS    string `json:"thread_ts,omitempty"`
+	UnfurlLinks *bool  `json:"unfurl_links,omitempty"`
+	UnfurlMedia *bool  `json:"unfurl_media,omitempty"`
+}
+
+// WebhookOnly returns whether the config has an incoming webhook but no way to get a token, so the
+// only thing a Client can do with it is post to the webhook.
+func (c Config) WebhookOnly() bool {
+	return c.WebhookURL != "" && c.AccessToken == "" && !c.canRotateToken()
+}
+
+// SendWebhookMessage posts msg to the incoming webhook in the Client's config. It doesn't need a
+// token, so it works for teams that only have a webhook.
+func (c *Client) SendWebhookMessage(ctx context.Context, msg WebhookMessage) error {
+	webhook := c.CurrentConfig().WebhookURL
+	if webhook == "" {
+		return fmt.Errorf("no webhook is configured")
+	}
+	return c.PostWebhook(ctx, webhook, msg)
+}
+
+// PostWebhook posts msg to the incoming webhook at webhookURL. Like other calls that post
+// messages, it is retried when Slack rate limits us or the request couldn't be sent, but not after
+// Slack fails, since the message may have been posted anyway, and it is skipped in dry-run mode.
+// Slack describes the problem with a rejected message, such as "invalid_blocks", in the Code of
+// the returned APIError.
+func (c *Client) PostWebhook(ctx context.Context, webhookURL string, msg WebhookMessage) error {
+	marshalled, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook message: %v", err)
+	}
+	if c.skipDryRun(ctx, webhookURL, string(marshalled)) {
+		return nil
+	}
+	return c.callWithRetries(ctx, webhookURL, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewReader(marshalled))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		return req, nil
+	}, nil)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sigs.k8s.io/slack-infra/slack/blocks"
+)
+
+func TestPostWebhook(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          WebhookMessage
+		expectedBody string
+		expectedCode string
+	}{
+		{
+			name:         "text",
+			msg:          WebhookMessage{Text: "honk"},
+			expectedBody: `{"text":"honk"}`,
+		},
+		{
+			name:         "blocks",
+			msg:          WebhookMessage{Text: "honk", Blocks: []blocks.Block{blocks.Section(blocks.Markdown("*honk*"))}},
+			expectedBody: `{"text":"honk","blocks":[{"type":"section","text":{"type":"mrkdwn","text":"*honk*"}}]}`,
+		},
+		{
+			name:         "rejected",
+			msg:          WebhookMessage{Blocks: []blocks.Block{blocks.RawBlock(`{"type":"honk"}`)}},
+			expectedBody: `{"text":"","blocks":[{"type":"honk"}]}`,
+			expectedCode: "invalid_blocks",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if auth := r.Header.Get("Authorization"); auth != "" {
+					t.Errorf("expected no Authorization header, but got %q", auth)
+				}
+				body, _ := ioutil.ReadAll(r.Body)
+				if string(body) != tc.expectedBody {
+					t.Errorf("expected body %s, but got %s", tc.expectedBody, body)
+				}
+				w.Header().Set("Content-Type", "text/plain")
+				if tc.expectedCode != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(tc.expectedCode))
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			c := New(Config{WebhookURL: server.URL + "/services/T1/B1/secret"})
+			c.HTTPClient = server.Client()
+			err := c.SendWebhookMessage(context.Background(), tc.msg)
+			if code := ErrorCode(err); code != tc.expectedCode {
+				t.Errorf("expected error code %q, but got %v", tc.expectedCode, err)
+			}
+		})
+	}
+
+	if err := New(Config{AccessToken: "xoxb-token"}).SendWebhookMessage(context.Background(), WebhookMessage{Text: "honk"}); err == nil {
+		t.Errorf("expected an error when no webhook is configured")
+	}
+}
+
+func TestWebhookOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected bool
+	}{
+		{
+			name:     "webhook only",
+			config:   Config{WebhookURL: "https://hooks.slack.com/services/T1/B1/secret", SigningSecret: "secret"},
+			expected: true,
+		},
+		{
+			name:   "webhook and token",
+			config: Config{WebhookURL: "https://hooks.slack.com/services/T1/B1/secret", AccessToken: "xoxb-token"},
+		},
+		{
+			name:   "webhook and rotating token",
+			config: Config{WebhookURL: "https://hooks.slack.com/services/T1/B1/secret", RefreshToken: "xoxe-1", ClientID: "id", ClientSecret: "secret"},
+		},
+		{
+			name: "neither",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := tc.config.WebhookOnly(); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
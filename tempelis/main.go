@@ -18,10 +18,10 @@ package main
 
 import (
 	"flag"
-	"log"
 	"os"
 	"path"
 
+	"sigs.k8s.io/slack-infra/logging"
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/tempelis/config"
 	"sigs.k8s.io/slack-infra/tempelis/reconciler"
@@ -32,6 +32,7 @@ type options struct {
 	config       string
 	restrictions string
 	authConfig   string
+	logging      logging.Options
 }
 
 func parseOptions() options {
@@ -40,27 +41,31 @@ func parseOptions() options {
 	flag.StringVar(&o.config, "config", "", "path to a configuration file, or directory of files")
 	flag.StringVar(&o.restrictions, "restrictions", "", "path to a configuration file containing restrictions")
 	flag.StringVar(&o.authConfig, "auth", "", "path to slack auth")
+	o.logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 	return o
 }
 
 func main() {
 	o := parseOptions()
+	if _, err := logging.Setup(o.logging, "tempelis"); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
 
 	sc, err := slack.LoadConfig(o.authConfig)
 	if err != nil {
-		log.Fatalf("Failed to load slack auth config: %v.\n", err)
+		logging.Fatal("Failed to load slack auth config", "error", err)
 	}
 
 	stat, err := os.Stat(o.config)
 	if err != nil {
-		log.Fatalf("Failed to stat %s: %v\n", o.config, err)
+		logging.Fatal("Failed to stat config", "path", o.config, "error", err)
 	}
 	p := config.NewParser()
 
 	if o.restrictions != "" {
 		if err := p.ParseFile(o.restrictions, path.Dir(o.restrictions)); err != nil {
-			log.Fatalf("Failed to parse restrictions file: %v.\n", err)
+			logging.Fatal("Failed to parse restrictions file", "path", o.restrictions, "error", err)
 		}
 	}
 
@@ -70,11 +75,11 @@ func main() {
 		err = p.ParseFile(o.config, path.Dir(o.config))
 	}
 	if err != nil {
-		log.Fatalf("Failed to load config: %v\n", err)
+		logging.Fatal("Failed to load config", "path", o.config, "error", err)
 	}
 
 	r := reconciler.New(slack.New(sc), p.Config)
 	if err := r.Reconcile(o.dryRun); err != nil {
-		log.Fatalf("Reconciliation failed: %v\n", err)
+		logging.Fatal("Reconciliation failed", "error", err)
 	}
 }
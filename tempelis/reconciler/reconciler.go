@@ -18,7 +18,7 @@ package reconciler
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"sigs.k8s.io/slack-infra/slack"
 	"sigs.k8s.io/slack-infra/tempelis/config"
@@ -58,32 +58,32 @@ func (r *Reconciler) Reconcile(dryRun bool) error {
 
 	failed := false
 	if len(errors) > 0 {
-		log.Printf("This configuration cannot be applied against the current reality:")
+		slog.Error("This configuration cannot be applied against the current reality", "errors", len(errors))
 		failed = true
 	}
 
 	for i, e := range errors {
-		log.Printf("Error %d: %v.\n", i+1, e)
+		slog.Error("Configuration error", "number", i+1, "error", e)
 	}
 
 	if !dryRun && failed {
 		dryRun = true
-		log.Println("We will not execute anything due to errors, but this what we would've done:")
+		slog.Info("We will not execute anything due to errors, but this is what we would've done")
 	} else if dryRun {
-		log.Println("In dry run mode so taking no action, but this is what we would've done:")
+		slog.Info("In dry run mode so taking no action, but this is what we would've done")
 	}
 
 	if len(actions) > 0 {
 		for i, a := range actions {
-			log.Printf("Step %d: %s.\n", i+1, a.Describe())
+			slog.Info("Step", "number", i+1, "action", a.Describe())
 			if !dryRun {
 				if err := a.Perform(r); err != nil {
-					log.Printf("Failed: %v.\n", err)
+					slog.Error("Step failed", "number", i+1, "error", err)
 				}
 			}
 		}
 	} else {
-		log.Println("Nothing to do.")
+		slog.Info("Nothing to do")
 	}
 
 	if failed {
@@ -17,11 +17,13 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
 
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
 )
 
 func (r *Reconciler) reconcileUsergroups() ([]Action, []error) {
@@ -215,7 +217,7 @@ func (a updateUsergroupMembersAction) Perform(reconciler *Reconciler) error {
 			return fmt.Errorf("couldn't find the ID for the group %q", a.name)
 		}
 	}
-	if err := reconciler.slack.CallMethod("usergroups.users.update", map[string]string{"usergroup": a.id, "users": strings.Join(a.users, ",")}, nil); err != nil {
+	if _, err := api.New(reconciler.slack).UpdateUsergroupMembers(context.Background(), a.id, a.users); err != nil {
 		return fmt.Errorf("failed to update members of usergroup %s: %v", a.id, err)
 	}
 	return nil
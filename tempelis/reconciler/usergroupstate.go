@@ -17,8 +17,11 @@ limitations under the License.
 package reconciler
 
 import (
+	"context"
 	"fmt"
+
 	"sigs.k8s.io/slack-infra/slack"
+	"sigs.k8s.io/slack-infra/slack/api"
 )
 
 type usergroupState struct {
@@ -30,14 +33,12 @@ func (u *usergroupState) init(s *slack.Client) error {
 	u.byHandle = map[string]*slack.Subteam{}
 	u.byID = map[string]*slack.Subteam{}
 
-	result := struct {
-		Usergroups []slack.Subteam `json:"usergroups"`
-	}{}
-	if err := s.CallOldMethod("usergroups.list", map[string]string{"include_users": "true", "include_disabled": "true"}, &result); err != nil {
+	usergroups, err := api.New(s).ListUsergroups(context.Background(), api.ListUsergroupsRequest{IncludeUsers: true, IncludeDisabled: true})
+	if err != nil {
 		return fmt.Errorf("couldn't get usergroup list: %v", err)
 	}
 
-	for _, ug := range result.Usergroups {
+	for _, ug := range usergroups {
 		ug2 := ug
 		u.byHandle[ug.Handle] = &ug2
 		u.byID[ug.ID] = &ug2